@@ -0,0 +1,209 @@
+// Package backup captures the configuration of a controller into a single
+// file so that it can be restored onto the same or a replacement board.
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+// Version is the current version of the backup file format.
+const Version = 1
+
+// Backup is the full configuration of a controller.
+type Backup struct {
+	Version        int                               // This is the version of the file format.
+	Created        time.Time                         // This is when the backup was captured.
+	BoardAddress   uint16                            // This is the board address of the controller.
+	BasicInfo      BasicInfo                         // This is informational only; it cannot be restored.
+	NetworkInfo    NetworkInfo                       // This is only restored on request.
+	Settings       Registers                         // These are the setting registers, indexed by address.
+	ControlPeriods []wire.UpdateControlPeriodRequest // These are the non-empty control periods.
+	Permissions    []Permission                      // These are the card permissions.
+	PINs           bool                              `json:",omitempty"` // If this is set, then the permissions include their PINs; otherwise, restoring keeps the PINs that are on the controller.
+}
+
+// Registers is a list of register values.
+//
+// This is stored as a list of numbers rather than as a base64-encoded string
+// so that the backup file can be read (and edited) by hand.
+type Registers []uint8
+
+func (r Registers) MarshalJSON() ([]byte, error) {
+	values := make([]int, len(r))
+	for i, value := range r {
+		values[i] = int(value)
+	}
+	return json.Marshal(values)
+}
+
+func (r *Registers) UnmarshalJSON(data []byte) error {
+	var values []int
+	err := json.Unmarshal(data, &values)
+	if err != nil {
+		return err
+	}
+	*r = make(Registers, len(values))
+	for i, value := range values {
+		if value < 0 || value > 0xff {
+			return fmt.Errorf("invalid register value at index %d: %d", i, value)
+		}
+		(*r)[i] = uint8(value)
+	}
+	return nil
+}
+
+type BasicInfo struct {
+	IssueDate time.Time
+	Version   uint8
+	Model     uint8
+}
+
+type NetworkInfo struct {
+	MACAddress string
+	IPAddress  string
+	Netmask    string
+	Gateway    string
+	Port       uint16
+}
+
+type Permission struct {
+	CardID     string // This is informational only; see `IDNumber` and `AreaNumber`.
+	IDNumber   uint16
+	AreaNumber uint8
+	Door       uint8
+	StartDate  time.Time
+	EndDate    time.Time
+	Time       uint8
+	FirstCard  bool   `json:",omitempty"`
+	Group      uint8  `json:",omitempty"` // This is the user group for multi-card opening.
+	PIN        uint32 `json:",omitempty"` // This is only kept when the backup includes PINs (see `Backup.PINs`).
+}
+
+// Key returns a string that uniquely identifies the card and door of the permission.
+func (p Permission) Key() string {
+	return fmt.Sprintf("%s/%d", wire.CardID(p.AreaNumber, p.IDNumber), p.Door)
+}
+
+// CaptureOptions control what is captured.
+type CaptureOptions struct {
+	PINs bool // If set, then the PINs of the permissions are included.
+}
+
+// Capture reads the full configuration from the controller.
+func Capture(client *wire.Client, options CaptureOptions) (*Backup, error) {
+	b := &Backup{
+		Version:      Version,
+		Created:      time.Now(),
+		BoardAddress: client.BoardAddress,
+		PINs:         options.PINs,
+	}
+
+	{
+		var response wire.GetBasicInfoResponse
+		err := client.Do(wire.FunctionGetBasicInfo, nil, &response)
+		if err != nil {
+			return nil, fmt.Errorf("could not get basic info: %w", err)
+		}
+		b.BasicInfo = BasicInfo{
+			IssueDate: response.IssueDate,
+			Version:   response.Version,
+			Model:     response.Model,
+		}
+	}
+
+	{
+		request := wire.GetNetworkInfoRequest{
+			Unknown1: 1,
+		}
+		var response wire.GetNetworkInfoResponse
+		err := client.Do(wire.FunctionGetNetworkInfo, &request, &response)
+		if err != nil {
+			return nil, fmt.Errorf("could not get network info: %w", err)
+		}
+		b.NetworkInfo = NetworkInfo{
+			MACAddress: response.MACAddress.String(),
+			IPAddress:  response.IPAddress.String(),
+			Netmask:    response.Netmask.String(),
+			Gateway:    response.Gateway.String(),
+			Port:       response.Port,
+		}
+	}
+
+	{
+		settings, err := client.Settings()
+		if err != nil {
+			return nil, fmt.Errorf("could not get settings: %w", err)
+		}
+		b.Settings = settings
+	}
+
+	{
+		controlPeriods, err := client.ControlPeriods()
+		if err != nil {
+			return nil, fmt.Errorf("could not get control periods: %w", err)
+		}
+		for _, controlPeriod := range controlPeriods {
+			b.ControlPeriods = append(b.ControlPeriods, wire.UpdateControlPeriodRequest(controlPeriod))
+		}
+	}
+
+	{
+		permissions, err := client.Permissions()
+		if err != nil {
+			return nil, fmt.Errorf("could not get permissions: %w", err)
+		}
+		for _, permission := range permissions {
+			p := Permission{
+				CardID:     wire.CardID(permission.AreaNumber, permission.IDNumber),
+				IDNumber:   permission.IDNumber,
+				AreaNumber: permission.AreaNumber,
+				Door:       permission.DoorNumber,
+				StartDate:  permission.StartDate,
+				EndDate:    permission.EndDate,
+				Time:       permission.Time,
+				FirstCard:  permission.FirstCard(),
+				Group:      permission.Group(),
+			}
+			if options.PINs {
+				p.PIN = uint32(permission.Password)
+			}
+			b.Permissions = append(b.Permissions, p)
+		}
+	}
+
+	return b, nil
+}
+
+// Load reads a backup file.
+func Load(filename string) (*Backup, error) {
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var b Backup
+	err = json.Unmarshal(contents, &b)
+	if err != nil {
+		return nil, err
+	}
+	if b.Version == 0 || b.Version > Version {
+		return nil, fmt.Errorf("unsupported backup version: %d (expected: %d)", b.Version, Version)
+	}
+	if len(b.Settings) != 0 && len(b.Settings) != 256 {
+		return nil, fmt.Errorf("invalid number of settings: %d (expected: %d)", len(b.Settings), 256)
+	}
+	return &b, nil
+}
+
+// Save writes a backup file.
+func Save(filename string, b *Backup) error {
+	contents, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, contents, 0600)
+}
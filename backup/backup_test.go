@@ -0,0 +1,175 @@
+package backup
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+func TestSaveLoad(t *testing.T) {
+	settings := make(Registers, 256)
+	settings[0x40] = 10
+
+	input := &Backup{
+		Version:      Version,
+		Created:      time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+		BoardAddress: 0xf010,
+		NetworkInfo: NetworkInfo{
+			MACAddress: "00:57:47:64:f0:10",
+			IPAddress:  "192.168.201.194",
+			Netmask:    "255.255.255.0",
+			Gateway:    "192.168.201.254",
+			Port:       wire.PortDefault,
+		},
+		Settings: settings,
+		Permissions: []Permission{
+			{
+				CardID:     wire.CardID(83, 10352),
+				IDNumber:   10352,
+				AreaNumber: 83,
+				Door:       1,
+				StartDate:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+				EndDate:    time.Date(2050, 12, 31, 0, 0, 0, 0, time.UTC),
+				Time:       1,
+				FirstCard:  true,
+				Group:      3,
+				PIN:        1234,
+			},
+		},
+		PINs: true,
+	}
+
+	filename := filepath.Join(t.TempDir(), "backup.json")
+	err := Save(filename, input)
+	require.Nil(t, err)
+
+	output, err := Load(filename)
+	require.Nil(t, err)
+	assert.Equal(t, input, output)
+}
+
+func TestDiff(t *testing.T) {
+	permission := Permission{
+		IDNumber:   10352,
+		AreaNumber: 83,
+		Door:       1,
+		StartDate:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:    time.Date(2050, 12, 31, 0, 0, 0, 0, time.UTC),
+		Time:       1,
+	}
+
+	current := &Backup{
+		Version:  Version,
+		Settings: make(Registers, 256),
+		NetworkInfo: NetworkInfo{
+			MACAddress: "00:57:47:64:f0:10",
+			IPAddress:  "192.168.201.194",
+		},
+		Permissions: []Permission{
+			permission,
+		},
+	}
+
+	t.Run("No changes", func(t *testing.T) {
+		changes := Diff(current, current, RestoreOptions{Network: true})
+		assert.Empty(t, changes)
+	})
+
+	t.Run("Changes", func(t *testing.T) {
+		desired := &Backup{
+			Version:  Version,
+			Settings: make(Registers, 256),
+			NetworkInfo: NetworkInfo{
+				MACAddress: "00:57:47:64:00:01", // This is a different board.
+				IPAddress:  "192.168.201.195",
+			},
+		}
+		desired.Settings[0x40] = 1
+		otherPermission := permission
+		otherPermission.IDNumber = 10353
+		desired.Permissions = []Permission{otherPermission}
+
+		rows := []struct {
+			options  RestoreOptions
+			sections []string
+		}{
+			{
+				options:  RestoreOptions{},
+				sections: []string{"permissions"},
+			},
+			{
+				options:  RestoreOptions{RawRegisters: true},
+				sections: []string{"settings", "permissions"},
+			},
+			{
+				options:  RestoreOptions{Prune: true},
+				sections: []string{"permissions", "permissions"},
+			},
+			{
+				// The network is changed last, since the controller moves.
+				options:  RestoreOptions{Network: true, RawRegisters: true, Prune: true},
+				sections: []string{"settings", "permissions", "permissions", "network"},
+			},
+		}
+		for _, row := range rows {
+			var sections []string
+			for _, change := range Diff(current, desired, row.options) {
+				sections = append(sections, change.Section)
+			}
+			assert.Equal(t, row.sections, sections, "options: %+v", row.options)
+		}
+	})
+	t.Run("PINs", func(t *testing.T) {
+		current := &Backup{
+			Version:     Version,
+			PINs:        true,
+			Permissions: []Permission{permission},
+		}
+		current.Permissions[0].PIN = 1234
+
+		// Without PINs, the controller's PIN is kept.
+		desired := &Backup{
+			Version:     Version,
+			Permissions: []Permission{permission},
+		}
+		assert.Empty(t, Diff(current, desired, RestoreOptions{}))
+
+		// With PINs, the backup's PIN is restored.
+		desired.PINs = true
+		changes := Diff(current, desired, RestoreOptions{})
+		require.Len(t, changes, 1)
+		assert.Equal(t, "permissions", changes[0].Section)
+
+		// The first card and group are restored too.
+		desired.PINs = false
+		desired.Permissions[0].Group = 2
+		changes = Diff(current, desired, RestoreOptions{})
+		require.Len(t, changes, 1)
+		assert.Equal(t, "permissions", changes[0].Section)
+	})
+	t.Run("Prune", func(t *testing.T) {
+		current := &Backup{
+			Version: Version,
+			ControlPeriods: []wire.UpdateControlPeriodRequest{
+				{TimeIndex: 2, WeekControl: 0x1f},
+			},
+			Permissions: []Permission{permission},
+		}
+		desired := &Backup{
+			Version: Version,
+		}
+
+		assert.Empty(t, Diff(current, desired, RestoreOptions{}))
+
+		changes := Diff(current, desired, RestoreOptions{Prune: true})
+		require.Len(t, changes, 2)
+		assert.Equal(t, "control periods", changes[0].Section)
+		assert.Equal(t, "clear period 2", changes[0].Description)
+		assert.Equal(t, "permissions", changes[1].Section)
+		assert.Equal(t, "remove card 8310352 from door 1", changes[1].Description)
+	})
+}
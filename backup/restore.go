@@ -0,0 +1,200 @@
+package backup
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+// RestoreOptions control what is restored.
+type RestoreOptions struct {
+	Network      bool // If set, then the network configuration will be restored (the MAC address of the target is kept).
+	Prune        bool // If set, then the control periods and permissions that are not in the backup will be removed.
+	RawRegisters bool // If set, then every setting register will be restored, even the ones without a known meaning (see `wire.KnownSettings`).
+}
+
+// Change is a single change needed to make a controller match a backup.
+type Change struct {
+	Section     string // This is the section of the backup (for example, "settings").
+	Description string // This is a human-readable description of the change.
+
+	apply func(client *wire.Client) error
+}
+
+// Apply applies the change to the controller.
+func (c Change) Apply(client *wire.Client) error {
+	return c.apply(client)
+}
+
+// Diff returns the changes needed to make the `current` configuration match the `desired` one.
+//
+// The changes are in the order that they should be applied.
+//
+// If the desired configuration does not include PINs, then each permission
+// keeps the PIN that it has in the current one; the current configuration
+// should be captured with its PINs so that they can be kept.
+func Diff(current *Backup, desired *Backup, options RestoreOptions) []Change {
+	var changes []Change
+
+	for address := range desired.Settings {
+		if address < len(current.Settings) && current.Settings[address] == desired.Settings[address] {
+			continue
+		}
+		var currentValue uint8
+		if address < len(current.Settings) {
+			currentValue = current.Settings[address]
+		}
+		address := uint8(address)
+		value := desired.Settings[address]
+		setting := wire.LookupSettingByAddress(address)
+		if setting == nil && !options.RawRegisters {
+			continue
+		}
+		description := fmt.Sprintf("0x%02x: %d -> %d", address, currentValue, value)
+		if setting != nil {
+			description = fmt.Sprintf("0x%02x (%s): %s -> %s", address, setting.Name, setting.FormatValue(currentValue), setting.FormatValue(value))
+		}
+		changes = append(changes, Change{
+			Section:     "settings",
+			Description: description,
+			apply: func(client *wire.Client) error {
+				return client.SetSetting(address, value)
+			},
+		})
+	}
+
+	currentControlPeriods := map[uint16]wire.UpdateControlPeriodRequest{}
+	for _, controlPeriod := range current.ControlPeriods {
+		currentControlPeriods[controlPeriod.TimeIndex] = controlPeriod
+	}
+	desiredControlPeriods := map[uint16]bool{}
+	for _, controlPeriod := range desired.ControlPeriods {
+		desiredControlPeriods[controlPeriod.TimeIndex] = true
+	}
+	if options.Prune {
+		for _, controlPeriod := range current.ControlPeriods {
+			index := controlPeriod.TimeIndex
+			if desiredControlPeriods[index] {
+				continue
+			}
+			changes = append(changes, Change{
+				Section:     "control periods",
+				Description: fmt.Sprintf("clear period %d", index),
+				apply: func(client *wire.Client) error {
+					return client.ClearControlPeriod(index)
+				},
+			})
+		}
+	}
+	for _, controlPeriod := range desired.ControlPeriods {
+		controlPeriod := controlPeriod
+		if currentControlPeriod, ok := currentControlPeriods[controlPeriod.TimeIndex]; ok && reflect.DeepEqual(currentControlPeriod, controlPeriod) {
+			continue
+		}
+		changes = append(changes, Change{
+			Section:     "control periods",
+			Description: fmt.Sprintf("period %d: %+v", controlPeriod.TimeIndex, controlPeriod),
+			apply: func(client *wire.Client) error {
+				return client.SetControlPeriod(controlPeriod)
+			},
+		})
+	}
+
+	currentPermissions := map[string]Permission{}
+	for _, permission := range current.Permissions {
+		currentPermissions[permission.Key()] = permission
+	}
+	desiredPermissions := map[string]Permission{}
+	for _, permission := range desired.Permissions {
+		desiredPermissions[permission.Key()] = permission
+	}
+	if options.Prune {
+		for _, permission := range current.Permissions {
+			permission := permission
+			if _, ok := desiredPermissions[permission.Key()]; ok {
+				continue
+			}
+			changes = append(changes, Change{
+				Section:     "permissions",
+				Description: fmt.Sprintf("remove card %s from door %d", wire.CardID(permission.AreaNumber, permission.IDNumber), permission.Door),
+				apply: func(client *wire.Client) error {
+					return client.DeletePermission(wire.DeletePermissionsRequest{
+						CardID:    permission.IDNumber,
+						Area:      permission.AreaNumber,
+						Door:      permission.Door,
+						StartDate: &permission.StartDate,
+						EndDate:   &permission.EndDate,
+						Time:      permission.Time,
+						Password:  wire.PIN(permission.PIN),
+					})
+				},
+			})
+		}
+	}
+	for _, permission := range desired.Permissions {
+		permission := permission
+		currentPermission, ok := currentPermissions[permission.Key()]
+		if !desired.PINs {
+			permission.PIN = currentPermission.PIN
+		}
+		if ok && permissionsEqual(currentPermission, permission) {
+			continue
+		}
+		changes = append(changes, Change{
+			Section:     "permissions",
+			Description: fmt.Sprintf("add card %s to door %d (%s to %s)", wire.CardID(permission.AreaNumber, permission.IDNumber), permission.Door, permission.StartDate.Format("2006-01-02"), permission.EndDate.Format("2006-01-02")),
+			apply: func(client *wire.Client) error {
+				return client.AddPermission(wire.Defaults(wire.UpdatePermissionsRequest{
+					CardID:    permission.IDNumber,
+					Area:      permission.AreaNumber,
+					Door:      permission.Door,
+					StartDate: permission.StartDate,
+					EndDate:   permission.EndDate,
+					Time:      permission.Time,
+					Password:  wire.PIN(permission.PIN),
+					Standby:   wire.PermissionStandby(permission.FirstCard, permission.Group),
+				}))
+			},
+		})
+	}
+
+	// The network is changed last, since the controller is no longer at the same
+	// address afterward.
+	if options.Network {
+		currentNetwork := current.NetworkInfo
+		desiredNetwork := desired.NetworkInfo
+		desiredNetwork.MACAddress = currentNetwork.MACAddress // The MAC address belongs to the board.
+		if currentNetwork != desiredNetwork {
+			changes = append(changes, Change{
+				Section:     "network",
+				Description: fmt.Sprintf("%+v -> %+v", currentNetwork, desiredNetwork),
+				apply: func(client *wire.Client) error {
+					macAddress, err := net.ParseMAC(desiredNetwork.MACAddress)
+					if err != nil {
+						return fmt.Errorf("could not parse MAC address: %w", err)
+					}
+					request := wire.SetNetworkInfoRequest{
+						MACAddress: macAddress,
+						IPAddress:  net.ParseIP(desiredNetwork.IPAddress),
+						Netmask:    net.ParseIP(desiredNetwork.Netmask),
+						Gateway:    net.ParseIP(desiredNetwork.Gateway),
+						Port:       desiredNetwork.Port,
+					}
+					var response wire.SetNetworkInfoResponse
+					return client.Do(wire.FunctionSetNetworkInfo, &request, &response)
+				},
+			})
+		}
+	}
+
+	return changes
+}
+
+// permissionsEqual returns true if the two permissions grant the same access.
+//
+// The informational card ID is ignored.
+func permissionsEqual(a Permission, b Permission) bool {
+	return a.IDNumber == b.IDNumber && a.AreaNumber == b.AreaNumber && a.Door == b.Door && a.StartDate.Equal(b.StartDate) && a.EndDate.Equal(b.EndDate) && a.Time == b.Time && a.FirstCard == b.FirstCard && a.Group == b.Group && a.PIN == b.PIN
+}
@@ -0,0 +1,478 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tekkamanendless/cobra-controls/cobrafile"
+	"github.com/tekkamanendless/cobra-controls/wire"
+	"golang.org/x/term"
+)
+
+// dashboardMaximumRecords is the most records that a controller will be asked
+// for in a single poll; anything older is skipped.
+const dashboardMaximumRecords = 20
+
+// DashboardDoor is the state of a single door.
+type DashboardDoor struct {
+	Door      uint8
+	Name      string
+	Energized bool
+	Open      bool
+	LastEvent *DashboardEvent
+	Alarm     *DashboardEvent // This is the most recent alarm that has not been acknowledged.
+}
+
+// DashboardEvent is a decoded access record.
+type DashboardEvent struct {
+	Record wire.Record
+	Person string // This is the name of the person (if known).
+}
+
+// String returns a short description of the event.
+func (e DashboardEvent) String() string {
+	description := string(e.Record.Event())
+	switch e.Record.Event() {
+	case wire.RecordEventGranted, wire.RecordEventDenied:
+		who := wire.CardID(e.Record.AreaNumber, e.Record.IDNumber)
+		if e.Person != "" {
+			who = e.Person
+		}
+		description += " " + who
+		if reason := e.Record.DeniedReason(); reason != "" {
+			description += " (" + reason + ")"
+		}
+	}
+	return e.Record.BrushDateTime.Format(time.DateTime) + " " + description
+}
+
+// DashboardController is the state of a single controller.
+type DashboardController struct {
+	Name       string
+	Client     *wire.Client
+	Connected  bool
+	LastError  error
+	LastPoll   time.Time
+	Latency    time.Duration
+	Drift      time.Duration // + is ahead, - is behind.
+	Fault      wire.FaultNumber
+	Alarm      *DashboardEvent // This is the most recent controller-wide alarm (such as a fire alarm) that has not been acknowledged.
+	Doors      []*DashboardDoor
+	nextRecord uint32 // This is the next record index to read; 0 means that nothing has been read yet.
+	commands   chan func()
+}
+
+// Dashboard polls the controllers and renders their state.
+type Dashboard struct {
+	Controllers    []*DashboardController
+	ControllerList cobrafile.ControllerList
+	PersonnelList  cobrafile.PersonnelList
+	Interval       time.Duration
+
+	mutex    sync.Mutex
+	selected int    // This is the index of the selected door row.
+	message  string // This is shown at the bottom of the screen.
+	confirm  func() // If set, this is run when the user confirms with "y".
+	redraw   chan struct{}
+}
+
+// NewDashboard returns a dashboard for the clients.
+func NewDashboard(clients []*wire.Client, controllerList cobrafile.ControllerList, personnelList cobrafile.PersonnelList, interval time.Duration) *Dashboard {
+	d := &Dashboard{
+		ControllerList: controllerList,
+		PersonnelList:  personnelList,
+		Interval:       interval,
+		redraw:         make(chan struct{}, 1),
+	}
+	for _, client := range clients {
+		controller := &DashboardController{
+			Name:     controllerList.LookupName(client.ControllerAddress),
+			Client:   client,
+			commands: make(chan func(), 10),
+		}
+		if controller.Name == "" {
+			controller.Name = client.ControllerAddress
+		}
+		for door := uint8(1); door <= 4; door++ {
+			name := controllerList.LookupDoor(client.ControllerAddress, door)
+			if name == "" {
+				name = fmt.Sprintf("%d", door)
+			}
+			controller.Doors = append(controller.Doors, &DashboardDoor{
+				Door: door,
+				Name: name,
+			})
+		}
+		d.Controllers = append(d.Controllers, controller)
+	}
+	return d
+}
+
+// Run shows the dashboard until the user quits.
+func (d *Dashboard) Run(input *os.File, output io.Writer) error {
+	if term.IsTerminal(int(input.Fd())) {
+		oldState, err := term.MakeRaw(int(input.Fd()))
+		if err != nil {
+			return fmt.Errorf("could not put the terminal into raw mode: %w", err)
+		}
+		defer term.Restore(int(input.Fd()), oldState)
+	}
+
+	// Anything logged would scribble over the screen.
+	logrus.SetOutput(io.Discard)
+	defer logrus.SetOutput(os.Stderr)
+
+	// Use the alternate screen and hide the cursor.
+	fmt.Fprintf(output, "\033[?1049h\033[?25l")
+	defer fmt.Fprintf(output, "\033[?25h\033[?1049l")
+
+	for _, controller := range d.Controllers {
+		go d.runController(controller)
+	}
+
+	keys := make(chan byte)
+	go func() {
+		defer close(keys)
+		buffer := make([]byte, 16)
+		for {
+			bytesRead, err := input.Read(buffer)
+			if err != nil {
+				return
+			}
+			for _, b := range buffer[:bytesRead] {
+				keys <- b
+			}
+		}
+	}()
+
+	d.render(output)
+	var escape []byte
+	for {
+		select {
+		case <-d.redraw:
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			// Arrow keys arrive as "ESC [ A" through "ESC [ D".
+			if key == 0x1B || len(escape) > 0 {
+				escape = append(escape, key)
+				if len(escape) < 3 {
+					continue
+				}
+				switch string(escape) {
+				case "\033[A":
+					key = 'k'
+				case "\033[B":
+					key = 'j'
+				}
+				escape = nil
+			}
+			if !d.handleKey(key) {
+				return nil
+			}
+		}
+		d.render(output)
+	}
+}
+
+// handleKey handles a single key press; this returns false if the user wants to quit.
+func (d *Dashboard) handleKey(key byte) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.confirm != nil {
+		if key == 'y' || key == 'Y' {
+			d.confirm()
+		} else {
+			d.message = "Cancelled."
+		}
+		d.confirm = nil
+		return true
+	}
+
+	rows := d.rows()
+	switch key {
+	case 'q', 'Q', 0x03: // 0x03 is Ctrl+C.
+		return false
+	case 'j':
+		if d.selected < len(rows)-1 {
+			d.selected++
+		}
+	case 'k':
+		if d.selected > 0 {
+			d.selected--
+		}
+	case 'o':
+		if d.selected >= len(rows) {
+			break
+		}
+		controller, door := rows[d.selected].controller, rows[d.selected].door
+		d.message = fmt.Sprintf("Open door %s on %s? (y/n)", door.Name, controller.Name)
+		d.confirm = func() {
+			d.message = fmt.Sprintf("Opening door %s on %s...", door.Name, controller.Name)
+			d.sendCommand(controller, func() {
+				request := wire.Defaults(wire.OpenDoorRequest{
+					Door: door.Door,
+				})
+				var response wire.OpenDoorResponse
+				err := controller.Client.Do(wire.FunctionOpenDoor, &request, &response)
+				d.mutex.Lock()
+				if err != nil {
+					d.message = fmt.Sprintf("Could not open door %s on %s: %v", door.Name, controller.Name, err)
+				} else {
+					d.message = fmt.Sprintf("Opened door %s on %s.", door.Name, controller.Name)
+				}
+				d.mutex.Unlock()
+				d.requestRedraw()
+			})
+		}
+	case 'a':
+		if d.selected >= len(rows) {
+			break
+		}
+		controller, door := rows[d.selected].controller, rows[d.selected].door
+		if door.Alarm != nil {
+			door.Alarm = nil
+			d.message = fmt.Sprintf("Acknowledged the alarm for door %s on %s.", door.Name, controller.Name)
+		} else if controller.Alarm != nil {
+			controller.Alarm = nil
+			d.message = fmt.Sprintf("Acknowledged the alarm for %s.", controller.Name)
+		}
+	case 'A':
+		for _, row := range rows {
+			row.door.Alarm = nil
+			row.controller.Alarm = nil
+		}
+		d.message = "Acknowledged all alarms."
+	case 'r':
+		for _, controller := range d.Controllers {
+			d.sendCommand(controller, func() {})
+		}
+		d.message = "Refreshing..."
+	}
+	return true
+}
+
+// dashboardRow is a single door row in the table.
+type dashboardRow struct {
+	controller *DashboardController
+	door       *DashboardDoor
+}
+
+// rows returns the door rows in the order that they are shown.
+func (d *Dashboard) rows() []dashboardRow {
+	var rows []dashboardRow
+	for _, controller := range d.Controllers {
+		for _, door := range controller.Doors {
+			rows = append(rows, dashboardRow{controller: controller, door: door})
+		}
+	}
+	return rows
+}
+
+// sendCommand queues the command to be run by the controller's poller.
+//
+// This never blocks (the mutex may be held); if the queue is full, the command
+// is dropped.
+func (d *Dashboard) sendCommand(controller *DashboardController, command func()) {
+	select {
+	case controller.commands <- command:
+	default:
+		d.message = fmt.Sprintf("%s is busy; try again.", controller.Name)
+	}
+}
+
+// requestRedraw asks the main loop to redraw the screen.
+func (d *Dashboard) requestRedraw() {
+	select {
+	case d.redraw <- struct{}{}:
+	default:
+		// A redraw is already pending.
+	}
+}
+
+// runController polls a single controller forever.
+//
+// The client is only used from here, so commands for the controller (such as
+// opening a door) are run here between polls.
+func (d *Dashboard) runController(controller *DashboardController) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+	for {
+		d.poll(controller)
+		d.requestRedraw()
+		select {
+		case <-ticker.C:
+		case command := <-controller.commands:
+			command()
+		}
+	}
+}
+
+// poll reads the status of the controller and any new records.
+func (d *Dashboard) poll(controller *DashboardController) {
+	start := time.Now()
+	request := wire.GetOperationStatusRequest{
+		RecordIndex: 0,
+	}
+	var response wire.GetOperationStatusResponse
+	err := controller.Client.Do(wire.FunctionGetOperationStatus, &request, &response)
+	latency := time.Since(start)
+
+	var events []DashboardEvent
+	if err == nil {
+		d.mutex.Lock()
+		nextRecord := controller.nextRecord
+		d.mutex.Unlock()
+		if nextRecord == 0 || response.RecordCount+1 < nextRecord {
+			// Start with the most recent record (or start over if the records were cleared).
+			nextRecord = response.RecordCount
+		}
+		if response.RecordCount >= dashboardMaximumRecords && nextRecord < response.RecordCount-dashboardMaximumRecords {
+			nextRecord = response.RecordCount - dashboardMaximumRecords
+		}
+		for index := nextRecord; index > 0 && index <= response.RecordCount; index++ {
+			request := wire.GetOperationStatusRequest{
+				RecordIndex: index,
+			}
+			var recordResponse wire.GetOperationStatusResponse
+			err := controller.Client.Do(wire.FunctionGetOperationStatus, &request, &recordResponse)
+			if err != nil {
+				logrus.Debugf("Could not read record %d: %v", index, err)
+				break
+			}
+			nextRecord = index + 1
+			if recordResponse.Record == nil {
+				continue
+			}
+			event := DashboardEvent{
+				Record: *recordResponse.Record,
+			}
+			if !event.Record.IsSpecial() && d.PersonnelList != nil {
+				if person := d.PersonnelList.FindByCardID(wire.CardID(event.Record.AreaNumber, event.Record.IDNumber)); person != nil {
+					event.Person = person.Name
+				}
+			}
+			events = append(events, event)
+		}
+		d.mutex.Lock()
+		controller.nextRecord = nextRecord
+		d.mutex.Unlock()
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	controller.LastPoll = start
+	controller.Latency = latency
+	controller.LastError = err
+	controller.Connected = err == nil
+	if err != nil {
+		return
+	}
+	controller.Drift = response.CurrentTime.Sub(controller.Client.WallClock(start))
+	controller.Fault = response.FaultNumber
+	for _, door := range controller.Doors {
+		door.Energized = response.RelayStatus.Energized(door.Door)
+		door.Open = response.MagnetState.DoorOpen(door.Door)
+	}
+	for i := range events {
+		event := &events[i]
+		doorNumber := event.Record.Door()
+		if doorNumber == 0 {
+			if event.Record.IsAlarm() {
+				controller.Alarm = event
+			}
+			continue
+		}
+		if int(doorNumber) > len(controller.Doors) {
+			continue
+		}
+		door := controller.Doors[doorNumber-1]
+		door.LastEvent = event
+		if event.Record.IsAlarm() {
+			door.Alarm = event
+		}
+	}
+}
+
+// render draws the whole screen.
+func (d *Dashboard) render(output io.Writer) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var builder strings.Builder
+	builder.WriteString("\033[H\033[2J")
+	fmt.Fprintf(&builder, "Cobra Controls | %s\n", time.Now().Format(time.DateTime))
+	fmt.Fprintf(&builder, "Keys: up/down (or j/k) select | o open door | a acknowledge alarm | A acknowledge all | r refresh | q quit\n\n")
+
+	table := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "\tCONTROLLER\tSTATUS\tDRIFT\tDOOR\tRELAY\tSENSOR\tALARM\tLAST EVENT\n")
+	for rowIndex, row := range d.rows() {
+		controller, door := row.controller, row.door
+
+		cursor := " "
+		if rowIndex == d.selected {
+			cursor = ">"
+		}
+
+		status := "never polled"
+		drift := "-"
+		relay := "-"
+		sensor := "-"
+		if !controller.LastPoll.IsZero() {
+			if controller.Connected {
+				status = fmt.Sprintf("ok (%v)", controller.Latency.Round(time.Millisecond))
+				if controller.Fault != wire.FaultNone {
+					status = controller.Fault.Description()
+				}
+				drift = controller.Drift.String()
+				relay = "off"
+				if door.Energized {
+					relay = "energized"
+				}
+				sensor = "closed"
+				if door.Open {
+					sensor = "open"
+				}
+			} else {
+				status = "unreachable"
+			}
+		}
+
+		alarm := ""
+		if door.Alarm != nil {
+			alarm = string(door.Alarm.Record.Event())
+		} else if controller.Alarm != nil {
+			alarm = string(controller.Alarm.Record.Event())
+		}
+
+		lastEvent := ""
+		if door.LastEvent != nil {
+			lastEvent = door.LastEvent.String()
+		}
+
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", cursor, controller.Name, status, drift, door.Name, relay, sensor, alarm, lastEvent)
+	}
+	table.Flush()
+
+	for _, controller := range d.Controllers {
+		if controller.LastError != nil {
+			fmt.Fprintf(&builder, "\n%s: %v", controller.Name, controller.LastError)
+		}
+	}
+	if d.message != "" {
+		fmt.Fprintf(&builder, "\n%s", d.message)
+	}
+	builder.WriteString("\n")
+
+	// The terminal is in raw mode, so every line needs a carriage return.
+	fmt.Fprint(output, strings.ReplaceAll(builder.String(), "\n", "\r\n"))
+}
@@ -1,16 +1,29 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net"
+	"net/http"
 	"os"
 	"path"
 	"strconv"
+	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/tekkamanendless/cobra-controls/backup"
 	"github.com/tekkamanendless/cobra-controls/cobrafile"
+	"github.com/tekkamanendless/cobra-controls/exporter"
+	"github.com/tekkamanendless/cobra-controls/report"
+	"github.com/tekkamanendless/cobra-controls/server"
+	"github.com/tekkamanendless/cobra-controls/timesync"
+	"github.com/tekkamanendless/cobra-controls/webhook"
 	"github.com/tekkamanendless/cobra-controls/wire"
 )
 
@@ -73,6 +86,7 @@ func main() {
 					BoardAddress:      boardAddress,
 					Protocol:          wire.Protocol(protocol),
 				}
+				client.Location = lookupController(controllerList, client).Location()
 				logrus.Debugf("Client: %+v", client)
 				clients = append(clients, client)
 			} else if controllerName != "" {
@@ -83,14 +97,7 @@ func main() {
 				}
 				for _, controller := range controllerList {
 					if ok, _ := path.Match(controllerName, controller.Name); ok {
-						client := &wire.Client{
-							ControllerAddress: controller.Address,
-							ControllerPort:    controller.Port,
-							BoardAddress:      controller.SN,
-							Protocol:          wire.Protocol(protocol),
-						}
-						logrus.Debugf("Client: %+v", client)
-						clients = append(clients, client)
+						clients = append(clients, controllerClient(controller, protocol))
 					}
 				}
 			}
@@ -109,6 +116,69 @@ func main() {
 	rootCommand.PersistentFlags().StringVar(&protocol, "protocol", "", "Use this protocol to communicate (if unspecified, the appropriate default for the command will be used)")
 	rootCommand.PersistentFlags().BoolVar(&verbose, "verbose", false, "Enable verbose output")
 
+	{
+		var captureOptions backup.CaptureOptions
+
+		cmd := &cobra.Command{
+			Use:   "backup <file>",
+			Short: "Back up the controller configuration",
+			Long:  `This captures the basic info, network info, setting registers, control periods, and permissions into a single file.  Timing tasks are not included because they cannot be read back from the controller.  The PINs of the permissions are only included with "--include-pins"; without them, a restore keeps the PINs that are on the controller.`,
+			Args:  cobra.ExactArgs(1),
+			Run: func(cmd *cobra.Command, args []string) {
+				if len(clients) != 1 {
+					logrus.Errorf("Exactly one controller must be specified")
+					os.Exit(1)
+				}
+				client := clients[0]
+
+				b, err := backup.Capture(client, captureOptions)
+				if err != nil {
+					logrus.Errorf("Could not capture backup: %v", err)
+					os.Exit(1)
+				}
+				err = backup.Save(args[0], b)
+				if err != nil {
+					logrus.Errorf("Could not save backup: %v", err)
+					os.Exit(1)
+				}
+				fmt.Printf("Wrote backup to %s | Settings: %d | Control periods: %d | Permissions: %d | PINs: %t\n", args[0], len(b.Settings), len(b.ControlPeriods), len(b.Permissions), b.PINs)
+			},
+		}
+		cmd.Flags().BoolVar(&captureOptions.PINs, "include-pins", false, "Also keep the PINs of the permissions (the file is only readable by its owner)")
+
+		rootCommand.AddCommand(cmd)
+	}
+
+	{
+		var interval time.Duration
+
+		cmd := &cobra.Command{
+			Use:   "dashboard",
+			Short: "Show a live dashboard of every door",
+			Long:  `This polls every controller (by default, every controller in the controller file) and shows the relay and sensor state of each door, the last event, any alarms, the drift, and whether the controller is reachable.  A door may be opened from the dashboard, and alarms stay on the screen until they are acknowledged.`,
+			Args:  cobra.NoArgs,
+			Run: func(cmd *cobra.Command, args []string) {
+				if len(clients) == 0 {
+					clients = controllerClients(controllerList, protocol)
+				}
+				if len(clients) == 0 {
+					logrus.Errorf("Invalid client")
+					os.Exit(1)
+				}
+
+				dashboard := NewDashboard(clients, controllerList, personnelList, interval)
+				err := dashboard.Run(os.Stdin, os.Stdout)
+				if err != nil {
+					logrus.Errorf("Error: %v", err)
+					os.Exit(1)
+				}
+			},
+		}
+		cmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "How long to wait between polls of each controller")
+
+		rootCommand.AddCommand(cmd)
+	}
+
 	{
 		cmd := &cobra.Command{
 			Use:   "drift",
@@ -133,8 +203,7 @@ func main() {
 					var sum time.Duration
 					count := 0
 					for i := 0; i < 10; i++ {
-						currentTime := time.Now()
-						currentTime = time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), currentTime.Hour(), currentTime.Minute(), currentTime.Second(), 0, time.UTC)
+						currentTime := client.WallClock(time.Now())
 
 						request := wire.GetOperationStatusRequest{
 							RecordIndex: 0,
@@ -152,10 +221,10 @@ func main() {
 						sum += timeAhead
 						count++
 					}
-					drift := sum / time.Duration(count)
 					if count == 0 {
 						fmt.Printf("Controller: %s | Drift: unknown\n", controller)
 					} else {
+						drift := sum / time.Duration(count)
 						fmt.Printf("Controller: %s | Drift: %v (+ is ahead, - is behind)\n", controller, drift)
 					}
 				}
@@ -165,6 +234,43 @@ func main() {
 		rootCommand.AddCommand(cmd)
 	}
 
+	{
+		var listen string
+		var interval time.Duration
+
+		cmd := &cobra.Command{
+			Use:   "exporter",
+			Short: "Serve Prometheus metrics for the controllers",
+			Long:  `This polls every controller (by default, every controller in the controller file) in the background and serves the reachability, request latency, errors, record count, drift, fault, door state, and granted/denied counts at "/metrics".`,
+			Args:  cobra.NoArgs,
+			Run: func(cmd *cobra.Command, args []string) {
+				if len(clients) == 0 {
+					clients = controllerClients(controllerList, protocol)
+				}
+				if len(clients) == 0 {
+					logrus.Errorf("Invalid client")
+					os.Exit(1)
+				}
+
+				e := exporter.New(clients, controllerList)
+				go e.Run(context.Background(), interval)
+
+				mux := http.NewServeMux()
+				mux.Handle("/metrics", e.Handler())
+				logrus.Infof("Serving metrics on %s.", listen)
+				err := http.ListenAndServe(listen, mux)
+				if err != nil {
+					logrus.Errorf("Error: %v", err)
+					os.Exit(1)
+				}
+			},
+		}
+		cmd.Flags().StringVar(&listen, "listen", ":9101", "The address to listen on")
+		cmd.Flags().DurationVar(&interval, "interval", 15*time.Second, "How long to wait between polls of each controller")
+
+		rootCommand.AddCommand(cmd)
+	}
+
 	{
 		cmd := &cobra.Command{
 			Use:   "get-upload <index>[ ...]",
@@ -389,6 +495,9 @@ func main() {
 	{
 		var batchCount int
 		var sleepDuration time.Duration
+		var webhookFile string
+		var occupancy bool
+		var occupancyMaxAge time.Duration
 
 		cmd := &cobra.Command{
 			Use:   "monitor",
@@ -401,6 +510,31 @@ func main() {
 					os.Exit(1)
 				}
 
+				var dispatcher *webhook.Dispatcher
+				if webhookFile != "" {
+					config, err := webhook.LoadConfig(webhookFile)
+					if err != nil {
+						logrus.Errorf("Could not load webhook file: %v", err)
+						os.Exit(1)
+					}
+					dispatcher, err = webhook.NewDispatcher(*config)
+					if err != nil {
+						logrus.Errorf("Invalid webhook file: %v", err)
+						os.Exit(1)
+					}
+					defer dispatcher.Wait()
+				}
+
+				var tracker *report.Occupancy
+				if occupancy {
+					tracker = report.NewOccupancy(personnelList, occupancyMaxAge)
+					now := time.Now()
+					midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+					for _, entry := range fetchRecords(clients, controllerList, midnight, now.Add(24*time.Hour)) {
+						tracker.Observe(entry)
+					}
+				}
+
 				nextNumbers := make([]uint32, len(clients)) // If this is zero, then we'll ask for the latest value.
 				for batch := 0; ; batch++ {
 					if batchCount > 0 {
@@ -462,6 +596,16 @@ func main() {
 										} else {
 											fmt.Printf("%v | Controller: %s | Door: %s | Card ID: %s | Name: %s | Access: %t\n", response.Record.BrushDateTime, controller, door, wire.CardID(response.Record.AreaNumber, response.Record.IDNumber), person.Name, response.Record.AccessGranted())
 										}
+										event := server.NewEvent(controllerList, personnelList, lookupController(controllerList, client), index, *response.Record)
+										if tracker != nil && tracker.Observe(report.Entry{Controller: lookupController(controllerList, client), Index: index, Record: *response.Record}) {
+											event.Muster = tracker.Muster(time.Now())
+											event.Muster.Alarm = &event.Time
+											event.Muster.Controller = event.Controller
+											event.Muster.WriteText(os.Stdout)
+										}
+										if dispatcher != nil {
+											dispatcher.Handle(event)
+										}
 									}
 								}
 							}
@@ -474,6 +618,84 @@ func main() {
 		}
 		cmd.Flags().IntVar(&batchCount, "batch", 10, "How many iterations to run (use 0 for infinite)")
 		cmd.Flags().DurationVar(&sleepDuration, "batch-interval", 5*time.Second, "How long to wait between batches")
+		cmd.Flags().StringVar(&webhookFile, "webhook-file", "", "Send webhooks for the access events according to the rules in this file")
+		cmd.Flags().BoolVar(&occupancy, "occupancy", false, "Track who is on site (starting with today's records) and print a muster report on a fire alarm")
+		cmd.Flags().DurationVar(&occupancyMaxAge, "occupancy-max-age", report.DefaultMaxAge, "Assume that someone has left if they have not been seen for this long (use 0 to never assume this)")
+
+		rootCommand.AddCommand(cmd)
+	}
+
+	{
+		var from string
+		var maxAge time.Duration
+		var format string
+		var muster bool
+
+		cmd := &cobra.Command{
+			Use:   "occupancy",
+			Short: "List who is on site",
+			Long:  `This replays the records since the start of --from (default: today) to work out who is on site.  A granted card at an "in" door puts the person on site, and a granted card at an "out" door takes them off (see the "Door # Direction" columns of the controller file); at a controller with no door directions, every granted card puts the person on site.  With --muster, this prints a muster report that can be checked off during an evacuation.`,
+			Args:  cobra.NoArgs,
+			Run: func(cmd *cobra.Command, args []string) {
+				if from == "" {
+					from = time.Now().Format(time.DateOnly)
+				}
+				fromTime, _, err := parseDateRange(from, "")
+				if err != nil {
+					logrus.Errorf("Invalid dates: %v", err)
+					os.Exit(1)
+				}
+				if len(clients) == 0 {
+					clients = controllerClients(controllerList, protocol)
+				}
+				if len(clients) == 0 {
+					logrus.Errorf("Invalid client")
+					os.Exit(1)
+				}
+
+				// The end is well past now in case a controller's clock is ahead.
+				now := time.Now()
+				tracker := report.NewOccupancy(personnelList, maxAge)
+				var alarm *report.Entry
+				for _, entry := range fetchRecords(clients, controllerList, fromTime, now.Add(24*time.Hour)) {
+					if tracker.Observe(entry) {
+						entry := entry
+						alarm = &entry
+					}
+				}
+				result := tracker.Muster(now)
+				if alarm != nil {
+					result.Alarm = &alarm.Record.BrushDateTime
+					result.Controller = alarm.ControllerName()
+				}
+
+				switch {
+				case format == "json":
+					err = writeJSON(os.Stdout, result)
+				case format != "text":
+					err = fmt.Errorf("invalid format: %q", format)
+				case muster:
+					err = result.WriteText(os.Stdout)
+				default:
+					for _, occupant := range result.Occupants {
+						name := occupant.Name
+						if name == "" {
+							name = "(unknown)"
+						}
+						fmt.Printf("Name: %s | Card ID: %s | Department: %s | Entered: %s | Last Seen: %s | Controller: %s | Door: %s\n", name, occupant.CardID, occupant.Department, occupant.Entered.Format(time.DateTime), occupant.LastSeen.Format(time.DateTime), occupant.Controller, occupant.DoorName)
+					}
+					fmt.Printf("On site: %d\n", len(result.Occupants))
+				}
+				if err != nil {
+					logrus.Errorf("Error: %v", err)
+					os.Exit(1)
+				}
+			},
+		}
+		cmd.Flags().StringVar(&from, "from", "", "Replay the records from the start of this date (default: today)")
+		cmd.Flags().DurationVar(&maxAge, "max-age", report.DefaultMaxAge, "Assume that someone has left if they have not been seen for this long (use 0 to never assume this)")
+		cmd.Flags().StringVar(&format, "format", "text", "The output format (\"text\" or \"json\")")
+		cmd.Flags().BoolVar(&muster, "muster", false, "Print a muster report")
 
 		rootCommand.AddCommand(cmd)
 	}
@@ -493,33 +715,17 @@ func main() {
 				for _, client := range clients {
 					for _, arg := range args {
 						logrus.Infof("Door string: %s", arg)
-						var door uint8
-						switch arg {
-						case "1":
-							door = 1
-						case "2":
-							door = 2
-						case "3":
-							door = 3
-						case "4":
-							door = 4
-						default:
-							if controllerList != nil {
-								var ok bool
-								door, ok = controllerList.FindDoor(client.ControllerAddress, arg)
-								if !ok {
-									logrus.Warnf("No such door %q for controller %s", arg, client.ControllerAddress)
-									continue
-								}
-							}
+						door, ok := parseDoor(controllerList, client.ControllerAddress, arg)
+						if !ok {
+							logrus.Warnf("No such door %q for controller %s", arg, client.ControllerAddress)
+							continue
 						}
 						logrus.Infof("Door value: %d", door)
-						request := wire.OpenDoorRequest{
-							Door:     door,
-							Unkonwn1: 1,
-						}
+						request := wire.Defaults(wire.OpenDoorRequest{
+							Door: door,
+						})
 						var response wire.OpenDoorResponse
-						err := client.Do(wire.FunctionGetBasicInfo, &request, &response)
+						err := client.Do(wire.FunctionOpenDoor, &request, &response)
 						if err != nil {
 							logrus.Errorf("Error: %v", err)
 							continue
@@ -535,162 +741,1318 @@ func main() {
 
 	{
 		cmd := &cobra.Command{
-			Use:   "search",
-			Short: "Search for controllers on the local network",
-			Long:  ``,
-			Args:  cobra.NoArgs,
+			Use:   "permissions",
+			Short: "Manage the card permissions",
+			Long:  `A permission lets a card open a door.  A "first card" user must open a door that requires a first card before anyone else is let in, and the user group is used for multi-card opening (0 is the general user group); the denied reasons in the history report when a card is refused because of these.  The controller's anti-passback, multi-card, and interlock modes cannot be configured here; the registers that hold them are not known.`,
 			Run: func(cmd *cobra.Command, args []string) {
-				if len(clients) == 0 {
-					client := &wire.Client{
-						ControllerAddress: controllerAddress,
-						BoardAddress:      boardAddress,
-						ControllerPort:    controllerPort,
-					}
-					clients = append(clients, client)
-				}
-				client := clients[0]
-
-				if len(client.Protocol) == 0 {
-					client.Protocol = wire.ProtocolUDP
-				}
-				if client.ControllerAddress == "" {
-					client.ControllerAddress = "255.255.255.255"
-				}
-				if client.BoardAddress == 0 {
-					client.BoardAddress = 0xffff
-				}
-				logrus.Debugf("Client: %+v", client)
-
-				request := wire.GetNetworkInfoRequest{
-					Unknown1: 0,
-				}
-				var responses []wire.GetNetworkInfoResponse
-				responseEnvelopes, err := client.DoWithEnvelopes(wire.FunctionGetNetworkInfo, &request, &responses)
-				if err != nil {
-					logrus.Errorf("Error from client: %v", err)
-					return
-				}
-
-				logrus.Debugf("Responses: %+v", responses)
-				for i, response := range responses {
-					responseEnvelope := responseEnvelopes[i]
-					logrus.Debugf("Response envelope: %+v", responseEnvelope)
-					logrus.Debugf("Response: %+v", response)
-					fmt.Printf("Board address: %d :: MAC address: %s (%s / %s via %s on port %d)\n", responseEnvelope.BoardAddress, response.MACAddress, response.IPAddress, response.Netmask, response.Gateway, response.Port)
-				}
+				cmd.Help()
+				os.Exit(1)
 			},
 		}
 
-		rootCommand.AddCommand(cmd)
-	}
+		{
+			var card string
 
-	{
-		cmd := &cobra.Command{
-			Use:   "set-network <mac> <ip> <netmask> <gateway> [<port>]",
-			Short: "Set the network information",
-			Long:  ``,
-			Args:  cobra.RangeArgs(4, 5),
-			Run: func(cmd *cobra.Command, args []string) {
-				newMACAddress, err := net.ParseMAC(args[0])
-				if err != nil {
-					logrus.Errorf("Could not parse MAC address: [%T] %v", err, err)
-					return
-				}
-				newIPAddress := net.ParseIP(args[1])
-				newNetmask := net.ParseIP(args[2])
-				newGateway := net.ParseIP(args[3])
-				newPort := wire.PortDefault
-				if len(args) > 4 {
-					v, err := strconv.ParseUint(args[4], 10, 16)
-					if err != nil {
-						logrus.Errorf("Could not parse port number: [%T] %v", err, err)
-						return
+			subcommand := &cobra.Command{
+				Use:   "list",
+				Short: "List the permissions",
+				Long:  ``,
+				Args:  cobra.NoArgs,
+				Run: func(cmd *cobra.Command, args []string) {
+					if len(clients) == 0 {
+						logrus.Errorf("Invalid client")
+						os.Exit(1)
 					}
-					newPort = uint16(v)
-				}
 
-				if len(clients) == 0 {
-					client := &wire.Client{
-						ControllerAddress: controllerAddress,
-						BoardAddress:      boardAddress,
-						ControllerPort:    controllerPort,
+					for _, client := range clients {
+						permissions, err := client.Permissions()
+						if err != nil {
+							logrus.Errorf("Could not read the permissions from controller %s: %v", client.ControllerAddress, err)
+							continue
+						}
+						for _, permission := range permissions {
+							cardID := wire.CardID(permission.AreaNumber, permission.IDNumber)
+							if card != "" && cardID != card {
+								continue
+							}
+							name := ""
+							if person := personnelList.FindByCardID(cardID); person != nil {
+								name = " | Name: " + person.Name
+							}
+							controller, door := controllerList.LookupNameAndDoor(client.ControllerAddress, permission.DoorNumber)
+							if controller == "" {
+								controller = client.ControllerAddress
+							}
+							if door == "" {
+								door = fmt.Sprintf("%d", permission.DoorNumber)
+							}
+							fmt.Printf("Controller: %s | Card ID: %s%s | Door: %s | Start: %s | End: %s | Time Index: %d | First Card: %t | Group: %d | PIN: %v\n", controller, cardID, name, door, permission.StartDate.Format(time.DateOnly), permission.EndDate.Format(time.DateOnly), permission.Time, permission.FirstCard(), permission.Group(), permission.Password)
+						}
 					}
-					clients = append(clients, client)
-				}
-				client := clients[0]
-
-				if len(client.Protocol) == 0 {
-					client.Protocol = wire.ProtocolUDP
-				}
-				if client.ControllerAddress == "" {
-					client.ControllerAddress = "255.255.255.255"
-				}
-				if client.BoardAddress == 0 {
-					client.BoardAddress = 0xffff
-				}
-				logrus.Debugf("Client: %+v", client)
-
-				request := wire.SetNetworkInfoRequest{
-					MACAddress: newMACAddress,
-					IPAddress:  newIPAddress,
-					Netmask:    newNetmask,
-					Gateway:    newGateway,
-					Port:       newPort,
-				}
-				var response wire.SetNetworkInfoResponse
-				err = client.Do(wire.FunctionSetNetworkInfo, &request, &response)
-				if err != nil {
-					logrus.Errorf("Error: %v", err)
-					return
-				}
-				logrus.Debugf("Response: %+v", response)
+				},
+			}
+			subcommand.Flags().StringVar(&card, "card", "", "Only list the permissions for this card ID")
 
-				// TODO: Print something about the response.
-			},
+			cmd.AddCommand(subcommand)
 		}
 
-		rootCommand.AddCommand(cmd)
-	}
-
-	{
-		cmd := &cobra.Command{
-			Use:   "set-time",
-			Short: "Set the time",
-			Long:  ``,
-			Args:  cobra.NoArgs,
-			Run: func(cmd *cobra.Command, args []string) {
-				if len(clients) == 0 {
-					logrus.Errorf("Invalid client")
-					os.Exit(1)
-				}
+		{
+			var from string
+			var to string
+			var timeIndex uint8
+			var firstCard bool
+			var group uint8
+			var pinValue string
 
-				for _, client := range clients {
-					var controller string
-					if controllerList != nil {
-						controller = controllerList.LookupName(client.ControllerAddress)
-					}
-					if controller == "" {
-						controller = client.ControllerAddress
+			subcommand := &cobra.Command{
+				Use:   "grant <card> <door>[ ...]",
+				Short: "Let a card open doors",
+				Long:  `If the card already has a permission for a door, then only the options that are given are changed; everything else (including the PIN) is kept.  Use "permissions pin --clear" to remove a PIN.  Use "--pin -" to read the PIN from the standard input so that it is not kept in the shell history.`,
+				Args:  cobra.MinimumNArgs(2),
+				Run: func(cmd *cobra.Command, args []string) {
+					if len(clients) == 0 {
+						logrus.Errorf("Invalid client")
+						os.Exit(1)
 					}
+					area, cardNumber, err := wire.ParseCardID(args[0])
+					if err != nil {
+						logrus.Errorf("Invalid card ID: %v", err)
+						os.Exit(1)
+					}
+					startDate, err := time.Parse(time.DateOnly, from)
+					if err != nil {
+						logrus.Errorf("Invalid start date: %v", err)
+						os.Exit(1)
+					}
+					endDate, err := time.Parse(time.DateOnly, to)
+					if err != nil {
+						logrus.Errorf("Invalid end date: %v", err)
+						os.Exit(1)
+					}
+					var pin wire.PIN
+					if pinValue != "" {
+						pin, err = readPIN(pinValue)
+						if err != nil {
+							logrus.Errorf("Invalid PIN: %v", err)
+							os.Exit(1)
+						}
+					}
+
+					standby := wire.PermissionStandby(firstCard, group)
+
+					failed := false
+					for _, client := range clients {
+						permissions, err := client.Permissions()
+						if err != nil {
+							logrus.Errorf("Could not read the permissions from controller %s: %v", client.ControllerAddress, err)
+							failed = true
+							continue
+						}
+						for _, arg := range args[1:] {
+							door, ok := parseDoor(controllerList, client.ControllerAddress, arg)
+							if !ok {
+								logrus.Errorf("No such door %q for controller %s", arg, client.ControllerAddress)
+								failed = true
+								continue
+							}
+							request := wire.Defaults(wire.UpdatePermissionsRequest{
+								CardID:    cardNumber,
+								Area:      area,
+								Door:      door,
+								StartDate: startDate,
+								EndDate:   endDate,
+								Time:      timeIndex,
+								Password:  pin,
+								Standby:   standby,
+							})
+							for _, permission := range permissions {
+								if permission.IDNumber == cardNumber && permission.AreaNumber == area && permission.DoorNumber == door {
+									// Keep whatever was not given.
+									request = permission.UpdateRequest()
+									if cmd.Flags().Changed("from") {
+										request.StartDate = startDate
+									}
+									if cmd.Flags().Changed("to") {
+										request.EndDate = endDate
+									}
+									if cmd.Flags().Changed("time-index") {
+										request.Time = timeIndex
+									}
+									if cmd.Flags().Changed("pin") {
+										request.Password = pin
+									}
+									if cmd.Flags().Changed("first-card") {
+										request.Standby[0] = standby[0]
+									}
+									if cmd.Flags().Changed("group") {
+										request.Standby[1] = standby[1]
+									}
+								}
+							}
+							if request.EndDate.Before(request.StartDate) {
+								logrus.Errorf("The end date for door %s on controller %s would be before the start date.", arg, client.ControllerAddress)
+								failed = true
+								continue
+							}
+							err := client.AddPermission(request)
+							if err != nil {
+								logrus.Errorf("Could not grant door %s on controller %s: %v", arg, client.ControllerAddress, err)
+								failed = true
+								continue
+							}
+							fmt.Printf("Controller: %s | Card ID: %s | Door: %d | Granted\n", client.ControllerAddress, wire.CardID(area, cardNumber), door)
+						}
+					}
+					if failed {
+						os.Exit(1)
+					}
+				},
+			}
+			subcommand.Flags().StringVar(&from, "from", server.DefaultStartDate.Format(time.DateOnly), "The first date that the card may be used")
+			subcommand.Flags().StringVar(&to, "to", server.DefaultEndDate.Format(time.DateOnly), "The last date that the card may be used")
+			subcommand.Flags().Uint8Var(&timeIndex, "time-index", 0, "Only allow the card during this control period (0 for any time)")
+			subcommand.Flags().BoolVar(&firstCard, "first-card", false, "Make the card a \"first card\" user")
+			subcommand.Flags().Uint8Var(&group, "group", 0, "Put the card in this user group for multi-card opening (0 is the general user group)")
+			subcommand.Flags().StringVar(&pinValue, "pin", "", "Require this PIN (up to 6 digits) along with the card (\"-\" to read it from the standard input)")
+
+			cmd.AddCommand(subcommand)
+		}
+
+		{
+			var pinValue string
+			var clearPIN bool
+
+			subcommand := &cobra.Command{
+				Use:   "pin <card> <door>[ ...]",
+				Short: "Set or clear the PIN of a card's existing permissions",
+				Long:  `Everything else about each permission is kept.  Use "--pin -" to read the PIN from the standard input so that it is not kept in the shell history.  The PIN is never shown; "permissions list" only shows whether there is one.`,
+				Args:  cobra.MinimumNArgs(2),
+				Run: func(cmd *cobra.Command, args []string) {
+					if len(clients) == 0 {
+						logrus.Errorf("Invalid client")
+						os.Exit(1)
+					}
+					if (pinValue == "") == !clearPIN {
+						logrus.Errorf("Exactly one of --pin and --clear must be given.")
+						os.Exit(1)
+					}
+					area, cardNumber, err := wire.ParseCardID(args[0])
+					if err != nil {
+						logrus.Errorf("Invalid card ID: %v", err)
+						os.Exit(1)
+					}
+					var pin wire.PIN
+					if !clearPIN {
+						pin, err = readPIN(pinValue)
+						if err != nil {
+							logrus.Errorf("Invalid PIN: %v", err)
+							os.Exit(1)
+						}
+					}
+
+					failed := false
+					for _, client := range clients {
+						permissions, err := client.Permissions()
+						if err != nil {
+							logrus.Errorf("Could not read the permissions from controller %s: %v", client.ControllerAddress, err)
+							failed = true
+							continue
+						}
+						for _, arg := range args[1:] {
+							door, ok := parseDoor(controllerList, client.ControllerAddress, arg)
+							if !ok {
+								logrus.Errorf("No such door %q for controller %s", arg, client.ControllerAddress)
+								failed = true
+								continue
+							}
+							var permission *wire.GetUploadResponse
+							for p := range permissions {
+								if permissions[p].IDNumber == cardNumber && permissions[p].AreaNumber == area && permissions[p].DoorNumber == door {
+									permission = &permissions[p]
+								}
+							}
+							if permission == nil {
+								logrus.Errorf("Card %s has no permission for door %s on controller %s", args[0], arg, client.ControllerAddress)
+								failed = true
+								continue
+							}
+							err := client.AddPermission(wire.Defaults(wire.UpdatePermissionsRequest{
+								CardID:    cardNumber,
+								Area:      area,
+								Door:      door,
+								StartDate: permission.StartDate,
+								EndDate:   permission.EndDate,
+								Time:      permission.Time,
+								Password:  pin,
+								Standby:   []byte{permission.Standby1, permission.Standby2, permission.Standby3, permission.Standby4},
+							}))
+							if err != nil {
+								logrus.Errorf("Could not update door %s on controller %s: %v", arg, client.ControllerAddress, err)
+								failed = true
+								continue
+							}
+							fmt.Printf("Controller: %s | Card ID: %s | Door: %d | PIN: %v\n", client.ControllerAddress, wire.CardID(area, cardNumber), door, pin)
+						}
+					}
+					if failed {
+						os.Exit(1)
+					}
+				},
+			}
+			subcommand.Flags().StringVar(&pinValue, "pin", "", "Require this PIN (up to 6 digits) along with the card (\"-\" to read it from the standard input)")
+			subcommand.Flags().BoolVar(&clearPIN, "clear", false, "Remove the PIN")
+
+			cmd.AddCommand(subcommand)
+		}
+
+		{
+			subcommand := &cobra.Command{
+				Use:   "revoke <card> <door>[ ...]",
+				Short: "Stop a card from opening doors",
+				Long:  ``,
+				Args:  cobra.MinimumNArgs(2),
+				Run: func(cmd *cobra.Command, args []string) {
+					if len(clients) == 0 {
+						logrus.Errorf("Invalid client")
+						os.Exit(1)
+					}
+					area, cardNumber, err := wire.ParseCardID(args[0])
+					if err != nil {
+						logrus.Errorf("Invalid card ID: %v", err)
+						os.Exit(1)
+					}
+
+					failed := false
+					for _, client := range clients {
+						for _, arg := range args[1:] {
+							door, ok := parseDoor(controllerList, client.ControllerAddress, arg)
+							if !ok {
+								logrus.Errorf("No such door %q for controller %s", arg, client.ControllerAddress)
+								failed = true
+								continue
+							}
+							err := client.DeletePermission(wire.DeletePermissionsRequest{
+								CardID: cardNumber,
+								Area:   area,
+								Door:   door,
+							})
+							if err != nil {
+								logrus.Errorf("Could not revoke door %s on controller %s: %v", arg, client.ControllerAddress, err)
+								failed = true
+								continue
+							}
+							fmt.Printf("Controller: %s | Card ID: %s | Door: %d | Revoked\n", client.ControllerAddress, wire.CardID(area, cardNumber), door)
+						}
+					}
+					if failed {
+						os.Exit(1)
+					}
+				},
+			}
+
+			cmd.AddCommand(subcommand)
+		}
+
+		rootCommand.AddCommand(cmd)
+	}
+
+	{
+		var basicConfigFile string
+
+		cmd := &cobra.Command{
+			Use:   "super-passwords",
+			Short: "Manage the super passwords",
+			Long:  `A super password opens a door from the keypad without a card.  The super passwords are part of the "basic" block (0x10F9), which cannot be read from a controller; it has to be taken from a capture of the vendor software with "view-packets basic-config".  Only the super passwords are changed; the rest of the block is sent back exactly as it was captured, so the capture should be recent.  The super passwords are treated as 16-bit numbers, and they are never shown.`,
+			Run: func(cmd *cobra.Command, args []string) {
+				cmd.Help()
+				os.Exit(1)
+			},
+		}
+
+		loadBasicConfig := func() *wire.BasicConfigRequest {
+			request, err := cobrafile.LoadBasicConfig(basicConfigFile)
+			if err != nil {
+				logrus.Errorf("Could not load the basic block: %v", err)
+				os.Exit(1)
+			}
+			return request
+		}
+		saveBasicConfig := func(request *wire.BasicConfigRequest) {
+			err := cobrafile.SaveBasicConfig(basicConfigFile, *request)
+			if err != nil {
+				logrus.Errorf("Could not save the basic block: %v", err)
+				os.Exit(1)
+			}
+		}
+
+		{
+			subcommand := &cobra.Command{
+				Use:   "list",
+				Short: "List which super password slots are in use",
+				Long:  ``,
+				Args:  cobra.NoArgs,
+				Run: func(cmd *cobra.Command, args []string) {
+					request := loadBasicConfig()
+					for i, password := range request.Config.SuperPasswords {
+						fmt.Printf("Slot: %d | Password: %v\n", i+1, password)
+					}
+				},
+			}
+
+			cmd.AddCommand(subcommand)
+		}
+
+		{
+			subcommand := &cobra.Command{
+				Use:   "set <slot> <password>",
+				Short: "Set a super password in the file",
+				Long:  `The slot is from 1 to 16, and the password is up to 5 digits.  Use "-" as the password to read it from the standard input so that it is not kept in the shell history.  Use "super-passwords push" to send the change to the controllers.`,
+				Args:  cobra.ExactArgs(2),
+				Run: func(cmd *cobra.Command, args []string) {
+					slot, err := parseSuperPasswordSlot(args[0])
+					if err != nil {
+						logrus.Errorf("Invalid slot: %v", err)
+						os.Exit(1)
+					}
+					password, err := readSuperPassword(args[1])
+					if err != nil {
+						logrus.Errorf("Invalid super password: %v", err)
+						os.Exit(1)
+					}
+
+					request := loadBasicConfig()
+					request.Config.SuperPasswords[slot] = password
+					saveBasicConfig(request)
+					fmt.Printf("Slot: %d | Password: %v\n", slot+1, password)
+				},
+			}
+
+			cmd.AddCommand(subcommand)
+		}
+
+		{
+			subcommand := &cobra.Command{
+				Use:   "clear <slot>[ ...]",
+				Short: "Clear super passwords in the file",
+				Long:  `Use "super-passwords push" to send the change to the controllers.`,
+				Args:  cobra.MinimumNArgs(1),
+				Run: func(cmd *cobra.Command, args []string) {
+					var slots []int
+					for _, arg := range args {
+						slot, err := parseSuperPasswordSlot(arg)
+						if err != nil {
+							logrus.Errorf("Invalid slot %q: %v", arg, err)
+							os.Exit(1)
+						}
+						slots = append(slots, slot)
+					}
+
+					request := loadBasicConfig()
+					for _, slot := range slots {
+						request.Config.SuperPasswords[slot] = wire.NoSuperPassword
+					}
+					saveBasicConfig(request)
+					for _, slot := range slots {
+						fmt.Printf("Slot: %d | Password: %v\n", slot+1, request.Config.SuperPasswords[slot])
+					}
+				},
+			}
+
+			cmd.AddCommand(subcommand)
+		}
+
+		{
+			subcommand := &cobra.Command{
+				Use:   "push",
+				Short: "Send the basic block (with the super passwords) to the controllers",
+				Long:  `The whole block is sent, including the settings that are not understood yet.  The meaning of the result that the controller sends back is not known, so it is shown as-is; check a door with a super password afterward.`,
+				Args:  cobra.NoArgs,
+				Run: func(cmd *cobra.Command, args []string) {
+					if len(clients) == 0 {
+						logrus.Errorf("Invalid client")
+						os.Exit(1)
+					}
+
+					request := loadBasicConfig()
+					failed := false
+					for _, client := range clients {
+						result, err := client.PushBasicConfig(*request)
+						if err != nil {
+							logrus.Errorf("Could not push the basic block to controller %s: %v", client.ControllerAddress, err)
+							failed = true
+							continue
+						}
+						fmt.Printf("Controller: %s | Result: %d\n", client.ControllerAddress, result)
+					}
+					if failed {
+						os.Exit(1)
+					}
+				},
+			}
+
+			cmd.AddCommand(subcommand)
+		}
+
+		cmd.PersistentFlags().StringVar(&basicConfigFile, "basic-config-file", "", "The file with the basic block (from \"view-packets basic-config\")")
+		cmd.MarkPersistentFlagRequired("basic-config-file")
+		rootCommand.AddCommand(cmd)
+	}
+
+	{
+		cmd := &cobra.Command{
+			Use:   "report",
+			Short: "Build reports from the access records",
+			Long:  `The records are read from every controller (by default, every controller in the controller file) for the given dates.  The dates are in the form "2006-01-02", and both ends are included.`,
+			Run: func(cmd *cobra.Command, args []string) {
+				cmd.Help()
+				os.Exit(1)
+			},
+		}
+
+		{
+			var from string
+			var to string
+			var format string
+			var table string
+			var everyone bool
+
+			subcommand := &cobra.Command{
+				Use:   "attendance",
+				Short: "Report the time on site for each person",
+				Long:  `This pairs the first swipe in with the last swipe out for each person on each day and totals the hours per person and per department.  The "Door # Direction" columns of the controller file mark doors as "in" or "out"; the first in is never at an "out" door, and the last out is never at an "in" door.  Only the people marked for attendance in the personnel file are included unless --everyone is given.`,
+				Args:  cobra.NoArgs,
+				Run: func(cmd *cobra.Command, args []string) {
+					fromTime, toTime, err := parseDateRange(from, to)
+					if err != nil {
+						logrus.Errorf("Invalid dates: %v", err)
+						os.Exit(1)
+					}
+					if len(clients) == 0 {
+						clients = controllerClients(controllerList, protocol)
+					}
+					if len(clients) == 0 {
+						logrus.Errorf("Invalid client")
+						os.Exit(1)
+					}
+
+					entries := fetchRecords(clients, controllerList, fromTime, toTime)
+					attendance := report.BuildAttendance(entries, personnelList, fromTime, toTime, everyone)
+					switch format {
+					case "csv":
+						err = attendance.WriteCSV(os.Stdout, table)
+					case "json":
+						err = writeJSON(os.Stdout, attendance)
+					default:
+						err = fmt.Errorf("invalid format: %q", format)
+					}
+					if err != nil {
+						logrus.Errorf("Error: %v", err)
+						os.Exit(1)
+					}
+				},
+			}
+			subcommand.Flags().StringVar(&from, "from", "", "The first date to include (required)")
+			subcommand.Flags().StringVar(&to, "to", "", "The last date to include (default: today)")
+			subcommand.Flags().StringVar(&format, "format", "csv", "The output format (\"csv\" or \"json\")")
+			subcommand.Flags().StringVar(&table, "table", report.AttendanceTablePeople, "The table to write as CSV (\"days\", \"people\", or \"departments\")")
+			subcommand.Flags().BoolVar(&everyone, "everyone", false, "Include everyone in the personnel file, not just the people marked for attendance")
+
+			cmd.AddCommand(subcommand)
+		}
+
+		{
+			var from string
+			var to string
+			var format string
+			var businessHours string
+			var businessDays string
+			var top int
+			var noPermissions bool
+
+			subcommand := &cobra.Command{
+				Use:   "audit",
+				Short: "Report denied attempts, unknown cards, and after-hours entries",
+				Long:  `This summarizes the denied attempts by reason and by card, the cards used outside of their permission windows, the cards that are not in the personnel file, the entries outside of business hours, and the doors with the most activity.  The permissions are read from each controller to check the permission windows unless --no-permissions is given; the controller's own "expired or outside of its time period" denials are always included.  Business hours are in each controller's time zone.`,
+				Args:  cobra.NoArgs,
+				Run: func(cmd *cobra.Command, args []string) {
+					fromTime, toTime, err := parseDateRange(from, to)
+					if err != nil {
+						logrus.Errorf("Invalid dates: %v", err)
+						os.Exit(1)
+					}
+					options := report.AuditOptions{
+						TopDoors: top,
+					}
+					options.BusinessHours.Start, options.BusinessHours.End, err = report.ParseHours(businessHours)
+					if err != nil {
+						logrus.Errorf("Invalid business hours: %v", err)
+						os.Exit(1)
+					}
+					options.BusinessHours.Days, err = parseWeekdays(businessDays)
+					if err != nil {
+						logrus.Errorf("Invalid business days: %v", err)
+						os.Exit(1)
+					}
+					if len(clients) == 0 {
+						clients = controllerClients(controllerList, protocol)
+					}
+					if len(clients) == 0 {
+						logrus.Errorf("Invalid client")
+						os.Exit(1)
+					}
+
+					entries := fetchRecords(clients, controllerList, fromTime, toTime)
+					if !noPermissions {
+						options.Permissions = map[string][]wire.GetUploadResponse{}
+						for _, client := range clients {
+							permissions, err := client.Permissions()
+							if err != nil {
+								logrus.Errorf("Could not read the permissions from controller %s: %v", client.ControllerAddress, err)
+								continue
+							}
+							options.Permissions[client.ControllerAddress] = permissions
+						}
+					}
+					audit := report.BuildAudit(entries, personnelList, fromTime, toTime, options)
+					switch format {
+					case "text":
+						err = audit.WriteText(os.Stdout)
+					case "json":
+						err = writeJSON(os.Stdout, audit)
+					default:
+						err = fmt.Errorf("invalid format: %q", format)
+					}
+					if err != nil {
+						logrus.Errorf("Error: %v", err)
+						os.Exit(1)
+					}
+				},
+			}
+			subcommand.Flags().StringVar(&from, "from", "", "The first date to include (required)")
+			subcommand.Flags().StringVar(&to, "to", "", "The last date to include (default: today)")
+			subcommand.Flags().StringVar(&format, "format", "text", "The output format (\"text\" or \"json\")")
+			subcommand.Flags().StringVar(&businessHours, "business-hours", "07:00-19:00", "The business hours (\"hh:mm-hh:mm\")")
+			subcommand.Flags().StringVar(&businessDays, "business-days", "mon-fri", "The business days (for example, \"mon-fri\" or \"mon,wed,fri\")")
+			subcommand.Flags().IntVar(&top, "top", report.DefaultTopDoors, "The number of doors in the busiest doors list (0 for every door)")
+			subcommand.Flags().BoolVar(&noPermissions, "no-permissions", false, "Do not read the permissions to check the permission windows")
+
+			cmd.AddCommand(subcommand)
+		}
+
+		rootCommand.AddCommand(cmd)
+	}
+
+	{
+		var restoreOptions backup.RestoreOptions
+		var yes bool
+
+		cmd := &cobra.Command{
+			Use:   "restore <file>",
+			Short: "Restore the controller configuration from a backup",
+			Long:  `The changes needed to make the controller match the backup are shown first; nothing is changed unless "--yes" is given.  Permissions and control periods that are not in the backup are only removed with "--prune".  Only the setting registers with a known meaning are restored unless "--raw-registers" is given; be careful with that when restoring onto a different board.  The network configuration is only restored with "--network", and it is changed last, since the controller is at its new address afterward.  Timing tasks are never touched, since they cannot be read back from the controller.  If the backup does not include PINs, then each permission keeps the PIN that it has on the controller.`,
+			Args:  cobra.ExactArgs(1),
+			Run: func(cmd *cobra.Command, args []string) {
+				if len(clients) != 1 {
+					logrus.Errorf("Exactly one controller must be specified")
+					os.Exit(1)
+				}
+				client := clients[0]
+
+				desired, err := backup.Load(args[0])
+				if err != nil {
+					logrus.Errorf("Could not load backup: %v", err)
+					os.Exit(1)
+				}
+				if desired.BoardAddress != client.BoardAddress {
+					logrus.Warnf("Backup is from board %d; restoring onto board %d", desired.BoardAddress, client.BoardAddress)
+				}
+
+				current, err := backup.Capture(client, backup.CaptureOptions{PINs: true}) // The PINs are needed to keep them.
+				if err != nil {
+					logrus.Errorf("Could not capture current configuration: %v", err)
+					os.Exit(1)
+				}
+
+				changes := backup.Diff(current, desired, restoreOptions)
+				for _, change := range changes {
+					fmt.Printf("%s | %s\n", change.Section, change.Description)
+				}
+				fmt.Printf("Changes: %d\n", len(changes))
+				if !yes {
+					if len(changes) > 0 {
+						fmt.Printf("Re-run with --yes to apply these changes.\n")
+					}
+					return
+				}
+
+				failures := 0
+				for _, change := range changes {
+					err := change.Apply(client)
+					if err != nil {
+						logrus.Errorf("Could not apply change (%s | %s): %v", change.Section, change.Description, err)
+						failures++
+						continue
+					}
+				}
+				fmt.Printf("Applied: %d | Failed: %d\n", len(changes)-failures, failures)
+				if failures > 0 {
+					os.Exit(1)
+				}
+			},
+		}
+		cmd.Flags().BoolVar(&restoreOptions.Network, "network", false, "Also restore the network configuration (the MAC address of the board is kept)")
+		cmd.Flags().BoolVar(&restoreOptions.Prune, "prune", false, "Also remove the permissions and control periods that are not in the backup")
+		cmd.Flags().BoolVar(&restoreOptions.RawRegisters, "raw-registers", false, "Also restore the setting registers without a known meaning")
+		cmd.Flags().BoolVar(&yes, "yes", false, "Apply the changes")
+
+		rootCommand.AddCommand(cmd)
+	}
+
+	{
+		cmd := &cobra.Command{
+			Use:   "search",
+			Short: "Search for controllers on the local network",
+			Long:  ``,
+			Args:  cobra.NoArgs,
+			Run: func(cmd *cobra.Command, args []string) {
+				if len(clients) == 0 {
+					client := &wire.Client{
+						ControllerAddress: controllerAddress,
+						BoardAddress:      boardAddress,
+						ControllerPort:    controllerPort,
+					}
+					clients = append(clients, client)
+				}
+				client := clients[0]
+
+				if len(client.Protocol) == 0 {
+					client.Protocol = wire.ProtocolUDP
+				}
+				if client.ControllerAddress == "" {
+					client.ControllerAddress = "255.255.255.255"
+				}
+				if client.BoardAddress == 0 {
+					client.BoardAddress = 0xffff
+				}
+				logrus.Debugf("Client: %+v", client)
+
+				request := wire.GetNetworkInfoRequest{
+					Unknown1: 0,
+				}
+				var responses []wire.GetNetworkInfoResponse
+				responseEnvelopes, err := client.DoWithEnvelopes(wire.FunctionGetNetworkInfo, &request, &responses)
+				if err != nil {
+					logrus.Errorf("Error from client: %v", err)
+					return
+				}
+
+				logrus.Debugf("Responses: %+v", responses)
+				for i, response := range responses {
+					responseEnvelope := responseEnvelopes[i]
+					logrus.Debugf("Response envelope: %+v", responseEnvelope)
+					logrus.Debugf("Response: %+v", response)
+					fmt.Printf("Board address: %d :: MAC address: %s (%s / %s via %s on port %d)\n", responseEnvelope.BoardAddress, response.MACAddress, response.IPAddress, response.Netmask, response.Gateway, response.Port)
+				}
+			},
+		}
+
+		rootCommand.AddCommand(cmd)
+	}
+
+	{
+		cmd := &cobra.Command{
+			Use:   "set-network <mac> <ip> <netmask> <gateway> [<port>]",
+			Short: "Set the network information",
+			Long:  ``,
+			Args:  cobra.RangeArgs(4, 5),
+			Run: func(cmd *cobra.Command, args []string) {
+				newMACAddress, err := net.ParseMAC(args[0])
+				if err != nil {
+					logrus.Errorf("Could not parse MAC address: [%T] %v", err, err)
+					return
+				}
+				newIPAddress := net.ParseIP(args[1])
+				newNetmask := net.ParseIP(args[2])
+				newGateway := net.ParseIP(args[3])
+				newPort := wire.PortDefault
+				if len(args) > 4 {
+					v, err := strconv.ParseUint(args[4], 10, 16)
+					if err != nil {
+						logrus.Errorf("Could not parse port number: [%T] %v", err, err)
+						return
+					}
+					newPort = uint16(v)
+				}
+
+				if len(clients) == 0 {
+					client := &wire.Client{
+						ControllerAddress: controllerAddress,
+						BoardAddress:      boardAddress,
+						ControllerPort:    controllerPort,
+					}
+					clients = append(clients, client)
+				}
+				client := clients[0]
+
+				if len(client.Protocol) == 0 {
+					client.Protocol = wire.ProtocolUDP
+				}
+				if client.ControllerAddress == "" {
+					client.ControllerAddress = "255.255.255.255"
+				}
+				if client.BoardAddress == 0 {
+					client.BoardAddress = 0xffff
+				}
+				logrus.Debugf("Client: %+v", client)
+
+				request := wire.SetNetworkInfoRequest{
+					MACAddress: newMACAddress,
+					IPAddress:  newIPAddress,
+					Netmask:    newNetmask,
+					Gateway:    newGateway,
+					Port:       newPort,
+				}
+				var response wire.SetNetworkInfoResponse
+				err = client.Do(wire.FunctionSetNetworkInfo, &request, &response)
+				if err != nil {
+					logrus.Errorf("Error: %v", err)
+					return
+				}
+				logrus.Debugf("Response: %+v", response)
+
+				// TODO: Print something about the response.
+			},
+		}
+
+		rootCommand.AddCommand(cmd)
+	}
+
+	{
+		cmd := &cobra.Command{
+			Use:   "set-time",
+			Short: "Set the time",
+			Long:  ``,
+			Args:  cobra.NoArgs,
+			Run: func(cmd *cobra.Command, args []string) {
+				if len(clients) == 0 {
+					logrus.Errorf("Invalid client")
+					os.Exit(1)
+				}
+
+				for _, client := range clients {
+					var controller string
+					if controllerList != nil {
+						controller = controllerList.LookupName(client.ControllerAddress)
+					}
+					if controller == "" {
+						controller = client.ControllerAddress
+					}
+
+					currentTime := client.WallClock(time.Now())
+
+					request := wire.SetTimeRequest{
+						CurrentTime: currentTime,
+					}
+					var response wire.SetTimeResponse
+					err := client.Do(wire.FunctionSetTime, &request, &response)
+					if err != nil {
+						logrus.Errorf("Error: %v", err)
+						continue
+					}
+					logrus.Debugf("Response: %+v", response)
+
+					fmt.Printf("Controller: %s | Current time: %v | System time: %s\n", controller, currentTime, response.CurrentTime)
+				}
+			},
+		}
+
+		rootCommand.AddCommand(cmd)
+	}
+
+	{
+		cmd := &cobra.Command{
+			Use:   "settings",
+			Short: "Manage the setting registers",
+			Long:  `The setting registers can be dumped and compared by address.  None of the registers has a known meaning yet, so they cannot be read or set by name.`,
+			Run: func(cmd *cobra.Command, args []string) {
+				cmd.Help()
+				os.Exit(1)
+			},
+		}
+
+		{
+			subcommand := &cobra.Command{
+				Use:   "diff <controller|file> <controller|file>",
+				Short: "Compare the setting registers",
+				Long:  `Each side may be either a settings dump file or the name of a controller in the controller file.`,
+				Args:  cobra.ExactArgs(2),
+				Run: func(cmd *cobra.Command, args []string) {
+					load := func(source string) (cobrafile.SettingsDump, error) {
+						if _, err := os.Stat(source); err == nil {
+							return cobrafile.LoadSettings(source)
+						}
+						for _, controller := range controllerList {
+							if controller.Name == source {
+								return controllerClient(controller, protocol).Settings()
+							}
+						}
+						return nil, fmt.Errorf("no such file or controller: %s", source)
+					}
+
+					left, err := load(args[0])
+					if err != nil {
+						logrus.Errorf("Could not load %s: %v", args[0], err)
+						os.Exit(1)
+					}
+					right, err := load(args[1])
+					if err != nil {
+						logrus.Errorf("Could not load %s: %v", args[1], err)
+						os.Exit(1)
+					}
+
+					differences := 0
+					for address := range left {
+						if address >= len(right) || left[address] == right[address] {
+							continue
+						}
+						differences++
+						if setting := wire.LookupSettingByAddress(uint8(address)); setting != nil {
+							fmt.Printf("Address: 0x%02x | Name: %s | %s: %s | %s: %s\n", address, setting.Name, args[0], setting.FormatValue(left[address]), args[1], setting.FormatValue(right[address]))
+						} else {
+							fmt.Printf("Address: 0x%02x | %s: %d | %s: %d\n", address, args[0], left[address], args[1], right[address])
+						}
+					}
+					fmt.Printf("Differences: %d\n", differences)
+				},
+			}
+
+			cmd.AddCommand(subcommand)
+		}
+
+		{
+			subcommand := &cobra.Command{
+				Use:   "dump <file>",
+				Short: "Read all of the setting registers into a file",
+				Long:  ``,
+				Args:  cobra.ExactArgs(1),
+				Run: func(cmd *cobra.Command, args []string) {
+					if len(clients) != 1 {
+						logrus.Errorf("Exactly one controller must be specified")
+						os.Exit(1)
+					}
+					client := clients[0]
+
+					settings, err := client.Settings()
+					if err != nil {
+						logrus.Errorf("Error from client: %v", err)
+						os.Exit(1)
+					}
+					err = cobrafile.SaveSettings(args[0], settings)
+					if err != nil {
+						logrus.Errorf("Could not save settings: %v", err)
+						os.Exit(1)
+					}
+					fmt.Printf("Wrote %d settings to %s\n", len(settings), args[0])
+				},
+			}
 
-					currentTime := time.Now()
-					currentTime = time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), currentTime.Hour(), currentTime.Minute(), currentTime.Second(), 0, time.UTC)
+			cmd.AddCommand(subcommand)
+		}
 
-					request := wire.SetTimeRequest{
-						CurrentTime: currentTime,
+		rootCommand.AddCommand(cmd)
+	}
+
+	{
+		var watch bool
+		var interval time.Duration
+
+		cmd := &cobra.Command{
+			Use:   "status",
+			Short: "Show the state of each door",
+			Long:  `This shows whether each door's relay is energized, whether its sensor reports that it is open, and any fault on the controller (only "no fault" has a description so far; any other fault is shown by its number).  With "--watch", the table is refreshed until interrupted.`,
+			Args:  cobra.NoArgs,
+			Run: func(cmd *cobra.Command, args []string) {
+				if len(clients) == 0 {
+					logrus.Errorf("Invalid client")
+					os.Exit(1)
+				}
+
+				for iteration := 0; ; iteration++ {
+					if iteration > 0 {
+						logrus.Debugf("Sleeping for %v.", interval)
+						time.Sleep(interval)
 					}
-					var response wire.SetTimeResponse
-					err := client.Do(wire.FunctionSetTime, &request, &response)
+
+					table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+					fmt.Fprintf(table, "CONTROLLER\tDOOR\tRELAY\tSENSOR\tFAULT\tTIME\n")
+					for _, client := range clients {
+						var controller string
+						if controllerList != nil {
+							controller = controllerList.LookupName(client.ControllerAddress)
+						}
+						if controller == "" {
+							controller = client.ControllerAddress
+						}
+
+						request := wire.GetOperationStatusRequest{
+							RecordIndex: 0,
+						}
+						var response wire.GetOperationStatusResponse
+						err := client.Do(wire.FunctionGetOperationStatus, &request, &response)
+						if err != nil {
+							logrus.Debugf("Error from client: %v", err)
+							fmt.Fprintf(table, "%s\t-\t-\t-\t-\terror: %v\n", controller, err)
+							continue
+						}
+						logrus.Debugf("Response: %+v", response)
+
+						for door := uint8(1); door <= 4; door++ {
+							var doorName string
+							if controllerList != nil {
+								doorName = controllerList.LookupDoor(client.ControllerAddress, door)
+							}
+							if doorName == "" {
+								doorName = fmt.Sprintf("%d", door)
+							}
+							relay := "off"
+							if response.RelayStatus.Energized(door) {
+								relay = "energized"
+							}
+							sensor := "closed"
+							if response.MagnetState.DoorOpen(door) {
+								sensor = "open"
+							}
+							fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%v\n", controller, doorName, relay, sensor, response.FaultNumber.Description(), response.CurrentTime)
+						}
+					}
+
+					if watch {
+						// Clear the screen so that the table stays in place.
+						fmt.Printf("\033[H\033[2J")
+					}
+					table.Flush()
+
+					if !watch {
+						break
+					}
+				}
+			},
+		}
+		cmd.Flags().BoolVar(&watch, "watch", false, "Keep refreshing the table")
+		cmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "How long to wait between refreshes")
+
+		rootCommand.AddCommand(cmd)
+	}
+
+	{
+		cmd := &cobra.Command{
+			Use:   "tasks",
+			Short: "Manage the timing tasks",
+			Long:  `The timing tasks cannot be read back from the controller, so every task that is added is recorded in the task file given by "--task-file"; "tasks list" shows what is in that file.`,
+			Run: func(cmd *cobra.Command, args []string) {
+				cmd.Help()
+				os.Exit(1)
+			},
+		}
+
+		var taskFile string
+
+		{
+			var doorString string
+			var daysString string
+			var controlString string
+			var fromString string
+			var toString string
+			var startDateString string
+			var endDateString string
+			var taskIndex uint16
+
+			subcommand := &cobra.Command{
+				Use:   "add",
+				Short: "Add a timing task",
+				Long:  `This sets the door to the given control at the "from" time.  If a "to" time is given, then a second task is added to return the door to normal control at that time.  The tasks are stored in the slot given by "--index" (and the one after it, for the "to" time), replacing whatever was there; the tasks cannot be read back from the controller, so use "--task-file" to keep track of which slots are in use.  For example, "--index 1 --door 1 --days mon-fri --from 08:00 --to 17:00" unlocks door 1 during business hours on weekdays.`,
+				Args:  cobra.NoArgs,
+				Run: func(cmd *cobra.Command, args []string) {
+					if len(clients) == 0 {
+						logrus.Errorf("Invalid client")
+						os.Exit(1)
+					}
+
+					weekdays, err := parseWeekdays(daysString)
 					if err != nil {
-						logrus.Errorf("Error: %v", err)
-						continue
+						logrus.Errorf("Could not parse days: %v", err)
+						os.Exit(1)
+					}
+					control, err := parseDoorControl(controlString)
+					if err != nil {
+						logrus.Errorf("Could not parse control: %v", err)
+						os.Exit(1)
+					}
+					fromTime, err := time.Parse("15:04", fromString)
+					if err != nil {
+						logrus.Errorf("Could not parse from time: %v", err)
+						os.Exit(1)
+					}
+					var toTime *time.Time
+					if toString != "" {
+						v, err := time.Parse("15:04", toString)
+						if err != nil {
+							logrus.Errorf("Could not parse to time: %v", err)
+							os.Exit(1)
+						}
+						toTime = &v
+					}
+					startDate, err := time.Parse(time.DateOnly, startDateString)
+					if err != nil {
+						logrus.Errorf("Could not parse start date: %v", err)
+						os.Exit(1)
+					}
+					endDate, err := time.Parse(time.DateOnly, endDateString)
+					if err != nil {
+						logrus.Errorf("Could not parse end date: %v", err)
+						os.Exit(1)
+					}
+					lastIndex := int(taskIndex)
+					if toTime != nil {
+						lastIndex++
+					}
+					if taskIndex == 0 || lastIndex > wire.TimingTaskMax {
+						logrus.Errorf("The tasks do not fit in slots %d to %d (the slots are 1-%d)", taskIndex, lastIndex, wire.TimingTaskMax)
+						os.Exit(1)
 					}
-					logrus.Debugf("Response: %+v", response)
 
-					fmt.Printf("Controller: %s | Current time: %v | System time: %s\n", controller, currentTime, response.CurrentTime)
+					var tasks cobrafile.TaskList
+					if taskFile != "" {
+						tasks, err = cobrafile.LoadTasks(taskFile)
+						if err != nil {
+							logrus.Errorf("Could not load task file: %v", err)
+							os.Exit(1)
+						}
+					}
+
+					failed := false
+					for _, client := range clients {
+						door, ok := parseDoor(controllerList, client.ControllerAddress, doorString)
+						if !ok {
+							logrus.Warnf("No such door %q for controller %s", doorString, client.ControllerAddress)
+							continue
+						}
+
+						var requests []wire.RealizeTimingTaskRequest
+						requests = append(requests, wire.RealizeTimingTaskRequest{
+							WeekControl: wire.WeekControl(weekdays...),
+							Door:        door,
+							Control:     control,
+							StartTime:   fromTime,
+							StartDate:   startDate,
+							EndDate:     endDate,
+						})
+						if toTime != nil {
+							requests = append(requests, wire.RealizeTimingTaskRequest{
+								WeekControl: wire.WeekControl(weekdays...),
+								Door:        door,
+								Control:     wire.DoorControlControlled,
+								StartTime:   *toTime,
+								StartDate:   startDate,
+								EndDate:     endDate,
+							})
+						}
+
+						nextIndex := taskIndex
+						for _, request := range requests {
+							request.TaskIndex = nextIndex
+							nextIndex++
+
+							logrus.Debugf("Request: %+v", request)
+							err := client.SetTimingTask(request)
+							if err != nil {
+								logrus.Errorf("Error from client: %v", err)
+								failed = true
+								break
+							}
+							tasks.Set(client.ControllerAddress, request)
+							fmt.Printf("Controller: %s | Task: %d | Door: %d | Control: %s | Time: %s | Days: %s\n", client.ControllerAddress, request.TaskIndex, request.Door, doorControlName(request.Control), request.StartTime.Format("15:04"), formatWeekdays(weekdays))
+						}
+					}
+
+					// Record whatever was written, even if something failed.
+					if taskFile != "" {
+						err = cobrafile.SaveTasks(taskFile, tasks)
+						if err != nil {
+							logrus.Errorf("Could not save task file: %v", err)
+							os.Exit(1)
+						}
+					}
+					if failed {
+						os.Exit(1)
+					}
+				},
+			}
+			subcommand.Flags().StringVar(&doorString, "door", "", "The door (either the number or the name from the controller file)")
+			subcommand.Flags().StringVar(&daysString, "days", "all", `The days of the week (for example, "mon-fri", "sat,sun", or "all")`)
+			subcommand.Flags().StringVar(&controlString, "control", "open", `The door control at the "from" time ("open", "closed", or "controlled")`)
+			subcommand.Flags().StringVar(&fromString, "from", "", "The time of day to apply the control (HH:MM)")
+			subcommand.Flags().StringVar(&toString, "to", "", "The time of day to return the door to normal control (HH:MM)")
+			subcommand.Flags().StringVar(&startDateString, "start-date", "2000-01-01", "The first day that the task is active")
+			subcommand.Flags().StringVar(&endDateString, "end-date", "2050-12-31", "The last day that the task is active")
+			subcommand.Flags().Uint16Var(&taskIndex, "index", 0, "The task slot to use (1-64)")
+			subcommand.MarkFlagRequired("index")
+			subcommand.MarkFlagRequired("door")
+			subcommand.MarkFlagRequired("from")
+
+			cmd.AddCommand(subcommand)
+		}
+
+		{
+			subcommand := &cobra.Command{
+				Use:   "list",
+				Short: "List the timing tasks in the task file",
+				Long:  `Only the tasks that were added with this task file are shown; tasks set by other software are not known.  If controllers are given, then only their tasks are shown.`,
+				Args:  cobra.NoArgs,
+				Run: func(cmd *cobra.Command, args []string) {
+					if taskFile == "" {
+						logrus.Errorf("A task file must be given with --task-file")
+						os.Exit(1)
+					}
+					tasks, err := cobrafile.LoadTasks(taskFile)
+					if err != nil {
+						logrus.Errorf("Could not load task file: %v", err)
+						os.Exit(1)
+					}
+
+					for _, task := range tasks {
+						if len(clients) > 0 {
+							found := false
+							for _, client := range clients {
+								if client.ControllerAddress == task.Controller {
+									found = true
+								}
+							}
+							if !found {
+								continue
+							}
+						}
+						controller, door := controllerList.LookupNameAndDoor(task.Controller, task.Door)
+						if controller == "" {
+							controller = task.Controller
+						}
+						if door == "" {
+							door = fmt.Sprintf("%d", task.Door)
+						}
+						fmt.Printf("Controller: %s | Task: %d | Door: %s | Control: %s | Time: %s | Days: %s | Start: %s | End: %s\n", controller, task.TaskIndex, door, doorControlName(task.Control), task.StartTime.Format("15:04"), formatWeekdays(wire.WeekControlDays(task.WeekControl)), task.StartDate.Format(time.DateOnly), task.EndDate.Format(time.DateOnly))
+					}
+				},
+			}
+
+			cmd.AddCommand(subcommand)
+		}
+
+		cmd.PersistentFlags().StringVar(&taskFile, "task-file", "", "The CSV file that records the timing tasks that were added")
+		rootCommand.AddCommand(cmd)
+	}
+
+	{
+		var interval time.Duration
+		var threshold time.Duration
+		var samples int
+		var once bool
+
+		cmd := &cobra.Command{
+			Use:   "time-sync",
+			Short: "Keep the controllers' clocks in sync",
+			Long:  `This checks the drift of every controller (by default, every controller in the controller file) on a schedule and sets the time when the drift is over the threshold or when the controller's time zone changes its offset (such as for daylight saving time).  The round trip to the controller is taken into account, and the time zone comes from the "Time Zone" column of the controller file (the local time zone is used if it is blank).  Every adjustment is logged.`,
+			Args:  cobra.NoArgs,
+			Run: func(cmd *cobra.Command, args []string) {
+				if len(clients) == 0 {
+					clients = controllerClients(controllerList, protocol)
+				}
+				if len(clients) == 0 {
+					logrus.Errorf("Invalid client")
+					os.Exit(1)
+				}
+
+				syncer := &timesync.Syncer{
+					Interval:  interval,
+					Threshold: threshold,
+					Samples:   samples,
 				}
+				for _, client := range clients {
+					controller := lookupController(controllerList, client)
+					name := controller.Name
+					if name == "" {
+						name = client.ControllerAddress
+					}
+					syncer.Targets = append(syncer.Targets, timesync.Target{
+						Name:   name,
+						Client: client,
+					})
+				}
+
+				if once {
+					adjustments := syncer.CheckAll()
+					fmt.Printf("Checked: %d | Adjusted: %d\n", len(syncer.Targets), len(adjustments))
+					return
+				}
+				logrus.Infof("Checking %d controller(s) every %v (threshold: %v).", len(syncer.Targets), interval, threshold)
+				syncer.Run(context.Background())
+			},
+		}
+		cmd.Flags().DurationVar(&interval, "interval", time.Hour, "How long to wait between checks")
+		cmd.Flags().DurationVar(&threshold, "threshold", timesync.DefaultThreshold, "Set the time when the drift is more than this")
+		cmd.Flags().IntVar(&samples, "samples", timesync.DefaultSamples, "How many times to read each clock per check (the fastest round trip is used)")
+		cmd.Flags().BoolVar(&once, "once", false, "Check once and exit")
+
+		rootCommand.AddCommand(cmd)
+	}
+
+	{
+		var webhookFile string
+
+		cmd := &cobra.Command{
+			Use:   "webhooks",
+			Short: "Manage the webhooks",
+			Long:  ``,
+			Run: func(cmd *cobra.Command, args []string) {
+				cmd.Help()
+				os.Exit(1)
 			},
 		}
+		cmd.PersistentFlags().StringVar(&webhookFile, "webhook-file", "", "Use the rules in this webhook file")
+
+		{
+			subcommand := &cobra.Command{
+				Use:   "redeliver",
+				Short: "Deliver the webhooks in the dead-letter file again",
+				Long:  `Each webhook is sent using the current rule with the same name (with the usual retries); whatever still fails is left in the dead-letter file.`,
+				Args:  cobra.NoArgs,
+				Run: func(cmd *cobra.Command, args []string) {
+					if webhookFile == "" {
+						logrus.Errorf("Missing webhook file.")
+						os.Exit(1)
+					}
+					config, err := webhook.LoadConfig(webhookFile)
+					if err != nil {
+						logrus.Errorf("Could not load webhook file: %v", err)
+						os.Exit(1)
+					}
+					dispatcher, err := webhook.NewDispatcher(*config)
+					if err != nil {
+						logrus.Errorf("Invalid webhook file: %v", err)
+						os.Exit(1)
+					}
+					succeeded, failed, err := dispatcher.Redeliver()
+					if err != nil {
+						logrus.Errorf("Error: %v", err)
+						os.Exit(1)
+					}
+					fmt.Printf("Delivered: %d | Failed: %d\n", succeeded, failed)
+					if failed > 0 {
+						os.Exit(1)
+					}
+				},
+			}
+			cmd.AddCommand(subcommand)
+		}
 
 		rootCommand.AddCommand(cmd)
 	}
@@ -701,3 +2063,240 @@ func main() {
 	}
 	os.Exit(0)
 }
+
+// controllerClients returns a client for every controller in the controller list.
+func controllerClients(controllerList cobrafile.ControllerList, protocol string) []*wire.Client {
+	var clients []*wire.Client
+	for _, controller := range controllerList {
+		clients = append(clients, controllerClient(controller, protocol))
+	}
+	return clients
+}
+
+// controllerClient returns a client for the controller from the controller file.
+func controllerClient(controller cobrafile.Controller, protocol string) *wire.Client {
+	client := &wire.Client{
+		ControllerAddress: controller.Address,
+		ControllerPort:    controller.Port,
+		BoardAddress:      controller.SN,
+		Protocol:          wire.Protocol(protocol),
+		Location:          controller.Location(),
+	}
+	logrus.Debugf("Client: %+v", client)
+	return client
+}
+
+// parseDateRange parses the dates of a report.
+//
+// Both dates are included, so the end of the range is the start of the day after
+// `to`.  If `to` is empty, then it is today.
+func parseDateRange(from string, to string) (time.Time, time.Time, error) {
+	if from == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("missing start date")
+	}
+	fromTime, err := time.ParseInLocation(time.DateOnly, from, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("could not parse start date: %w", err)
+	}
+	toTime := time.Now()
+	if to != "" {
+		toTime, err = time.ParseInLocation(time.DateOnly, to, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("could not parse end date: %w", err)
+		}
+	}
+	toTime = time.Date(toTime.Year(), toTime.Month(), toTime.Day()+1, 0, 0, 0, 0, time.Local)
+	if !fromTime.Before(toTime) {
+		return time.Time{}, time.Time{}, fmt.Errorf("the start date is after the end date")
+	}
+	return fromTime, toTime, nil
+}
+
+// fetchRecords returns the records from every client in the time range.
+//
+// A controller that cannot be read is skipped with an error.
+func fetchRecords(clients []*wire.Client, controllerList cobrafile.ControllerList, from time.Time, to time.Time) []report.Entry {
+	var entries []report.Entry
+	for _, client := range clients {
+		controller := lookupController(controllerList, client)
+		result, err := report.Fetch(client, controller, from, to)
+		if err != nil {
+			logrus.Errorf("Could not read the records from controller %s: %v", client.ControllerAddress, err)
+			continue
+		}
+		logrus.Debugf("Controller %s: %d record(s) in range", client.ControllerAddress, len(result))
+		entries = append(entries, result...)
+	}
+	report.Sort(entries)
+	return entries
+}
+
+// writeJSON writes the value as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// readSecret returns the value; if the value is "-", then it is read from the
+// first line of the standard input instead.
+func readSecret(value string) (string, error) {
+	if value != "-" {
+		return value, nil
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// readPIN parses a PIN; if the value is "-", then the PIN is read from the
+// first line of the standard input.
+func readPIN(value string) (wire.PIN, error) {
+	value, err := readSecret(value)
+	if err != nil {
+		return 0, fmt.Errorf("could not read the PIN: %w", err)
+	}
+	return wire.ParsePIN(value)
+}
+
+// readSuperPassword parses a super password; if the value is "-", then the
+// super password is read from the first line of the standard input.
+func readSuperPassword(value string) (wire.SuperPassword, error) {
+	value, err := readSecret(value)
+	if err != nil {
+		return 0, fmt.Errorf("could not read the super password: %w", err)
+	}
+	return wire.ParseSuperPassword(value)
+}
+
+// parseSuperPasswordSlot returns the 0-index of the 1-index super password
+// slot.
+func parseSuperPasswordSlot(value string) (int, error) {
+	slot, err := strconv.Atoi(value)
+	if err != nil || slot < 1 || slot > wire.SuperPasswordCount {
+		return 0, fmt.Errorf("expected a number from 1 to %d", wire.SuperPasswordCount)
+	}
+	return slot - 1, nil
+}
+
+// lookupController returns the controller for the client from the controller
+// list; if it is not in the list, then only the address is filled in.
+func lookupController(controllerList cobrafile.ControllerList, client *wire.Client) cobrafile.Controller {
+	for _, controller := range controllerList {
+		if controller.Address == client.ControllerAddress {
+			return controller
+		}
+	}
+	return cobrafile.Controller{
+		Address: client.ControllerAddress,
+		Port:    client.ControllerPort,
+		SN:      client.BoardAddress,
+	}
+}
+
+// parseDoor returns the 1-index door number for the given value.
+//
+// The value may either be the door number (1-4) or the name of the door from
+// the controller list.
+func parseDoor(controllerList cobrafile.ControllerList, address string, value string) (uint8, bool) {
+	switch value {
+	case "1":
+		return 1, true
+	case "2":
+		return 2, true
+	case "3":
+		return 3, true
+	case "4":
+		return 4, true
+	}
+	if controllerList != nil {
+		return controllerList.FindDoor(address, value)
+	}
+	return 0, false
+}
+
+// parseDoorControl parses a door control name into one of the wire.DoorControl* constants.
+func parseDoorControl(value string) (uint8, error) {
+	switch strings.ToLower(value) {
+	case "open":
+		return wire.DoorControlOpen, nil
+	case "closed":
+		return wire.DoorControlClosed, nil
+	case "controlled":
+		return wire.DoorControlControlled, nil
+	}
+	return 0, fmt.Errorf("invalid door control: %q", value)
+}
+
+// doorControlName returns the name of one of the wire.DoorControl* constants.
+func doorControlName(value uint8) string {
+	switch value {
+	case wire.DoorControlOpen:
+		return "open"
+	case wire.DoorControlClosed:
+		return "closed"
+	case wire.DoorControlControlled:
+		return "controlled"
+	}
+	return fmt.Sprintf("unknown (%d)", value)
+}
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// parseWeekdays parses a list of days of the week.
+//
+// This may be "all", a comma-separated list of days ("mon,wed,fri"), or a
+// range of days ("mon-fri").
+func parseWeekdays(value string) ([]time.Weekday, error) {
+	lookup := func(name string) (time.Weekday, error) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for d, weekdayName := range weekdayNames {
+			if strings.HasPrefix(name, weekdayName) {
+				return time.Weekday(d), nil
+			}
+		}
+		return 0, fmt.Errorf("invalid day: %q", name)
+	}
+
+	if strings.EqualFold(value, "all") {
+		return []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}, nil
+	}
+
+	var result []time.Weekday
+	for _, item := range strings.Split(value, ",") {
+		if start, end, ok := strings.Cut(item, "-"); ok {
+			startDay, err := lookup(start)
+			if err != nil {
+				return nil, err
+			}
+			endDay, err := lookup(end)
+			if err != nil {
+				return nil, err
+			}
+			for day := startDay; ; day = (day + 1) % 7 {
+				result = append(result, day)
+				if day == endDay {
+					break
+				}
+			}
+		} else {
+			day, err := lookup(item)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, day)
+		}
+	}
+	return result, nil
+}
+
+// formatWeekdays returns a human-readable list of days of the week.
+func formatWeekdays(weekdays []time.Weekday) string {
+	var names []string
+	for _, weekday := range weekdays {
+		names = append(names, weekdayNames[weekday])
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+const (
+	ActionForward = "forward" // The envelope was passed through unchanged.
+	ActionRewrite = "rewrite" // The function code of the envelope was changed.
+	ActionBlock   = "block"   // The envelope was dropped.
+)
+
+// Filter decides what to do with the requests sent to the controller.
+type Filter struct {
+	ReadOnly bool              // If set, then only read-only functions will be forwarded.
+	Block    map[uint16]bool   // These function codes will never be forwarded.
+	Rewrite  map[uint16]uint16 // These function codes will be replaced before being forwarded.
+}
+
+// Apply applies the filter to the request envelope, rewriting it if needed.
+//
+// This returns one of the Action* constants.
+func (f Filter) Apply(envelope *wire.Envelope) string {
+	action := ActionForward
+	if replacement, ok := f.Rewrite[envelope.Function]; ok {
+		envelope.Function = replacement
+		action = ActionRewrite
+	}
+	if f.Block[envelope.Function] {
+		return ActionBlock
+	}
+	if f.ReadOnly {
+		info := wire.LookupFunction(envelope.Function)
+		if info == nil || !info.ReadOnly {
+			return ActionBlock
+		}
+	}
+	return action
+}
+
+// parseFunctionCode parses a function code, such as "0x109d" or "OpenDoor".
+func parseFunctionCode(value string) (uint16, error) {
+	for _, info := range wire.Functions() {
+		if strings.EqualFold(info.Name, value) {
+			return info.Code, nil
+		}
+	}
+	code, err := strconv.ParseUint(value, 0, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid function: %q", value)
+	}
+	return uint16(code), nil
+}
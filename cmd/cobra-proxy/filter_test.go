@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+func TestFilter(t *testing.T) {
+	rows := []struct {
+		name     string
+		filter   Filter
+		function uint16
+		action   string
+		output   uint16
+	}{
+		{name: "Forward", filter: Filter{}, function: wire.FunctionOpenDoor, action: ActionForward, output: wire.FunctionOpenDoor},
+		{name: "Block", filter: Filter{Block: map[uint16]bool{wire.FunctionOpenDoor: true}}, function: wire.FunctionOpenDoor, action: ActionBlock, output: wire.FunctionOpenDoor},
+		{name: "Not blocked", filter: Filter{Block: map[uint16]bool{wire.FunctionOpenDoor: true}}, function: wire.FunctionGetRecord, action: ActionForward, output: wire.FunctionGetRecord},
+		{name: "Read-only allows a read", filter: Filter{ReadOnly: true}, function: wire.FunctionGetOperationStatus, action: ActionForward, output: wire.FunctionGetOperationStatus},
+		{name: "Read-only blocks a write", filter: Filter{ReadOnly: true}, function: wire.FunctionSetTime, action: ActionBlock, output: wire.FunctionSetTime},
+		{name: "Read-only blocks an unknown function", filter: Filter{ReadOnly: true}, function: 0x1234, action: ActionBlock, output: 0x1234},
+		{name: "Rewrite", filter: Filter{Rewrite: map[uint16]uint16{wire.FunctionOpenDoor: wire.FunctionGetOperationStatus}}, function: wire.FunctionOpenDoor, action: ActionRewrite, output: wire.FunctionGetOperationStatus},
+		{name: "Rewrite then block", filter: Filter{Rewrite: map[uint16]uint16{wire.FunctionGetRecord: wire.FunctionOpenDoor}, Block: map[uint16]bool{wire.FunctionOpenDoor: true}}, function: wire.FunctionGetRecord, action: ActionBlock, output: wire.FunctionOpenDoor},
+		{name: "Rewrite to a read", filter: Filter{ReadOnly: true, Rewrite: map[uint16]uint16{wire.FunctionOpenDoor: wire.FunctionGetOperationStatus}}, function: wire.FunctionOpenDoor, action: ActionRewrite, output: wire.FunctionGetOperationStatus},
+	}
+	for _, row := range rows {
+		t.Run(row.name, func(t *testing.T) {
+			envelope := wire.Envelope{BoardAddress: 0xf010, Function: row.function}
+			action := row.filter.Apply(&envelope)
+			assert.Equal(t, row.action, action)
+			assert.Equal(t, row.output, envelope.Function)
+		})
+	}
+}
+
+func TestParseFunctionCode(t *testing.T) {
+	rows := []struct {
+		input  string
+		output uint16
+		valid  bool
+	}{
+		{input: "OpenDoor", output: wire.FunctionOpenDoor, valid: true},
+		{input: "opendoor", output: wire.FunctionOpenDoor, valid: true},
+		{input: "0x109d", output: 0x109d, valid: true},
+		{input: "4253", output: 4253, valid: true},
+		{input: "0x10000"},
+		{input: "OpenTheDoor"},
+		{input: ""},
+	}
+	for _, row := range rows {
+		t.Run(row.input, func(t *testing.T) {
+			code, err := parseFunctionCode(row.input)
+			if !row.valid {
+				assert.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, row.output, code)
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+const (
+	DirectionToController   = "to-controller"
+	DirectionFromController = "from-controller"
+)
+
+// Entry is the structured log entry for a single envelope.
+type Entry struct {
+	Timestamp    time.Time // This is when the envelope was received.
+	Protocol     string    // This is "tcp" or "udp".
+	Direction    string    // This is one of the Direction* constants.
+	Client       string    // This is the address of the client (the vendor software).
+	Controller   string    // This is the address of the controller.
+	Action       string    `json:",omitempty"` // This is one of the Action* constants (requests only).
+	BoardAddress uint16    // This is the board address from the envelope.
+	FunctionCode uint16    // This is the function code from the envelope (after any rewrite).
+	Function     string    // This is the name of the function.
+	Raw          string    `json:",omitempty"` // This is the full envelope, in hexadecimal; it is left out for a secret function (see `wire.FunctionInfo.Secret`).
+	Fields       any       `json:",omitempty"` // This is the decoded request or response.
+	Errors       []string  `json:",omitempty"` // These are any errors encountered while decoding.
+}
+
+// NewEntry decodes the data for the log.
+func NewEntry(protocol string, direction string, client net.Addr, controller net.Addr, data []byte) *Entry {
+	entry := &Entry{
+		Timestamp:  time.Now(),
+		Protocol:   protocol,
+		Direction:  direction,
+		Client:     client.String(),
+		Controller: controller.String(),
+		Raw:        fmt.Sprintf("%X", data),
+	}
+
+	var envelope wire.Envelope
+	err := wire.Decode(wire.NewReader(data), &envelope)
+	if err != nil {
+		entry.Errors = append(entry.Errors, fmt.Sprintf("could not decode envelope: %v", err))
+		return entry
+	}
+	entry.BoardAddress = envelope.BoardAddress
+	entry.FunctionCode = envelope.Function
+	entry.Function = wire.FunctionName(envelope.Function)
+
+	if info := wire.LookupFunction(envelope.Function); info != nil {
+		if info.Secret {
+			entry.Raw = ""
+		}
+		var value any
+		if direction == DirectionToController {
+			value, err = info.DecodeRequest(envelope.Contents)
+		} else {
+			value, err = info.DecodeResponse(envelope.Contents)
+		}
+		if err != nil {
+			entry.Errors = append(entry.Errors, err.Error())
+		}
+		entry.Fields = value
+	}
+	return entry
+}
+
+// Logger writes the log entries and, optionally, a pcap file of the traffic.
+type Logger struct {
+	mutex      sync.Mutex
+	encoder    *json.Encoder
+	pcapWriter *pcapgo.Writer
+}
+
+// NewLogger returns a new logger.
+//
+// Either writer may be nil.
+func NewLogger(logWriter io.Writer, pcapWriter io.Writer) (*Logger, error) {
+	l := &Logger{}
+	if logWriter != nil {
+		l.encoder = json.NewEncoder(logWriter)
+	}
+	if pcapWriter != nil {
+		l.pcapWriter = pcapgo.NewWriter(pcapWriter)
+		err := l.pcapWriter.WriteFileHeader(65536, layers.LinkTypeRaw)
+		if err != nil {
+			return nil, fmt.Errorf("could not write pcap header: %w", err)
+		}
+	}
+	return l, nil
+}
+
+// Log writes the entry, along with the packet that it came from.
+func (l *Logger) Log(entry *Entry, source net.Addr, destination net.Addr, data []byte) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.encoder != nil {
+		err := l.encoder.Encode(entry)
+		if err != nil {
+			return fmt.Errorf("could not write log entry: %w", err)
+		}
+	}
+	if l.pcapWriter != nil {
+		packet, err := serializePacket(source, destination, data)
+		if err != nil {
+			return fmt.Errorf("could not serialize packet: %w", err)
+		}
+		err = l.pcapWriter.WritePacket(gopacket.CaptureInfo{
+			Timestamp:     entry.Timestamp,
+			CaptureLength: len(packet),
+			Length:        len(packet),
+		}, packet)
+		if err != nil {
+			return fmt.Errorf("could not write packet: %w", err)
+		}
+	}
+	return nil
+}
+
+// serializePacket builds a raw IP packet containing the data so that it can
+// be read by "view-packets" (or Wireshark).
+//
+// The TCP sequence numbers are not tracked, so each packet stands alone.
+func serializePacket(source net.Addr, destination net.Addr, data []byte) ([]byte, error) {
+	var sourceIP, destinationIP net.IP
+	var sourcePort, destinationPort int
+	var ipProtocol layers.IPProtocol
+	switch source := source.(type) {
+	case *net.TCPAddr:
+		destination, ok := destination.(*net.TCPAddr)
+		if !ok {
+			return nil, fmt.Errorf("mismatched addresses: %T and %T", source, destination)
+		}
+		sourceIP, sourcePort = source.IP, source.Port
+		destinationIP, destinationPort = destination.IP, destination.Port
+		ipProtocol = layers.IPProtocolTCP
+	case *net.UDPAddr:
+		destination, ok := destination.(*net.UDPAddr)
+		if !ok {
+			return nil, fmt.Errorf("mismatched addresses: %T and %T", source, destination)
+		}
+		sourceIP, sourcePort = source.IP, source.Port
+		destinationIP, destinationPort = destination.IP, destination.Port
+		ipProtocol = layers.IPProtocolUDP
+	default:
+		return nil, fmt.Errorf("unsupported address: %T", source)
+	}
+
+	var networkLayer gopacket.NetworkLayer
+	var networkSerializable gopacket.SerializableLayer
+	if sourceIP.To4() != nil && destinationIP.To4() != nil {
+		ip := &layers.IPv4{
+			Version:  4,
+			TTL:      64,
+			Protocol: ipProtocol,
+			SrcIP:    sourceIP.To4(),
+			DstIP:    destinationIP.To4(),
+		}
+		networkLayer, networkSerializable = ip, ip
+	} else {
+		ip := &layers.IPv6{
+			Version:    6,
+			HopLimit:   64,
+			NextHeader: ipProtocol,
+			SrcIP:      sourceIP.To16(),
+			DstIP:      destinationIP.To16(),
+		}
+		networkLayer, networkSerializable = ip, ip
+	}
+
+	var transportLayer gopacket.SerializableLayer
+	if ipProtocol == layers.IPProtocolTCP {
+		tcp := &layers.TCP{
+			SrcPort: layers.TCPPort(sourcePort),
+			DstPort: layers.TCPPort(destinationPort),
+			PSH:     true,
+			ACK:     true,
+			Window:  65535,
+		}
+		tcp.SetNetworkLayerForChecksum(networkLayer)
+		transportLayer = tcp
+	} else {
+		udp := &layers.UDP{
+			SrcPort: layers.UDPPort(sourcePort),
+			DstPort: layers.UDPPort(destinationPort),
+		}
+		udp.SetNetworkLayerForChecksum(networkLayer)
+		transportLayer = udp
+	}
+
+	buffer := gopacket.NewSerializeBuffer()
+	options := gopacket.SerializeOptions{
+		FixLengths:       true,
+		ComputeChecksums: true,
+	}
+	err := gopacket.SerializeLayers(buffer, options, networkSerializable, transportLayer, gopacket.Payload(data))
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
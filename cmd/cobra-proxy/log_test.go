@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+// encodeEnvelope returns the full envelope for the request.
+func encodeEnvelope(t *testing.T, function uint16, request any) []byte {
+	writer := wire.NewWriter()
+	if request != nil {
+		err := wire.Encode(writer, request)
+		require.Nil(t, err)
+	}
+	envelope := wire.Envelope{
+		BoardAddress: 0xf010,
+		Function:     function,
+		Contents:     writer.Bytes(),
+	}
+	writer = wire.NewWriter()
+	err := wire.Encode(writer, &envelope)
+	require.Nil(t, err)
+	return writer.Bytes()
+}
+
+func TestNewEntry(t *testing.T) {
+	client := &net.TCPAddr{IP: net.ParseIP("10.0.0.2"), Port: 50000}
+	controller := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: int(wire.PortDefault)}
+
+	t.Run("Request", func(t *testing.T) {
+		data := encodeEnvelope(t, wire.FunctionOpenDoor, &wire.OpenDoorRequest{Door: 2, Unkonwn1: 1})
+		entry := NewEntry("tcp", DirectionToController, client, controller, data)
+		assert.Equal(t, "10.0.0.2:50000", entry.Client)
+		assert.Equal(t, "10.0.0.1:60000", entry.Controller)
+		assert.Equal(t, uint16(0xf010), entry.BoardAddress)
+		assert.Equal(t, uint16(wire.FunctionOpenDoor), entry.FunctionCode)
+		assert.Equal(t, "OpenDoor", entry.Function)
+		assert.Equal(t, wire.OpenDoorRequest{Door: 2, Unkonwn1: 1}, entry.Fields)
+		assert.Empty(t, entry.Errors)
+	})
+	t.Run("Secret", func(t *testing.T) {
+		data := encodeEnvelope(t, wire.FunctionUpdatePermissions, &wire.UpdatePermissionsRequest{CardID: 23439, Area: 178, Door: 1, Password: 123456, Standby: []byte{0, 0, 0, 0}})
+		entry := NewEntry("tcp", DirectionToController, client, controller, data)
+		assert.Empty(t, entry.Raw)
+		assert.Empty(t, entry.Errors)
+		contents, err := json.Marshal(entry)
+		require.Nil(t, err)
+		assert.NotContains(t, string(contents), "123456")
+		assert.NotContains(t, string(contents), "40E201") // This is the PIN as it is on the wire.
+		assert.Contains(t, string(contents), `"Password":"set"`)
+	})
+	t.Run("Unknown function", func(t *testing.T) {
+		data := encodeEnvelope(t, 0x1234, nil)
+		entry := NewEntry("tcp", DirectionToController, client, controller, data)
+		assert.Equal(t, uint16(0x1234), entry.FunctionCode)
+		assert.Nil(t, entry.Fields)
+		assert.Empty(t, entry.Errors)
+	})
+	t.Run("Bad envelope", func(t *testing.T) {
+		entry := NewEntry("tcp", DirectionFromController, client, controller, []byte{1, 2, 3})
+		assert.Equal(t, "010203", entry.Raw)
+		assert.Len(t, entry.Errors, 1)
+	})
+}
+
+func TestLogger(t *testing.T) {
+	client := &net.UDPAddr{IP: net.ParseIP("10.0.0.2"), Port: 50000}
+	controller := &net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: int(wire.PortDefault)}
+	data := encodeEnvelope(t, wire.FunctionOpenDoor, &wire.OpenDoorRequest{Door: 2, Unkonwn1: 1})
+
+	var logBuffer, pcapBuffer bytes.Buffer
+	logger, err := NewLogger(&logBuffer, &pcapBuffer)
+	require.Nil(t, err)
+
+	entry := NewEntry("udp", DirectionToController, client, controller, data)
+	entry.Action = ActionForward
+	err = logger.Log(entry, client, controller, data)
+	require.Nil(t, err)
+
+	var output map[string]any
+	err = json.Unmarshal(logBuffer.Bytes(), &output)
+	require.Nil(t, err)
+	assert.Equal(t, "udp", output["Protocol"])
+	assert.Equal(t, DirectionToController, output["Direction"])
+	assert.Equal(t, ActionForward, output["Action"])
+	assert.Equal(t, "OpenDoor", output["Function"])
+	assert.Equal(t, map[string]any{"Door": float64(2), "Unkonwn1": float64(1)}, output["Fields"])
+
+	reader, err := pcapgo.NewReader(&pcapBuffer)
+	require.Nil(t, err)
+	packetData, _, err := reader.ReadPacketData()
+	require.Nil(t, err)
+	packet := gopacket.NewPacket(packetData, layers.LayerTypeIPv4, gopacket.Default)
+	udp, ok := packet.Layer(layers.LayerTypeUDP).(*layers.UDP)
+	require.True(t, ok)
+	assert.Equal(t, layers.UDPPort(wire.PortDefault), udp.DstPort)
+	assert.Equal(t, data, udp.Payload)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+func main() {
+	var listenAddress string
+	var controllerAddress string
+	var controllerPort uint16
+	var logFile string
+	var pcapFile string
+	var readOnly bool
+	var blockFunctions []string
+	var rewriteFunctions []string
+	var enableTCP bool
+	var enableUDP bool
+	verbose := false
+
+	rootCommand := &cobra.Command{
+		Use:   "cobra-proxy",
+		Short: "Proxy between the vendor software and a controller, logging every envelope",
+		Long:  `Every envelope is decoded and written to a JSON log (one object per line) and, optionally, to a pcap file that "view-packets" can read.  PINs and passwords are left out of the JSON log, but the pcap file has the full packets, so it is only readable by its owner.`,
+		Args:  cobra.NoArgs,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if verbose {
+				logrus.SetLevel(logrus.DebugLevel)
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if controllerAddress == "" {
+				logrus.Errorf("Missing controller address.")
+				os.Exit(1)
+			}
+			if !enableTCP && !enableUDP {
+				logrus.Errorf("At least one of TCP or UDP must be enabled.")
+				os.Exit(1)
+			}
+
+			filter := Filter{
+				ReadOnly: readOnly,
+				Block:    map[uint16]bool{},
+				Rewrite:  map[uint16]uint16{},
+			}
+			for _, value := range blockFunctions {
+				code, err := parseFunctionCode(value)
+				if err != nil {
+					logrus.Errorf("Could not parse blocked function: %v", err)
+					os.Exit(1)
+				}
+				filter.Block[code] = true
+			}
+			for _, value := range rewriteFunctions {
+				parts := strings.SplitN(value, "=", 2)
+				if len(parts) != 2 {
+					logrus.Errorf("Invalid rewrite: %q (expected: FROM=TO)", value)
+					os.Exit(1)
+				}
+				from, err := parseFunctionCode(parts[0])
+				if err != nil {
+					logrus.Errorf("Could not parse rewrite: %v", err)
+					os.Exit(1)
+				}
+				to, err := parseFunctionCode(parts[1])
+				if err != nil {
+					logrus.Errorf("Could not parse rewrite: %v", err)
+					os.Exit(1)
+				}
+				filter.Rewrite[from] = to
+			}
+			logrus.Debugf("Filter: %+v", filter)
+
+			var logWriter io.Writer
+			switch logFile {
+			case "":
+				// No log.
+			case "-":
+				logWriter = os.Stdout
+			default:
+				file, err := os.OpenFile(logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+				if err != nil {
+					logrus.Errorf("Could not open log file: %v", err)
+					os.Exit(1)
+				}
+				defer file.Close()
+				logWriter = file
+			}
+
+			var pcapWriter io.Writer
+			if pcapFile != "" {
+				file, err := os.OpenFile(pcapFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600) // This has the full packets, including any PINs.
+				if err != nil {
+					logrus.Errorf("Could not create pcap file: %v", err)
+					os.Exit(1)
+				}
+				defer file.Close()
+				pcapWriter = file
+			}
+
+			logger, err := NewLogger(logWriter, pcapWriter)
+			if err != nil {
+				logrus.Errorf("Could not create logger: %v", err)
+				os.Exit(1)
+			}
+
+			proxy := &Proxy{
+				ControllerAddress: net.JoinHostPort(controllerAddress, strconv.Itoa(int(controllerPort))),
+				Filter:            filter,
+				Logger:            logger,
+				BufferSize:        1024,
+			}
+
+			errs := make(chan error, 2)
+			if enableTCP {
+				listener, err := net.Listen("tcp", listenAddress)
+				if err != nil {
+					logrus.Errorf("Could not listen on TCP: %v", err)
+					os.Exit(1)
+				}
+				logrus.Infof("Listening on TCP %s; forwarding to %s.", listener.Addr(), proxy.ControllerAddress)
+				go func() {
+					errs <- fmt.Errorf("tcp: %w", proxy.ServeTCP(listener))
+				}()
+			}
+			if enableUDP {
+				conn, err := net.ListenPacket("udp", listenAddress)
+				if err != nil {
+					logrus.Errorf("Could not listen on UDP: %v", err)
+					os.Exit(1)
+				}
+				logrus.Infof("Listening on UDP %s; forwarding to %s.", conn.LocalAddr(), proxy.ControllerAddress)
+				go func() {
+					errs <- fmt.Errorf("udp: %w", proxy.ServeUDP(conn))
+				}()
+			}
+			err = <-errs
+			logrus.Errorf("Proxy stopped: %v", err)
+			os.Exit(1)
+		},
+	}
+	rootCommand.Flags().StringVar(&listenAddress, "listen", fmt.Sprintf(":%d", wire.PortDefault), "Listen on this address (for both TCP and UDP)")
+	rootCommand.Flags().StringVar(&controllerAddress, "controller-address", "", "Forward to the controller at this address")
+	rootCommand.Flags().Uint16Var(&controllerPort, "controller-port", wire.PortDefault, "Forward to the controller on this port")
+	rootCommand.Flags().StringVar(&logFile, "log-file", "-", `Write the JSON log to this file ("-" for stdout; "" to disable)`)
+	rootCommand.Flags().StringVar(&pcapFile, "pcap-file", "", "Also write the traffic to this pcap file (including any PINs)")
+	rootCommand.Flags().BoolVar(&readOnly, "read-only", false, "Block every function that could change the controller")
+	rootCommand.Flags().StringSliceVar(&blockFunctions, "block", nil, `Block these functions (either a code, such as "0x109d", or a name, such as "OpenDoor")`)
+	rootCommand.Flags().StringSliceVar(&rewriteFunctions, "rewrite", nil, `Rewrite these functions, of the form "FROM=TO" (for example, "OpenDoor=GetOperationStatus")`)
+	rootCommand.Flags().BoolVar(&enableTCP, "tcp", true, "Proxy TCP")
+	rootCommand.Flags().BoolVar(&enableUDP, "udp", true, "Proxy UDP")
+	rootCommand.PersistentFlags().BoolVar(&verbose, "verbose", false, "Enable verbose output")
+
+	err := rootCommand.Execute()
+	if err != nil {
+		logrus.Errorf("Error: %v", err)
+	}
+	os.Exit(0)
+}
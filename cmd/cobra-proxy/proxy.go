@@ -0,0 +1,217 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+// udpSessionTimeout is how long a UDP client may be idle before its upstream
+// connection is closed.
+const udpSessionTimeout = 1 * time.Minute
+
+// Proxy forwards envelopes between clients and a single controller.
+type Proxy struct {
+	ControllerAddress string // This is the "host:port" of the controller.
+	Filter            Filter
+	Logger            *Logger
+	BufferSize        int
+}
+
+// request applies the filter to the data from a client and logs it.
+//
+// This returns the data to forward, or nil if the request was blocked.
+func (p *Proxy) request(protocol string, client net.Addr, controller net.Addr, data []byte) []byte {
+	var envelope wire.Envelope
+	err := wire.Decode(wire.NewReader(data), &envelope)
+	action := ActionForward
+	if err != nil {
+		// We cannot tell what this is, so only pass it through if we're not trying to protect the controller.
+		if p.Filter.ReadOnly {
+			action = ActionBlock
+		}
+	} else {
+		action = p.Filter.Apply(&envelope)
+		if action == ActionRewrite {
+			writer := wire.NewWriter()
+			err = wire.Encode(writer, &envelope)
+			if err != nil {
+				logrus.Warnf("Could not encode rewritten envelope: %v", err)
+				action = ActionBlock
+			} else {
+				data = writer.Bytes()
+			}
+		}
+	}
+
+	entry := NewEntry(protocol, DirectionToController, client, controller, data)
+	entry.Action = action
+	logrus.Infof("%s | %s -> %s | Function: 0x%04X (%s) | Action: %s", protocol, client, controller, entry.FunctionCode, entry.Function, action)
+	err = p.Logger.Log(entry, client, controller, data)
+	if err != nil {
+		logrus.Warnf("Could not log request: %v", err)
+	}
+
+	if action == ActionBlock {
+		return nil
+	}
+	return data
+}
+
+// response logs the data from the controller.
+func (p *Proxy) response(protocol string, client net.Addr, controller net.Addr, data []byte) {
+	entry := NewEntry(protocol, DirectionFromController, client, controller, data)
+	logrus.Infof("%s | %s <- %s | Function: 0x%04X (%s)", protocol, client, controller, entry.FunctionCode, entry.Function)
+	err := p.Logger.Log(entry, controller, client, data)
+	if err != nil {
+		logrus.Warnf("Could not log response: %v", err)
+	}
+}
+
+// ServeTCP accepts TCP connections and forwards them to the controller.
+func (p *Proxy) ServeTCP(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go p.handleTCP(conn)
+	}
+}
+
+func (p *Proxy) handleTCP(conn net.Conn) {
+	defer conn.Close()
+	logrus.Debugf("Accepted TCP connection from %s.", conn.RemoteAddr())
+
+	upstream, err := net.Dial("tcp", p.ControllerAddress)
+	if err != nil {
+		logrus.Warnf("Could not connect to controller %s: %v", p.ControllerAddress, err)
+		return
+	}
+	defer upstream.Close()
+
+	client := conn.RemoteAddr()
+	controller := upstream.RemoteAddr()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// When the controller goes away, so does the client.
+		defer conn.Close()
+
+		buffer := make([]byte, p.BufferSize)
+		for {
+			bytesRead, err := upstream.Read(buffer)
+			if err != nil {
+				logrus.Debugf("Could not read from controller: %v", err)
+				return
+			}
+			data := append([]byte{}, buffer[:bytesRead]...)
+			p.response("tcp", client, controller, data)
+			_, err = conn.Write(data)
+			if err != nil {
+				logrus.Debugf("Could not write to client: %v", err)
+				return
+			}
+		}
+	}()
+
+	// Each read is assumed to be a single envelope, just like the client does.
+	buffer := make([]byte, p.BufferSize)
+	for {
+		bytesRead, err := conn.Read(buffer)
+		if err != nil {
+			logrus.Debugf("Could not read from client: %v", err)
+			break
+		}
+		data := p.request("tcp", client, controller, append([]byte{}, buffer[:bytesRead]...))
+		if data == nil {
+			continue
+		}
+		_, err = upstream.Write(data)
+		if err != nil {
+			logrus.Debugf("Could not write to controller: %v", err)
+			break
+		}
+	}
+	upstream.Close()
+	wg.Wait()
+	logrus.Debugf("Closed TCP connection from %s.", client)
+}
+
+// ServeUDP reads UDP packets and forwards them to the controller.
+//
+// Each client gets its own upstream socket so that the responses can be
+// returned to the right place.
+func (p *Proxy) ServeUDP(conn net.PacketConn) error {
+	controllerAddress, err := net.ResolveUDPAddr("udp", p.ControllerAddress)
+	if err != nil {
+		return err
+	}
+
+	var mutex sync.Mutex
+	sessions := map[string]*net.UDPConn{}
+
+	buffer := make([]byte, p.BufferSize)
+	for {
+		bytesRead, client, err := conn.ReadFrom(buffer)
+		if err != nil {
+			return err
+		}
+		data := p.request("udp", client, controllerAddress, append([]byte{}, buffer[:bytesRead]...))
+		if data == nil {
+			continue
+		}
+
+		mutex.Lock()
+		upstream := sessions[client.String()]
+		if upstream == nil {
+			upstream, err = net.DialUDP("udp", nil, controllerAddress)
+			if err != nil {
+				mutex.Unlock()
+				logrus.Warnf("Could not connect to controller %s: %v", controllerAddress, err)
+				continue
+			}
+			sessions[client.String()] = upstream
+			go func(client net.Addr, upstream *net.UDPConn) {
+				defer func() {
+					mutex.Lock()
+					delete(sessions, client.String())
+					mutex.Unlock()
+					upstream.Close()
+				}()
+
+				buffer := make([]byte, p.BufferSize)
+				for {
+					upstream.SetReadDeadline(time.Now().Add(udpSessionTimeout))
+					bytesRead, err := upstream.Read(buffer)
+					if err != nil {
+						if !errors.Is(err, os.ErrDeadlineExceeded) {
+							logrus.Debugf("Could not read from controller: %v", err)
+						}
+						return
+					}
+					data := append([]byte{}, buffer[:bytesRead]...)
+					p.response("udp", client, controllerAddress, data)
+					_, err = conn.WriteTo(data, client)
+					if err != nil {
+						logrus.Debugf("Could not write to client: %v", err)
+						return
+					}
+				}
+			}(client, upstream)
+		}
+		mutex.Unlock()
+
+		_, err = upstream.Write(data)
+		if err != nil {
+			logrus.Warnf("Could not write to controller: %v", err)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/tekkamanendless/cobra-controls/cobrafile"
+	"github.com/tekkamanendless/cobra-controls/mqttbridge"
+	"github.com/tekkamanendless/cobra-controls/report"
+	"github.com/tekkamanendless/cobra-controls/server"
+	"github.com/tekkamanendless/cobra-controls/webhook"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+func main() {
+	var listenAddress string
+	var controllerFile string
+	var personnelFile string
+	var apiKeys []string
+	var apiKeyFile string
+	var noAuth bool
+	var protocol string
+	var pollInterval time.Duration
+	var webhookFile string
+	var mqttOptions mqttbridge.Options
+	var occupancy bool
+	var occupancyMaxAge time.Duration
+	verbose := false
+
+	rootCommand := &cobra.Command{
+		Use:   "cobra-server",
+		Short: "Serve a REST API for the controllers in the controller file",
+		Long:  `The API is described by the OpenAPI specification at "/api/v1/openapi.yaml".  Every other request must include one of the API keys, either as "Authorization: Bearer <key>", as "X-API-Key: <key>", or as the "api_key" query parameter.  Access events are streamed as server-sent events from "/api/v1/events".`,
+		Args:  cobra.NoArgs,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if verbose {
+				logrus.SetLevel(logrus.DebugLevel)
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if controllerFile == "" {
+				logrus.Errorf("Missing controller file.")
+				os.Exit(1)
+			}
+			controllerList, err := cobrafile.LoadController(controllerFile)
+			if err != nil {
+				logrus.Errorf("Could not load controller file: %v", err)
+				os.Exit(1)
+			}
+			logrus.Debugf("Controllers: (%d)", len(controllerList))
+
+			var personnelList cobrafile.PersonnelList
+			if personnelFile != "" {
+				personnelList, err = cobrafile.LoadPersonnel(personnelFile)
+				if err != nil {
+					logrus.Errorf("Could not load personnel file: %v", err)
+					os.Exit(1)
+				}
+				logrus.Debugf("Personnel: (%d)", len(personnelList))
+			}
+
+			if apiKeyFile != "" {
+				contents, err := os.ReadFile(apiKeyFile)
+				if err != nil {
+					logrus.Errorf("Could not read API key file: %v", err)
+					os.Exit(1)
+				}
+				for _, line := range strings.Split(string(contents), "\n") {
+					line = strings.TrimSpace(line)
+					if line == "" || strings.HasPrefix(line, "#") {
+						continue
+					}
+					apiKeys = append(apiKeys, line)
+				}
+			}
+			if len(apiKeys) == 0 && !noAuth {
+				logrus.Errorf("Missing API key (use --no-auth to allow anyone to use the API).")
+				os.Exit(1)
+			}
+			if noAuth {
+				apiKeys = nil
+				logrus.Warnf("Authentication is disabled.")
+			}
+
+			s := &server.Server{
+				ControllerList: controllerList,
+				PersonnelList:  personnelList,
+				APIKeys:        apiKeys,
+				Protocol:       wire.Protocol(protocol),
+			}
+			if occupancy {
+				if pollInterval <= 0 {
+					logrus.Errorf("Occupancy tracking requires polling.")
+					os.Exit(1)
+				}
+				s.Occupancy = report.NewOccupancy(personnelList, occupancyMaxAge)
+				now := time.Now()
+				s.LoadOccupancy(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local))
+			}
+			if pollInterval > 0 {
+				go s.Monitor(context.Background(), pollInterval)
+			}
+			if webhookFile != "" {
+				if pollInterval <= 0 {
+					logrus.Errorf("Webhooks require polling.")
+					os.Exit(1)
+				}
+				config, err := webhook.LoadConfig(webhookFile)
+				if err != nil {
+					logrus.Errorf("Could not load webhook file: %v", err)
+					os.Exit(1)
+				}
+				dispatcher, err := webhook.NewDispatcher(*config)
+				if err != nil {
+					logrus.Errorf("Invalid webhook file: %v", err)
+					os.Exit(1)
+				}
+				logrus.Debugf("Webhook rules: (%d)", len(config.Rules))
+				go s.Follow(context.Background(), nil, dispatcher.Handle)
+			}
+			if mqttOptions.Broker != "" {
+				if pollInterval <= 0 {
+					logrus.Errorf("The MQTT bridge requires polling.")
+					os.Exit(1)
+				}
+				bridge := mqttbridge.New(s, mqttOptions)
+				go func() {
+					err := bridge.Run(context.Background())
+					logrus.Errorf("MQTT bridge stopped: %v", err)
+					os.Exit(1)
+				}()
+			}
+			logrus.Infof("Listening on %s.", listenAddress)
+			err = http.ListenAndServe(listenAddress, s)
+			logrus.Errorf("Server stopped: %v", err)
+			os.Exit(1)
+		},
+	}
+	rootCommand.Flags().StringVar(&listenAddress, "listen", ":8080", "Listen on this address")
+	rootCommand.Flags().StringVar(&controllerFile, "controller-file", "", "Use this controller file")
+	rootCommand.Flags().StringVar(&personnelFile, "personnel-file", "", "Use this personnel file")
+	rootCommand.Flags().StringSliceVar(&apiKeys, "api-key", nil, "Accept this API key")
+	rootCommand.Flags().StringVar(&apiKeyFile, "api-key-file", "", "Accept the API keys in this file (one per line)")
+	rootCommand.Flags().BoolVar(&noAuth, "no-auth", false, "Do not require an API key")
+	rootCommand.Flags().DurationVar(&pollInterval, "poll-interval", 2*time.Second, "Poll the controllers for new events this often (use 0 to disable the event stream)")
+	rootCommand.Flags().StringVar(&webhookFile, "webhook-file", "", "Send webhooks for the access events according to the rules in this file")
+	rootCommand.Flags().StringVar(&mqttOptions.Broker, "mqtt-broker", "", `Bridge to this MQTT broker (for example, "tcp://localhost:1883")`)
+	rootCommand.Flags().StringVar(&mqttOptions.ClientID, "mqtt-client-id", "cobra-bridge", "Use this MQTT client ID")
+	rootCommand.Flags().StringVar(&mqttOptions.Username, "mqtt-username", "", "Use this MQTT username")
+	rootCommand.Flags().StringVar(&mqttOptions.Password, "mqtt-password", "", "Use this MQTT password")
+	rootCommand.Flags().StringVar(&mqttOptions.Prefix, "mqtt-prefix", mqttbridge.DefaultPrefix, "Use this prefix for the MQTT topics")
+	rootCommand.Flags().StringVar(&mqttOptions.DiscoveryPrefix, "mqtt-discovery-prefix", mqttbridge.DefaultDiscoveryPrefix, `Publish the Home Assistant discovery payloads with this prefix ("-" to disable)`)
+	rootCommand.Flags().DurationVar(&mqttOptions.StatusInterval, "mqtt-status-interval", 10*time.Second, "Publish the door states this often")
+	rootCommand.Flags().BoolVar(&occupancy, "occupancy", false, "Track who is on site (starting with today's records) and attach the muster list to fire alarm events")
+	rootCommand.Flags().DurationVar(&occupancyMaxAge, "occupancy-max-age", report.DefaultMaxAge, "Assume that someone has left if they have not been seen for this long (use 0 to never assume this)")
+	rootCommand.Flags().StringVar(&protocol, "protocol", "", "Use this protocol to communicate with the controllers (default: tcp)")
+	rootCommand.PersistentFlags().BoolVar(&verbose, "verbose", false, "Enable verbose output")
+
+	err := rootCommand.Execute()
+	if err != nil {
+		logrus.Errorf("Error: %v", err)
+	}
+	os.Exit(0)
+}
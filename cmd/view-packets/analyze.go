@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+// Analysis groups captured packets by function and direction so that the
+// contents can be compared byte-by-byte.
+type Analysis struct {
+	Groups map[string]*AnalysisGroup
+}
+
+// AnalysisGroup is the set of packets for a single function in a single direction.
+type AnalysisGroup struct {
+	FunctionCode uint16
+	Function     string
+	Direction    string
+	Packets      int
+	Offsets      []*OffsetStats // This is indexed by the offset into the envelope contents.
+}
+
+// OffsetStats tracks the values seen at a single offset.
+type OffsetStats struct {
+	Offset     int
+	Samples    int
+	Histogram  map[uint8]int
+	knownTotal map[string]int // This is the number of samples where the known value was present.
+	knownMatch map[string]int // This is the number of samples where the byte matched the known value.
+}
+
+// Correlation is a known value that a byte matches.
+type Correlation struct {
+	Name    string
+	Matches int
+	Total   int
+}
+
+// NewAnalysis returns a new, empty analysis.
+func NewAnalysis() *Analysis {
+	return &Analysis{
+		Groups: map[string]*AnalysisGroup{},
+	}
+}
+
+// Add adds a packet to the analysis.
+//
+// The contents are those of the envelope, and the known values are the named
+// bytes that are known for the packet (see `knownValues`).
+func (a *Analysis) Add(packet *Packet, contents []byte, known map[string]uint8) {
+	key := fmt.Sprintf("%04x/%s", packet.FunctionCode, packet.Direction)
+	group := a.Groups[key]
+	if group == nil {
+		group = &AnalysisGroup{
+			FunctionCode: packet.FunctionCode,
+			Function:     packet.Function,
+			Direction:    packet.Direction,
+		}
+		a.Groups[key] = group
+	}
+	group.Packets++
+
+	for offset, value := range contents {
+		for len(group.Offsets) <= offset {
+			group.Offsets = append(group.Offsets, &OffsetStats{
+				Offset:     len(group.Offsets),
+				Histogram:  map[uint8]int{},
+				knownTotal: map[string]int{},
+				knownMatch: map[string]int{},
+			})
+		}
+		stats := group.Offsets[offset]
+		stats.Samples++
+		stats.Histogram[value]++
+		for name, knownValue := range known {
+			stats.knownTotal[name]++
+			if value == knownValue {
+				stats.knownMatch[name]++
+			}
+		}
+	}
+}
+
+// SortedGroups returns the groups ordered by function code and direction.
+func (a *Analysis) SortedGroups() []*AnalysisGroup {
+	var result []*AnalysisGroup
+	for _, group := range a.Groups {
+		result = append(result, group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].FunctionCode != result[j].FunctionCode {
+			return result[i].FunctionCode < result[j].FunctionCode
+		}
+		return result[i].Direction > result[j].Direction // "to-controller" first.
+	})
+	return result
+}
+
+// Constant returns true if the offset has only ever had a single value.
+func (s *OffsetStats) Constant() bool {
+	return len(s.Histogram) == 1
+}
+
+// TopValues returns up to `count` values ordered by how often they were seen.
+func (s *OffsetStats) TopValues(count int) []uint8 {
+	var values []uint8
+	for value := range s.Histogram {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if s.Histogram[values[i]] != s.Histogram[values[j]] {
+			return s.Histogram[values[i]] > s.Histogram[values[j]]
+		}
+		return values[i] < values[j]
+	})
+	if len(values) > count {
+		values = values[:count]
+	}
+	return values
+}
+
+// Correlations returns the known values that this offset matches at least
+// `threshold` (0 to 1) of the time.
+//
+// Constant offsets do not correlate with anything; they are flagged separately.
+func (s *OffsetStats) Correlations(threshold float64, minimumSamples int) []Correlation {
+	if s.Constant() {
+		return nil
+	}
+	var result []Correlation
+	for name, total := range s.knownTotal {
+		if total < minimumSamples {
+			continue
+		}
+		matches := s.knownMatch[name]
+		if float64(matches) < threshold*float64(total) {
+			continue
+		}
+		result = append(result, Correlation{
+			Name:    name,
+			Matches: matches,
+			Total:   total,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// knownValues returns the bytes that are known for the given packet.
+//
+// This is the capture time (both as-is and in the "insane" hex-as-decimal
+// form), along with any door, area, and card numbers from the decoded fields.
+func knownValues(timestamp time.Time, fields any) map[string]uint8 {
+	known := map[string]uint8{}
+	if !timestamp.IsZero() {
+		for name, value := range map[string]int{
+			"year":   timestamp.Year() % 100,
+			"month":  int(timestamp.Month()),
+			"day":    timestamp.Day(),
+			"hour":   timestamp.Hour(),
+			"minute": timestamp.Minute(),
+			"second": timestamp.Second(),
+		} {
+			known["time:"+name] = uint8(value)
+			if bcd := uint8((value/10)<<4 | value%10); bcd != uint8(value) {
+				known["time:"+name+" (bcd)"] = bcd
+			}
+		}
+	}
+	if fields != nil {
+		addKnownFields(known, reflect.ValueOf(fields))
+	}
+	return known
+}
+
+// addKnownFields walks the decoded fields and adds any door, area, and card values.
+func addKnownFields(known map[string]uint8, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			addKnownFields(known, v.Elem())
+		}
+		return
+	case reflect.Struct:
+		// This is handled below.
+	default:
+		return
+	}
+	if v.Type() == reflect.TypeOf(time.Time{}) {
+		return
+	}
+
+	if record, ok := v.Interface().(wire.Record); ok {
+		if door := record.Door(); door != 0 {
+			known["door"] = door
+			known["door (0-indexed)"] = door - 1
+		}
+	}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		value := v.Field(i)
+		name := strings.ToLower(field.Name)
+		switch {
+		case strings.Contains(name, "door") && value.Kind() == reflect.Uint8:
+			if door := uint8(value.Uint()); door != 0 {
+				known["door"] = door
+				known["door (0-indexed)"] = door - 1
+			}
+		case strings.HasPrefix(name, "area") && value.Kind() == reflect.Uint8:
+			known["card:area"] = uint8(value.Uint())
+		case (name == "idnumber" || name == "cardid" || name == "cardnumber") && value.Kind() == reflect.Uint16:
+			known["card:id (low)"] = uint8(value.Uint())
+			known["card:id (high)"] = uint8(value.Uint() >> 8)
+		default:
+			addKnownFields(known, value)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+// loadExchanges reads the packets from "testdata/exchanges.txt".
+func loadExchanges(t *testing.T) []CapturedPacket {
+	file, err := os.Open("testdata/exchanges.txt")
+	require.Nil(t, err)
+	defer file.Close()
+
+	var result []CapturedPacket
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Fields(line)
+		require.Len(t, parts, 3, "line: %s", line)
+		data, err := hex.DecodeString(parts[2])
+		require.Nil(t, err, "line: %s", line)
+		result = append(result, CapturedPacket{
+			ControllerAddress: parts[0],
+			FromClient:        parts[1] == DirectionToController,
+			Data:              data,
+		})
+	}
+	require.Nil(t, scanner.Err())
+	return result
+}
+
+// envelopeOf decodes the envelope of the captured packet.
+func envelopeOf(t *testing.T, capturedPacket CapturedPacket) wire.Envelope {
+	var envelope wire.Envelope
+	err := wire.Decode(wire.NewReader(capturedPacket.Data), &envelope)
+	require.Nil(t, err)
+	return envelope
+}
+
+func TestPairExchanges(t *testing.T) {
+	packets := loadExchanges(t)
+	exchanges := pairExchanges(packets)
+	require.Len(t, exchanges, 4)
+
+	// Each controller gets its own response, even though the second one answered first.
+	assert.Equal(t, "10.0.0.1", exchanges[0].Controller)
+	require.NotNil(t, exchanges[0].Response)
+	assert.Equal(t, envelopeOf(t, packets[3]), *exchanges[0].Response)
+	assert.Equal(t, "10.0.0.2", exchanges[1].Controller)
+	require.NotNil(t, exchanges[1].Response)
+	assert.Equal(t, envelopeOf(t, packets[2]), *exchanges[1].Response)
+
+	// The door opening was never answered, so the record response is not given to it.
+	assert.Equal(t, uint16(wire.FunctionOpenDoor), exchanges[2].Request.Function)
+	assert.Nil(t, exchanges[2].Response)
+	assert.Equal(t, uint16(wire.FunctionGetRecord), exchanges[3].Request.Function)
+	require.NotNil(t, exchanges[3].Response)
+	assert.Equal(t, envelopeOf(t, packets[6]), *exchanges[3].Response)
+}
+
+func TestCompareContents(t *testing.T) {
+	assert.Empty(t, compareContents([]byte{1, 2, 3}, []byte{1, 2, 3}))
+	assert.Equal(t, []int{1}, compareContents([]byte{1, 2, 3}, []byte{1, 0, 3}))
+	assert.Equal(t, []int{2, 3}, compareContents([]byte{1, 2, 3, 4}, []byte{1, 2}))
+}
+
+func TestAnalysis(t *testing.T) {
+	analysis := NewAnalysis()
+
+	// The status responses from the fixture.
+	for _, capturedPacket := range loadExchanges(t) {
+		envelope := envelopeOf(t, capturedPacket)
+		if envelope.Function != wire.FunctionGetOperationStatus || capturedPacket.FromClient {
+			continue
+		}
+		packet := &Packet{
+			Direction:    DirectionFromController,
+			FunctionCode: envelope.Function,
+			Function:     wire.FunctionName(envelope.Function),
+		}
+		analysis.Add(packet, envelope.Contents, nil)
+	}
+
+	// A door opening for each door.
+	for door := uint8(1); door <= 4; door++ {
+		request := wire.OpenDoorRequest{Door: door, Unkonwn1: 1}
+		writer := wire.NewWriter()
+		err := wire.Encode(writer, &request)
+		require.Nil(t, err)
+		packet := &Packet{
+			Direction:    DirectionToController,
+			FunctionCode: wire.FunctionOpenDoor,
+			Function:     wire.FunctionName(wire.FunctionOpenDoor),
+		}
+		analysis.Add(packet, writer.Bytes(), knownValues(time.Time{}, request))
+	}
+
+	groups := analysis.SortedGroups()
+	require.Len(t, groups, 2)
+
+	status := groups[0]
+	assert.Equal(t, uint16(wire.FunctionGetOperationStatus), status.FunctionCode)
+	assert.Equal(t, 2, status.Packets)
+	assert.True(t, status.Offsets[0].Constant())   // The year of the current time.
+	assert.False(t, status.Offsets[12].Constant()) // The start of the record.
+	assert.Equal(t, []uint8{0x8F, 0xFF}, status.Offsets[12].TopValues(5))
+
+	openDoor := groups[1]
+	assert.Equal(t, uint16(wire.FunctionOpenDoor), openDoor.FunctionCode)
+	assert.Equal(t, 4, openDoor.Packets)
+	assert.Equal(t, []Correlation{
+		{Name: "door", Matches: 4, Total: 4},
+	}, openDoor.Offsets[0].Correlations(0.9, 2))
+	assert.True(t, openDoor.Offsets[1].Constant())
+	assert.Empty(t, openDoor.Offsets[1].Correlations(0.9, 2))
+	assert.Empty(t, openDoor.Offsets[0].Correlations(0.9, 5), "too few samples")
+}
+
+func TestKnownValues(t *testing.T) {
+	known := knownValues(time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC), wire.GetUploadResponse{IDNumber: 0x1234, AreaNumber: 83, DoorNumber: 2})
+	assert.Equal(t, uint8(23), known["time:year"])
+	assert.Equal(t, uint8(0x23), known["time:year (bcd)"])
+	assert.Equal(t, uint8(15), known["time:hour"])
+	assert.Equal(t, uint8(0x15), known["time:hour (bcd)"])
+	_, ok := known["time:month (bcd)"]
+	assert.False(t, ok, "a single digit is the same in BCD")
+	assert.Equal(t, uint8(2), known["door"])
+	assert.Equal(t, uint8(1), known["door (0-indexed)"])
+	assert.Equal(t, uint8(83), known["card:area"])
+	assert.Equal(t, uint8(0x34), known["card:id (low)"])
+	assert.Equal(t, uint8(0x12), known["card:id (high)"])
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcap"
+	"github.com/sirupsen/logrus"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+// CapturedPacket is the controller payload of a single captured packet.
+type CapturedPacket struct {
+	Timestamp         time.Time // This is the capture time.
+	FromClient        bool      // If set, then this packet was sent to the controller.
+	ControllerAddress string    // This is the address of the controller.
+	Data              []byte    // This is the transport payload (the full envelope).
+}
+
+// readCapture calls the callback for every controller packet in the given pcap file.
+func readCapture(filename string, callback func(capturedPacket CapturedPacket)) error {
+	logrus.Infof("File: %s", filename)
+	handle, err := pcap.OpenOffline(filename)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	packetSource := gopacket.NewPacketSource(handle, handle.LinkType())
+	for packet := range packetSource.Packets() {
+		capturedPacket, ok := capturedPacketFrom(packet)
+		if !ok {
+			continue
+		}
+		callback(capturedPacket)
+	}
+	return nil
+}
+
+// capturedPacketFrom extracts the controller payload from the packet.
+//
+// This returns false if the packet is not to or from a controller.
+func capturedPacketFrom(packet gopacket.Packet) (CapturedPacket, bool) {
+	logrus.Debugf("Packet: %+v", packet)
+
+	capturedPacket := CapturedPacket{
+		Timestamp: packet.Metadata().Timestamp,
+	}
+	if tcpLayer := packet.Layer(layers.LayerTypeTCP); tcpLayer != nil {
+		logrus.Debugf("This is a TCP packet.")
+		tcp, _ := tcpLayer.(*layers.TCP)
+		logrus.Debugf("From src port %d to dst port %d.", tcp.SrcPort, tcp.DstPort)
+		if tcp.SrcPort != layers.TCPPort(wire.PortDefault) && tcp.DstPort != layers.TCPPort(wire.PortDefault) {
+			return capturedPacket, false
+		}
+		capturedPacket.FromClient = tcp.DstPort == layers.TCPPort(wire.PortDefault)
+	} else if udpLayer := packet.Layer(layers.LayerTypeUDP); udpLayer != nil {
+		logrus.Debugf("This is a UDP packet.")
+		udp, _ := udpLayer.(*layers.UDP)
+		logrus.Debugf("From src port %d to dst port %d.", udp.SrcPort, udp.DstPort)
+		if udp.SrcPort != layers.UDPPort(wire.PortDefault) && udp.DstPort != layers.UDPPort(wire.PortDefault) {
+			return capturedPacket, false
+		}
+		capturedPacket.FromClient = udp.DstPort == layers.UDPPort(wire.PortDefault)
+	} else {
+		return capturedPacket, false
+	}
+
+	capturedPacket.Data = packet.TransportLayer().LayerPayload()
+	if len(capturedPacket.Data) == 0 {
+		return capturedPacket, false
+	}
+
+	if packet.NetworkLayer() == nil {
+		logrus.Warnf("Could not determine source/destination from packet.")
+	} else if capturedPacket.FromClient {
+		capturedPacket.ControllerAddress = packet.NetworkLayer().NetworkFlow().Dst().String()
+	} else {
+		capturedPacket.ControllerAddress = packet.NetworkLayer().NetworkFlow().Src().String()
+	}
+	return capturedPacket, true
+}
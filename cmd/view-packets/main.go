@@ -1,12 +1,13 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"net"
 	"os"
+	"strconv"
+	"strings"
 
-	"github.com/google/gopacket"
-	"github.com/google/gopacket/layers"
-	"github.com/google/gopacket/pcap"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/tekkamanendless/cobra-controls/cobrafile"
@@ -16,6 +17,7 @@ import (
 func main() {
 	var controllerFile string
 	var personnelFile string
+	var format string
 
 	var controllerList cobrafile.ControllerList
 	var personnelList cobrafile.PersonnelList
@@ -26,6 +28,18 @@ func main() {
 		Short: "View packets",
 		Long:  ``,
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			switch format {
+			case "text":
+				// This is the default.
+			case "json":
+				if !verbose {
+					// Only show problems; the packets themselves will be written to stdout.
+					logrus.SetLevel(logrus.WarnLevel)
+				}
+			default:
+				logrus.Errorf("Invalid format: %q", format)
+				os.Exit(1)
+			}
 			if verbose {
 				logrus.SetLevel(logrus.DebugLevel)
 			}
@@ -52,82 +66,291 @@ func main() {
 		Run: func(cmd *cobra.Command, args []string) {
 			filenames := args
 
-			filterPacket := func(packet gopacket.Packet) bool {
-				logrus.Debugf("Packet: %+v", packet)
-				if tcpLayer := packet.Layer(layers.LayerTypeTCP); tcpLayer != nil {
-					logrus.Debugf("This is a TCP packet.")
-					tcp, _ := tcpLayer.(*layers.TCP)
-					logrus.Debugf("From src port %d to dst port %d.", tcp.SrcPort, tcp.DstPort)
-					if tcp.SrcPort != layers.TCPPort(wire.PortDefault) && tcp.DstPort != layers.TCPPort(wire.PortDefault) {
-						return false
-					}
-				} else if udpLayer := packet.Layer(layers.LayerTypeUDP); udpLayer != nil {
-					logrus.Debugf("This is a UDP packet.")
-					udp, _ := udpLayer.(*layers.UDP)
-					logrus.Debugf("From src port %d to dst port %d.", udp.SrcPort, udp.DstPort)
-					if udp.SrcPort != layers.UDPPort(wire.PortDefault) && udp.DstPort != layers.UDPPort(wire.PortDefault) {
-						return false
-					}
-				} else {
-					return false
-				}
-				if len(packet.TransportLayer().LayerPayload()) == 0 {
-					return false
-				}
-				return true
-			}
+			encoder := json.NewEncoder(os.Stdout)
 
 			for _, filename := range filenames {
-				logrus.Infof("File: %s", filename)
-				handle, err := pcap.OpenOffline(filename)
+				err := readCapture(filename, func(capturedPacket CapturedPacket) {
+					logrus.Infof("--------------------")
+					logrus.Debugf("Data: (%d)", len(capturedPacket.Data))
+
+					output := &Packet{
+						Timestamp:  capturedPacket.Timestamp,
+						Direction:  DirectionFromController,
+						Controller: capturedPacket.ControllerAddress,
+						Raw:        fmt.Sprintf("%X", capturedPacket.Data),
+					}
+					if capturedPacket.FromClient {
+						output.Direction = DirectionToController
+					}
+					if controllerList != nil {
+						output.ControllerName = controllerList.LookupName(capturedPacket.ControllerAddress)
+					}
+
+					err := parseData(wire.NewReader(capturedPacket.Data), capturedPacket.FromClient, capturedPacket.ControllerAddress, controllerList, personnelList, output)
+					if err != nil {
+						logrus.Warnf("Could not parse data: [%T] %v", err, err)
+						output.Errors = append(output.Errors, err.Error())
+					}
+
+					if format == "json" {
+						err = encoder.Encode(output)
+						if err != nil {
+							logrus.Errorf("Could not encode packet: %v", err)
+							os.Exit(1)
+						}
+					}
+				})
 				if err != nil {
 					logrus.Errorf("Error opening file: [%T] %v", err, err)
 					continue
 				}
-				packetSource := gopacket.NewPacketSource(handle, handle.LinkType())
-				for packet := range packetSource.Packets() {
-					if !filterPacket(packet) {
+			}
+		},
+	}
+	{
+		var threshold float64
+		var minimumSamples int
+		cmd := &cobra.Command{
+			Use:   "analyze <pcap-file>[ ...]",
+			Short: "Analyze the bytes of each function to help find the meaning of unknown fields",
+			Long:  ``,
+			Args:  cobra.MinimumNArgs(1),
+			Run: func(cmd *cobra.Command, args []string) {
+				if !verbose {
+					// Only show problems; the analysis will be written to stdout.
+					logrus.SetLevel(logrus.WarnLevel)
+				}
+
+				analysis := NewAnalysis()
+				for _, filename := range args {
+					err := readCapture(filename, func(capturedPacket CapturedPacket) {
+						output := &Packet{
+							Timestamp:  capturedPacket.Timestamp,
+							Direction:  DirectionFromController,
+							Controller: capturedPacket.ControllerAddress,
+						}
+						if capturedPacket.FromClient {
+							output.Direction = DirectionToController
+						}
+
+						var envelope wire.Envelope
+						err := wire.Decode(wire.NewReader(capturedPacket.Data), &envelope)
+						if err != nil {
+							logrus.Warnf("Could not decode envelope: %v", err)
+							return
+						}
+						err = parseData(wire.NewReader(capturedPacket.Data), capturedPacket.FromClient, capturedPacket.ControllerAddress, controllerList, personnelList, output)
+						if err != nil {
+							logrus.Debugf("Could not parse data: [%T] %v", err, err)
+						}
+						analysis.Add(output, envelope.Contents, knownValues(output.Timestamp, output.Fields))
+					})
+					if err != nil {
+						logrus.Errorf("Error opening file: [%T] %v", err, err)
 						continue
 					}
-					logrus.Infof("--------------------")
+				}
 
-					fromClient := false
-					var controllerAddress string
-					if tcpLayer := packet.Layer(layers.LayerTypeTCP); tcpLayer != nil {
-						if tcp, ok := tcpLayer.(*layers.TCP); ok {
-							if tcp.DstPort == layers.TCPPort(wire.PortDefault) {
-								fromClient = true
-								controllerAddress = packet.NetworkLayer().NetworkFlow().Dst().String()
-							} else {
-								controllerAddress = packet.NetworkLayer().NetworkFlow().Src().String()
-							}
+				for _, group := range analysis.SortedGroups() {
+					function := group.Function
+					if function == "" {
+						function = "Unknown"
+					}
+					fmt.Printf("Function: 0x%04X (%s) | Direction: %s | Packets: %d\n", group.FunctionCode, function, group.Direction, group.Packets)
+					for _, stats := range group.Offsets {
+						var values []string
+						for _, value := range stats.TopValues(5) {
+							values = append(values, fmt.Sprintf("%02X×%d", value, stats.Histogram[value]))
 						}
-					} else if udpLayer := packet.Layer(layers.LayerTypeUDP); udpLayer != nil {
-						if udp, ok := udpLayer.(*layers.UDP); ok {
-							if udp.DstPort == layers.UDPPort(wire.PortDefault) {
-								fromClient = true
-								controllerAddress = packet.NetworkLayer().NetworkFlow().Dst().String()
-							} else {
-								controllerAddress = packet.NetworkLayer().NetworkFlow().Src().String()
-							}
+						if len(stats.Histogram) > 5 {
+							values = append(values, "...")
 						}
-					} else {
-						logrus.Warnf("Could not determine source/destination from packet.")
+						var notes []string
+						if stats.Constant() {
+							notes = append(notes, "constant")
+						}
+						for _, correlation := range stats.Correlations(threshold, minimumSamples) {
+							notes = append(notes, fmt.Sprintf("matches %s (%d/%d)", correlation.Name, correlation.Matches, correlation.Total))
+						}
+						fmt.Printf("   Offset: %2d | Distinct: %3d | Values: %s", stats.Offset, len(stats.Histogram), strings.Join(values, " "))
+						if len(notes) > 0 {
+							fmt.Printf(" | %s", strings.Join(notes, "; "))
+						}
+						fmt.Printf("\n")
 					}
+				}
+			},
+		}
+		cmd.Flags().Float64Var(&threshold, "threshold", 0.9, "The fraction of packets that must match a known field for a byte to be considered correlated")
+		cmd.Flags().IntVar(&minimumSamples, "minimum-samples", 2, "The minimum number of packets needed to consider a correlation")
+		rootCommand.AddCommand(cmd)
+	}
+	{
+		var target string
+		var protocol string
+		var boardAddress uint16
+		var includeWrites bool
+		cmd := &cobra.Command{
+			Use:   "replay <pcap-file>[ ...]",
+			Short: "Re-send the requests from a capture to a controller and compare the responses",
+			Long:  `The responses are compared to those in the capture, byte-by-byte.  By default, only the requests that do not change the controller are sent; use "--include-writes" to send everything.`,
+			Args:  cobra.MinimumNArgs(1),
+			Run: func(cmd *cobra.Command, args []string) {
+				if !verbose {
+					// Only show problems; the results will be written to stdout.
+					logrus.SetLevel(logrus.WarnLevel)
+				}
 
-					data := packet.TransportLayer().LayerPayload()
-					logrus.Debugf("Data (%d): %X", len(data), data)
+				host, port, err := net.SplitHostPort(target)
+				if err != nil {
+					host = target
+					port = strconv.Itoa(int(wire.PortDefault))
+				}
+				portNumber, err := strconv.ParseUint(port, 10, 16)
+				if err != nil {
+					logrus.Errorf("Invalid port: %q", port)
+					os.Exit(1)
+				}
+				client := &wire.Client{
+					Protocol:          wire.Protocol(protocol),
+					ControllerAddress: host,
+					ControllerPort:    uint16(portNumber),
+				}
 
-					err = parseData(wire.NewReader(data), fromClient, controllerAddress, controllerList, personnelList)
+				var capturedPackets []CapturedPacket
+				for _, filename := range args {
+					err := readCapture(filename, func(capturedPacket CapturedPacket) {
+						capturedPackets = append(capturedPackets, capturedPacket)
+					})
 					if err != nil {
-						logrus.Warnf("Could not parse data: [%T] %v", err, err)
+						logrus.Errorf("Error opening file: [%T] %v", err, err)
+						os.Exit(1)
 					}
 				}
-			}
-		},
+
+				var matches, mismatches, skipped, failures int
+				for i, exchange := range pairExchanges(capturedPackets) {
+					request := exchange.Request
+					function := wire.FunctionName(request.Function)
+					if info := wire.LookupFunction(request.Function); !includeWrites && (info == nil || !info.ReadOnly) {
+						fmt.Printf("Exchange: %d | Function: 0x%04X (%s) | Result: skipped (not read-only)\n", i, request.Function, function)
+						skipped++
+						continue
+					}
+					if boardAddress != 0 {
+						request.BoardAddress = boardAddress
+					}
+
+					var response *wire.Envelope
+					if client.Protocol == wire.ProtocolUDP {
+						var responses []*wire.Envelope
+						responses, err = client.RawMulticast(request)
+						if err == nil && len(responses) == 0 {
+							err = os.ErrDeadlineExceeded
+						}
+						if err == nil {
+							response = responses[0]
+						}
+					} else {
+						response, err = client.RawUnicast(request)
+					}
+					if err != nil {
+						fmt.Printf("Exchange: %d | Function: 0x%04X (%s) | Result: error (%v)\n", i, request.Function, function, err)
+						failures++
+						continue
+					}
+					if exchange.Response == nil {
+						fmt.Printf("Exchange: %d | Function: 0x%04X (%s) | Result: no captured response | Actual: %s\n", i, request.Function, function, printable(request.Function, response.Contents))
+						skipped++
+						continue
+					}
+					if response.Function != exchange.Response.Function {
+						fmt.Printf("Exchange: %d | Function: 0x%04X (%s) | Result: mismatch (function 0x%04X)\n", i, request.Function, function, response.Function)
+						mismatches++
+						continue
+					}
+					offsets := compareContents(exchange.Response.Contents, response.Contents)
+					if len(offsets) == 0 {
+						fmt.Printf("Exchange: %d | Function: 0x%04X (%s) | Result: match\n", i, request.Function, function)
+						matches++
+						continue
+					}
+					fmt.Printf("Exchange: %d | Function: 0x%04X (%s) | Result: mismatch (offsets: %v) | Expected: %s | Actual: %s\n", i, request.Function, function, offsets, printable(request.Function, exchange.Response.Contents), printable(request.Function, response.Contents))
+					mismatches++
+				}
+				fmt.Printf("Matches: %d | Mismatches: %d | Skipped: %d | Failures: %d\n", matches, mismatches, skipped, failures)
+				if mismatches > 0 || failures > 0 {
+					os.Exit(1)
+				}
+			},
+		}
+		cmd.Flags().StringVar(&target, "target", "", "The address (host or host:port) of the controller or simulator")
+		cmd.Flags().StringVar(&protocol, "protocol", wire.ProtocolTCP, `The protocol to use ("tcp" or "udp")`)
+		cmd.Flags().Uint16Var(&boardAddress, "board-address", 0, "Replace the board address in every request with this one (0 keeps the captured address)")
+		cmd.Flags().BoolVar(&includeWrites, "include-writes", false, "Also send requests that change the controller")
+		cmd.MarkFlagRequired("target")
+		rootCommand.AddCommand(cmd)
+	}
+	{
+		cmd := &cobra.Command{
+			Use:   "basic-config <pcap-file>[ ...] <output-file>",
+			Short: "Save the last \"basic\" block that the vendor software pushed",
+			Long:  `The "basic" block (0x10F9) holds the super passwords, among other things.  There is no known way to read it from a controller, so it is taken from the last request in the captures that pushed it.  The output file is used by "cobra-cli super-passwords"; it contains the super passwords, so it is only readable by its owner.`,
+			Args:  cobra.MinimumNArgs(2),
+			Run: func(cmd *cobra.Command, args []string) {
+				filenames := args[:len(args)-1]
+				outputFile := args[len(args)-1]
+
+				var request *wire.BasicConfigRequest
+				var controller string
+				for _, filename := range filenames {
+					err := readCapture(filename, func(capturedPacket CapturedPacket) {
+						if !capturedPacket.FromClient {
+							return
+						}
+						var envelope wire.Envelope
+						err := wire.Decode(wire.NewReader(capturedPacket.Data), &envelope)
+						if err != nil || envelope.Function != wire.FunctionUnknown10F9 {
+							return
+						}
+						var candidate wire.BasicConfigRequest
+						err = wire.Decode(wire.NewReader(envelope.Contents), &candidate)
+						if err != nil {
+							logrus.Debugf("Skipping 0x10F9 request: %v", err)
+							return
+						}
+						request = &candidate
+						controller = capturedPacket.ControllerAddress
+					})
+					if err != nil {
+						logrus.Errorf("Error opening file: [%T] %v", err, err)
+						os.Exit(1)
+					}
+				}
+				if request == nil {
+					logrus.Errorf("No basic block was found.")
+					os.Exit(1)
+				}
+
+				err := cobrafile.SaveBasicConfig(outputFile, *request)
+				if err != nil {
+					logrus.Errorf("Could not save basic block: %v", err)
+					os.Exit(1)
+				}
+				passwordCount := 0
+				for _, password := range request.Config.SuperPasswords {
+					if password.IsSet() {
+						passwordCount++
+					}
+				}
+				fmt.Printf("File: %s | Controller: %s | Super passwords: %d set\n", outputFile, controller, passwordCount)
+			},
+		}
+		rootCommand.AddCommand(cmd)
 	}
 	rootCommand.PersistentFlags().StringVar(&controllerFile, "controller-file", "", "Use this CSV file to load the controller information")
 	rootCommand.PersistentFlags().StringVar(&personnelFile, "personnel-file", "", "Use this CSV file to load the personnel information")
+	rootCommand.PersistentFlags().StringVar(&format, "format", "text", `The output format ("text" or "json")`)
 	rootCommand.PersistentFlags().BoolVar(&verbose, "verbose", false, "Enable verbose output")
 
 	err := rootCommand.Execute()
@@ -137,7 +360,7 @@ func main() {
 	os.Exit(0)
 }
 
-func parseData(fullContents *wire.Reader, fromClient bool, controllerAddress string, controllerList cobrafile.ControllerList, personnelList cobrafile.PersonnelList) error {
+func parseData(fullContents *wire.Reader, fromClient bool, controllerAddress string, controllerList cobrafile.ControllerList, personnelList cobrafile.PersonnelList, packet *Packet) error {
 	if fromClient {
 		logrus.Infof("From: Client")
 	} else {
@@ -154,601 +377,313 @@ func parseData(fullContents *wire.Reader, fromClient bool, controllerAddress str
 	if fromClient {
 		logrus.Infof("   Source: %s", controllerAddress)
 	} else {
-		var controllerName string
-		if controllerList != nil {
-			controllerName = controllerList.LookupName(controllerAddress)
-		}
-		logrus.Infof("   Controller: %s (name: %s)", controllerAddress, controllerName)
+		logrus.Infof("   Controller: %s (name: %s)", controllerAddress, packet.ControllerName)
 	}
 	logrus.Infof("   Board address: 0x%X", envelope.BoardAddress)
+	packet.BoardAddress = envelope.BoardAddress
+	packet.FunctionCode = envelope.Function
 	logrus.Infof("   Function type: 0x%X", envelope.Function)
-	logrus.Infof("   Remaining data: (%d) %X", len(envelope.Contents), envelope.Contents)
+	logrus.Infof("   Remaining data: (%d) %s", len(envelope.Contents), printable(envelope.Function, envelope.Contents))
+	if info := wire.LookupFunction(envelope.Function); info != nil && info.Secret {
+		packet.Raw = ""
+	}
 
 	data := wire.NewReader(envelope.Contents)
 
-	switch envelope.Function {
-	case wire.FunctionGetOperationStatus:
-		logrus.Infof("Function: GetOperationStatus")
-		if fromClient {
-			var request wire.GetOperationStatusRequest
-			err = wire.Decode(data, &request)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Request: %+v", request)
-		} else {
-			var response wire.GetOperationStatusResponse
-			err = wire.Decode(data, &response)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Response: %+v", response)
-			if response.Record != nil {
-				logrus.Infof("Record: %+v", *response.Record)
-				if personnelList != nil {
-					if person := personnelList.FindByCardID(wire.CardID(response.Record.AreaNumber, response.Record.IDNumber)); person != nil {
-						logrus.Infof("   Person: %+v", *person)
-					}
-				}
-				if response.Record.AreaNumber == 0 && response.Record.IDNumber < 100 {
-					if response.Record.RecordState == 0b00 && response.Record.IDNumber <= 3 {
-						logrus.Infof("   Action: Button")
-					} else if response.Record.RecordState == 0b11 && response.Record.IDNumber <= 3 {
-						logrus.Infof("   Action: Remote control")
-					} else {
-						logrus.Warnf("   Action: TODO UNHANDLED")
-					}
-				}
-				logrus.Infof("   Door: %d (access: %t)", response.Record.Door(), response.Record.AccessGranted())
-				if controllerList != nil {
-					door := controllerList.LookupDoor(controllerAddress, response.Record.Door())
-					if door != "" {
-						logrus.Infof("   Door name: %s", door)
-					}
-				}
-			}
-		}
-	case wire.FunctionGetBasicInfo:
-		logrus.Infof("Function: GetBasicInfo")
-		if fromClient {
-			var request wire.GetBasicInfoRequest
-			err = wire.Decode(data, &request)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Request: %+v", request)
-		} else {
-			var response wire.GetBasicInfoResponse
-			err = wire.Decode(data, &response)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Response: %+v", response)
-		}
-	case wire.FunctionSetTime:
-		logrus.Infof("Function: SetTime")
-		if fromClient {
-			var request wire.SetTimeRequest
-			err = wire.Decode(data, &request)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Request: %+v", request)
-		} else {
-			var response wire.SetTimeResponse
-			err = wire.Decode(data, &response)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Response: %+v", response)
-		}
-	case wire.FunctionGetRecord:
-		logrus.Infof("Function: GetRecord")
-		if fromClient {
-			var request wire.GetRecordRequest
-			err = wire.Decode(data, &request)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Request: %+v", request)
-		} else {
-			var response wire.GetRecordResponse
-			err = wire.Decode(data, &response)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Response: %+v", response)
-		}
-	case wire.FunctionDeleteRecord:
-		logrus.Infof("Function: DeleteRecord")
-		if fromClient {
-			var request wire.DeleteRecordRequest
-			err = wire.Decode(data, &request)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Request: %+v", request)
-		} else {
-			var response wire.DeleteRecordResponse
-			err = wire.Decode(data, &response)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Response: %+v", response)
-		}
-	case 0x108F:
-		logrus.Infof("Function: Set door controls (online/delay)")
-		logrus.Warnf("TODO NOT IMPLEMENTED")
-	case 0x1091:
-		logrus.Infof("Function: Upload the mission")
+	if envelope.Function == wire.FunctionUnknown10F9 {
+		// TODO: This appears to be the thing that pushes the config up.
+		logrus.Infof("Function: Unknown10F9")
+		packet.Function = "Unknown10F9"
+		return parseUnknown10F9(data, fromClient, personnelList, packet)
+	}
+
+	info := wire.LookupFunction(envelope.Function)
+	if info == nil {
+		logrus.Warnf("TODO UNHANDLED FUNCTION: %X", envelope.Function)
+		return nil
+	}
+	logrus.Infof("Function: %s", info.Name)
+	packet.Function = info.Name
+
+	var value any
+	if fromClient {
+		value, err = info.DecodeRequest(envelope.Contents)
+	} else {
+		value, err = info.DecodeResponse(envelope.Contents)
+	}
+	if err != nil {
+		return err
+	}
+	if value == nil {
 		logrus.Warnf("TODO NOT IMPLEMENTED")
-	case wire.FunctionClearUpload:
-		logrus.Infof("Function: ClearUpload")
-		if fromClient {
-			var request wire.ClearUploadRequest
-			err = wire.Decode(data, &request)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Request: %+v", request)
-		} else {
-			var response wire.ClearUploadResponse
-			err = wire.Decode(data, &response)
-			if err != nil {
-				return err
+		return nil
+	}
+	if fromClient {
+		logrus.Infof("Request: %+v", value)
+	} else {
+		logrus.Infof("Response: %+v", value)
+	}
+	packet.Fields = value
+
+	annotate(value, controllerAddress, controllerList, personnelList, packet)
+	return nil
+}
+
+// printable returns the contents in hexadecimal, unless the function is secret
+// (see `wire.FunctionInfo.Secret`).
+func printable(function uint16, contents []byte) string {
+	if info := wire.LookupFunction(function); info != nil && info.Secret {
+		return fmt.Sprintf("(%d bytes redacted)", len(contents))
+	}
+	return fmt.Sprintf("%X", contents)
+}
+
+// annotate adds the extra details (person, door, and so on) for the decoded
+// request or response.
+func annotate(value any, controllerAddress string, controllerList cobrafile.ControllerList, personnelList cobrafile.PersonnelList, packet *Packet) {
+	annotatePerson := func(cardID string) {
+		if personnelList != nil {
+			if person := personnelList.FindByCardID(cardID); person != nil {
+				logrus.Infof("   Person: %+v", *person)
+				packet.Annotate("Person", person.Name)
 			}
-			logrus.Infof("Response: %+v", response)
 		}
-	case 0x1096:
-		logrus.Infof("Function: Read control period of time")
-		logrus.Warnf("TODO NOT IMPLEMENTED")
-	case wire.FunctionUpdateControlPeriod:
-		logrus.Infof("Function: UpdateControlPeriod")
-		if fromClient {
-			var request wire.UpdateControlPeriodRequest
-			err = wire.Decode(data, &request)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Request: %+v", request)
-		} else {
-			var response wire.UpdateControlPeriodResponse
-			err = wire.Decode(data, &response)
-			if err != nil {
-				return err
+	}
+	annotateDoor := func(doorNumber uint8) {
+		if controllerList != nil {
+			door := controllerList.LookupDoor(controllerAddress, doorNumber)
+			if door != "" {
+				logrus.Infof("   Door name: %s", door)
+				packet.Annotate("Door", door)
 			}
-			logrus.Infof("Response: %+v", response)
 		}
+	}
 
-	case wire.FunctionUnknown1098:
-		logrus.Infof("Function: Unknown1098")
-		if fromClient {
-			var request wire.Unknown1098Request
-			err = wire.Decode(data, &request)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Request: %+v", request)
-		} else {
-			var response wire.Unknown1098Response
-			err = wire.Decode(data, &response)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Response: %+v", response)
-		}
-	case wire.FunctionTailPlusPermissions:
-		logrus.Infof("Function: FunctionTailPlusPermissions")
-		if fromClient {
-			var request wire.TailPlusPermissionsRequest
-			err = wire.Decode(data, &request)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Request: %+v", request)
-			if personnelList != nil {
-				if person := personnelList.FindByCardID(wire.CardID(request.AreaNumber, request.CardNumber)); person != nil {
-					logrus.Infof("   Person: %+v", *person)
-				}
-			}
-			if controllerList != nil {
-				door := controllerList.LookupDoor(controllerAddress, request.Door)
-				if door != "" {
-					logrus.Infof("Door: %s", door)
+	switch v := value.(type) {
+	case wire.GetOperationStatusResponse:
+		if v.Record != nil {
+			logrus.Infof("Record: %+v", *v.Record)
+			annotatePerson(wire.CardID(v.Record.AreaNumber, v.Record.IDNumber))
+			if v.Record.AreaNumber == 0 && v.Record.IDNumber < 100 {
+				if v.Record.RecordState == 0b00 && v.Record.IDNumber <= 3 {
+					logrus.Infof("   Action: Button")
+					packet.Annotate("Action", "Button")
+				} else if v.Record.RecordState == 0b11 && v.Record.IDNumber <= 3 {
+					logrus.Infof("   Action: Remote control")
+					packet.Annotate("Action", "Remote control")
+				} else {
+					logrus.Warnf("   Action: TODO UNHANDLED")
 				}
 			}
-		} else {
-			var response wire.TailPlusPermissionsResponse
-			err = wire.Decode(data, &response)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Response: %+v", response)
+			logrus.Infof("   Door: %d (access: %t)", v.Record.Door(), v.Record.AccessGranted())
+			annotateDoor(v.Record.Door())
 		}
-	case wire.FunctionOpenDoor:
-		logrus.Infof("Function: OpenDoor")
-		if fromClient {
-			var request wire.OpenDoorRequest
-			err = wire.Decode(data, &request)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Request: %+v", request)
-			if controllerList != nil {
-				door := controllerList.LookupDoor(controllerAddress, request.Door)
-				if door != "" {
-					logrus.Infof("Door: %s", door)
-				}
-			}
-		} else {
-			var response wire.OpenDoorResponse
-			err = wire.Decode(data, &response)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Response: %+v", response)
+	case wire.GetUploadResponse:
+		annotatePerson(wire.CardID(v.AreaNumber, v.IDNumber))
+		annotateDoor(v.DoorNumber)
+	case wire.TailPlusPermissionsRequest:
+		annotatePerson(wire.CardID(v.AreaNumber, v.CardNumber))
+		annotateDoor(v.Door)
+	case wire.UpdatePermissionsRequest:
+		logrus.Infof("Card ID: %s", wire.CardID(v.Area, v.CardID))
+		annotatePerson(wire.CardID(v.Area, v.CardID))
+		annotateDoor(v.Door)
+	case wire.DeletePermissionsRequest:
+		logrus.Infof("Card ID: %s", wire.CardID(v.Area, v.CardID))
+		annotatePerson(wire.CardID(v.Area, v.CardID))
+		annotateDoor(v.Door)
+	case wire.OpenDoorRequest:
+		annotateDoor(v.Door)
+	case wire.RealizeTimingTaskRequest:
+		logrus.Infof("Days: %v", wire.WeekControlDays(v.WeekControl))
+		annotateDoor(v.Door)
+	}
+}
+
+// parseUnknown10F9 parses the (partially understood) 0x10F9 function.
+func parseUnknown10F9(data *wire.Reader, fromClient bool, personnelList cobrafile.PersonnelList, packet *Packet) error {
+	if fromClient {
+		unknown1, err := data.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("could not read unknown1: %w", err)
 		}
-	case wire.FunctionGetSetting:
-		logrus.Infof("Function: GetSetting")
-		if fromClient {
-			var request wire.GetSettingRequest
-			err = wire.Decode(data, &request)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Request: %+v", request)
-		} else {
-			var response wire.GetSettingResponse
-			err = wire.Decode(data, &response)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Response: %+v", response)
+		unknown2, err := data.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("could not read unknown2: %w", err)
 		}
-	case wire.FunctionUpdateSetting:
-		logrus.Infof("Function: UpdateSetting")
-		if fromClient {
-			var request wire.UpdateSettingRequest
-			err = wire.Decode(data, &request)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Request: %+v", request)
-		} else {
-			var response wire.UpdateSettingResponse
-			err = wire.Decode(data, &response)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Response: %+v", response)
+		unknown3, err := data.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("could not read unknown3: %w", err)
 		}
-	case wire.FunctionGetUpload:
-		logrus.Infof("Function: GetUpload")
-		if fromClient {
-			var request wire.GetUploadRequest
-			err = wire.Decode(data, &request)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Request: %+v", request)
-		} else {
-			var response wire.GetUploadResponse
-			err = wire.Decode(data, &response)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Response: %+v", response)
-			if personnelList != nil {
-				if person := personnelList.FindByCardID(wire.CardID(response.AreaNumber, response.IDNumber)); person != nil {
-					logrus.Infof("   Person: %+v", *person)
-				}
-			}
+		unknown4, err := data.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("could not read unknown4: %w", err)
 		}
-	case 0x10F5:
-		logrus.Infof("Function: Realize timing task")
-		logrus.Warnf("TODO NOT IMPLEMENTED")
-	case 0x10F9:
-		// TODO: This appears to be the thing that pushes the config up.
-		logrus.Infof("Function: Unknown10F9")
-		if fromClient {
-			unknown1, err := data.ReadUint8()
-			if err != nil {
-				return fmt.Errorf("could not read unknown1: %w", err)
-			}
-			unknown2, err := data.ReadUint8()
-			if err != nil {
-				return fmt.Errorf("could not read unknown2: %w", err)
-			}
-			unknown3, err := data.ReadUint8()
-			if err != nil {
-				return fmt.Errorf("could not read unknown3: %w", err)
-			}
-			unknown4, err := data.ReadUint8()
-			if err != nil {
-				return fmt.Errorf("could not read unknown4: %w", err)
+		logrus.Infof("Unknown1: %d", unknown1)                             // This seems to always be "3".
+		logrus.Infof("Unknown2: %d", unknown2)                             // This seems to always be "0".
+		logrus.Infof("Unknown3: %d (index, maybe?)", unknown3)             // This seems to be the 0-index for access uploads.
+		logrus.Infof("Unknown4: %d (1 for basic, 4 for access)", unknown4) // This seems to be 1 for basic upload, 4 for access upload.
+		// TODO: The remaining data appears to be different based on Unknown4.
+		// TODO: For "basic" uploads, I don't know what this is yet.
+		// TODO: For "access" uploads (Unknown4=4), this is a list of popedom records.
+		switch unknown4 {
+		case 1:
+			newData, err := data.Read(wire.BasicConfigLength)
+			if err != nil {
+				return fmt.Errorf("could not read proper payload: %w", err)
+			}
+			if !wire.IsAll(data.Bytes(), 0x00) {
+				logrus.Infof("Remainder is not all 0x00: %x", data.Bytes())
 			}
-			logrus.Infof("Unknown1: %d", unknown1)                             // This seems to always be "3".
-			logrus.Infof("Unknown2: %d", unknown2)                             // This seems to always be "0".
-			logrus.Infof("Unknown3: %d (index, maybe?)", unknown3)             // This seems to be the 0-index for access uploads.
-			logrus.Infof("Unknown4: %d (1 for basic, 4 for access)", unknown4) // This seems to be 1 for basic upload, 4 for access upload.
-			// TODO: The remaining data appears to be different based on Unknown4.
-			// TODO: For "basic" uploads, I don't know what this is yet.
-			// TODO: For "access" uploads (Unknown4=4), this is a list of popedom records.
-			switch unknown4 {
+
+			switch unknown3 {
 			case 1:
-				newData, err := data.Read(270)
+				var config wire.BasicConfig
+				err = wire.Decode(newData, &config)
 				if err != nil {
-					return fmt.Errorf("could not read proper payload: %w", err)
+					return fmt.Errorf("could not decode basic block: %w", err)
+				}
+				logrus.Infof("Unknown5: %d", config.Unknown1)
+				for i, openDelay := range config.OpenDelays {
+					logrus.Infof("Open delay %d: %d seconds", i+1, openDelay/10)
 				}
-				if !wire.IsAll(data.Bytes(), 0x00) {
-					logrus.Infof("Remainder is not all 0x00: %x", data.Bytes())
+				for i, controlState := range config.ControlStates {
+					logrus.Infof("Control state %d: %d (1 is open, 2 is closed, 3 is door controlled)", i+1, controlState)
 				}
 
+				// Remainder example:
+				// 000000000000000000000000000000010100100000fa006401015500000000700000000000000000000000000000000000000000000000000000000084941309ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000d00000000000000000000000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000ff000000000000000000000000000000000000000000000000000000000000000000fcffc3e3f929001000fcffffff0f
+				//                                     ^|                                                                                      ^                                                              |
+				//                                     Invalid card swiping                                                                    \______________________________________________________________/
+				//                                                                                                                               Passwords (list of 16-bit numbers)
+
+				logrus.Infof("Unknown10F9_%d: %x", unknown4, config.Unknown2)
+				// The super passwords are never logged; only the number that are set is shown.
+				passwordCount := 0
+				for _, password := range config.SuperPasswords {
+					if password.IsSet() {
+						passwordCount++
+					}
+				}
+				logrus.Infof("Super passwords: %d set", passwordCount)
+				packet.Annotate("Super passwords", fmt.Sprintf("%d set", passwordCount))
+				logrus.Infof("Unknown10F9_%d (after the super passwords): %x", unknown4, config.Unknown3)
+			default:
 				data = newData
-				switch unknown3 {
-				case 1:
-					unknown5, err := data.ReadUint16()
+				if wire.IsAll(data.Bytes(), 0xff) {
+					logrus.Infof("Remainder is all 0xff.")
+				} else {
+					remainder, err := data.Read(data.Length())
 					if err != nil {
-						return fmt.Errorf("could not read unknown5: %w", err)
+						return fmt.Errorf("could not read data: %w", err)
 					}
-					logrus.Infof("Unknown5: %d", unknown5)
-
-					openDelay1, err := data.ReadUint16()
+					logrus.Infof("Unknown10F9_%d_%d: %x", unknown4, unknown3, remainder.Bytes())
+				}
+			}
+		case 4:
+			for p := 0; data.Length() >= 16; p++ {
+				popedom, err := data.Read(16)
+				if err != nil {
+					logrus.Warnf("could not read popedom [%d]: %v", p, err)
+					continue
+				}
+				if wire.IsAll(popedom.Bytes(), 0xff) {
+					logrus.Infof("Skipping bogus popedom.")
+					continue
+				}
+				err = func(popedom *wire.Reader) error {
+					id, err := popedom.ReadUint16()
 					if err != nil {
-						return fmt.Errorf("could not read openDelay1: %w", err)
+						return fmt.Errorf("could not read id: %w", err)
 					}
-					logrus.Infof("Open delay 1: %d seconds", openDelay1/10)
-					openDelay2, err := data.ReadUint16()
+					area, err := popedom.ReadUint8()
 					if err != nil {
-						return fmt.Errorf("could not read openDelay2: %w", err)
+						return fmt.Errorf("could not read area: %w", err)
 					}
-					logrus.Infof("Open delay 2: %d seconds", openDelay2/10)
-					openDelay3, err := data.ReadUint16()
+					door, err := popedom.ReadUint8()
 					if err != nil {
-						return fmt.Errorf("could not read openDelay3: %w", err)
+						return fmt.Errorf("could not read door: %w", err)
 					}
-					logrus.Infof("Open delay 3: %d seconds", openDelay3/10)
-					openDelay4, err := data.ReadUint16()
+					openDate, err := popedom.ReadDate()
 					if err != nil {
-						return fmt.Errorf("could not read openDelay4: %w", err)
+						return fmt.Errorf("could not read open date: %w", err)
 					}
-					logrus.Infof("Open delay 4: %d seconds", openDelay4/10)
-
-					controlState1, err := data.ReadUint8()
+					closeDate, err := popedom.ReadDate()
 					if err != nil {
-						return fmt.Errorf("could not read controlState1: %w", err)
+						return fmt.Errorf("could not read close date: %w", err)
 					}
-					logrus.Infof("Control state 1: %d (1 is open, 2 is closed, 3 is door controlled)", controlState1)
-					controlState2, err := data.ReadUint8()
+					controlIndex, err := popedom.ReadUint8()
 					if err != nil {
-						return fmt.Errorf("could not read controlState2: %w", err)
+						return fmt.Errorf("could not read control index: %w", err)
 					}
-					logrus.Infof("Control state 2: %d (1 is open, 2 is closed, 3 is door controlled)", controlState2)
-					controlState3, err := data.ReadUint8()
+					password, err := popedom.ReadUint24()
 					if err != nil {
-						return fmt.Errorf("could not read controlState3: %w", err)
+						return fmt.Errorf("could not read password: %w", err)
 					}
-					logrus.Infof("Control state 3: %d (1 is open, 2 is closed, 3 is door controlled)", controlState3)
-					controlState4, err := data.ReadUint8()
+					standby1, err := popedom.ReadUint8()
 					if err != nil {
-						return fmt.Errorf("could not read controlState4: %w", err)
+						return fmt.Errorf("could not read standby 1: %w", err)
 					}
-					logrus.Infof("Control state 4: %d (1 is open, 2 is closed, 3 is door controlled)", controlState4)
-
-					// Remainder example:
-					// 000000000000000000000000000000010100100000fa006401015500000000700000000000000000000000000000000000000000000000000000000084941309ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000d00000000000000000000000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000ff000000000000000000000000000000000000000000000000000000000000000000fcffc3e3f929001000fcffffff0f
-					//                                     ^|                                                                                      ^                                                              |
-					//                                     Invalid card swiping                                                                    \______________________________________________________________/
-					//                                                                                                                               Passwords (list of 16-bit numbers)
-
-					remainder, err := data.Read(data.Length())
+					standby2, err := popedom.ReadUint8()
 					if err != nil {
-						return fmt.Errorf("could not read data: %w", err)
+						return fmt.Errorf("could not read standby 2: %w", err)
 					}
-					logrus.Infof("Unknown10F9_%d: %x", unknown4, remainder.Bytes())
-				default:
-					if wire.IsAll(data.Bytes(), 0xff) {
-						logrus.Infof("Remainder is all 0xff.")
-					} else {
-						remainder, err := data.Read(data.Length())
-						if err != nil {
-							return fmt.Errorf("could not read data: %w", err)
-						}
-						logrus.Infof("Unknown10F9_%d_%d: %x", unknown4, unknown3, remainder.Bytes())
+					standby3, err := popedom.ReadUint8()
+					if err != nil {
+						return fmt.Errorf("could not read standby 3: %w", err)
 					}
-				}
-			case 4:
-				for p := 0; data.Length() >= 16; p++ {
-					popedom, err := data.Read(16)
+					standby4, err := popedom.ReadUint8()
 					if err != nil {
-						logrus.Warnf("could not read popedom [%d]: %v", p, err)
-						continue
+						return fmt.Errorf("could not read standby 4: %w", err)
 					}
-					logrus.Infof("Popedom[%2d]: %X", p, popedom.Bytes())
-					if wire.IsAll(popedom.Bytes(), 0xff) {
-						logrus.Infof("Skipping bogus popedom.")
-						continue
+					if popedom.Length() != 0 {
+						logrus.Warnf("Unexpected extra popedom data: (%d)", popedom.Length())
 					}
-					err = func(popedom *wire.Reader) error {
-						id, err := popedom.ReadUint16()
-						if err != nil {
-							return fmt.Errorf("could not read id: %w", err)
-						}
-						area, err := popedom.ReadUint8()
-						if err != nil {
-							return fmt.Errorf("could not read area: %w", err)
-						}
-						door, err := popedom.ReadUint8()
-						if err != nil {
-							return fmt.Errorf("could not read door: %w", err)
-						}
-						openDate, err := popedom.ReadDate()
-						if err != nil {
-							return fmt.Errorf("could not read open date: %w", err)
-						}
-						closeDate, err := popedom.ReadDate()
-						if err != nil {
-							return fmt.Errorf("could not read close date: %w", err)
-						}
-						controlIndex, err := popedom.ReadUint8()
-						if err != nil {
-							return fmt.Errorf("could not read control index: %w", err)
-						}
-						password, err := popedom.ReadBytes(3)
-						if err != nil {
-							return fmt.Errorf("could not read password: %w", err)
-						}
-						standby1, err := popedom.ReadUint8()
-						if err != nil {
-							return fmt.Errorf("could not read standby 1: %w", err)
-						}
-						standby2, err := popedom.ReadUint8()
-						if err != nil {
-							return fmt.Errorf("could not read standby 2: %w", err)
-						}
-						standby3, err := popedom.ReadUint8()
-						if err != nil {
-							return fmt.Errorf("could not read standby 3: %w", err)
-						}
-						standby4, err := popedom.ReadUint8()
-						if err != nil {
-							return fmt.Errorf("could not read standby 4: %w", err)
-						}
-						if popedom.Length() != 0 {
-							logrus.Warnf("Unexpected extra popedom data: (%d)", popedom.Length())
-						}
-						logrus.Infof("ID: %d", id)
-						logrus.Infof("Area: %d", area)
-						logrus.Infof("Card ID: %s", wire.CardID(area, id))
-						if personnelList != nil {
-							if person := personnelList.FindByCardID(wire.CardID(area, id)); person != nil {
-								logrus.Infof("   Person: %+v", *person)
-							}
+					logrus.Infof("ID: %d", id)
+					logrus.Infof("Area: %d", area)
+					logrus.Infof("Card ID: %s", wire.CardID(area, id))
+					if personnelList != nil {
+						if person := personnelList.FindByCardID(wire.CardID(area, id)); person != nil {
+							logrus.Infof("   Person: %+v", *person)
+							packet.Annotate("Person", person.Name)
 						}
-						logrus.Infof("Door: %d", door)
-						logrus.Infof("Open Date: %v", openDate)
-						logrus.Infof("Close Date: %v", closeDate)
-						logrus.Infof("Control index: %X", controlIndex) // 0 to not use control time; 1 to specify a time.
-						logrus.Infof("Password: %X", password)
-						logrus.Infof("Standby 1: %X", standby1) // 1 for the "first card users"; 0 for not those users.
-						logrus.Infof("Standby 2: %X", standby2) // 0 for the general user group; >0 for special group permissions.
-						logrus.Infof("Standby 3: %X", standby3)
-						logrus.Infof("Standby 4: %X", standby4)
-
-						return nil
-					}(popedom)
-					if err != nil {
-						logrus.Warnf("could not parse popedom [%d]: %v", p, err)
-						continue
 					}
-				}
-				if data.Length() != 0 {
-					logrus.Warnf("Unexpected trailing data length: (%d)", data.Length())
-				}
-			default:
-				remainder, err := data.Read(data.Length())
+					logrus.Infof("Door: %d", door)
+					logrus.Infof("Open Date: %v", openDate)
+					logrus.Infof("Close Date: %v", closeDate)
+					logrus.Infof("Control index: %X", controlIndex)  // 0 to not use control time; 1 to specify a time.
+					logrus.Infof("Password: %v", wire.PIN(password)) // This only shows whether there is one.
+					logrus.Infof("Standby 1: %X", standby1)          // 1 for the "first card users"; 0 for not those users.
+					logrus.Infof("Standby 2: %X", standby2)          // 0 for the general user group; >0 for special group permissions.
+					logrus.Infof("Standby 3: %X", standby3)
+					logrus.Infof("Standby 4: %X", standby4)
+
+					return nil
+				}(popedom)
 				if err != nil {
-					return fmt.Errorf("could not read data: %w", err)
+					logrus.Warnf("could not parse popedom [%d]: %v", p, err)
+					continue
 				}
-				logrus.Infof("Unknown10F9_%d: %x", unknown4, remainder.Bytes())
-			}
-		} else {
-			result, err := data.ReadUint8()
-			if err != nil {
-				return fmt.Errorf("could not read result: %w", err)
-			}
-			if !wire.IsAll(data.Bytes(), 0) {
-				logrus.Warnf("Unexpected remaining data; should be all zeros: %X", data.Bytes())
-			}
-			logrus.Infof("Result: %d", result)
-		}
-	case 0x10FF:
-		logrus.Infof("Function: Formatting")
-		// This will factory-reset the unit.
-		logrus.Warnf("TODO NOT IMPLEMENTED")
-	case wire.FunctionGetNetworkInfo:
-		logrus.Infof("Function: GetNetworkInfo")
-		if fromClient {
-			var request wire.GetNetworkInfoRequest
-			err = wire.Decode(data, &request)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Request: %+v", request)
-		} else {
-			var response wire.GetNetworkInfoResponse
-			err = wire.Decode(data, &response)
-			if err != nil {
-				return err
 			}
-			logrus.Infof("Response: %+v", response)
-		}
-	case wire.FunctionUpdatePermissions:
-		logrus.Infof("Function: UpdatePermissions")
-		if fromClient {
-			var request wire.UpdatePermissionsRequest
-			err = wire.Decode(data, &request)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Request: %+v", request)
-			logrus.Infof("Card ID: %s", wire.CardID(request.Area, request.CardID))
-			if personnelList != nil {
-				if person := personnelList.FindByCardID(wire.CardID(request.Area, request.CardID)); person != nil {
-					logrus.Infof("   Person: %+v", *person)
-				}
+			if data.Length() != 0 {
+				logrus.Warnf("Unexpected trailing data length: (%d)", data.Length())
 			}
-		} else {
-			var response wire.UpdatePermissionsResponse
-			err = wire.Decode(data, &response)
+		default:
+			remainder, err := data.Read(data.Length())
 			if err != nil {
-				return err
+				return fmt.Errorf("could not read data: %w", err)
 			}
-			logrus.Infof("Response: %+v", response)
+			logrus.Infof("Unknown10F9_%d: %x", unknown4, remainder.Bytes())
 		}
-	case wire.FunctionDeletePermissions:
-		logrus.Infof("Function: DeletePermissions")
-		if fromClient {
-			var request wire.DeletePermissionsRequest
-			err = wire.Decode(data, &request)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Request: %+v", request)
-			logrus.Infof("Card ID: %s", wire.CardID(request.Area, request.CardID))
-			if personnelList != nil {
-				if person := personnelList.FindByCardID(wire.CardID(request.Area, request.CardID)); person != nil {
-					logrus.Infof("   Person: %+v", *person)
-				}
-			}
-		} else {
-			var response wire.DeletePermissionsResponse
-			err = wire.Decode(data, &response)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Response: %+v", response)
+	} else {
+		result, err := data.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("could not read result: %w", err)
 		}
-	case wire.FunctionSetNetworkInfo:
-		logrus.Infof("Function: SetNetworkInfo")
-		if fromClient {
-			var request wire.SetNetworkInfoRequest
-			err = wire.Decode(data, &request)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Request: %+v", request)
-		} else {
-			var response wire.SetNetworkInfoResponse
-			err = wire.Decode(data, &response)
-			if err != nil {
-				return err
-			}
-			logrus.Infof("Response: %+v", response)
+		if !wire.IsAll(data.Bytes(), 0) {
+			logrus.Warnf("Unexpected remaining data; should be all zeros: %X", data.Bytes())
 		}
-
-	default:
-		logrus.Warnf("TODO UNHANDLED FUNCTION: %X", envelope.Function)
+		logrus.Infof("Result: %d", result)
 	}
 	return nil
 }
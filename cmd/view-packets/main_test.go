@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+func TestParseDataSecret(t *testing.T) {
+	writer := wire.NewWriter()
+	err := wire.Encode(writer, &wire.UpdatePermissionsRequest{CardID: 23439, Area: 178, Door: 1, Password: 123456, Standby: []byte{0, 0, 0, 0}})
+	require.Nil(t, err)
+	envelope := wire.Envelope{BoardAddress: 0xf010, Function: wire.FunctionUpdatePermissions, Contents: writer.Bytes()}
+	writer = wire.NewWriter()
+	err = wire.Encode(writer, &envelope)
+	require.Nil(t, err)
+
+	packet := &Packet{Raw: fmt.Sprintf("%X", writer.Bytes())}
+	err = parseData(wire.NewReader(writer.Bytes()), true, "10.0.0.1", nil, nil, packet)
+	require.Nil(t, err)
+	assert.Equal(t, "UpdatePermissions", packet.Function)
+	assert.Empty(t, packet.Raw)
+
+	contents, err := json.Marshal(packet)
+	require.Nil(t, err)
+	assert.NotContains(t, string(contents), "123456")
+	assert.NotContains(t, string(contents), "40E201") // This is the PIN as it is on the wire.
+}
+
+func TestPrintable(t *testing.T) {
+	assert.Equal(t, "0102", printable(wire.FunctionGetOperationStatus, []byte{1, 2}))
+	assert.Equal(t, "(3 bytes redacted)", printable(wire.FunctionGetUpload, []byte{1, 2, 3}))
+	assert.Equal(t, "(3 bytes redacted)", printable(wire.FunctionUnknown10F9, []byte{1, 2, 3}))
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"time"
+)
+
+const (
+	DirectionToController   = "to-controller"
+	DirectionFromController = "from-controller"
+)
+
+// Packet is the dissected form of a single captured packet.
+//
+// This is what is emitted for each packet with "--format json".
+type Packet struct {
+	Timestamp      time.Time         // This is the capture time.
+	Direction      string            // This is one of the Direction* constants.
+	Controller     string            // This is the address of the controller.
+	ControllerName string            `json:",omitempty"` // This is the name of the controller from the controller file.
+	BoardAddress   uint16            // This is the board address from the envelope.
+	FunctionCode   uint16            // This is the function code from the envelope.
+	Function       string            `json:",omitempty"` // This is the name of the function, if known.
+	Raw            string            `json:",omitempty"` // This is the full payload, in hexadecimal; it is left out for a secret function (see `wire.FunctionInfo.Secret`).
+	Fields         any               `json:",omitempty"` // This is the decoded request or response.
+	Annotations    map[string]string `json:",omitempty"` // These are extra details, such as the person or door name.
+	Errors         []string          `json:",omitempty"` // These are any errors encountered while decoding.
+}
+
+// Annotate adds an extra detail to the packet.
+func (p *Packet) Annotate(key string, value string) {
+	if p.Annotations == nil {
+		p.Annotations = map[string]string{}
+	}
+	p.Annotations[key] = value
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+// Exchange is a request from a capture along with the response to it (if any).
+type Exchange struct {
+	Controller string         // This is the address of the controller.
+	Request    wire.Envelope  // This is the request sent to the controller.
+	Response   *wire.Envelope // This is the response from the controller; nil if there was no response in the capture.
+}
+
+// pairExchanges pairs each request in the capture with its response.
+//
+// The response is the next unclaimed packet from the same controller with the
+// same board address and function code.
+func pairExchanges(capturedPackets []CapturedPacket) []Exchange {
+	type decodedPacket struct {
+		CapturedPacket
+		envelope wire.Envelope
+		claimed  bool
+	}
+	var packets []*decodedPacket
+	for _, capturedPacket := range capturedPackets {
+		packet := &decodedPacket{CapturedPacket: capturedPacket}
+		err := wire.Decode(wire.NewReader(capturedPacket.Data), &packet.envelope)
+		if err != nil {
+			continue
+		}
+		packets = append(packets, packet)
+	}
+
+	var result []Exchange
+	for i, packet := range packets {
+		if !packet.FromClient {
+			continue
+		}
+		exchange := Exchange{
+			Controller: packet.ControllerAddress,
+			Request:    packet.envelope,
+		}
+		for _, other := range packets[i+1:] {
+			if other.FromClient || other.claimed {
+				continue
+			}
+			if other.ControllerAddress != packet.ControllerAddress || other.envelope.BoardAddress != packet.envelope.BoardAddress || other.envelope.Function != packet.envelope.Function {
+				continue
+			}
+			other.claimed = true
+			response := other.envelope
+			exchange.Response = &response
+			break
+		}
+		result = append(result, exchange)
+	}
+	return result
+}
+
+// compareContents returns the offsets at which the two contents differ.
+//
+// Any bytes past the end of the shorter contents are considered different.
+func compareContents(expected []byte, actual []byte) []int {
+	var result []int
+	for i := 0; i < len(expected) || i < len(actual); i++ {
+		if i >= len(expected) || i >= len(actual) || expected[i] != actual[i] {
+			result = append(result, i)
+		}
+	}
+	return result
+}
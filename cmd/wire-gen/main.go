@@ -0,0 +1,783 @@
+// wire-gen generates static Encode and Decode methods for the request and
+// response structs in the "wire" package, based on their "wire" tags.
+//
+// The generated methods produce exactly the same bytes as the reflection-based
+// encoder, but without walking the struct tags at runtime.
+//
+// This is run via "go generate" in the "wire" directory.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+func main() {
+	var directory string
+	var output string
+	flag.StringVar(&directory, "directory", ".", "The directory of the package")
+	flag.StringVar(&output, "output", "encoding_generated.go", "The name of the file to generate (in the package directory)")
+	flag.Parse()
+
+	contents, err := generate(directory, output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	err = os.WriteFile(filepath.Join(directory, output), contents, 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// generator holds the parsed package.
+type generator struct {
+	packageName string
+	types       map[string]*ast.TypeSpec // These are all of the types in the package.
+	methods     map[string]map[string]bool
+	buffer      bytes.Buffer
+}
+
+// generate parses the package in the directory and returns the generated source.
+func generate(directory string, output string) ([]byte, error) {
+	fileSet := token.NewFileSet()
+	packages, err := parser.ParseDir(fileSet, directory, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go") && info.Name() != output
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(packages) != 1 {
+		return nil, fmt.Errorf("expected exactly one package; found %d", len(packages))
+	}
+
+	g := &generator{
+		types:   map[string]*ast.TypeSpec{},
+		methods: map[string]map[string]bool{},
+	}
+	var roots []string
+	for packageName, astPackage := range packages {
+		g.packageName = packageName
+		for filename, file := range astPackage.Files {
+			isFunctionFile := strings.HasPrefix(filepath.Base(filename), "functions_")
+			for _, declaration := range file.Decls {
+				switch declaration := declaration.(type) {
+				case *ast.GenDecl:
+					for _, spec := range declaration.Specs {
+						typeSpec, ok := spec.(*ast.TypeSpec)
+						if !ok {
+							continue
+						}
+						g.types[typeSpec.Name.Name] = typeSpec
+						name := typeSpec.Name.Name
+						if isFunctionFile && (strings.HasSuffix(name, "Request") || strings.HasSuffix(name, "Response")) {
+							roots = append(roots, name)
+						}
+					}
+				case *ast.FuncDecl:
+					if declaration.Recv == nil || len(declaration.Recv.List) == 0 {
+						continue
+					}
+					receiver := declaration.Recv.List[0].Type
+					if star, ok := receiver.(*ast.StarExpr); ok {
+						receiver = star.X
+					}
+					if ident, ok := receiver.(*ast.Ident); ok {
+						if g.methods[ident.Name] == nil {
+							g.methods[ident.Name] = map[string]bool{}
+						}
+						g.methods[ident.Name][declaration.Name.Name] = true
+					}
+				}
+			}
+		}
+	}
+
+	// Find every struct that the roots use.
+	names := map[string]bool{}
+	var visit func(name string) error
+	visit = func(name string) error {
+		if names[name] {
+			return nil
+		}
+		if g.methods[name]["Encode"] || g.methods[name]["Decode"] {
+			// This already has a hand-written implementation.
+			return nil
+		}
+		structType, err := g.structType(name)
+		if err != nil {
+			return err
+		}
+		names[name] = true
+		for _, field := range structType.Fields.List {
+			if local := g.localStructName(field.Type); local != "" {
+				err := visit(local)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	for _, name := range roots {
+		err := visit(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var sortedNames []string
+	for name := range names {
+		sortedNames = append(sortedNames, name)
+	}
+	sort.Strings(sortedNames)
+
+	g.printf("// Code generated by wire-gen; DO NOT EDIT.\n\n")
+	g.printf("package %s\n\n", g.packageName)
+	g.printf("import (\n\"fmt\"\n)\n\n")
+	for _, name := range sortedNames {
+		err := g.generateType(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	result, err := format.Source(g.buffer.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("could not format source: %w\n%s", err, g.buffer.String())
+	}
+	return result, nil
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buffer, format, args...)
+}
+
+// structType returns the struct for the type, following any type definitions
+// such as "type A B".
+func (g *generator) structType(name string) (*ast.StructType, error) {
+	typeSpec, ok := g.types[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown type: %s", name)
+	}
+	switch t := typeSpec.Type.(type) {
+	case *ast.StructType:
+		return t, nil
+	case *ast.Ident:
+		return g.structType(t.Name)
+	}
+	return nil, fmt.Errorf("type %s is not a struct", name)
+}
+
+// localStructName returns the name of the struct in this package that the
+// expression refers to (either directly or through a pointer).
+func (g *generator) localStructName(expression ast.Expr) string {
+	if star, ok := expression.(*ast.StarExpr); ok {
+		expression = star.X
+	}
+	ident, ok := expression.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	if _, err := g.structType(ident.Name); err != nil {
+		return ""
+	}
+	return ident.Name
+}
+
+// field is a single field of a struct.
+type field struct {
+	Name    string
+	Kind    string // This is one of the field* constants.
+	Struct  string // This is the name of the struct for fieldStruct and fieldStructPointer.
+	Named   string // This is the name of the named type for an integer or bool field (such as "type A uint8"), if any.
+	Length  int    // This is the array length for fieldArray.
+	Options wire.TagOptions
+}
+
+const (
+	fieldUint8         = "uint8"
+	fieldUint16        = "uint16"
+	fieldUint32        = "uint32"
+	fieldBool          = "bool"
+	fieldTime          = "time"
+	fieldTimePointer   = "*time"
+	fieldStruct        = "struct"
+	fieldStructPointer = "*struct"
+	fieldBytes         = "bytes"
+	fieldIP            = "ip"
+	fieldArray         = "array"
+)
+
+// fields returns the fields of the struct.
+func (g *generator) fields(name string) ([]field, error) {
+	structType, err := g.structType(name)
+	if err != nil {
+		return nil, err
+	}
+	var result []field
+	for _, astField := range structType.Fields.List {
+		var tag string
+		if astField.Tag != nil {
+			unquoted, err := strconv.Unquote(astField.Tag.Value)
+			if err != nil {
+				return nil, err
+			}
+			tag = reflect.StructTag(unquoted).Get("wire")
+		}
+		if tag == "-" {
+			continue
+		}
+		options, err := wire.ParseTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if len(astField.Names) == 0 {
+			return nil, fmt.Errorf("embedded fields are not supported")
+		}
+
+		kind, typeName, length, err := g.fieldKind(astField.Type)
+		if err != nil {
+			return nil, err
+		}
+		for _, fieldName := range astField.Names {
+			f := field{
+				Name:    fieldName.Name,
+				Kind:    kind,
+				Length:  length,
+				Options: options,
+			}
+			if kind == fieldStruct || kind == fieldStructPointer {
+				f.Struct = typeName
+			} else {
+				f.Named = typeName
+			}
+			f.Options.Name = fieldName.Name
+			err := f.validate()
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", f.Name, err)
+			}
+			result = append(result, f)
+		}
+	}
+	return result, nil
+}
+
+// fieldKind returns the field* constant for the type expression.
+//
+// The type name is the name of the struct for a struct, or the name of the
+// named type for an integer or bool.
+func (g *generator) fieldKind(expression ast.Expr) (kind string, typeName string, length int, err error) {
+	switch t := expression.(type) {
+	case *ast.Ident:
+		switch t.Name {
+		case "uint8", "byte":
+			return fieldUint8, "", 0, nil
+		case "uint16":
+			return fieldUint16, "", 0, nil
+		case "uint32":
+			return fieldUint32, "", 0, nil
+		case "bool":
+			return fieldBool, "", 0, nil
+		}
+		if local := g.localStructName(t); local != "" {
+			return fieldStruct, local, 0, nil
+		}
+		if typeSpec, ok := g.types[t.Name]; ok {
+			kind, _, _, err := g.fieldKind(typeSpec.Type)
+			if err == nil && (kind == fieldUint8 || kind == fieldUint16 || kind == fieldUint32 || kind == fieldBool) {
+				return kind, t.Name, 0, nil
+			}
+		}
+	case *ast.SelectorExpr:
+		switch typeString(t) {
+		case "time.Time":
+			return fieldTime, "", 0, nil
+		case "net.IP":
+			return fieldIP, "", 0, nil
+		case "net.HardwareAddr":
+			return fieldBytes, "", 0, nil
+		}
+	case *ast.StarExpr:
+		if typeString(t.X) == "time.Time" {
+			return fieldTimePointer, "", 0, nil
+		}
+		if local := g.localStructName(t.X); local != "" {
+			return fieldStructPointer, local, 0, nil
+		}
+	case *ast.ArrayType:
+		if elementType := typeString(t.Elt); elementType != "byte" && elementType != "uint8" {
+			break
+		}
+		if t.Len == nil {
+			return fieldBytes, "", 0, nil
+		}
+		literal, ok := t.Len.(*ast.BasicLit)
+		if !ok || literal.Kind != token.INT {
+			break
+		}
+		length, err := strconv.Atoi(literal.Value)
+		if err != nil {
+			return "", "", 0, err
+		}
+		return fieldArray, "", length, nil
+	}
+	return "", "", 0, fmt.Errorf("unsupported type: %s", typeString(expression))
+}
+
+// typeString returns the source form of a (simple) type expression.
+func typeString(expression ast.Expr) string {
+	switch t := expression.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return typeString(t.X) + "." + t.Sel.Name
+	case *ast.StarExpr:
+		return "*" + typeString(t.X)
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "[]" + typeString(t.Elt)
+		}
+		if literal, ok := t.Len.(*ast.BasicLit); ok {
+			return "[" + literal.Value + "]" + typeString(t.Elt)
+		}
+	}
+	return fmt.Sprintf("%T", expression)
+}
+
+// validate returns an error if the options do not make sense for the field.
+func (f field) validate() error {
+	err := f.Options.ValidateKind(f.reflectKind())
+	if err != nil {
+		return err
+	}
+	if f.Name == "_" && f.Kind != fieldArray && !f.Options.Bitfield {
+		return fmt.Errorf("an unnamed field must be an array or a bitfield")
+	}
+	switch f.Kind {
+	case fieldBool:
+		if !f.Options.Bitfield {
+			return fmt.Errorf("a bool must be a bitfield")
+		}
+	case fieldUint8, fieldUint16:
+		if f.Options.Type != "" {
+			return fmt.Errorf("invalid type for %s: %q", f.Kind, f.Options.Type)
+		}
+	case fieldUint32:
+		if f.Options.Type != "" && f.Options.Type != wire.TypeUint24 {
+			return fmt.Errorf("invalid type for %s: %q", f.Kind, f.Options.Type)
+		}
+	case fieldTime, fieldTimePointer:
+		if f.Options.Type == wire.TypeUint24 {
+			return fmt.Errorf("invalid type for %s: %q", f.Kind, f.Options.Type)
+		}
+	}
+	switch f.Kind {
+	case fieldTimePointer, fieldStructPointer:
+		if f.length() <= 0 {
+			return fmt.Errorf("a pointer needs a length")
+		}
+	case fieldArray:
+		if f.Options.Length > 0 {
+			return fmt.Errorf("an array cannot have a length option")
+		}
+		if f.Options.Length < 0 && f.Length != 0 {
+			return fmt.Errorf("only an empty array may read the remainder")
+		}
+	}
+	return nil
+}
+
+// reflectKind returns the reflect.Kind of the field (as used by the reflection-based encoder).
+func (f field) reflectKind() reflect.Kind {
+	switch f.Kind {
+	case fieldUint8:
+		return reflect.Uint8
+	case fieldUint16:
+		return reflect.Uint16
+	case fieldUint32:
+		return reflect.Uint32
+	case fieldBool:
+		return reflect.Bool
+	case fieldTimePointer, fieldStructPointer:
+		return reflect.Pointer
+	case fieldBytes, fieldIP:
+		return reflect.Slice
+	case fieldArray:
+		return reflect.Array
+	}
+	return reflect.Struct
+}
+
+// basicType returns the Go type that the Reader and Writer use for an integer field.
+func (f field) basicType() string {
+	if f.Kind == fieldUint32 {
+		return "uint32"
+	}
+	return f.Kind
+}
+
+// convert wraps the expression in a conversion to the named type, if there is one.
+func (f field) convert(expression string) string {
+	if f.Named == "" {
+		return expression
+	}
+	return f.Named + "(" + expression + ")"
+}
+
+// hasValueOptions returns true if the integer field has options that change its value.
+func (f field) hasValueOptions() bool {
+	return f.Options.Enum != nil || f.Options.BCD
+}
+
+// enumLiteral returns the Go source for the allowed values of the enum.
+func (f field) enumLiteral() string {
+	if f.Options.Enum == nil {
+		return "nil"
+	}
+	var values []string
+	for _, value := range f.Options.Enum {
+		values = append(values, strconv.FormatUint(value, 10))
+	}
+	return "[]uint64{" + strings.Join(values, ", ") + "}"
+}
+
+// bitfieldOptions returns the Go source for the TagOptions that encodeBitfield and decodeBitfield need.
+func (f field) bitfieldOptions() string {
+	result := fmt.Sprintf("TagOptions{BitStart: %d, BitEnd: %d", f.Options.BitStart, f.Options.BitEnd)
+	if f.Options.Enum != nil {
+		result += ", Enum: " + f.enumLiteral()
+	}
+	return result + "}"
+}
+
+// length returns the number of bytes for a pointer field.
+func (f field) length() int {
+	if f.Options.Length != 0 {
+		return f.Options.Length
+	}
+	return f.Options.TypeLength()
+}
+
+// timeType returns the name of the Type* constant for the field.
+func (f field) timeType() string {
+	switch f.Options.Type {
+	case wire.TypeDate:
+		return "TypeDate"
+	case wire.TypeTime:
+		return "TypeTime"
+	case wire.TypeHexDate:
+		return "TypeHexDate"
+	case wire.TypeHexTime:
+		return "TypeHexTime"
+	case wire.TypeHexDateTime:
+		return "TypeHexDateTime"
+	}
+	return "TypeDateTime"
+}
+
+// generateType writes the Encode and Decode methods for the type.
+func (g *generator) generateType(name string) error {
+	fields, err := g.fields(name)
+	if err != nil {
+		return err
+	}
+
+	groups := bitfieldGroups(fields)
+
+	g.printf("// Encode encodes the %s.\n", name)
+	g.printf("func (v *%s) Encode(writer *Writer) error {\n", name)
+	for _, group := range groups {
+		if group[0].Options.Bitfield {
+			g.generateEncodeBitfields(group)
+			continue
+		}
+		g.generateEncodeField(group[0])
+	}
+	g.printf("return nil\n}\n\n")
+
+	g.printf("// Decode decodes the %s.\n", name)
+	g.printf("func (v *%s) Decode(reader *Reader) error {\n", name)
+	if len(fields) > 0 {
+		g.printf("var err error\n")
+	}
+	for _, group := range groups {
+		if group[0].Options.Bitfield {
+			g.generateDecodeBitfields(group)
+			continue
+		}
+		g.generateDecodeField(group[0])
+	}
+	g.printf("return nil\n}\n\n")
+	return nil
+}
+
+// bitfieldGroups splits the fields into groups that are each written as a unit.
+//
+// Consecutive bitfields share a byte until one of them needs a bit that is
+// already in use; every other field is in a group by itself.
+func bitfieldGroups(fields []field) [][]field {
+	var groups [][]field
+	var bitsUsed uint8
+	for _, f := range fields {
+		if f.Options.Bitfield && len(groups) > 0 {
+			last := groups[len(groups)-1]
+			if last[0].Options.Bitfield && bitsUsed&f.Options.BitMask() == 0 {
+				groups[len(groups)-1] = append(last, f)
+				bitsUsed |= f.Options.BitMask()
+				continue
+			}
+		}
+		groups = append(groups, []field{f})
+		bitsUsed = f.Options.BitMask()
+	}
+	return groups
+}
+
+// generateEncodeBitfields writes the shared byte for a group of bitfields.
+func (g *generator) generateEncodeBitfields(group []field) {
+	g.printf("{\nvar bits uint8\n")
+	for _, f := range group {
+		if f.Name == "_" {
+			// Unnamed bits are always written as zeroes.
+			continue
+		}
+		value := "uint64(v." + f.Name + ")"
+		if f.Kind == fieldBool {
+			value = "boolValue(v." + f.Name + ")"
+		}
+		g.printf("{\nvalue, err := encodeBitfield(%s, %s)\n", value, f.bitfieldOptions())
+		g.returnError(f, "")
+		g.printf("bits |= value\n}\n")
+	}
+	g.printf("writer.WriteUint8(bits)\n}\n")
+}
+
+// generateDecodeBitfields reads the shared byte for a group of bitfields.
+func (g *generator) generateDecodeBitfields(group []field) {
+	g.printf("{\nvar bits uint8\nbits, err = reader.ReadUint8()\n")
+	g.returnError(group[0], "")
+	for _, f := range group {
+		g.printf("{\nvalue, err := decodeBitfield(bits, %s)\n", f.bitfieldOptions())
+		g.returnError(f, "")
+		switch {
+		case f.Name == "_":
+			g.printf("_ = value\n")
+		case f.Kind == fieldBool:
+			g.printf("v.%s = %s\n", f.Name, f.convert("value != 0"))
+		default:
+			g.printf("v.%s = %s\n", f.Name, f.convert("value"))
+		}
+		g.printf("}\n")
+	}
+	g.printf("}\n")
+}
+
+// returnError writes the error check for the field.
+func (g *generator) returnError(f field, message string) {
+	g.printf("if err != nil {\nreturn fmt.Errorf(%q, err)\n}\n", "field "+f.Name+": "+message+"%w")
+}
+
+func (g *generator) generateEncodeField(f field) {
+	g.generateEncodeValue(f)
+	if f.Options.Pad > 0 {
+		g.printf("writer.WriteBytes(make([]byte, %d))\n", f.Options.Pad)
+	}
+}
+
+func (g *generator) generateEncodeValue(f field) {
+	value := "v." + f.Name
+	if f.Name == "_" {
+		// Unnamed fields are always written as zeroes.
+		if f.Kind == fieldArray && f.Length > 0 {
+			g.printf("writer.WriteBytes(make([]byte, %d))\n", f.Length)
+		}
+		return
+	}
+
+	writeNull := func() {
+		if f.Options.Null != nil {
+			g.printf("for i := 0; i < %d; i++ {\nwriter.WriteUint8(0x%02x)\n}\n", f.length(), *f.Options.Null)
+		}
+	}
+
+	// writeUint writes the integer (after applying the options).
+	writeUint := func(method string) {
+		if !f.hasValueOptions() {
+			if f.Named != "" {
+				value = f.basicType() + "(" + value + ")"
+			}
+			g.printf("writer.%s(%s)\n", method, value)
+			return
+		}
+		if f.Named != "" {
+			value = f.basicType() + "(" + value + ")"
+		}
+		g.printf("{\nvalue := %s\n", value)
+		if f.Options.Enum != nil || f.Options.BCD {
+			g.printf("var err error\n")
+		}
+		if f.Options.Enum != nil {
+			g.printf("err = checkEnum(uint64(value), %s)\n", f.enumLiteral())
+			g.returnError(f, "")
+		}
+		if f.Options.BCD {
+			g.printf("value, err = encodeBCD(value)\n")
+			g.returnError(f, "")
+		}
+		g.printf("writer.%s(value)\n}\n", method)
+	}
+
+	switch f.Kind {
+	case fieldUint8:
+		writeUint("WriteUint8")
+	case fieldUint16:
+		writeUint("WriteUint16")
+	case fieldUint32:
+		if f.Options.Type == wire.TypeUint24 {
+			writeUint("WriteUint24")
+		} else {
+			writeUint("WriteUint32")
+		}
+	case fieldTime:
+		g.printf("{\nerr := encodeTime(writer, %s, %s)\n", value, f.timeType())
+		g.returnError(f, "")
+		g.printf("}\n")
+	case fieldTimePointer:
+		g.printf("if %s == nil {\n", value)
+		writeNull()
+		g.printf("} else {\nerr := encodeTime(writer, *%s, %s)\n", value, f.timeType())
+		g.returnError(f, "")
+		g.printf("}\n")
+	case fieldStruct:
+		g.printf("{\nerr := %s.Encode(writer)\n", value)
+		g.returnError(f, "")
+		g.printf("}\n")
+	case fieldStructPointer:
+		g.printf("if %s == nil {\n", value)
+		writeNull()
+		g.printf("} else {\nerr := %s.Encode(writer)\n", value)
+		g.returnError(f, "")
+		g.printf("}\n")
+	case fieldBytes:
+		g.printf("{\nerr := encodeFixedBytes(writer, %s, %d)\n", value, f.Options.Length)
+		g.returnError(f, "")
+		g.printf("}\n")
+	case fieldIP:
+		g.printf("if %s.To4() == nil {\nreturn fmt.Errorf(%q)\n}\n", value, "field "+f.Name+": could not convert address to IPv4")
+		g.printf("{\nerr := encodeFixedBytes(writer, %s.To4(), %d)\n", value, f.Options.Length)
+		g.returnError(f, "")
+		g.printf("}\n")
+	case fieldArray:
+		if f.Length > 0 {
+			g.printf("writer.WriteBytes(%s[:])\n", value)
+		}
+	}
+}
+
+func (g *generator) generateDecodeField(f field) {
+	g.generateDecodeValue(f)
+	if f.Options.Pad > 0 {
+		g.printf("_, err = reader.ReadBytes(%d)\n", f.Options.Pad)
+		g.returnError(f, "could not read padding: ")
+	}
+}
+
+func (g *generator) generateDecodeValue(f field) {
+	value := "v." + f.Name
+
+	if f.Name == "_" {
+		if f.Options.Length < 0 {
+			g.printf("err = decodeEmptyRemainder(reader)\n")
+			g.returnError(f, "")
+		} else if f.Length > 0 {
+			g.printf("_, err = reader.ReadBytes(%d)\n", f.Length)
+			g.returnError(f, "could not read remainder: ")
+		}
+		return
+	}
+
+	// decodePointer reads the sub-structure for a pointer and leaves it nil if it is null.
+	decodePointer := func(decode func()) {
+		g.printf("{\nsubReader, err := reader.Read(%d)\n", f.length())
+		g.returnError(f, "could not read sub structure: ")
+		g.printf("%s = nil\n", value)
+		if f.Options.Null != nil {
+			g.printf("if !IsAll(subReader.Bytes(), 0x%02x) {\n", *f.Options.Null)
+		} else {
+			g.printf("{\n")
+		}
+		decode()
+		g.returnError(f, "could not decode sub structure: ")
+		g.printf("%s = &value\n}\n}\n", value)
+	}
+
+	// readUint reads the integer (and applies the options).
+	readUint := func(method string) {
+		if !f.hasValueOptions() && f.Named == "" {
+			g.printf("%s, err = reader.%s()\n", value, method)
+			g.returnError(f, "")
+			return
+		}
+		g.printf("{\nvalue, err := reader.%s()\n", method)
+		g.returnError(f, "")
+		if f.Options.BCD {
+			g.printf("value, err = decodeBCD(value)\n")
+			g.returnError(f, "")
+		}
+		if f.Options.Enum != nil {
+			g.printf("err = checkEnum(uint64(value), %s)\n", f.enumLiteral())
+			g.returnError(f, "")
+		}
+		g.printf("%s = %s\n}\n", value, f.convert("value"))
+	}
+
+	switch f.Kind {
+	case fieldUint8:
+		readUint("ReadUint8")
+	case fieldUint16:
+		readUint("ReadUint16")
+	case fieldUint32:
+		if f.Options.Type == wire.TypeUint24 {
+			readUint("ReadUint24")
+		} else {
+			readUint("ReadUint32")
+		}
+	case fieldTime:
+		g.printf("%s, err = decodeTime(reader, %s)\n", value, f.timeType())
+		g.returnError(f, "")
+	case fieldTimePointer:
+		decodePointer(func() {
+			g.printf("value, err := decodeTime(subReader, %s)\n", f.timeType())
+		})
+	case fieldStruct:
+		g.printf("err = %s.Decode(reader)\n", value)
+		g.returnError(f, "")
+	case fieldStructPointer:
+		decodePointer(func() {
+			g.printf("var value %s\nerr := value.Decode(subReader)\n", f.Struct)
+		})
+	case fieldBytes, fieldIP:
+		g.printf("%s, err = decodeFixedBytes(reader, %d)\n", value, f.Options.Length)
+		g.returnError(f, "")
+	case fieldArray:
+		if f.Length > 0 {
+			g.printf("{\ncontents, err := reader.ReadBytes(%d)\n", f.Length)
+			g.returnError(f, "could not read remainder: ")
+			g.printf("copy(%s[:], contents)\n}\n", value)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package cobrafile
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+// LoadBasicConfig loads a "basic" block written by `SaveBasicConfig`.
+func LoadBasicConfig(filename string) (*wire.BasicConfigRequest, error) {
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	data, err := hex.DecodeString(string(bytes.TrimSpace(contents)))
+	if err != nil {
+		return nil, fmt.Errorf("could not decode hexadecimal: %w", err)
+	}
+	var request wire.BasicConfigRequest
+	err = wire.Decode(wire.NewReader(data), &request)
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// SaveBasicConfig writes the "basic" block (the full 0x10F9 request) in
+// hexadecimal.
+//
+// The block includes the super passwords, so the file is only readable by its
+// owner.
+func SaveBasicConfig(filename string, request wire.BasicConfigRequest) error {
+	writer := wire.NewWriter()
+	err := wire.Encode(writer, &request)
+	if err != nil {
+		return err
+	}
+	// An existing file keeps its mode when it is written, so restrict it first.
+	err = os.Chmod(filename, 0600)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return os.WriteFile(filename, []byte(hex.EncodeToString(writer.Bytes())+"\n"), 0600)
+}
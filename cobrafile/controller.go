@@ -7,16 +7,63 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type ControllerList []Controller
 
+// Direction is the direction that a door is used in.
+type Direction string
+
+const (
+	DirectionNone Direction = ""    // The door is used in both directions (or it is not known).
+	DirectionIn   Direction = "in"  // The door is used to enter the site.
+	DirectionOut  Direction = "out" // The door is used to leave the site.
+)
+
+// ParseDirection parses a door direction.
+//
+// "in" may also be written as "entry" or "enter", and "out" may also be written
+// as "exit".
+func ParseDirection(value string) (Direction, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "":
+		return DirectionNone, nil
+	case "in", "entry", "enter":
+		return DirectionIn, nil
+	case "out", "exit":
+		return DirectionOut, nil
+	}
+	return DirectionNone, fmt.Errorf("invalid direction: %q", value)
+}
+
 type Controller struct {
 	Name    string
 	Address string
 	Port    uint16
 	SN      uint16
 	Doors   []string
+	// Directions are the directions of the doors (the same length as "Doors").
+	Directions []Direction
+	// TimeZone is the time zone that the controller's clock is kept in; if
+	// this is nil, then the local time zone is assumed.
+	TimeZone *time.Location
+}
+
+// Direction returns the direction of the 1-index door.
+func (c Controller) Direction(door uint8) Direction {
+	if door > 0 && int(door) <= len(c.Directions) {
+		return c.Directions[door-1]
+	}
+	return DirectionNone
+}
+
+// Location returns the time zone that the controller's clock is kept in.
+func (c Controller) Location() *time.Location {
+	if c.TimeZone == nil {
+		return time.Local
+	}
+	return c.TimeZone
 }
 
 func LoadController(filename string) (ControllerList, error) {
@@ -46,7 +93,8 @@ func LoadController(filename string) (ControllerList, error) {
 	result := make([]Controller, 0, len(rows))
 	for r, row := range rows {
 		p := Controller{
-			Doors: make([]string, 4),
+			Doors:      make([]string, 4),
+			Directions: make([]Direction, 4),
 		}
 
 		for c, value := range row {
@@ -75,6 +123,21 @@ func LoadController(filename string) (ControllerList, error) {
 				p.Doors[2] = value
 			case "door 4":
 				p.Doors[3] = value
+			case "door 1 direction", "door 2 direction", "door 3 direction", "door 4 direction":
+				direction, err := ParseDirection(value)
+				if err != nil {
+					return nil, fmt.Errorf("row %d: could not parse %s: %w", r, headerRow[c], err)
+				}
+				p.Directions[headerRow[c][5]-'1'] = direction
+			case "time zone", "timezone":
+				if value == "" {
+					continue
+				}
+				location, err := time.LoadLocation(value)
+				if err != nil {
+					return nil, fmt.Errorf("row %d: could not parse time zone: %w", r, err)
+				}
+				p.TimeZone = location
 			}
 		}
 
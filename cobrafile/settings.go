@@ -0,0 +1,81 @@
+package cobrafile
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+// SettingsDump is the contents of a controller's setting registers, indexed by address.
+type SettingsDump []uint8
+
+// LoadSettings loads a settings dump written by `SaveSettings`.
+func LoadSettings(filename string) (SettingsDump, error) {
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	reader := csv.NewReader(bytes.NewReader(contents))
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("missing header row")
+	}
+	headerRow := rows[0]
+	rows = rows[1:]
+
+	for c, value := range headerRow {
+		headerRow[c] = strings.ToLower(value)
+	}
+
+	result := make(SettingsDump, 256)
+	for r, row := range rows {
+		var address, value uint8
+		for c, column := range row {
+			switch headerRow[c] {
+			case "address":
+				v, err := strconv.ParseUint(column, 0 /*auto-detect base*/, 8)
+				if err != nil {
+					return nil, fmt.Errorf("row %d: could not parse address: %w", r, err)
+				}
+				address = uint8(v)
+			case "value":
+				v, err := strconv.ParseUint(column, 0 /*auto-detect base*/, 8)
+				if err != nil {
+					return nil, fmt.Errorf("row %d: could not parse value: %w", r, err)
+				}
+				value = uint8(v)
+			}
+		}
+		result[address] = value
+	}
+	return result, nil
+}
+
+// SaveSettings writes a settings dump as a CSV file.
+//
+// The name column is informational only.
+func SaveSettings(filename string, settings SettingsDump) error {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	writer.Write([]string{"Address", "Value", "Name"})
+	for address, value := range settings {
+		var name string
+		if setting := wire.LookupSettingByAddress(uint8(address)); setting != nil {
+			name = setting.Name
+		}
+		writer.Write([]string{fmt.Sprintf("0x%02x", address), fmt.Sprintf("%d", value), name})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, buffer.Bytes(), 0644)
+}
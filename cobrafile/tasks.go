@@ -0,0 +1,152 @@
+package cobrafile
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+// TaskList is the record of the timing tasks that were written to the
+// controllers.
+//
+// The controllers have no known way to read a timing task back, so this is the
+// only place to find out which slots are in use.
+type TaskList []Task
+
+// Task is a timing task that was written to a controller.
+type Task struct {
+	Controller string // This is the address of the controller.
+	wire.RealizeTimingTaskRequest
+}
+
+// Set records the task for the controller, replacing any task already in the
+// same slot.
+func (l *TaskList) Set(controller string, request wire.RealizeTimingTaskRequest) {
+	for t := range *l {
+		if (*l)[t].Controller == controller && (*l)[t].TaskIndex == request.TaskIndex {
+			(*l)[t].RealizeTimingTaskRequest = request
+			return
+		}
+	}
+	*l = append(*l, Task{Controller: controller, RealizeTimingTaskRequest: request})
+	sort.SliceStable(*l, func(i, j int) bool {
+		if (*l)[i].Controller != (*l)[j].Controller {
+			return (*l)[i].Controller < (*l)[j].Controller
+		}
+		return (*l)[i].TaskIndex < (*l)[j].TaskIndex
+	})
+}
+
+// LoadTasks loads a task list written by `SaveTasks`.
+//
+// If the file does not exist, then the list is empty.
+func LoadTasks(filename string) (TaskList, error) {
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	reader := csv.NewReader(bytes.NewReader(contents))
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("missing header row")
+	}
+	headerRow := rows[0]
+	rows = rows[1:]
+
+	for c, value := range headerRow {
+		headerRow[c] = strings.ToLower(value)
+	}
+
+	var result TaskList
+	for r, row := range rows {
+		var task Task
+		for c, column := range row {
+			switch headerRow[c] {
+			case "controller":
+				task.Controller = column
+			case "index":
+				v, err := strconv.ParseUint(column, 10, 16)
+				if err != nil {
+					return nil, fmt.Errorf("row %d: could not parse index: %w", r, err)
+				}
+				task.TaskIndex = uint16(v)
+			case "week control":
+				v, err := strconv.ParseUint(column, 0 /*auto-detect base*/, 8)
+				if err != nil {
+					return nil, fmt.Errorf("row %d: could not parse week control: %w", r, err)
+				}
+				task.WeekControl = uint8(v)
+			case "door":
+				v, err := strconv.ParseUint(column, 10, 8)
+				if err != nil {
+					return nil, fmt.Errorf("row %d: could not parse door: %w", r, err)
+				}
+				task.Door = uint8(v)
+			case "control":
+				v, err := strconv.ParseUint(column, 10, 8)
+				if err != nil {
+					return nil, fmt.Errorf("row %d: could not parse control: %w", r, err)
+				}
+				task.Control = uint8(v)
+			case "time":
+				v, err := time.Parse("15:04", column)
+				if err != nil {
+					return nil, fmt.Errorf("row %d: could not parse time: %w", r, err)
+				}
+				task.StartTime = v
+			case "start date":
+				v, err := time.Parse(time.DateOnly, column)
+				if err != nil {
+					return nil, fmt.Errorf("row %d: could not parse start date: %w", r, err)
+				}
+				task.StartDate = v
+			case "end date":
+				v, err := time.Parse(time.DateOnly, column)
+				if err != nil {
+					return nil, fmt.Errorf("row %d: could not parse end date: %w", r, err)
+				}
+				task.EndDate = v
+			}
+		}
+		result = append(result, task)
+	}
+	return result, nil
+}
+
+// SaveTasks writes a task list as a CSV file.
+func SaveTasks(filename string, tasks TaskList) error {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	writer.Write([]string{"Controller", "Index", "Week Control", "Door", "Control", "Time", "Start Date", "End Date"})
+	for _, task := range tasks {
+		writer.Write([]string{
+			task.Controller,
+			fmt.Sprintf("%d", task.TaskIndex),
+			fmt.Sprintf("0x%02x", task.WeekControl),
+			fmt.Sprintf("%d", task.Door),
+			fmt.Sprintf("%d", task.Control),
+			task.StartTime.Format("15:04"),
+			task.StartDate.Format(time.DateOnly),
+			task.EndDate.Format(time.DateOnly),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, buffer.Bytes(), 0644)
+}
@@ -0,0 +1,314 @@
+// Package exporter exposes the state of the controllers as Prometheus metrics.
+//
+// Every controller is polled in the background; a scrape only reads the
+// values from the last poll, so a slow or unreachable controller never makes a
+// scrape time out.
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+	"github.com/tekkamanendless/cobra-controls/cobrafile"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+// Namespace is the prefix of every metric.
+const Namespace = "cobra"
+
+// MaxRecordsPerPoll is the most access records that are read from a controller
+// in a single poll; anything older is skipped.
+const MaxRecordsPerPoll = 1000
+
+// These are the values of the "type" label of the error counter.
+const (
+	ErrorTypeTimeout    = "timeout"    // The controller did not respond in time.
+	ErrorTypeConnection = "connection" // The connection could not be made or was lost.
+	ErrorTypeOther      = "other"      // Anything else (such as a response that could not be decoded).
+)
+
+// Exporter polls the controllers and serves their metrics.
+type Exporter struct {
+	registry *prometheus.Registry
+	targets  []*target
+
+	up              *prometheus.GaugeVec
+	requestDuration *prometheus.HistogramVec
+	requestErrors   *prometheus.CounterVec
+	records         *prometheus.GaugeVec
+	permissions     *prometheus.GaugeVec
+	drift           *prometheus.GaugeVec
+	fault           *prometheus.GaugeVec
+	doorOpen        *prometheus.GaugeVec
+	relayEnergized  *prometheus.GaugeVec
+	access          *prometheus.CounterVec
+	lastPoll        *prometheus.GaugeVec
+}
+
+// target is a single controller.
+type target struct {
+	name       string
+	client     *wire.Client
+	doors      [4]string // This is the "door" label for each door.
+	mutex      sync.Mutex
+	nextRecord uint32 // This is the next record index to read; 0 means that nothing has been read yet.
+}
+
+// New returns an exporter for the clients.
+//
+// The controller list is used to name the controllers and their doors.
+func New(clients []*wire.Client, controllerList cobrafile.ControllerList) *Exporter {
+	controllerLabels := []string{"controller"}
+	doorLabels := []string{"controller", "door", "door_name"}
+
+	e := &Exporter{
+		registry: prometheus.NewRegistry(),
+		up: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "controller_up",
+			Help:      "Whether the last poll of the controller succeeded.",
+		}, controllerLabels),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "request_duration_seconds",
+			Help:      "How long each request to the controller took.",
+			Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+		}, []string{"controller", "function"}),
+		requestErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "request_errors_total",
+			Help:      "The number of requests to the controller that failed, by type.",
+		}, []string{"controller", "type"}),
+		records: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "records",
+			Help:      "The number of access records stored on the controller.",
+		}, controllerLabels),
+		permissions: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "permissions",
+			Help:      "The number of permissions stored on the controller.",
+		}, controllerLabels),
+		drift: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "time_drift_seconds",
+			Help:      "How far the controller's clock is ahead of the local wall clock (negative if it is behind).",
+		}, controllerLabels),
+		fault: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "fault",
+			Help:      "The fault number reported by the controller (0 means no fault).",
+		}, controllerLabels),
+		doorOpen: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "door_open",
+			Help:      "Whether the door sensor reports that the door is open.",
+		}, doorLabels),
+		relayEnergized: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "door_relay_energized",
+			Help:      "Whether the door's relay is energized.",
+		}, doorLabels),
+		access: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "access_total",
+			Help:      "The number of card swipes seen since the exporter started, by result (\"granted\" or \"denied\").",
+		}, []string{"controller", "door", "door_name", "result"}),
+		lastPoll: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "last_poll_timestamp_seconds",
+			Help:      "When the controller was last polled.",
+		}, controllerLabels),
+	}
+	e.registry.MustRegister(
+		e.up,
+		e.requestDuration,
+		e.requestErrors,
+		e.records,
+		e.permissions,
+		e.drift,
+		e.fault,
+		e.doorOpen,
+		e.relayEnergized,
+		e.access,
+		e.lastPoll,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	for _, client := range clients {
+		t := &target{
+			name:   controllerList.LookupName(client.ControllerAddress),
+			client: client,
+		}
+		if t.name == "" {
+			t.name = client.ControllerAddress
+		}
+		for door := uint8(1); door <= 4; door++ {
+			t.doors[door-1] = controllerList.LookupDoor(client.ControllerAddress, door)
+		}
+		e.targets = append(e.targets, t)
+	}
+	return e
+}
+
+// Registry returns the registry that holds the metrics.
+func (e *Exporter) Registry() *prometheus.Registry {
+	return e.registry
+}
+
+// Handler returns the HTTP handler for the metrics.
+func (e *Exporter) Handler() http.Handler {
+	return promhttp.HandlerFor(e.registry, promhttp.HandlerOpts{})
+}
+
+// Run polls every controller until the context is done.
+func (e *Exporter) Run(ctx context.Context, interval time.Duration) {
+	var wg sync.WaitGroup
+	for _, t := range e.targets {
+		wg.Add(1)
+		go func(t *target) {
+			defer wg.Done()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				e.poll(t)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(t)
+	}
+	wg.Wait()
+}
+
+// Poll polls every controller once.
+func (e *Exporter) Poll() {
+	var wg sync.WaitGroup
+	for _, t := range e.targets {
+		wg.Add(1)
+		go func(t *target) {
+			defer wg.Done()
+			e.poll(t)
+		}(t)
+	}
+	wg.Wait()
+}
+
+// poll reads the status of the controller and counts any new records.
+func (e *Exporter) poll(t *target) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	start := time.Now()
+	e.lastPoll.WithLabelValues(t.name).Set(float64(start.Unix()))
+
+	request := wire.GetOperationStatusRequest{
+		RecordIndex: 0,
+	}
+	var response wire.GetOperationStatusResponse
+	err := e.do(t, wire.FunctionGetOperationStatus, &request, &response)
+	if err != nil {
+		logrus.Warnf("Could not get the status of controller %s: %v", t.name, err)
+		e.up.WithLabelValues(t.name).Set(0)
+		return
+	}
+	e.up.WithLabelValues(t.name).Set(1)
+
+	// This is how the "drift" command computes it.
+	e.drift.WithLabelValues(t.name).Set(response.CurrentTime.Sub(t.client.WallClock(start)).Seconds())
+	e.records.WithLabelValues(t.name).Set(float64(response.RecordCount))
+	e.permissions.WithLabelValues(t.name).Set(float64(response.PopedomAmount))
+	e.fault.WithLabelValues(t.name).Set(float64(response.FaultNumber))
+	for door := uint8(1); door <= 4; door++ {
+		labels := []string{t.name, strconv.Itoa(int(door)), t.doors[door-1]}
+		e.doorOpen.WithLabelValues(labels...).Set(boolValue(response.MagnetState.DoorOpen(door)))
+		e.relayEnergized.WithLabelValues(labels...).Set(boolValue(response.RelayStatus.Energized(door)))
+	}
+
+	if t.nextRecord == 0 || response.RecordCount+1 < t.nextRecord {
+		// Only count the records from now on (or start over if the records were cleared).
+		t.nextRecord = response.RecordCount + 1
+		return
+	}
+	if response.RecordCount > MaxRecordsPerPoll && t.nextRecord < response.RecordCount-MaxRecordsPerPoll {
+		t.nextRecord = response.RecordCount - MaxRecordsPerPoll
+	}
+	for ; t.nextRecord <= response.RecordCount; t.nextRecord++ {
+		request := wire.GetOperationStatusRequest{
+			RecordIndex: t.nextRecord,
+		}
+		var recordResponse wire.GetOperationStatusResponse
+		err := e.do(t, wire.FunctionGetOperationStatus, &request, &recordResponse)
+		if err != nil {
+			logrus.Warnf("Could not read record %d from controller %s: %v", t.nextRecord, t.name, err)
+			return
+		}
+		if recordResponse.Record == nil {
+			continue
+		}
+		record := recordResponse.Record
+		var result string
+		switch record.Event() {
+		case wire.RecordEventGranted:
+			result = "granted"
+		case wire.RecordEventDenied:
+			result = "denied"
+		default:
+			continue
+		}
+		door := record.Door()
+		var doorName string
+		if door >= 1 && door <= 4 {
+			doorName = t.doors[door-1]
+		}
+		e.access.WithLabelValues(t.name, strconv.Itoa(int(door)), doorName, result).Inc()
+	}
+}
+
+// do performs a request, timing it and counting any error.
+func (e *Exporter) do(t *target, functionCode uint16, request any, response any) error {
+	start := time.Now()
+	err := t.client.Do(functionCode, request, response)
+	e.requestDuration.WithLabelValues(t.name, wire.FunctionName(functionCode)).Observe(time.Since(start).Seconds())
+	if err != nil {
+		e.requestErrors.WithLabelValues(t.name, ErrorType(err)).Inc()
+		return fmt.Errorf("%s: %w", wire.FunctionName(functionCode), err)
+	}
+	return nil
+}
+
+// ErrorType returns the "type" label for an error from a client.
+func ErrorType(err error) string {
+	var netError net.Error
+	if errors.Is(err, os.ErrDeadlineExceeded) || (errors.As(err, &netError) && netError.Timeout()) {
+		return ErrorTypeTimeout
+	}
+	var opError *net.OpError
+	if errors.As(err, &opError) || errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+		return ErrorTypeConnection
+	}
+	return ErrorTypeOther
+}
+
+// boolValue returns 1 for true and 0 for false.
+func boolValue(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,100 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tekkamanendless/cobra-controls/cobrafile"
+	"github.com/tekkamanendless/cobra-controls/wire"
+	"github.com/tekkamanendless/cobra-controls/wire/wiretest"
+)
+
+func TestExporter(t *testing.T) {
+	fake, err := wiretest.NewController(0xF257)
+	require.Nil(t, err)
+	defer fake.Close()
+	fake.AddRecord(wire.Record{IDNumber: 23439, AreaNumber: 178, RecordState: 0b00000001, BrushDateTime: time.Date(2022, 12, 28, 11, 28, 42, 0, time.UTC)})
+
+	controllerList := cobrafile.ControllerList{
+		{
+			Name:    "front",
+			Address: fake.Address(),
+			Port:    fake.Port(),
+			SN:      fake.BoardAddress,
+			Doors:   []string{"Lobby", "Garage", "", ""},
+		},
+	}
+	e := New([]*wire.Client{fake.Client()}, controllerList)
+
+	fake.SetOffset(-time.Minute)
+	fake.SetDoorState(wire.RelayStatus{Door1: true}, wire.MagnetState{Door2: true, Door3: true, Door4: true})
+	e.Poll()
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(e.up.WithLabelValues("front")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(e.records.WithLabelValues("front")))
+	assert.InDelta(t, -60.0, testutil.ToFloat64(e.drift.WithLabelValues("front")), 2)
+	assert.Equal(t, 1.0, testutil.ToFloat64(e.doorOpen.WithLabelValues("front", "1", "Lobby")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(e.doorOpen.WithLabelValues("front", "2", "Garage")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(e.relayEnergized.WithLabelValues("front", "1", "Lobby")))
+	// The records from before the exporter started are not counted.
+	assert.Equal(t, 0, testutil.CollectAndCount(e.access))
+
+	fake.AddRecord(
+		wire.Record{IDNumber: 23439, AreaNumber: 178, RecordState: 0b00000001, BrushDateTime: time.Date(2022, 12, 28, 11, 29, 0, 0, time.UTC)},
+		wire.Record{IDNumber: 23439, AreaNumber: 178, RecordState: 0b00000000, BrushDateTime: time.Date(2022, 12, 28, 11, 29, 5, 0, time.UTC)},
+		wire.Record{IDNumber: 23440, AreaNumber: 178, RecordState: 0b10000001, BrushDateTime: time.Date(2022, 12, 28, 11, 29, 9, 0, time.UTC)},
+	)
+	e.Poll()
+	assert.Equal(t, 4.0, testutil.ToFloat64(e.records.WithLabelValues("front")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(e.access.WithLabelValues("front", "2", "Garage", "granted")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(e.access.WithLabelValues("front", "1", "Lobby", "granted")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(e.access.WithLabelValues("front", "2", "Garage", "denied")))
+
+	// Nothing is counted twice.
+	e.Poll()
+	assert.Equal(t, 3, testutil.CollectAndCount(e.access))
+	assert.Equal(t, 1.0, testutil.ToFloat64(e.access.WithLabelValues("front", "2", "Garage", "granted")))
+
+	fake.SetFail(true)
+	e.Poll()
+	assert.Equal(t, 0.0, testutil.ToFloat64(e.up.WithLabelValues("front")))
+	assert.Equal(t, 1, testutil.CollectAndCount(e.requestErrors))
+
+	server := httptest.NewServer(e.Handler())
+	defer server.Close()
+	response, err := server.Client().Get(server.URL)
+	require.Nil(t, err)
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	require.Nil(t, err)
+	assert.Contains(t, string(body), `cobra_controller_up{controller="front"} 0`)
+	assert.Contains(t, string(body), `cobra_request_duration_seconds_count{controller="front",function="GetOperationStatus"}`)
+	assert.Contains(t, string(body), "go_goroutines")
+}
+
+func TestErrorType(t *testing.T) {
+	rows := []struct {
+		err      error
+		expected string
+	}{
+		{err: os.ErrDeadlineExceeded, expected: ErrorTypeTimeout},
+		{err: fmt.Errorf("could not read contents: %w", &net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}), expected: ErrorTypeTimeout},
+		{err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, expected: ErrorTypeConnection},
+		{err: fmt.Errorf("could not read contents: %w", io.EOF), expected: ErrorTypeConnection},
+		{err: fmt.Errorf("could not decode envelope: %w", errors.New("invalid")), expected: ErrorTypeOther},
+	}
+	for _, row := range rows {
+		t.Run(row.err.Error(), func(t *testing.T) {
+			assert.Equal(t, row.expected, ErrorType(row.err))
+		})
+	}
+}
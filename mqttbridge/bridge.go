@@ -0,0 +1,334 @@
+// Package mqttbridge publishes the access events and door states from a
+// server to an MQTT broker and accepts commands from it.
+//
+// With the default prefix, the topics are:
+//
+//	cobra/status                        "online" or "offline" (retained; the bridge itself)
+//	cobra/<controller>/availability     "online" or "offline" (retained; whether the controller can be reached)
+//	cobra/<controller>/<door>/state     the door's relay and sensor state as JSON (retained)
+//	cobra/<controller>/<door>/event     each access event as JSON
+//	cobra/<controller>/<door>/open/set  publish anything here to open the door
+//	cobra/<controller>/time/set         publish anything here to set the controller's time
+//
+// The controller is its name (or address) and the door is its name (or
+// number); events that apply to the whole controller use "controller" as the
+// door.  Any characters that are not allowed in a topic are replaced with "_".
+//
+// Home Assistant discovery payloads are published for every door (a door
+// sensor, a lock sensor, the last event, and an "open" button) and every
+// controller (connectivity and a "sync time" button).
+package mqttbridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/sirupsen/logrus"
+	"github.com/tekkamanendless/cobra-controls/server"
+)
+
+const (
+	// DefaultPrefix is the default prefix for every topic.
+	DefaultPrefix = "cobra"
+	// DefaultDiscoveryPrefix is the default Home Assistant discovery prefix.
+	DefaultDiscoveryPrefix = "homeassistant"
+
+	// controllerDoor is the door segment for events that apply to the whole controller.
+	controllerDoor = "controller"
+	// publishTimeout is how long to wait for the broker to accept a message.
+	publishTimeout = 10 * time.Second
+)
+
+// Options configures the bridge.
+type Options struct {
+	Broker          string // For example, "tcp://localhost:1883".
+	ClientID        string
+	Username        string
+	Password        string
+	Prefix          string        // Defaults to "DefaultPrefix".
+	DiscoveryPrefix string        // Defaults to "DefaultDiscoveryPrefix"; use "-" to disable discovery.
+	StatusInterval  time.Duration // This is how often the door states are refreshed.
+}
+
+// EventMessage is the payload of an event topic.
+type EventMessage struct {
+	server.Event
+	DoorStatus *server.DoorStatus `json:",omitempty"` // This is the state of the door just after the event.
+}
+
+// Bridge connects a server to an MQTT broker.
+type Bridge struct {
+	server  *server.Server
+	options Options
+	client  mqtt.Client
+
+	mutex  sync.Mutex
+	states map[string][]byte // This is the last state published to each topic.
+}
+
+// New returns a bridge for the server.
+func New(s *server.Server, options Options) *Bridge {
+	if options.Prefix == "" {
+		options.Prefix = DefaultPrefix
+	}
+	if options.DiscoveryPrefix == "" {
+		options.DiscoveryPrefix = DefaultDiscoveryPrefix
+	}
+	if options.ClientID == "" {
+		options.ClientID = "cobra-bridge"
+	}
+	if options.StatusInterval <= 0 {
+		options.StatusInterval = 10 * time.Second
+	}
+	return &Bridge{
+		server:  s,
+		options: options,
+		states:  map[string][]byte{},
+	}
+}
+
+// Run connects to the broker and bridges until the context is done.
+//
+// The server's monitor must be running for events to be published.
+func (b *Bridge) Run(ctx context.Context) error {
+	clientOptions := mqtt.NewClientOptions().
+		AddBroker(b.options.Broker).
+		SetClientID(b.options.ClientID).
+		SetUsername(b.options.Username).
+		SetPassword(b.options.Password).
+		SetAutoReconnect(true).
+		SetOrderMatters(false).
+		SetWill(b.topic("status"), "offline", 1, true).
+		SetOnConnectHandler(func(client mqtt.Client) {
+			logrus.Infof("Connected to MQTT broker %s.", b.options.Broker)
+			b.onConnect()
+		}).
+		SetConnectionLostHandler(func(client mqtt.Client, err error) {
+			logrus.Warnf("Lost the connection to MQTT broker %s: %v", b.options.Broker, err)
+		})
+	b.client = mqtt.NewClient(clientOptions)
+	token := b.client.Connect()
+	token.Wait()
+	if token.Error() != nil {
+		return fmt.Errorf("could not connect to %s: %w", b.options.Broker, token.Error())
+	}
+	defer func() {
+		b.publish(b.topic("status"), []byte("offline"), true)
+		b.client.Disconnect(1000)
+	}()
+
+	go b.forwardEvents(ctx)
+
+	ticker := time.NewTicker(b.options.StatusInterval)
+	defer ticker.Stop()
+	for {
+		for _, controller := range b.server.Controllers() {
+			b.refresh(controller)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// onConnect subscribes to the command topics and publishes the discovery
+// payloads; this runs on every (re)connection.
+func (b *Bridge) onConnect() {
+	subscriptions := map[string]byte{
+		b.topic("+", "+", "open", "set"): 1,
+		b.topic("+", "time", "set"):      1,
+	}
+	token := b.client.SubscribeMultiple(subscriptions, func(client mqtt.Client, message mqtt.Message) {
+		b.handleCommand(message.Topic())
+	})
+	if token.WaitTimeout(publishTimeout) && token.Error() != nil {
+		logrus.Errorf("Could not subscribe to the command topics: %v", token.Error())
+	}
+
+	b.publish(b.topic("status"), []byte("online"), true)
+	if b.options.DiscoveryPrefix != "-" {
+		for _, controller := range b.server.Controllers() {
+			for topic, payload := range b.discovery(controller) {
+				data, err := json.Marshal(payload)
+				if err != nil {
+					logrus.Errorf("Could not encode discovery payload: %v", err)
+					continue
+				}
+				b.publish(topic, data, true)
+			}
+		}
+	}
+
+	// Republish the retained states in case the broker lost them.
+	b.mutex.Lock()
+	b.states = map[string][]byte{}
+	b.mutex.Unlock()
+}
+
+// forwardEvents publishes every event from the server.
+func (b *Bridge) forwardEvents(ctx context.Context) {
+	for ctx.Err() == nil {
+		subscription := b.server.Subscribe()
+		if subscription == nil {
+			// The monitor has not started yet.
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		func() {
+			defer b.server.Unsubscribe(subscription)
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case event, ok := <-subscription.Events:
+					if !ok {
+						return
+					}
+					b.publishEvent(event)
+				}
+			}
+		}()
+	}
+}
+
+// publishEvent refreshes the controller's state and then publishes the event.
+func (b *Bridge) publishEvent(event server.Event) {
+	message := EventMessage{
+		Event: event,
+	}
+	status := b.refreshByName(event.Controller)
+	if status != nil && event.Door >= 1 && int(event.Door) <= len(status.Doors) {
+		message.DoorStatus = &status.Doors[event.Door-1]
+	}
+	data, err := json.Marshal(message)
+	if err != nil {
+		logrus.Errorf("Could not encode event: %v", err)
+		return
+	}
+	door := controllerDoor
+	if event.Door != 0 {
+		door = doorSegment(event.DoorName, event.Door)
+	}
+	b.publish(b.topic(segment(event.Controller), door, "event"), data, false)
+}
+
+// refresh publishes the controller's availability and door states.
+func (b *Bridge) refresh(controller server.ControllerInfo) *server.ControllerStatus {
+	return b.refreshByName(controllerKey(controller))
+}
+
+func (b *Bridge) refreshByName(name string) *server.ControllerStatus {
+	status, err := b.server.ControllerStatus(name)
+	availability := "online"
+	if err != nil {
+		logrus.Warnf("Could not get the status of controller %s: %v", name, err)
+		availability = "offline"
+	}
+	b.publishState(b.topic(segment(name), "availability"), []byte(availability))
+	if status == nil {
+		return nil
+	}
+	for _, door := range status.Doors {
+		data, err := json.Marshal(door)
+		if err != nil {
+			continue
+		}
+		b.publishState(b.topic(segment(name), doorSegment(door.Name, door.Door), "state"), data)
+	}
+	return status
+}
+
+// publishState publishes a retained message if it is different from the last
+// one published to the topic.
+func (b *Bridge) publishState(topic string, payload []byte) {
+	b.mutex.Lock()
+	if string(b.states[topic]) == string(payload) {
+		b.mutex.Unlock()
+		return
+	}
+	b.states[topic] = payload
+	b.mutex.Unlock()
+	b.publish(topic, payload, true)
+}
+
+// handleCommand runs the command for the topic.
+func (b *Bridge) handleCommand(topic string) {
+	parts := strings.Split(strings.TrimPrefix(topic, b.options.Prefix+"/"), "/")
+	for _, controller := range b.server.Controllers() {
+		if segment(controllerKey(controller)) != parts[0] {
+			continue
+		}
+		switch {
+		case len(parts) == 3 && parts[1] == "time":
+			logrus.Infof("MQTT: setting the time on controller %s.", controllerKey(controller))
+			response, err := b.server.SyncTime(controllerKey(controller))
+			if err != nil {
+				logrus.Errorf("MQTT: could not set the time on controller %s: %v", controllerKey(controller), err)
+				return
+			}
+			logrus.Infof("MQTT: set the time on controller %s (drift: %s).", controllerKey(controller), response.Drift)
+		case len(parts) == 4 && parts[2] == "open":
+			for _, door := range controller.Doors {
+				if doorSegment(door.Name, door.Door) != parts[1] {
+					continue
+				}
+				logrus.Infof("MQTT: opening door %d on controller %s.", door.Door, controllerKey(controller))
+				_, err := b.server.OpenDoor(controllerKey(controller), strconv.Itoa(int(door.Door)))
+				if err != nil {
+					logrus.Errorf("MQTT: could not open door %d on controller %s: %v", door.Door, controllerKey(controller), err)
+				}
+				return
+			}
+			logrus.Warnf("MQTT: no such door: %s", topic)
+		}
+		return
+	}
+	logrus.Warnf("MQTT: no such controller: %s", topic)
+}
+
+// publish publishes a message at QoS 1.
+func (b *Bridge) publish(topic string, payload []byte, retain bool) {
+	logrus.Debugf("MQTT: publishing %s: %s", topic, payload)
+	token := b.client.Publish(topic, 1, retain, payload)
+	if !token.WaitTimeout(publishTimeout) {
+		logrus.Warnf("MQTT: timed out publishing to %s.", topic)
+		return
+	}
+	if token.Error() != nil {
+		logrus.Warnf("MQTT: could not publish to %s: %v", topic, token.Error())
+	}
+}
+
+// topic returns the topic with the prefix.
+func (b *Bridge) topic(parts ...string) string {
+	return b.options.Prefix + "/" + strings.Join(parts, "/")
+}
+
+// controllerKey returns the name that the server uses for the controller.
+func controllerKey(controller server.ControllerInfo) string {
+	if controller.Name != "" {
+		return controller.Name
+	}
+	return controller.Address
+}
+
+// doorSegment returns the topic segment for a door.
+func doorSegment(name string, door uint8) string {
+	if name != "" {
+		return segment(name)
+	}
+	return strconv.Itoa(int(door))
+}
+
+// segment replaces the characters that are not allowed in a topic segment.
+func segment(value string) string {
+	return strings.NewReplacer("/", "_", "+", "_", "#", "_").Replace(value)
+}
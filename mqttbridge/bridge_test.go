@@ -0,0 +1,171 @@
+package mqttbridge
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tekkamanendless/cobra-controls/cobrafile"
+	"github.com/tekkamanendless/cobra-controls/mqttbridge/mqtttest"
+	"github.com/tekkamanendless/cobra-controls/server"
+	"github.com/tekkamanendless/cobra-controls/wire"
+	"github.com/tekkamanendless/cobra-controls/wire/wiretest"
+)
+
+func TestBridge(t *testing.T) {
+	fake, err := wiretest.NewController(0xF257)
+	require.Nil(t, err)
+	defer fake.Close()
+
+	broker, err := mqtttest.NewBroker()
+	require.Nil(t, err)
+	defer broker.Close()
+
+	s := &server.Server{
+		ControllerList: cobrafile.ControllerList{
+			{
+				Name:    "front",
+				Address: fake.Address(),
+				Port:    fake.Port(),
+				SN:      fake.BoardAddress,
+				Doors:   []string{"Lobby", "Garage", "", ""},
+			},
+		},
+		PersonnelList: cobrafile.PersonnelList{
+			{Name: "Alice", CardID: "17823439"},
+		},
+		Protocol: wire.ProtocolTCP,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Monitor(ctx, 20*time.Millisecond)
+
+	bridge := New(s, Options{
+		Broker:         broker.URL(),
+		StatusInterval: 50 * time.Millisecond,
+	})
+	done := make(chan error)
+	go func() {
+		done <- bridge.Run(ctx)
+	}()
+
+	// retained waits for a retained message.
+	retained := func(topic string) []byte {
+		var payload []byte
+		require.Eventually(t, func() bool {
+			var ok bool
+			payload, ok = broker.Retained(topic)
+			return ok
+		}, 5*time.Second, 10*time.Millisecond, topic)
+		return payload
+	}
+	// published waits for a message and returns the last one.
+	published := func(topic string, count int) []byte {
+		var payload []byte
+		require.Eventually(t, func() bool {
+			var found int
+			for _, message := range broker.Messages() {
+				if message.Topic == topic {
+					payload = message.Payload
+					found++
+				}
+			}
+			return found >= count
+		}, 5*time.Second, 10*time.Millisecond, topic)
+		return payload
+	}
+
+	t.Run("Discovery", func(t *testing.T) {
+		assert.Equal(t, []byte("online"), retained("cobra/status"))
+
+		var payload map[string]any
+		err := json.Unmarshal(retained("homeassistant/binary_sensor/cobra_62039/door2/config"), &payload)
+		require.Nil(t, err)
+		assert.Equal(t, "Garage", payload["name"])
+		assert.Equal(t, "door", payload["device_class"])
+		assert.Equal(t, "cobra/front/Garage/state", payload["state_topic"])
+		assert.Equal(t, "cobra_62039_door2", payload["unique_id"])
+
+		err = json.Unmarshal(retained("homeassistant/button/cobra_62039/door1_open/config"), &payload)
+		require.Nil(t, err)
+		assert.Equal(t, "cobra/front/Lobby/open/set", payload["command_topic"])
+
+		// Unnamed doors are skipped when some doors are named.
+		_, ok := broker.Retained("homeassistant/binary_sensor/cobra_62039/door3/config")
+		assert.False(t, ok)
+	})
+	t.Run("State", func(t *testing.T) {
+		assert.Equal(t, []byte("online"), retained("cobra/front/availability"))
+
+		var status server.DoorStatus
+		err := json.Unmarshal(retained("cobra/front/Lobby/state"), &status)
+		require.Nil(t, err)
+		assert.Equal(t, server.DoorStatus{Door: 1, Name: "Lobby"}, status)
+
+		fake.SetDoorState(wire.RelayStatus{Door1: true}, wire.MagnetState{Door2: true, Door3: true, Door4: true})
+		require.Eventually(t, func() bool {
+			payload, _ := broker.Retained("cobra/front/Lobby/state")
+			err := json.Unmarshal(payload, &status)
+			return err == nil && status.Open
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.True(t, status.RelayEnergized)
+		retained("cobra/front/3/state")
+	})
+	t.Run("Event", func(t *testing.T) {
+		fake.AddRecord(wire.Record{IDNumber: 23439, AreaNumber: 178, RecordState: 0b00000001, BrushDateTime: time.Date(2022, 12, 28, 11, 28, 42, 0, time.UTC)})
+
+		var message EventMessage
+		err := json.Unmarshal(published("cobra/front/Garage/event", 1), &message)
+		require.Nil(t, err)
+		assert.Equal(t, "Alice", message.Person)
+		assert.Equal(t, wire.RecordEventGranted, message.Event.Event)
+		require.NotNil(t, message.DoorStatus)
+		assert.Equal(t, uint8(2), message.DoorStatus.Door)
+
+		fake.AddRecord(wire.Record{IDNumber: 0b0100, AreaNumber: 0, RecordState: 0b10100000, BrushDateTime: time.Date(2022, 12, 28, 11, 30, 0, 0, time.UTC)})
+		var alarm EventMessage
+		err = json.Unmarshal(published("cobra/front/controller/event", 1), &alarm)
+		require.Nil(t, err)
+		assert.Equal(t, wire.RecordEventFireAlarm, alarm.Event.Event)
+		assert.Nil(t, alarm.DoorStatus)
+	})
+	t.Run("OpenDoor", func(t *testing.T) {
+		broker.Publish("cobra/front/Lobby/open/set", []byte("PRESS"), false)
+		require.Eventually(t, func() bool {
+			records := fake.Records()
+			last := records[len(records)-1]
+			return last.Event() == wire.RecordEventRemoteOpen && last.Door() == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// The remote open shows up as an event too.
+		published("cobra/front/Lobby/event", 1)
+	})
+	t.Run("SyncTime", func(t *testing.T) {
+		fake.SetOffset(time.Hour)
+		broker.Publish("cobra/front/time/set", []byte("PRESS"), false)
+		require.Eventually(t, func() bool {
+			now := time.Now()
+			wallClock := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(), 0, time.UTC)
+			return fake.Now().Sub(wallClock).Abs() < 5*time.Second
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "the bridge did not stop")
+	}
+	assert.Equal(t, []byte("offline"), retained("cobra/status"))
+}
+
+func TestSegment(t *testing.T) {
+	assert.Equal(t, "Front_Back", segment("Front/Back"))
+	assert.Equal(t, "a_b_c", segment("a+b#c"))
+	assert.Equal(t, "Lobby", doorSegment("Lobby", 1))
+	assert.Equal(t, "3", doorSegment("", 3))
+}
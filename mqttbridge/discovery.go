@@ -0,0 +1,103 @@
+package mqttbridge
+
+import (
+	"fmt"
+
+	"github.com/tekkamanendless/cobra-controls/server"
+)
+
+// discovery returns the Home Assistant discovery payloads for the controller,
+// keyed by topic.
+//
+// See: https://www.home-assistant.io/integrations/mqtt/#mqtt-discovery
+func (b *Bridge) discovery(controller server.ControllerInfo) map[string]map[string]any {
+	name := controllerKey(controller)
+	id := fmt.Sprintf("cobra_%d", controller.SN)
+	device := map[string]any{
+		"identifiers":  []string{id},
+		"name":         name,
+		"manufacturer": "Cobra Controls",
+		"model":        "ACP-T",
+	}
+	availability := []map[string]any{
+		{"topic": b.topic("status")},
+		{"topic": b.topic(segment(name), "availability")},
+	}
+
+	result := map[string]map[string]any{}
+	add := func(component string, objectID string, payload map[string]any) {
+		payload["unique_id"] = id + "_" + objectID
+		payload["object_id"] = id + "_" + objectID
+		payload["device"] = device
+		if _, ok := payload["availability"]; !ok {
+			payload["availability"] = availability
+			payload["availability_mode"] = "all"
+		}
+		result[fmt.Sprintf("%s/%s/%s/%s/config", b.options.DiscoveryPrefix, component, id, objectID)] = payload
+	}
+
+	add("binary_sensor", "connectivity", map[string]any{
+		"name":         "Connectivity",
+		"device_class": "connectivity",
+		"state_topic":  b.topic(segment(name), "availability"),
+		"payload_on":   "online",
+		"payload_off":  "offline",
+		"availability": []map[string]any{{"topic": b.topic("status")}},
+	})
+	add("button", "sync_time", map[string]any{
+		"name":          "Sync time",
+		"command_topic": b.topic(segment(name), "time", "set"),
+		"payload_press": "PRESS",
+	})
+
+	for _, door := range doors(controller) {
+		doorName := door.Name
+		if doorName == "" {
+			doorName = fmt.Sprintf("Door %d", door.Door)
+		}
+		doorTopic := func(parts ...string) string {
+			return b.topic(append([]string{segment(name), doorSegment(door.Name, door.Door)}, parts...)...)
+		}
+		objectID := fmt.Sprintf("door%d", door.Door)
+
+		add("binary_sensor", objectID, map[string]any{
+			"name":           doorName,
+			"device_class":   "door",
+			"state_topic":    doorTopic("state"),
+			"value_template": "{{ 'ON' if value_json.Open else 'OFF' }}",
+		})
+		add("binary_sensor", objectID+"_lock", map[string]any{
+			"name":           doorName + " lock",
+			"device_class":   "lock", // "On" means unlocked.
+			"state_topic":    doorTopic("state"),
+			"value_template": "{{ 'ON' if value_json.RelayEnergized else 'OFF' }}",
+		})
+		add("sensor", objectID+"_event", map[string]any{
+			"name":                  doorName + " last event",
+			"state_topic":           doorTopic("event"),
+			"value_template":        "{{ value_json.Event }}",
+			"json_attributes_topic": doorTopic("event"),
+		})
+		add("button", objectID+"_open", map[string]any{
+			"name":          "Open " + doorName,
+			"command_topic": doorTopic("open", "set"),
+			"payload_press": "PRESS",
+		})
+	}
+	return result
+}
+
+// doors returns the doors that are named in the controller file, or every
+// door if none of them are.
+func doors(controller server.ControllerInfo) []server.DoorInfo {
+	var result []server.DoorInfo
+	for _, door := range controller.Doors {
+		if door.Name != "" {
+			result = append(result, door)
+		}
+	}
+	if len(result) == 0 {
+		return controller.Doors
+	}
+	return result
+}
@@ -0,0 +1,454 @@
+// Package mqtttest provides a minimal in-process MQTT 3.1.1 broker for tests.
+//
+// It supports QoS 0 and 1 publishing (everything is delivered at QoS 0),
+// retained messages, wildcard subscriptions, and wills.  It keeps a copy of
+// every message that it receives so that tests can check what was published.
+package mqtttest
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// These are the MQTT control packet types.
+const (
+	packetConnect     = 1
+	packetConnack     = 2
+	packetPublish     = 3
+	packetPuback      = 4
+	packetSubscribe   = 8
+	packetSuback      = 9
+	packetUnsubscribe = 10
+	packetUnsuback    = 11
+	packetPingreq     = 12
+	packetPingresp    = 13
+	packetDisconnect  = 14
+)
+
+// Message is a published message.
+type Message struct {
+	Topic    string
+	Payload  []byte
+	Retained bool
+}
+
+// Broker is an MQTT broker listening on a local TCP port.
+type Broker struct {
+	mutex    sync.Mutex
+	listener net.Listener
+	clients  map[*client]bool
+	retained map[string][]byte
+	messages []Message
+}
+
+// client is a connection to the broker.
+type client struct {
+	conn          net.Conn
+	writeMutex    sync.Mutex
+	subscriptions []string
+	will          *Message
+}
+
+// NewBroker starts a broker on a random local port.
+//
+// Call `Close` when done.
+func NewBroker() (*Broker, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	b := &Broker{
+		listener: listener,
+		clients:  map[*client]bool{},
+		retained: map[string][]byte{},
+	}
+	go b.serve()
+	return b, nil
+}
+
+// Close stops the broker and disconnects every client.
+func (b *Broker) Close() error {
+	err := b.listener.Close()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for c := range b.clients {
+		c.conn.Close()
+	}
+	return err
+}
+
+// URL returns the URL of the broker (for example, "tcp://127.0.0.1:1883").
+func (b *Broker) URL() string {
+	return "tcp://" + b.listener.Addr().String()
+}
+
+// Messages returns every message that was published to the broker.
+func (b *Broker) Messages() []Message {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]Message{}, b.messages...)
+}
+
+// Retained returns the retained message for the topic.
+func (b *Broker) Retained(topic string) ([]byte, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	payload, ok := b.retained[topic]
+	return payload, ok
+}
+
+// Publish publishes a message as if a client had sent it.
+func (b *Broker) Publish(topic string, payload []byte, retain bool) {
+	b.publish(Message{Topic: topic, Payload: payload, Retained: retain})
+}
+
+func (b *Broker) serve() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logrus.Warnf("mqtttest: could not accept connection: %v", err)
+			}
+			return
+		}
+		go b.handle(conn)
+	}
+}
+
+// handle reads the packets from the connection until it is closed.
+func (b *Broker) handle(conn net.Conn) {
+	c := &client{conn: conn}
+	b.mutex.Lock()
+	b.clients[c] = true
+	b.mutex.Unlock()
+
+	clean := false
+	defer func() {
+		conn.Close()
+		b.mutex.Lock()
+		delete(b.clients, c)
+		b.mutex.Unlock()
+		if !clean && c.will != nil {
+			b.publish(*c.will)
+		}
+	}()
+
+	reader := bufio.NewReader(conn)
+	for {
+		packetType, flags, body, err := readPacket(reader)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				logrus.Debugf("mqtttest: could not read packet: %v", err)
+			}
+			return
+		}
+		switch packetType {
+		case packetConnect:
+			err = b.connect(c, body)
+		case packetPublish:
+			err = b.receivePublish(c, flags, body)
+		case packetPuback:
+			// Everything is delivered at QoS 0, so there is nothing to do.
+		case packetSubscribe:
+			err = b.subscribe(c, body)
+		case packetUnsubscribe:
+			err = b.unsubscribe(c, body)
+		case packetPingreq:
+			err = c.write(packetPingresp<<4, nil)
+		case packetDisconnect:
+			clean = true
+			return
+		default:
+			err = fmt.Errorf("unsupported packet type: %d", packetType)
+		}
+		if err != nil {
+			logrus.Warnf("mqtttest: %v", err)
+			return
+		}
+	}
+}
+
+func (b *Broker) connect(c *client, body []byte) error {
+	r := &packetReader{data: body}
+	protocol := r.String()
+	level := r.Byte()
+	flags := r.Byte()
+	r.Uint16() // Keep alive.
+	r.Bytes()  // Client ID.
+	if flags&0x04 != 0 {
+		c.will = &Message{
+			Topic:    r.String(),
+			Payload:  r.Bytes(),
+			Retained: flags&0x20 != 0,
+		}
+	}
+	if r.err != nil {
+		return fmt.Errorf("invalid connect: %w", r.err)
+	}
+	if protocol != "MQTT" || level != 4 {
+		// "Unacceptable protocol version".
+		c.write(packetConnack<<4, []byte{0, 1})
+		return fmt.Errorf("unsupported protocol: %s %d", protocol, level)
+	}
+	return c.write(packetConnack<<4, []byte{0, 0})
+}
+
+func (b *Broker) receivePublish(c *client, flags byte, body []byte) error {
+	r := &packetReader{data: body}
+	topic := r.String()
+	qos := (flags >> 1) & 0b11
+	var packetID uint16
+	if qos > 0 {
+		packetID = r.Uint16()
+	}
+	if r.err != nil {
+		return fmt.Errorf("invalid publish: %w", r.err)
+	}
+	if qos > 1 {
+		return fmt.Errorf("unsupported QoS: %d", qos)
+	}
+	b.publish(Message{Topic: topic, Payload: r.Rest(), Retained: flags&0x01 != 0})
+	if qos == 1 {
+		return c.write(packetPuback<<4, binary.BigEndian.AppendUint16(nil, packetID))
+	}
+	return nil
+}
+
+// publish records the message and sends it to every matching subscriber.
+func (b *Broker) publish(message Message) {
+	b.mutex.Lock()
+	b.messages = append(b.messages, message)
+	if message.Retained {
+		if len(message.Payload) == 0 {
+			delete(b.retained, message.Topic)
+		} else {
+			b.retained[message.Topic] = message.Payload
+		}
+	}
+	var targets []*client
+	for c := range b.clients {
+		for _, filter := range c.subscriptions {
+			if Match(filter, message.Topic) {
+				targets = append(targets, c)
+				break
+			}
+		}
+	}
+	b.mutex.Unlock()
+
+	for _, c := range targets {
+		// A message is only delivered with the retain flag when it is sent because of a new subscription.
+		c.sendPublish(message.Topic, message.Payload, false)
+	}
+}
+
+func (b *Broker) subscribe(c *client, body []byte) error {
+	r := &packetReader{data: body}
+	packetID := r.Uint16()
+	var filters []string
+	for r.err == nil && len(r.data) > 0 {
+		filters = append(filters, r.String())
+		r.Byte() // Requested QoS.
+	}
+	if r.err != nil || len(filters) == 0 {
+		return fmt.Errorf("invalid subscribe: %v", r.err)
+	}
+
+	b.mutex.Lock()
+	c.subscriptions = append(c.subscriptions, filters...)
+	var retained []Message
+	for topic, payload := range b.retained {
+		for _, filter := range filters {
+			if Match(filter, topic) {
+				retained = append(retained, Message{Topic: topic, Payload: payload, Retained: true})
+				break
+			}
+		}
+	}
+	b.mutex.Unlock()
+
+	response := binary.BigEndian.AppendUint16(nil, packetID)
+	for range filters {
+		response = append(response, 0) // Granted QoS 0.
+	}
+	err := c.write(packetSuback<<4, response)
+	if err != nil {
+		return err
+	}
+	for _, message := range retained {
+		c.sendPublish(message.Topic, message.Payload, true)
+	}
+	return nil
+}
+
+func (b *Broker) unsubscribe(c *client, body []byte) error {
+	r := &packetReader{data: body}
+	packetID := r.Uint16()
+	var filters []string
+	for r.err == nil && len(r.data) > 0 {
+		filters = append(filters, r.String())
+	}
+	if r.err != nil {
+		return fmt.Errorf("invalid unsubscribe: %w", r.err)
+	}
+
+	b.mutex.Lock()
+	var subscriptions []string
+	for _, subscription := range c.subscriptions {
+		keep := true
+		for _, filter := range filters {
+			if subscription == filter {
+				keep = false
+			}
+		}
+		if keep {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	c.subscriptions = subscriptions
+	b.mutex.Unlock()
+
+	return c.write(packetUnsuback<<4, binary.BigEndian.AppendUint16(nil, packetID))
+}
+
+// sendPublish sends a QoS 0 publish packet to the client.
+func (c *client) sendPublish(topic string, payload []byte, retained bool) {
+	header := byte(packetPublish << 4)
+	if retained {
+		header |= 0x01
+	}
+	body := binary.BigEndian.AppendUint16(nil, uint16(len(topic)))
+	body = append(body, topic...)
+	body = append(body, payload...)
+	err := c.write(header, body)
+	if err != nil {
+		logrus.Debugf("mqtttest: could not send publish: %v", err)
+	}
+}
+
+// write sends a packet with the given first byte.
+func (c *client) write(header byte, body []byte) error {
+	packet := []byte{header}
+	length := len(body)
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		packet = append(packet, digit)
+		if length == 0 {
+			break
+		}
+	}
+	packet = append(packet, body...)
+
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	_, err := c.conn.Write(packet)
+	return err
+}
+
+// readPacket reads a packet and returns its type, flags, and body.
+func readPacket(reader *bufio.Reader) (byte, byte, []byte, error) {
+	header, err := reader.ReadByte()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	var length int
+	for multiplier := 1; ; multiplier *= 128 {
+		if multiplier > 128*128*128 {
+			return 0, 0, nil, fmt.Errorf("invalid remaining length")
+		}
+		digit, err := reader.ReadByte()
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		length += int(digit&0x7f) * multiplier
+		if digit&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	_, err = io.ReadFull(reader, body)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	return header >> 4, header & 0x0f, body, nil
+}
+
+// packetReader reads the fields of a packet body.
+//
+// Once there is an error, every read returns a zero value.
+type packetReader struct {
+	data []byte
+	err  error
+}
+
+func (r *packetReader) Byte() byte {
+	if r.err != nil || len(r.data) < 1 {
+		r.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	v := r.data[0]
+	r.data = r.data[1:]
+	return v
+}
+
+func (r *packetReader) Uint16() uint16 {
+	if r.err != nil || len(r.data) < 2 {
+		r.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	v := binary.BigEndian.Uint16(r.data)
+	r.data = r.data[2:]
+	return v
+}
+
+func (r *packetReader) Bytes() []byte {
+	length := int(r.Uint16())
+	if r.err != nil || len(r.data) < length {
+		r.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	v := r.data[:length]
+	r.data = r.data[length:]
+	return v
+}
+
+func (r *packetReader) String() string {
+	return string(r.Bytes())
+}
+
+func (r *packetReader) Rest() []byte {
+	v := r.data
+	r.data = nil
+	return v
+}
+
+// Match returns true if the topic matches the subscription filter (which may
+// contain the "+" and "#" wildcards).
+func Match(filter string, topic string) bool {
+	filterParts := strings.Split(filter, "/")
+	topicParts := strings.Split(topic, "/")
+	for i, filterPart := range filterParts {
+		if filterPart == "#" {
+			return true
+		}
+		if i >= len(topicParts) {
+			return false
+		}
+		if filterPart != "+" && filterPart != topicParts[i] {
+			return false
+		}
+	}
+	return len(filterParts) == len(topicParts)
+}
@@ -0,0 +1,95 @@
+package mqtttest
+
+import (
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	rows := []struct {
+		filter  string
+		topic   string
+		matched bool
+	}{
+		{filter: "a/b", topic: "a/b", matched: true},
+		{filter: "a/b", topic: "a/c"},
+		{filter: "a/+", topic: "a/b", matched: true},
+		{filter: "a/+", topic: "a/b/c"},
+		{filter: "a/+/c", topic: "a/b/c", matched: true},
+		{filter: "a/#", topic: "a/b/c", matched: true},
+		{filter: "#", topic: "a", matched: true},
+		{filter: "a/b/c", topic: "a/b"},
+	}
+	for _, row := range rows {
+		assert.Equal(t, row.matched, Match(row.filter, row.topic), "%s %s", row.filter, row.topic)
+	}
+}
+
+func TestBroker(t *testing.T) {
+	broker, err := NewBroker()
+	require.Nil(t, err)
+	defer broker.Close()
+
+	connect := func(clientID string, will bool) mqtt.Client {
+		options := mqtt.NewClientOptions().AddBroker(broker.URL()).SetClientID(clientID)
+		if will {
+			options.SetWill("test/status", "offline", 1, true)
+		}
+		client := mqtt.NewClient(options)
+		token := client.Connect()
+		require.True(t, token.WaitTimeout(5*time.Second))
+		require.Nil(t, token.Error())
+		return client
+	}
+
+	publisher := connect("publisher", true)
+	token := publisher.Publish("test/retained", 1, true, "hello")
+	require.True(t, token.WaitTimeout(5*time.Second))
+	require.Nil(t, token.Error())
+
+	received := make(chan Message, 10)
+	subscriber := connect("subscriber", false)
+	defer subscriber.Disconnect(0)
+	token = subscriber.Subscribe("test/#", 1, func(client mqtt.Client, message mqtt.Message) {
+		received <- Message{Topic: message.Topic(), Payload: message.Payload(), Retained: message.Retained()}
+	})
+	require.True(t, token.WaitTimeout(5*time.Second))
+	require.Nil(t, token.Error())
+
+	next := func() Message {
+		select {
+		case message := <-received:
+			return message
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timed out")
+		}
+		return Message{}
+	}
+	assert.Equal(t, Message{Topic: "test/retained", Payload: []byte("hello"), Retained: true}, next())
+
+	publisher.Publish("test/a/b", 0, false, "world").Wait()
+	assert.Equal(t, Message{Topic: "test/a/b", Payload: []byte("world")}, next())
+
+	broker.Publish("test/injected", []byte("x"), false)
+	assert.Equal(t, Message{Topic: "test/injected", Payload: []byte("x")}, next())
+
+	// The will is sent if the connection is lost.
+	broker.mutex.Lock()
+	for c := range broker.clients {
+		if c.will != nil {
+			c.conn.Close()
+		}
+	}
+	broker.mutex.Unlock()
+	assert.Equal(t, Message{Topic: "test/status", Payload: []byte("offline")}, next())
+	payload, ok := broker.Retained("test/status")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("offline"), payload)
+	publisher.Disconnect(0)
+
+	assert.NotEmpty(t, broker.Messages())
+}
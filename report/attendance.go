@@ -0,0 +1,229 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/tekkamanendless/cobra-controls/cobrafile"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+// These are the tables of an attendance report.
+const (
+	AttendanceTableDays        = "days"
+	AttendanceTablePeople      = "people"
+	AttendanceTableDepartments = "departments"
+)
+
+// AttendanceDay is when a person was on site on a single day.
+type AttendanceDay struct {
+	Date         string // This is the date in the controller's time zone ("2006-01-02").
+	WorkerNumber string `json:",omitempty"`
+	Name         string
+	Department   string `json:",omitempty"`
+	CardID       string
+	FirstIn      *time.Time `json:",omitempty"`
+	LastOut      *time.Time `json:",omitempty"`
+	Hours        float64
+	Complete     bool // This is false if either the first in or the last out is missing.
+}
+
+// AttendancePerson is the total time on site for a person.
+type AttendancePerson struct {
+	WorkerNumber string `json:",omitempty"`
+	Name         string
+	Department   string `json:",omitempty"`
+	CardID       string
+	Days         int
+	Hours        float64
+	Incomplete   int // This is the number of days that were missing an in or an out.
+}
+
+// AttendanceDepartment is the total time on site for a department.
+type AttendanceDepartment struct {
+	Department string
+	People     int
+	Days       int
+	Hours      float64
+}
+
+// Attendance is an attendance report.
+type Attendance struct {
+	From        time.Time
+	To          time.Time
+	Days        []AttendanceDay
+	People      []AttendancePerson
+	Departments []AttendanceDepartment
+}
+
+// BuildAttendance pairs the first in with the last out for each person on each
+// day.
+//
+// Only granted cards count.  The first in is the first swipe at a door that is
+// not an "out" door, and the last out is the last swipe at a door that is not
+// an "in" door (see the door directions in the controller file), so with no
+// directions, this is simply the first and last swipes of the day.
+//
+// Only the people with "Attendance" set are included unless `everyone` is set;
+// unknown cards are never included.
+func BuildAttendance(entries []Entry, personnelList cobrafile.PersonnelList, from time.Time, to time.Time, everyone bool) *Attendance {
+	type dayKey struct {
+		cardID string
+		date   string
+	}
+	days := map[dayKey]*AttendanceDay{}
+	for _, entry := range entries {
+		if entry.Record.Event() != wire.RecordEventGranted {
+			continue
+		}
+		person := personnelList.FindByCardID(entry.CardID())
+		if person == nil || !(person.Attendance || everyone) {
+			continue
+		}
+		t := entry.Record.BrushDateTime
+		key := dayKey{cardID: person.CardID, date: t.Format(time.DateOnly)}
+		day := days[key]
+		if day == nil {
+			day = &AttendanceDay{
+				Date:         key.date,
+				WorkerNumber: person.WorkerNumber,
+				Name:         person.Name,
+				Department:   person.Department,
+				CardID:       person.CardID,
+			}
+			days[key] = day
+		}
+		direction := entry.Controller.Direction(entry.Record.Door())
+		if direction != cobrafile.DirectionOut && (day.FirstIn == nil || t.Before(*day.FirstIn)) {
+			day.FirstIn = &t
+		}
+		if direction != cobrafile.DirectionIn && (day.LastOut == nil || t.After(*day.LastOut)) {
+			day.LastOut = &t
+		}
+	}
+
+	result := &Attendance{
+		From: from,
+		To:   to,
+	}
+	people := map[string]*AttendancePerson{}
+	departments := map[string]*AttendanceDepartment{}
+	departmentPeople := map[string]map[string]bool{}
+	for _, day := range days {
+		if day.FirstIn != nil && day.LastOut != nil && day.LastOut.After(*day.FirstIn) {
+			day.Complete = true
+			day.Hours = hours(day.LastOut.Sub(*day.FirstIn))
+		}
+		result.Days = append(result.Days, *day)
+
+		person := people[day.CardID]
+		if person == nil {
+			person = &AttendancePerson{
+				WorkerNumber: day.WorkerNumber,
+				Name:         day.Name,
+				Department:   day.Department,
+				CardID:       day.CardID,
+			}
+			people[day.CardID] = person
+		}
+		person.Days++
+		person.Hours += day.Hours
+		if !day.Complete {
+			person.Incomplete++
+		}
+
+		department := departments[day.Department]
+		if department == nil {
+			department = &AttendanceDepartment{
+				Department: day.Department,
+			}
+			departments[day.Department] = department
+			departmentPeople[day.Department] = map[string]bool{}
+		}
+		department.Days++
+		department.Hours += day.Hours
+		departmentPeople[day.Department][day.CardID] = true
+	}
+	for _, person := range people {
+		person.Hours = round(person.Hours)
+		result.People = append(result.People, *person)
+	}
+	for name, department := range departments {
+		department.People = len(departmentPeople[name])
+		department.Hours = round(department.Hours)
+		result.Departments = append(result.Departments, *department)
+	}
+
+	sort.Slice(result.Days, func(i, j int) bool {
+		if result.Days[i].Date != result.Days[j].Date {
+			return result.Days[i].Date < result.Days[j].Date
+		}
+		if result.Days[i].Name != result.Days[j].Name {
+			return result.Days[i].Name < result.Days[j].Name
+		}
+		return result.Days[i].CardID < result.Days[j].CardID
+	})
+	sort.Slice(result.People, func(i, j int) bool {
+		if result.People[i].Name != result.People[j].Name {
+			return result.People[i].Name < result.People[j].Name
+		}
+		return result.People[i].CardID < result.People[j].CardID
+	})
+	sort.Slice(result.Departments, func(i, j int) bool {
+		return result.Departments[i].Department < result.Departments[j].Department
+	})
+	return result
+}
+
+// WriteCSV writes one of the tables (see the AttendanceTable* constants) as CSV.
+func (a *Attendance) WriteCSV(w io.Writer, table string) error {
+	writer := csv.NewWriter(w)
+	switch table {
+	case AttendanceTableDays:
+		writer.Write([]string{"Date", "Worker No.", "Name", "Department", "Card ID", "First In", "Last Out", "Hours", "Complete"})
+		for _, day := range a.Days {
+			writer.Write([]string{day.Date, day.WorkerNumber, day.Name, day.Department, day.CardID, formatTime(day.FirstIn), formatTime(day.LastOut), formatHours(day.Hours), fmt.Sprintf("%t", day.Complete)})
+		}
+	case AttendanceTablePeople:
+		writer.Write([]string{"Worker No.", "Name", "Department", "Card ID", "Days", "Hours", "Incomplete Days"})
+		for _, person := range a.People {
+			writer.Write([]string{person.WorkerNumber, person.Name, person.Department, person.CardID, fmt.Sprintf("%d", person.Days), formatHours(person.Hours), fmt.Sprintf("%d", person.Incomplete)})
+		}
+	case AttendanceTableDepartments:
+		writer.Write([]string{"Department", "People", "Days", "Hours"})
+		for _, department := range a.Departments {
+			writer.Write([]string{department.Department, fmt.Sprintf("%d", department.People), fmt.Sprintf("%d", department.Days), formatHours(department.Hours)})
+		}
+	default:
+		return fmt.Errorf("invalid table: %q (expected one of: %s, %s, %s)", table, AttendanceTableDays, AttendanceTablePeople, AttendanceTableDepartments)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// hours returns the duration in hours, rounded to the hundredth.
+func hours(d time.Duration) float64 {
+	return round(d.Hours())
+}
+
+// round rounds to the hundredth.
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// formatHours formats a number of hours for a CSV file.
+func formatHours(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
+
+// formatTime formats an optional time of day for a CSV file.
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.TimeOnly)
+}
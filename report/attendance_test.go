@@ -0,0 +1,95 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tekkamanendless/cobra-controls/cobrafile"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+func TestAttendance(t *testing.T) {
+	controller := cobrafile.Controller{
+		Name:       "front",
+		Doors:      []string{"Entrance", "Exit", "Lab", ""},
+		Directions: []cobrafile.Direction{cobrafile.DirectionIn, cobrafile.DirectionOut, cobrafile.DirectionNone, cobrafile.DirectionNone},
+	}
+	personnelList := cobrafile.PersonnelList{
+		{WorkerNumber: "1", Name: "Alice", CardID: "17823439", Department: "Engineering", Attendance: true},
+		{WorkerNumber: "2", Name: "Bob", CardID: "17823440", Department: "Engineering", Attendance: true},
+		{WorkerNumber: "3", Name: "Carol", CardID: "17823441", Department: "Sales"},
+	}
+	swipe := func(id uint16, door uint8, granted bool, t time.Time) Entry {
+		state := door - 1
+		if !granted {
+			state |= 0b10010000
+		}
+		return Entry{
+			Controller: controller,
+			Record:     wire.Record{IDNumber: id, AreaNumber: 178, RecordState: state, BrushDateTime: t},
+		}
+	}
+	at := func(day int, hour int, minute int) time.Time {
+		return time.Date(2023, 1, day, hour, minute, 0, 0, time.UTC)
+	}
+	entries := []Entry{
+		// Alice: a full day, with a lab visit after she left through the exit.
+		swipe(23439, 3, true, at(2, 7, 30)),
+		swipe(23439, 1, true, at(2, 8, 0)),
+		swipe(23439, 2, true, at(2, 16, 30)),
+		swipe(23439, 1, true, at(2, 17, 0)), // An "in" door never ends the day.
+		// Alice: the next day, with no exit.
+		swipe(23439, 1, true, at(3, 9, 0)),
+		// Bob: a denied swipe does not count.
+		swipe(23440, 1, false, at(2, 6, 0)),
+		swipe(23440, 1, true, at(2, 9, 0)),
+		swipe(23440, 3, true, at(2, 12, 15)),
+		// Carol is not tracked.
+		swipe(23441, 1, true, at(2, 9, 0)),
+		swipe(23441, 2, true, at(2, 10, 0)),
+		// Unknown cards are ignored.
+		swipe(1, 1, true, at(2, 9, 0)),
+	}
+
+	a := BuildAttendance(entries, personnelList, at(1, 0, 0), at(4, 0, 0), false)
+	require.Len(t, a.Days, 3)
+	assert.Equal(t, "2023-01-02", a.Days[0].Date)
+	assert.Equal(t, "Alice", a.Days[0].Name)
+	assert.Equal(t, at(2, 7, 30), *a.Days[0].FirstIn)
+	assert.Equal(t, at(2, 16, 30), *a.Days[0].LastOut)
+	assert.Equal(t, 9.0, a.Days[0].Hours)
+	assert.True(t, a.Days[0].Complete)
+	assert.Equal(t, "Bob", a.Days[1].Name)
+	assert.Equal(t, 3.25, a.Days[1].Hours)
+	assert.Equal(t, "2023-01-03", a.Days[2].Date)
+	assert.Nil(t, a.Days[2].LastOut)
+	assert.False(t, a.Days[2].Complete)
+
+	assert.Equal(t, []AttendancePerson{
+		{WorkerNumber: "1", Name: "Alice", Department: "Engineering", CardID: "17823439", Days: 2, Hours: 9, Incomplete: 1},
+		{WorkerNumber: "2", Name: "Bob", Department: "Engineering", CardID: "17823440", Days: 1, Hours: 3.25},
+	}, a.People)
+	assert.Equal(t, []AttendanceDepartment{
+		{Department: "Engineering", People: 2, Days: 3, Hours: 12.25},
+	}, a.Departments)
+
+	everyone := BuildAttendance(entries, personnelList, at(1, 0, 0), at(4, 0, 0), true)
+	require.Len(t, everyone.Departments, 2)
+	assert.Equal(t, AttendanceDepartment{Department: "Sales", People: 1, Days: 1, Hours: 1}, everyone.Departments[1])
+
+	var buffer bytes.Buffer
+	err := a.WriteCSV(&buffer, AttendanceTableDepartments)
+	require.Nil(t, err)
+	assert.Equal(t, "Department,People,Days,Hours\nEngineering,2,3,12.25\n", buffer.String())
+
+	buffer.Reset()
+	err = a.WriteCSV(&buffer, AttendanceTableDays)
+	require.Nil(t, err)
+	assert.Contains(t, buffer.String(), "2023-01-03,1,Alice,Engineering,17823439,09:00:00,,0.00,false\n")
+
+	err = a.WriteCSV(&buffer, "bogus")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,407 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tekkamanendless/cobra-controls/cobrafile"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+// DefaultTopDoors is the default number of doors in the "busiest doors" list.
+const DefaultTopDoors = 10
+
+// BusinessHours are the hours that a site is normally open.
+type BusinessHours struct {
+	Start time.Duration // This is the time of day (since midnight) that the site opens.
+	End   time.Duration // This is the time of day (since midnight) that the site closes.
+	Days  []time.Weekday
+}
+
+// DefaultBusinessHours is 07:00-19:00, Monday through Friday.
+var DefaultBusinessHours = BusinessHours{
+	Start: 7 * time.Hour,
+	End:   19 * time.Hour,
+	Days:  []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+}
+
+// ParseHours parses a range of hours such as "07:00-19:00".
+func ParseHours(value string) (time.Duration, time.Duration, error) {
+	startValue, endValue, ok := strings.Cut(value, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid hours: %q (expected \"hh:mm-hh:mm\")", value)
+	}
+	parse := func(value string) (time.Duration, error) {
+		t, err := time.Parse("15:04", strings.TrimSpace(value))
+		if err != nil {
+			return 0, fmt.Errorf("invalid time: %q", value)
+		}
+		return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+	}
+	start, err := parse(startValue)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parse(endValue)
+	if err != nil {
+		return 0, 0, err
+	}
+	if end <= start {
+		return 0, 0, fmt.Errorf("invalid hours: %q (the end must be after the start)", value)
+	}
+	return start, end, nil
+}
+
+// Contains returns true if the time (in its own time zone) is during business hours.
+func (b BusinessHours) Contains(t time.Time) bool {
+	openDay := false
+	for _, day := range b.Days {
+		if t.Weekday() == day {
+			openDay = true
+		}
+	}
+	if !openDay {
+		return false
+	}
+	timeOfDay := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	return timeOfDay >= b.Start && timeOfDay < b.End
+}
+
+// AuditOptions configures an audit report.
+type AuditOptions struct {
+	// Permissions are the permissions on each controller, keyed by address;
+	// if a controller is missing, then its cards are not checked against
+	// their permission windows.
+	Permissions   map[string][]wire.GetUploadResponse
+	BusinessHours BusinessHours
+	TopDoors      int // This is the number of doors in the "busiest doors" list; 0 means every door.
+}
+
+// ReasonCount is the number of denied attempts for a reason.
+type ReasonCount struct {
+	Reason string
+	Count  int
+}
+
+// DeniedCount is the number of denied attempts for a card and reason.
+type DeniedCount struct {
+	Reason string
+	CardID string
+	Person string `json:",omitempty"`
+	Count  int
+	Last   time.Time
+}
+
+// AuditEvent is a single record of interest.
+type AuditEvent struct {
+	Time       time.Time
+	Controller string
+	Door       uint8
+	DoorName   string `json:",omitempty"`
+	CardID     string `json:",omitempty"`
+	Person     string `json:",omitempty"`
+	Event      wire.RecordEvent
+	Detail     string `json:",omitempty"`
+}
+
+// UnknownCard is a card that is not in the personnel file.
+type UnknownCard struct {
+	CardID  string
+	Count   int
+	Granted int
+	First   time.Time
+	Last    time.Time
+}
+
+// DoorActivity is the number of records for a door.
+type DoorActivity struct {
+	Controller string
+	Door       uint8
+	DoorName   string `json:",omitempty"`
+	Granted    int
+	Denied     int
+	Other      int // These are buttons, remote opens, sensor changes, and alarms.
+	Total      int
+}
+
+// Audit is an access audit report.
+type Audit struct {
+	From           time.Time
+	To             time.Time
+	Records        int
+	DeniedByReason []ReasonCount
+	Denied         []DeniedCount
+	OutsideWindow  []AuditEvent
+	UnknownCards   []UnknownCard
+	AfterHours     []AuditEvent
+	BusiestDoors   []DoorActivity
+}
+
+// BuildAudit summarizes the records.
+//
+// A card is outside of its permission window when it is used at a door that
+// it has a permission for but not on that date, or when the controller denied
+// it because it was expired or outside of its time period.  After-hours
+// entries are granted cards (and super password opens) outside of the
+// business hours in the controller's time zone.
+func BuildAudit(entries []Entry, personnelList cobrafile.PersonnelList, from time.Time, to time.Time, options AuditOptions) *Audit {
+	result := &Audit{
+		From:    from,
+		To:      to,
+		Records: len(entries),
+	}
+
+	reasons := map[string]*ReasonCount{}
+	type deniedKey struct {
+		reason string
+		cardID string
+	}
+	denied := map[deniedKey]*DeniedCount{}
+	unknown := map[string]*UnknownCard{}
+	type doorKey struct {
+		controller string
+		door       uint8
+	}
+	doors := map[doorKey]*DoorActivity{}
+
+	for _, entry := range entries {
+		record := entry.Record
+		event := record.Event()
+		isCard := event == wire.RecordEventGranted || event == wire.RecordEventDenied
+
+		var person string
+		if isCard {
+			if p := personnelList.FindByCardID(entry.CardID()); p != nil {
+				person = p.Name
+			}
+		}
+		auditEvent := func(detail string) AuditEvent {
+			e := AuditEvent{
+				Time:       record.BrushDateTime,
+				Controller: entry.ControllerName(),
+				Door:       record.Door(),
+				Person:     person,
+				Event:      event,
+				Detail:     detail,
+			}
+			if e.Door != 0 {
+				e.DoorName = entry.DoorName()
+			}
+			if isCard {
+				e.CardID = entry.CardID()
+			}
+			return e
+		}
+
+		if door := record.Door(); door != 0 {
+			key := doorKey{controller: entry.ControllerName(), door: door}
+			activity := doors[key]
+			if activity == nil {
+				activity = &DoorActivity{
+					Controller: key.controller,
+					Door:       door,
+					DoorName:   entry.DoorName(),
+				}
+				doors[key] = activity
+			}
+			switch event {
+			case wire.RecordEventGranted:
+				activity.Granted++
+			case wire.RecordEventDenied:
+				activity.Denied++
+			default:
+				activity.Other++
+			}
+			activity.Total++
+		}
+
+		if event == wire.RecordEventDenied {
+			reason := record.DeniedReason()
+			if reasons[reason] == nil {
+				reasons[reason] = &ReasonCount{Reason: reason}
+			}
+			reasons[reason].Count++
+
+			key := deniedKey{reason: reason, cardID: entry.CardID()}
+			if denied[key] == nil {
+				denied[key] = &DeniedCount{Reason: reason, CardID: key.cardID, Person: person}
+			}
+			denied[key].Count++
+			denied[key].Last = record.BrushDateTime
+		}
+
+		if isCard && person == "" {
+			card := unknown[entry.CardID()]
+			if card == nil {
+				card = &UnknownCard{CardID: entry.CardID(), First: record.BrushDateTime}
+				unknown[entry.CardID()] = card
+			}
+			card.Count++
+			if event == wire.RecordEventGranted {
+				card.Granted++
+			}
+			card.Last = record.BrushDateTime
+		}
+
+		if isCard {
+			if detail, outside := outsideWindow(entry, options.Permissions); outside {
+				result.OutsideWindow = append(result.OutsideWindow, auditEvent(detail))
+			}
+		}
+
+		if (event == wire.RecordEventGranted || event == wire.RecordEventSuperPassword) && !options.BusinessHours.Contains(record.BrushDateTime) {
+			result.AfterHours = append(result.AfterHours, auditEvent(record.BrushDateTime.Weekday().String()))
+		}
+	}
+
+	for _, reason := range reasons {
+		result.DeniedByReason = append(result.DeniedByReason, *reason)
+	}
+	sort.Slice(result.DeniedByReason, func(i, j int) bool {
+		if result.DeniedByReason[i].Count != result.DeniedByReason[j].Count {
+			return result.DeniedByReason[i].Count > result.DeniedByReason[j].Count
+		}
+		return result.DeniedByReason[i].Reason < result.DeniedByReason[j].Reason
+	})
+	for _, d := range denied {
+		result.Denied = append(result.Denied, *d)
+	}
+	sort.Slice(result.Denied, func(i, j int) bool {
+		if result.Denied[i].Count != result.Denied[j].Count {
+			return result.Denied[i].Count > result.Denied[j].Count
+		}
+		if result.Denied[i].CardID != result.Denied[j].CardID {
+			return result.Denied[i].CardID < result.Denied[j].CardID
+		}
+		return result.Denied[i].Reason < result.Denied[j].Reason
+	})
+	for _, card := range unknown {
+		result.UnknownCards = append(result.UnknownCards, *card)
+	}
+	sort.Slice(result.UnknownCards, func(i, j int) bool {
+		if result.UnknownCards[i].Count != result.UnknownCards[j].Count {
+			return result.UnknownCards[i].Count > result.UnknownCards[j].Count
+		}
+		return result.UnknownCards[i].CardID < result.UnknownCards[j].CardID
+	})
+	for _, activity := range doors {
+		result.BusiestDoors = append(result.BusiestDoors, *activity)
+	}
+	sort.Slice(result.BusiestDoors, func(i, j int) bool {
+		a, b := result.BusiestDoors[i], result.BusiestDoors[j]
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		if a.Controller != b.Controller {
+			return a.Controller < b.Controller
+		}
+		return a.Door < b.Door
+	})
+	if options.TopDoors > 0 && len(result.BusiestDoors) > options.TopDoors {
+		result.BusiestDoors = result.BusiestDoors[:options.TopDoors]
+	}
+	return result
+}
+
+// outsideWindow returns whether the card was used outside of its permission
+// window and why.
+func outsideWindow(entry Entry, permissions map[string][]wire.GetUploadResponse) (string, bool) {
+	if entry.Record.DeniedReason() == "card expired or outside of its time period" {
+		return entry.Record.DeniedReason(), true
+	}
+	list, ok := permissions[entry.Controller.Address]
+	if !ok {
+		return "", false
+	}
+	t := entry.Record.BrushDateTime
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	var windows []string
+	for _, permission := range list {
+		if permission.IDNumber != entry.Record.IDNumber || permission.AreaNumber != entry.Record.AreaNumber || permission.DoorNumber != entry.Record.Door() {
+			continue
+		}
+		if !date.Before(permission.StartDate) && !date.After(permission.EndDate) {
+			return "", false
+		}
+		windows = append(windows, permission.StartDate.Format(time.DateOnly)+" to "+permission.EndDate.Format(time.DateOnly))
+	}
+	if len(windows) == 0 {
+		// A card with no permission for the door is not "outside" a window;
+		// it is denied as "no permission".
+		return "", false
+	}
+	return "permitted " + strings.Join(windows, ", "), true
+}
+
+// WriteText writes the report for a person to read.
+func (a *Audit) WriteText(w io.Writer) error {
+	var err error
+	printf := func(format string, args ...any) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+	section := func(title string, count int) {
+		printf("\n%s (%d)\n", title, count)
+	}
+	formatEvent := func(e AuditEvent) string {
+		parts := []string{e.Time.Format(time.DateTime), "Controller: " + e.Controller}
+		if e.Door != 0 {
+			door := fmt.Sprintf("%d", e.Door)
+			if e.DoorName != "" && e.DoorName != door {
+				door += " (" + e.DoorName + ")"
+			}
+			parts = append(parts, "Door: "+door)
+		}
+		if e.CardID != "" {
+			parts = append(parts, "Card ID: "+e.CardID)
+		}
+		if e.Person != "" {
+			parts = append(parts, "Name: "+e.Person)
+		}
+		parts = append(parts, "Event: "+string(e.Event))
+		if e.Detail != "" {
+			parts = append(parts, "Detail: "+e.Detail)
+		}
+		return strings.Join(parts, " | ")
+	}
+
+	printf("From: %s | To: %s | Records: %d\n", a.From.Format(time.DateTime), a.To.Format(time.DateTime), a.Records)
+
+	section("Denied attempts by reason", len(a.DeniedByReason))
+	for _, reason := range a.DeniedByReason {
+		printf("Reason: %s | Count: %d\n", reason.Reason, reason.Count)
+	}
+	section("Denied attempts by card", len(a.Denied))
+	for _, d := range a.Denied {
+		name := ""
+		if d.Person != "" {
+			name = " | Name: " + d.Person
+		}
+		printf("Card ID: %s%s | Reason: %s | Count: %d | Last: %s\n", d.CardID, name, d.Reason, d.Count, d.Last.Format(time.DateTime))
+	}
+	section("Cards used outside of their permission windows", len(a.OutsideWindow))
+	for _, e := range a.OutsideWindow {
+		printf("%s\n", formatEvent(e))
+	}
+	section("Unknown cards", len(a.UnknownCards))
+	for _, card := range a.UnknownCards {
+		printf("Card ID: %s | Count: %d | Granted: %d | First: %s | Last: %s\n", card.CardID, card.Count, card.Granted, card.First.Format(time.DateTime), card.Last.Format(time.DateTime))
+	}
+	section("After-hours entries", len(a.AfterHours))
+	for _, e := range a.AfterHours {
+		printf("%s\n", formatEvent(e))
+	}
+	section("Busiest doors", len(a.BusiestDoors))
+	for _, d := range a.BusiestDoors {
+		door := fmt.Sprintf("%d", d.Door)
+		if d.DoorName != "" && d.DoorName != door {
+			door += " (" + d.DoorName + ")"
+		}
+		printf("Controller: %s | Door: %s | Total: %d | Granted: %d | Denied: %d | Other: %d\n", d.Controller, door, d.Total, d.Granted, d.Denied, d.Other)
+	}
+	return err
+}
@@ -0,0 +1,134 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tekkamanendless/cobra-controls/cobrafile"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+func TestParseHours(t *testing.T) {
+	rows := []struct {
+		input string
+		start time.Duration
+		end   time.Duration
+		valid bool
+	}{
+		{input: "07:00-19:00", start: 7 * time.Hour, end: 19 * time.Hour, valid: true},
+		{input: "8:30 - 17:15", start: 8*time.Hour + 30*time.Minute, end: 17*time.Hour + 15*time.Minute, valid: true},
+		{input: "19:00-07:00"},
+		{input: "07:00"},
+		{input: "7am-7pm"},
+	}
+	for _, row := range rows {
+		t.Run(row.input, func(t *testing.T) {
+			start, end, err := ParseHours(row.input)
+			if !row.valid {
+				assert.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, row.start, start)
+			assert.Equal(t, row.end, end)
+		})
+	}
+}
+
+func TestBusinessHours(t *testing.T) {
+	// 2023-01-02 is a Monday.
+	assert.True(t, DefaultBusinessHours.Contains(time.Date(2023, 1, 2, 7, 0, 0, 0, time.UTC)))
+	assert.True(t, DefaultBusinessHours.Contains(time.Date(2023, 1, 2, 18, 59, 59, 0, time.UTC)))
+	assert.False(t, DefaultBusinessHours.Contains(time.Date(2023, 1, 2, 19, 0, 0, 0, time.UTC)))
+	assert.False(t, DefaultBusinessHours.Contains(time.Date(2023, 1, 2, 6, 59, 0, 0, time.UTC)))
+	assert.False(t, DefaultBusinessHours.Contains(time.Date(2023, 1, 7, 12, 0, 0, 0, time.UTC)))
+}
+
+func TestAudit(t *testing.T) {
+	controller := cobrafile.Controller{
+		Name:    "front",
+		Address: "10.0.0.1",
+		Doors:   []string{"Entrance", "Lab", "", ""},
+	}
+	personnelList := cobrafile.PersonnelList{
+		{Name: "Alice", CardID: "17823439"},
+		{Name: "Bob", CardID: "17823440"},
+	}
+	at := func(day int, hour int) time.Time {
+		return time.Date(2023, 1, day, hour, 0, 0, 0, time.UTC)
+	}
+	record := func(area uint8, id uint16, state uint8, t time.Time) Entry {
+		return Entry{
+			Controller: controller,
+			Record:     wire.Record{IDNumber: id, AreaNumber: area, RecordState: state, BrushDateTime: t},
+		}
+	}
+	entries := []Entry{
+		record(178, 23439, 0b00000000, at(2, 9)),  // Alice, door 1.
+		record(178, 23439, 0b00000001, at(2, 10)), // Alice, door 2, after her lab permission ended.
+		record(178, 23439, 0b00000000, at(2, 22)), // Alice, after hours.
+		record(178, 23440, 0b10010000, at(2, 11)), // Bob, no permission.
+		record(178, 23440, 0b10010000, at(3, 11)), // Bob, no permission.
+		record(178, 23440, 0b11100001, at(3, 12)), // Bob, expired.
+		record(1, 2, 0b10010000, at(3, 13)),       // An unknown card.
+		record(1, 2, 0b00000000, at(7, 9)),        // An unknown card, on a Saturday.
+		record(0, 0b0000, 0b00000000, at(3, 14)),  // The exit button on door 1.
+		record(0, 0b0101, 0b00000001, at(3, 23)),  // The super password on door 2, after hours.
+		record(0, 0b0100, 0b10100000, at(3, 15)),  // The fire alarm has no door.
+	}
+	options := AuditOptions{
+		Permissions: map[string][]wire.GetUploadResponse{
+			"10.0.0.1": {
+				{IDNumber: 23439, AreaNumber: 178, DoorNumber: 1, StartDate: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)},
+				{IDNumber: 23439, AreaNumber: 178, DoorNumber: 2, StartDate: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		BusinessHours: DefaultBusinessHours,
+		TopDoors:      1,
+	}
+
+	Sort(entries)
+	a := BuildAudit(entries, personnelList, at(1, 0), at(8, 0), options)
+	assert.Equal(t, len(entries), a.Records)
+	assert.Equal(t, []ReasonCount{
+		{Reason: "no permission", Count: 3},
+		{Reason: "card expired or outside of its time period", Count: 1},
+	}, a.DeniedByReason)
+	require.Len(t, a.Denied, 3)
+	assert.Equal(t, DeniedCount{Reason: "no permission", CardID: "17823440", Person: "Bob", Count: 2, Last: at(3, 11)}, a.Denied[0])
+
+	require.Len(t, a.OutsideWindow, 2)
+	assert.Equal(t, "17823439", a.OutsideWindow[0].CardID)
+	assert.Equal(t, "Lab", a.OutsideWindow[0].DoorName)
+	assert.Equal(t, "permitted 2022-01-01 to 2023-01-01", a.OutsideWindow[0].Detail)
+	assert.Equal(t, "17823440", a.OutsideWindow[1].CardID)
+	assert.Equal(t, "card expired or outside of its time period", a.OutsideWindow[1].Detail)
+
+	assert.Equal(t, []UnknownCard{
+		{CardID: "100002", Count: 2, Granted: 1, First: at(3, 13), Last: at(7, 9)},
+	}, a.UnknownCards)
+
+	require.Len(t, a.AfterHours, 3)
+	assert.Equal(t, at(2, 22), a.AfterHours[0].Time)
+	assert.Equal(t, wire.RecordEventSuperPassword, a.AfterHours[1].Event)
+	assert.Equal(t, "100002", a.AfterHours[2].CardID)
+
+	assert.Equal(t, []DoorActivity{
+		{Controller: "front", Door: 1, DoorName: "Entrance", Granted: 3, Denied: 3, Other: 1, Total: 7},
+	}, a.BusiestDoors)
+
+	// Without the permissions, only the controller's own denials are outside of a window.
+	options.Permissions = nil
+	a = BuildAudit(entries, personnelList, at(1, 0), at(8, 0), options)
+	require.Len(t, a.OutsideWindow, 1)
+
+	var buffer bytes.Buffer
+	err := a.WriteText(&buffer)
+	require.Nil(t, err)
+	assert.Contains(t, buffer.String(), "Reason: no permission | Count: 3\n")
+	assert.Contains(t, buffer.String(), "Card ID: 17823440 | Name: Bob | Reason: no permission | Count: 2 | Last: 2023-01-03 11:00:00\n")
+	assert.Contains(t, buffer.String(), "Controller: front | Door: 1 (Entrance) | Total: 7 | Granted: 3 | Denied: 3 | Other: 1\n")
+}
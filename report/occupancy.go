@@ -0,0 +1,208 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tekkamanendless/cobra-controls/cobrafile"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+// DefaultMaxAge is how long someone is assumed to be on site after their last
+// swipe if they never swipe out.
+const DefaultMaxAge = 16 * time.Hour
+
+// Occupant is a person who is on site.
+type Occupant struct {
+	CardID       string
+	WorkerNumber string    `json:",omitempty"`
+	Name         string    `json:",omitempty"`
+	Department   string    `json:",omitempty"`
+	Entered      time.Time // This is the time of the first swipe on site.
+	LastSeen     time.Time
+	Controller   string // This is where the person was last seen.
+	Door         uint8
+	DoorName     string `json:",omitempty"`
+}
+
+// Occupancy tracks who is on site from the access records.
+//
+// A granted card at an "in" door puts the person on site, and a granted card
+// at an "out" door takes them off (see the door directions in the controller
+// file).  Any other door only updates where a person was last seen, unless
+// none of the controller's doors have a direction, in which case every granted
+// card puts the person on site.  Denied cards are ignored.
+//
+// An occupancy is safe to use from multiple goroutines.
+type Occupancy struct {
+	PersonnelList cobrafile.PersonnelList
+	MaxAge        time.Duration // This is how long someone stays on site without a swipe; 0 means forever.
+
+	mutex     sync.Mutex
+	occupants map[string]*Occupant
+}
+
+// NewOccupancy returns an empty occupancy.
+func NewOccupancy(personnelList cobrafile.PersonnelList, maxAge time.Duration) *Occupancy {
+	return &Occupancy{
+		PersonnelList: personnelList,
+		MaxAge:        maxAge,
+		occupants:     map[string]*Occupant{},
+	}
+}
+
+// Observe updates the occupancy with the record.
+//
+// This returns true if the record is a fire alarm.
+func (o *Occupancy) Observe(entry Entry) bool {
+	record := entry.Record
+	switch record.Event() {
+	case wire.RecordEventFireAlarm:
+		return true
+	case wire.RecordEventGranted:
+	default:
+		return false
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	cardID := entry.CardID()
+	occupant := o.occupants[cardID]
+	direction := entry.Controller.Direction(record.Door())
+	if !hasDirections(entry.Controller) {
+		direction = cobrafile.DirectionIn
+	}
+	switch direction {
+	case cobrafile.DirectionOut:
+		delete(o.occupants, cardID)
+		return false
+	case cobrafile.DirectionNone:
+		if occupant == nil {
+			return false
+		}
+	case cobrafile.DirectionIn:
+		if occupant == nil {
+			occupant = &Occupant{
+				CardID:  cardID,
+				Entered: record.BrushDateTime,
+			}
+			if person := o.PersonnelList.FindByCardID(cardID); person != nil {
+				occupant.WorkerNumber = person.WorkerNumber
+				occupant.Name = person.Name
+				occupant.Department = person.Department
+			}
+			o.occupants[cardID] = occupant
+		}
+	}
+	occupant.LastSeen = record.BrushDateTime
+	occupant.Controller = entry.ControllerName()
+	occupant.Door = record.Door()
+	occupant.DoorName = entry.DoorName()
+	return false
+}
+
+// hasDirections returns true if any of the controller's doors has a direction.
+func hasDirections(controller cobrafile.Controller) bool {
+	for _, direction := range controller.Directions {
+		if direction != cobrafile.DirectionNone {
+			return true
+		}
+	}
+	return false
+}
+
+// Occupants returns everyone on site at the given time, sorted by department
+// and then by name.
+func (o *Occupancy) Occupants(now time.Time) []Occupant {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	var result []Occupant
+	for cardID, occupant := range o.occupants {
+		if o.MaxAge > 0 && now.Sub(occupant.LastSeen) > o.MaxAge {
+			delete(o.occupants, cardID)
+			continue
+		}
+		result = append(result, *occupant)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Department != result[j].Department {
+			return result[i].Department < result[j].Department
+		}
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].CardID < result[j].CardID
+	})
+	return result
+}
+
+// Muster is the list of everyone on site, for a roll call.
+type Muster struct {
+	Time       time.Time
+	Alarm      *time.Time `json:",omitempty"` // This is the time of the fire alarm, if there was one.
+	Controller string     `json:",omitempty"` // This is the controller that reported the fire alarm.
+	Occupants  []Occupant
+}
+
+// Muster returns the list of everyone on site at the given time.
+func (o *Occupancy) Muster(now time.Time) *Muster {
+	return &Muster{
+		Time:      now,
+		Occupants: o.Occupants(now),
+	}
+}
+
+// WriteText writes the muster report so that it can be printed and checked
+// off.
+func (m *Muster) WriteText(w io.Writer) error {
+	var err error
+	printf := func(format string, args ...any) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+
+	printf("MUSTER REPORT\n")
+	printf("Time: %s\n", m.Time.Format(time.DateTime))
+	if m.Alarm != nil {
+		printf("Fire Alarm: %s | Controller: %s\n", m.Alarm.Format(time.DateTime), m.Controller)
+	}
+	printf("On Site: %d\n", len(m.Occupants))
+	department := ""
+	for i, occupant := range m.Occupants {
+		if i == 0 || occupant.Department != department {
+			department = occupant.Department
+			name := department
+			if name == "" {
+				name = "(No Department)"
+			}
+			printf("\n%s\n", name)
+		}
+		parts := []string{"[ ] " + occupantName(occupant)}
+		if occupant.WorkerNumber != "" {
+			parts = append(parts, "Worker No.: "+occupant.WorkerNumber)
+		}
+		parts = append(parts,
+			"Card ID: "+occupant.CardID,
+			"Entered: "+occupant.Entered.Format(time.DateTime),
+			fmt.Sprintf("Last Seen: %s (%s, door %s)", occupant.LastSeen.Format(time.DateTime), occupant.Controller, occupant.DoorName),
+		)
+		printf("%s\n", strings.Join(parts, " | "))
+	}
+	return err
+}
+
+// occupantName returns the name of the occupant, or a placeholder if the
+// card is not in the personnel file.
+func occupantName(occupant Occupant) string {
+	if occupant.Name != "" {
+		return occupant.Name
+	}
+	return "Unknown card"
+}
@@ -0,0 +1,83 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tekkamanendless/cobra-controls/cobrafile"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+func TestOccupancy(t *testing.T) {
+	front := cobrafile.Controller{
+		Name:       "front",
+		Doors:      []string{"Entrance", "Exit", "Lab", ""},
+		Directions: []cobrafile.Direction{cobrafile.DirectionIn, cobrafile.DirectionOut, cobrafile.DirectionNone, cobrafile.DirectionNone},
+	}
+	warehouse := cobrafile.Controller{
+		Name:  "warehouse",
+		Doors: []string{"Dock", "", "", ""},
+	}
+	personnelList := cobrafile.PersonnelList{
+		{WorkerNumber: "1", Name: "Alice", CardID: "17823439", Department: "Engineering"},
+		{WorkerNumber: "2", Name: "Bob", CardID: "17823440", Department: "Engineering"},
+		{WorkerNumber: "3", Name: "Carol", CardID: "17823441", Department: "Sales"},
+	}
+	at := func(hour int, minute int) time.Time {
+		return time.Date(2023, 1, 2, hour, minute, 0, 0, time.UTC)
+	}
+	swipe := func(controller cobrafile.Controller, id uint16, door uint8, granted bool, t time.Time) Entry {
+		state := door - 1
+		if !granted {
+			state |= 0b10010000
+		}
+		return Entry{
+			Controller: controller,
+			Record:     wire.Record{IDNumber: id, AreaNumber: 178, RecordState: state, BrushDateTime: t},
+		}
+	}
+
+	o := NewOccupancy(personnelList, 4*time.Hour)
+	entries := []Entry{
+		swipe(front, 23439, 1, true, at(8, 0)),   // Alice comes in.
+		swipe(front, 23439, 3, true, at(9, 0)),   // Alice goes to the lab.
+		swipe(front, 23440, 3, true, at(8, 30)),  // Bob was never seen coming in.
+		swipe(front, 23440, 1, false, at(8, 45)), // Bob is denied.
+		swipe(front, 23441, 1, true, at(8, 0)),   // Carol comes in.
+		swipe(front, 23441, 2, true, at(9, 30)),  // Carol leaves.
+		swipe(warehouse, 1, 1, true, at(5, 0)),   // An unknown card at a controller with no directions; this is too old.
+		swipe(warehouse, 2, 1, true, at(9, 45)),  // An unknown card at a controller with no directions.
+	}
+	for _, entry := range entries {
+		assert.False(t, o.Observe(entry))
+	}
+	fireAlarm := Entry{Controller: front, Record: wire.Record{IDNumber: 0b0100, RecordState: 0b10100000, BrushDateTime: at(10, 0)}}
+	assert.True(t, o.Observe(fireAlarm))
+
+	occupants := o.Occupants(at(10, 0))
+	require.Len(t, occupants, 2)
+	assert.Equal(t, "17800002", occupants[0].CardID)
+	assert.Equal(t, "warehouse", occupants[0].Controller)
+	assert.Equal(t, Occupant{
+		CardID:       "17823439",
+		WorkerNumber: "1",
+		Name:         "Alice",
+		Department:   "Engineering",
+		Entered:      at(8, 0),
+		LastSeen:     at(9, 0),
+		Controller:   "front",
+		Door:         3,
+		DoorName:     "Lab",
+	}, occupants[1])
+
+	muster := o.Muster(at(10, 0))
+	var buffer bytes.Buffer
+	err := muster.WriteText(&buffer)
+	require.Nil(t, err)
+	assert.Contains(t, buffer.String(), "On Site: 2\n")
+	assert.Contains(t, buffer.String(), "\n(No Department)\n[ ] Unknown card | Card ID: 17800002 | ")
+	assert.Contains(t, buffer.String(), "\nEngineering\n[ ] Alice | Worker No.: 1 | Card ID: 17823439 | Entered: 2023-01-02 08:00:00 | Last Seen: 2023-01-02 09:00:00 (front, door Lab)\n")
+}
@@ -0,0 +1,85 @@
+// Package report builds reports from the access records on the controllers.
+package report
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tekkamanendless/cobra-controls/cobrafile"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+// Entry is an access record from a controller.
+type Entry struct {
+	Controller cobrafile.Controller
+	Index      uint32
+	Record     wire.Record
+}
+
+// ControllerName returns the name of the controller (or its address).
+func (e Entry) ControllerName() string {
+	if e.Controller.Name != "" {
+		return e.Controller.Name
+	}
+	return e.Controller.Address
+}
+
+// DoorName returns the name of the door (or its number).
+func (e Entry) DoorName() string {
+	door := e.Record.Door()
+	if door > 0 && int(door) <= len(e.Controller.Doors) && e.Controller.Doors[door-1] != "" {
+		return e.Controller.Doors[door-1]
+	}
+	return fmt.Sprintf("%d", door)
+}
+
+// CardID returns the card ID of the record.
+func (e Entry) CardID() string {
+	return wire.CardID(e.Record.AreaNumber, e.Record.IDNumber)
+}
+
+// Fetch returns the controller's records from the given time range (the end
+// is exclusive).
+//
+// The records are read from the newest to the oldest, stopping at the first one
+// before the start, so this assumes that the records are in order.
+func Fetch(client *wire.Client, controller cobrafile.Controller, from time.Time, to time.Time) ([]Entry, error) {
+	status, err := client.OperationStatus(0)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Debugf("Controller %s: %d record(s)", controller.Name, status.RecordCount)
+
+	var result []Entry
+	for index := status.RecordCount; index >= 1; index-- {
+		response, err := client.OperationStatus(index)
+		if err != nil {
+			return nil, fmt.Errorf("could not read record %d: %w", index, err)
+		}
+		if response.Record == nil {
+			continue
+		}
+		if response.Record.BrushDateTime.Before(from) {
+			break
+		}
+		if !response.Record.BrushDateTime.Before(to) {
+			continue
+		}
+		result = append(result, Entry{
+			Controller: controller,
+			Index:      index,
+			Record:     *response.Record,
+		})
+	}
+	Sort(result)
+	return result, nil
+}
+
+// Sort sorts the entries by time.
+func Sort(entries []Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Record.BrushDateTime.Before(entries[j].Record.BrushDateTime)
+	})
+}
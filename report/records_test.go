@@ -0,0 +1,45 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tekkamanendless/cobra-controls/cobrafile"
+	"github.com/tekkamanendless/cobra-controls/wire"
+	"github.com/tekkamanendless/cobra-controls/wire/wiretest"
+)
+
+func TestFetch(t *testing.T) {
+	fake, err := wiretest.NewController(0xF257)
+	require.Nil(t, err)
+	defer fake.Close()
+	for day := 1; day <= 5; day++ {
+		fake.AddRecord(wire.Record{IDNumber: 23439, AreaNumber: 178, BrushDateTime: time.Date(2023, 1, day, 9, 0, 0, 0, time.UTC)})
+	}
+
+	controller := cobrafile.Controller{Name: "front", Address: fake.Address()}
+	entries, err := Fetch(fake.Client(), controller, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), time.Date(2023, 1, 4, 0, 0, 0, 0, time.UTC))
+	require.Nil(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, uint32(2), entries[0].Index)
+	assert.Equal(t, uint32(3), entries[1].Index)
+	assert.Equal(t, "front", entries[0].ControllerName())
+	assert.Equal(t, "17823439", entries[0].CardID())
+
+	// The newest records are read first, and reading stops at the first one before the start.
+	requests := fake.Requests()
+	assert.Len(t, requests, 1+5)
+}
+
+func TestEntry(t *testing.T) {
+	entry := Entry{
+		Controller: cobrafile.Controller{Address: "10.0.0.1", Doors: []string{"Lobby", "", "", ""}},
+		Record:     wire.Record{IDNumber: 23439, AreaNumber: 178, RecordState: 0b00000001},
+	}
+	assert.Equal(t, "10.0.0.1", entry.ControllerName())
+	assert.Equal(t, "2", entry.DoorName())
+	entry.Record.RecordState = 0
+	assert.Equal(t, "Lobby", entry.DoorName())
+}
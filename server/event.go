@@ -0,0 +1,57 @@
+package server
+
+import (
+	"time"
+
+	"github.com/tekkamanendless/cobra-controls/cobrafile"
+	"github.com/tekkamanendless/cobra-controls/report"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+// Event is an access record from a controller.
+type Event struct {
+	Index        uint32 // This is the index of the record on the controller (the first record is 1).
+	Time         time.Time
+	Controller   string
+	Door         uint8  `json:",omitempty"` // This is zero for events that apply to the whole controller.
+	DoorName     string `json:",omitempty"`
+	CardID       string `json:",omitempty"` // This is empty for events that do not involve a card (for example, a button).
+	Person       string `json:",omitempty"`
+	Event        wire.RecordEvent
+	Granted      bool
+	DeniedReason string `json:",omitempty"`
+	Alarm        bool   `json:",omitempty"`
+	// Muster is everyone on site; this is only set on a fire alarm, and only
+	// if the server is tracking occupancy.
+	Muster *report.Muster `json:",omitempty"`
+}
+
+// NewEvent returns the event for the record.
+func NewEvent(controllerList cobrafile.ControllerList, personnelList cobrafile.PersonnelList, controller cobrafile.Controller, index uint32, record wire.Record) Event {
+	event := Event{
+		Index:        index,
+		Time:         record.BrushDateTime,
+		Controller:   controller.Name,
+		Door:         record.Door(),
+		Event:        record.Event(),
+		DeniedReason: record.DeniedReason(),
+		Alarm:        record.IsAlarm(),
+	}
+	if event.Controller == "" {
+		event.Controller = controller.Address
+	}
+	if event.Door != 0 {
+		event.DoorName = controllerList.LookupDoor(controller.Address, event.Door)
+	}
+	switch event.Event {
+	case wire.RecordEventGranted, wire.RecordEventDenied:
+		event.CardID = wire.CardID(record.AreaNumber, record.IDNumber)
+		if person := personnelList.FindByCardID(event.CardID); person != nil {
+			event.Person = person.Name
+		}
+		event.Granted = event.Event == wire.RecordEventGranted
+	case wire.RecordEventButton, wire.RecordEventRemoteOpen, wire.RecordEventSuperPassword:
+		event.Granted = true
+	}
+	return event
+}
@@ -0,0 +1,490 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+const (
+	// DefaultHistoryLimit is the number of events returned by the history
+	// endpoint if no limit is given.
+	DefaultHistoryLimit = 50
+	// MaxHistoryLimit is the largest number of events that the history endpoint
+	// will return at once.
+	MaxHistoryLimit = 500
+)
+
+// These are the default dates for a new permission.
+var (
+	DefaultStartDate = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	DefaultEndDate   = time.Date(2050, 12, 31, 0, 0, 0, 0, time.UTC)
+)
+
+// ControllerInfo is a controller from the controller file.
+type ControllerInfo struct {
+	Name    string
+	Address string
+	Port    uint16
+	SN      uint16
+	Doors   []DoorInfo
+}
+
+// DoorInfo is a door on a controller.
+type DoorInfo struct {
+	Door uint8
+	Name string `json:",omitempty"`
+}
+
+// ControllerStatus is the current state of a controller.
+type ControllerStatus struct {
+	Controller       string
+	Time             time.Time
+	Drift            string  // This is how far the controller is ahead of the server (negative means behind).
+	DriftSeconds     float64 // This is the same as "Drift", in seconds.
+	RecordCount      uint32
+	PermissionCount  uint16
+	Fault            uint8
+	FaultDescription string
+	Doors            []DoorStatus
+}
+
+// DoorStatus is the current state of a door.
+type DoorStatus struct {
+	Door           uint8
+	Name           string `json:",omitempty"`
+	RelayEnergized bool
+	Open           bool
+}
+
+// OpenDoorResponse is the response to opening a door.
+type OpenDoorResponse struct {
+	Controller string
+	Door       uint8
+	DoorName   string `json:",omitempty"`
+}
+
+// HistoryResponse is a page of events, newest first.
+type HistoryResponse struct {
+	Events []Event
+	Next   uint32 `json:",omitempty"` // Pass this as "before" to get the next page; this is zero on the last page.
+}
+
+// Permission is a card's permission for a door.
+type Permission struct {
+	CardID    string
+	Person    string `json:",omitempty"`
+	Door      uint8
+	DoorName  string `json:",omitempty"`
+	StartDate string // YYYY-MM-DD
+	EndDate   string // YYYY-MM-DD
+}
+
+// PermissionInput is the request to add (or update) a permission.
+//
+// When a permission is updated, anything that is not given is kept.
+type PermissionInput struct {
+	CardID    string
+	Door      DoorReference
+	StartDate string `json:",omitempty"` // YYYY-MM-DD; defaults to the existing start date, or "DefaultStartDate".
+	EndDate   string `json:",omitempty"` // YYYY-MM-DD; defaults to the existing end date, or "DefaultEndDate".
+}
+
+// DoorReference is either a door number (1-4) or a door name.
+//
+// In JSON, this may be either a number or a string.
+type DoorReference string
+
+func (d *DoorReference) UnmarshalJSON(data []byte) error {
+	var number uint8
+	if err := json.Unmarshal(data, &number); err == nil {
+		*d = DoorReference(strconv.Itoa(int(number)))
+		return nil
+	}
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	*d = DoorReference(name)
+	return nil
+}
+
+// DeletePermissionResponse is the response to deleting a permission.
+type DeletePermissionResponse struct {
+	CardID string
+	Door   uint8
+}
+
+// SyncTimeResponse is the response to setting a controller's time.
+type SyncTimeResponse struct {
+	Controller   string
+	PreviousTime time.Time
+	Time         time.Time
+	Drift        string  // This is how far the controller was ahead of the server (negative means behind).
+	DriftSeconds float64 // This is the same as "Drift", in seconds.
+}
+
+func (s *Server) listControllers() []ControllerInfo {
+	result := []ControllerInfo{}
+	for _, c := range s.controllers {
+		result = append(result, s.controllerInfo(c))
+	}
+	return result
+}
+
+func (s *Server) controllerInfo(c *controller) ControllerInfo {
+	info := ControllerInfo{
+		Name:    c.Name,
+		Address: c.Address,
+		Port:    c.Port,
+		SN:      c.SN,
+	}
+	for door := uint8(1); door <= 4; door++ {
+		info.Doors = append(info.Doors, DoorInfo{
+			Door: door,
+			Name: s.ControllerList.LookupDoor(c.Address, door),
+		})
+	}
+	return info
+}
+
+// lookupDoor returns the door number for the reference.
+func (s *Server) lookupDoor(c *controller, reference string) (uint8, error) {
+	if v, err := strconv.ParseUint(reference, 10, 8); err == nil {
+		if v < 1 || v > 4 {
+			return 0, newError(http.StatusNotFound, "no such door: %s", reference)
+		}
+		return uint8(v), nil
+	}
+	if door, ok := s.ControllerList.FindDoor(c.Address, reference); ok {
+		return door, nil
+	}
+	return 0, newError(http.StatusNotFound, "no such door: %s", reference)
+}
+
+func (s *Server) controllerStatus(c *controller) (*ControllerStatus, error) {
+	var status *wire.GetOperationStatusResponse
+	var currentTime time.Time
+	err := c.do(func(client *wire.Client) error {
+		currentTime = client.WallClock(time.Now())
+		var err error
+		status, err = client.OperationStatus(0)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	drift := status.CurrentTime.Sub(currentTime)
+	result := &ControllerStatus{
+		Controller:       c.Name,
+		Time:             status.CurrentTime,
+		Drift:            drift.String(),
+		DriftSeconds:     drift.Seconds(),
+		RecordCount:      status.RecordCount,
+		PermissionCount:  status.PopedomAmount,
+		Fault:            uint8(status.FaultNumber),
+		FaultDescription: status.FaultNumber.Description(),
+	}
+	for door := uint8(1); door <= 4; door++ {
+		result.Doors = append(result.Doors, DoorStatus{
+			Door:           door,
+			Name:           s.ControllerList.LookupDoor(c.Address, door),
+			RelayEnergized: status.RelayStatus.Energized(door),
+			Open:           status.MagnetState.DoorOpen(door),
+		})
+	}
+	return result, nil
+}
+
+func (s *Server) doorStatus(c *controller, reference string) (*DoorStatus, error) {
+	door, err := s.lookupDoor(c, reference)
+	if err != nil {
+		return nil, err
+	}
+	status, err := s.controllerStatus(c)
+	if err != nil {
+		return nil, err
+	}
+	return &status.Doors[door-1], nil
+}
+
+func (s *Server) openDoor(c *controller, reference string) (*OpenDoorResponse, error) {
+	door, err := s.lookupDoor(c, reference)
+	if err != nil {
+		return nil, err
+	}
+	err = c.do(func(client *wire.Client) error {
+		request := wire.Defaults(wire.OpenDoorRequest{
+			Door: door,
+		})
+		var response wire.OpenDoorResponse
+		return client.Do(wire.FunctionOpenDoor, &request, &response)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &OpenDoorResponse{
+		Controller: c.Name,
+		Door:       door,
+		DoorName:   s.ControllerList.LookupDoor(c.Address, door),
+	}, nil
+}
+
+func (s *Server) history(c *controller, r *http.Request) (*HistoryResponse, error) {
+	query := r.URL.Query()
+	limit := DefaultHistoryLimit
+	if value := query.Get("limit"); value != "" {
+		v, err := strconv.Atoi(value)
+		if err != nil || v < 1 {
+			return nil, newError(http.StatusBadRequest, "invalid limit: %s", value)
+		}
+		limit = min(v, MaxHistoryLimit)
+	}
+	var before uint32
+	if value := query.Get("before"); value != "" {
+		v, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || v < 1 {
+			return nil, newError(http.StatusBadRequest, "invalid before: %s", value)
+		}
+		before = uint32(v)
+	}
+
+	result := &HistoryResponse{
+		Events: []Event{},
+	}
+	err := c.do(func(client *wire.Client) error {
+		status, err := client.OperationStatus(0)
+		if err != nil {
+			return err
+		}
+		index := status.RecordCount
+		if before != 0 && before-1 < index {
+			index = before - 1
+		}
+		for ; index >= 1 && len(result.Events) < limit; index-- {
+			status, err := client.OperationStatus(index)
+			if err != nil {
+				return err
+			}
+			if status.Record == nil {
+				continue
+			}
+			result.Events = append(result.Events, NewEvent(s.ControllerList, s.PersonnelList, c.Controller, index, *status.Record))
+		}
+		if index >= 1 {
+			result.Next = index + 1
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Server) listPermissions(c *controller, cardID string) ([]Permission, error) {
+	var permissions []wire.GetUploadResponse
+	err := c.do(func(client *wire.Client) error {
+		var err error
+		permissions, err = client.Permissions()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := []Permission{}
+	for _, permission := range permissions {
+		permissionCardID := wire.CardID(permission.AreaNumber, permission.IDNumber)
+		if cardID != "" && permissionCardID != cardID {
+			continue
+		}
+		p := Permission{
+			CardID:    permissionCardID,
+			Door:      permission.DoorNumber,
+			DoorName:  s.ControllerList.LookupDoor(c.Address, permission.DoorNumber),
+			StartDate: permission.StartDate.Format(time.DateOnly),
+			EndDate:   permission.EndDate.Format(time.DateOnly),
+		}
+		if person := s.PersonnelList.FindByCardID(permissionCardID); person != nil {
+			p.Person = person.Name
+		}
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+func (s *Server) putPermission(c *controller, input PermissionInput) (*Permission, error) {
+	area, cardID, err := wire.ParseCardID(input.CardID)
+	if err != nil {
+		return nil, newError(http.StatusBadRequest, "%v", err)
+	}
+	door, err := s.lookupDoor(c, string(input.Door))
+	if err != nil {
+		return nil, err
+	}
+	parseDate := func(value string) (*time.Time, error) {
+		if value == "" {
+			return nil, nil
+		}
+		t, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return nil, newError(http.StatusBadRequest, "invalid date: %s", value)
+		}
+		return &t, nil
+	}
+	startDate, err := parseDate(input.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	endDate, err := parseDate(input.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	// An existing permission keeps everything that is not given (including its
+	// PIN, time index, first card flag, and group).
+	var request wire.UpdatePermissionsRequest
+	err = c.do(func(client *wire.Client) error {
+		existing, err := client.Permission(area, cardID, door)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			request = existing.UpdateRequest()
+		} else {
+			request = wire.Defaults(wire.UpdatePermissionsRequest{
+				CardID:    cardID,
+				Area:      area,
+				Door:      door,
+				StartDate: DefaultStartDate,
+				EndDate:   DefaultEndDate,
+			})
+		}
+		if startDate != nil {
+			request.StartDate = *startDate
+		}
+		if endDate != nil {
+			request.EndDate = *endDate
+		}
+		if request.EndDate.Before(request.StartDate) {
+			return newError(http.StatusBadRequest, "the end date is before the start date")
+		}
+		return client.AddPermission(request)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Permission{
+		CardID:    wire.CardID(area, cardID),
+		Door:      door,
+		DoorName:  s.ControllerList.LookupDoor(c.Address, door),
+		StartDate: request.StartDate.Format(time.DateOnly),
+		EndDate:   request.EndDate.Format(time.DateOnly),
+	}
+	if person := s.PersonnelList.FindByCardID(result.CardID); person != nil {
+		result.Person = person.Name
+	}
+	return result, nil
+}
+
+func (s *Server) deletePermission(c *controller, cardIDString string, reference string) (*DeletePermissionResponse, error) {
+	area, cardID, err := wire.ParseCardID(cardIDString)
+	if err != nil {
+		return nil, newError(http.StatusBadRequest, "%v", err)
+	}
+	door, err := s.lookupDoor(c, reference)
+	if err != nil {
+		return nil, err
+	}
+	err = c.do(func(client *wire.Client) error {
+		return client.DeletePermission(wire.DeletePermissionsRequest{
+			CardID: cardID,
+			Area:   area,
+			Door:   door,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &DeletePermissionResponse{
+		CardID: wire.CardID(area, cardID),
+		Door:   door,
+	}, nil
+}
+
+func (s *Server) syncTime(c *controller) (*SyncTimeResponse, error) {
+	var result SyncTimeResponse
+	err := c.do(func(client *wire.Client) error {
+		currentTime := client.WallClock(time.Now())
+		status, err := client.OperationStatus(0)
+		if err != nil {
+			return err
+		}
+		drift := status.CurrentTime.Sub(currentTime)
+
+		request := wire.SetTimeRequest{
+			CurrentTime: client.WallClock(time.Now()),
+		}
+		var response wire.SetTimeResponse
+		err = client.Do(wire.FunctionSetTime, &request, &response)
+		if err != nil {
+			return err
+		}
+		result = SyncTimeResponse{
+			Controller:   c.Name,
+			PreviousTime: status.CurrentTime,
+			Time:         response.CurrentTime,
+			Drift:        drift.String(),
+			DriftSeconds: drift.Seconds(),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Controllers returns the controllers from the controller file.
+func (s *Server) Controllers() []ControllerInfo {
+	s.init()
+	return s.listControllers()
+}
+
+// ControllerStatus returns the current state of the controller (by name or
+// address).
+func (s *Server) ControllerStatus(name string) (*ControllerStatus, error) {
+	s.init()
+	c, err := s.lookupController(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.controllerStatus(c)
+}
+
+// OpenDoor opens a door (by number or name) on the controller (by name or
+// address).
+func (s *Server) OpenDoor(name string, door string) (*OpenDoorResponse, error) {
+	s.init()
+	c, err := s.lookupController(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.openDoor(c, door)
+}
+
+// SyncTime sets the controller's (by name or address) time to the local time.
+func (s *Server) SyncTime(name string) (*SyncTimeResponse, error) {
+	s.init()
+	c, err := s.lookupController(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.syncTime(c)
+}
@@ -0,0 +1,334 @@
+package server
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tekkamanendless/cobra-controls/report"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+const (
+	// subscriberBuffer is the number of events that a subscriber can fall
+	// behind before it is dropped.
+	subscriberBuffer = 256
+	// MaxReplay is the largest number of records per controller that are
+	// replayed when resuming from a cursor.
+	MaxReplay = 1000
+)
+
+// monitorState is the polling state for every controller and the subscribers
+// that are waiting for new events.
+type monitorState struct {
+	mutex       sync.Mutex
+	running     bool
+	next        map[string]uint32 // This is the index of the next record to read for each controller; a missing entry means that the controller has not been polled yet.
+	subscribers map[*Subscription]bool
+}
+
+// Subscription receives every event as it is read from the controllers.
+type Subscription struct {
+	Events <-chan Event // This is closed when the subscription ends (including if the subscriber falls too far behind).
+
+	events chan Event
+	next   map[string]uint32 // This is the "next" state at the time of the subscription.
+}
+
+// key returns the name that identifies the controller in events and cursors.
+func (c *controller) key() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Address
+}
+
+// Monitor polls every controller for new records until the context is done.
+//
+// Each controller is polled in its own goroutine so that an unreachable
+// controller does not hold up the others.  Only records added after the
+// first successful poll are reported.
+func (s *Server) Monitor(ctx context.Context, interval time.Duration) {
+	s.init()
+	s.monitor.mutex.Lock()
+	s.monitor.running = true
+	s.monitor.mutex.Unlock()
+	defer func() {
+		s.monitor.mutex.Lock()
+		defer s.monitor.mutex.Unlock()
+		s.monitor.running = false
+		for subscription := range s.monitor.subscribers {
+			close(subscription.events)
+			delete(s.monitor.subscribers, subscription)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for _, c := range s.controllers {
+		wg.Add(1)
+		go func(c *controller) {
+			defer wg.Done()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				err := s.poll(c)
+				if err != nil {
+					logrus.Warnf("Could not poll controller %s: %v", c.key(), err)
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(c)
+	}
+	wg.Wait()
+}
+
+// poll reads any new records from the controller and publishes them.
+func (s *Server) poll(c *controller) error {
+	s.monitor.mutex.Lock()
+	next, ok := s.monitor.next[c.key()]
+	s.monitor.mutex.Unlock()
+
+	var events []Event
+	started := ok
+	err := c.do(func(client *wire.Client) error {
+		status, err := client.OperationStatus(0)
+		if err != nil {
+			return err
+		}
+		started = true
+		if !ok || status.RecordCount+1 < next {
+			// Either this is the first poll or the records were cleared; either way, start with the next record.
+			next = status.RecordCount + 1
+		}
+		for ; next <= status.RecordCount; next++ {
+			record, err := readRecord(client, next)
+			if err != nil {
+				return err
+			}
+			if record != nil {
+				events = append(events, s.observe(c, next, *record))
+			}
+		}
+		return nil
+	})
+	if !started {
+		// The first status read failed, so there is no starting point yet; the next poll tries again.
+		return err
+	}
+	// Publish whatever was read, even if there was an error partway through.
+	s.publish(c, next, events)
+	return err
+}
+
+// readRecord reads the record at the given index.
+//
+// If there is no such record, then this returns nil.
+func readRecord(client *wire.Client, index uint32) (*wire.Record, error) {
+	status, err := client.OperationStatus(index)
+	if err != nil {
+		return nil, err
+	}
+	return status.Record, nil
+}
+
+// readEvent reads the record at the given index.
+//
+// If there is no such record, then this returns nil.
+func (s *Server) readEvent(c *controller, client *wire.Client, index uint32) (*Event, error) {
+	record, err := readRecord(client, index)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, nil
+	}
+	event := NewEvent(s.ControllerList, s.PersonnelList, c.Controller, index, *record)
+	return &event, nil
+}
+
+// observe returns the event for a new record, updating the occupancy.
+//
+// A fire alarm carries the muster list.
+func (s *Server) observe(c *controller, index uint32, record wire.Record) Event {
+	event := NewEvent(s.ControllerList, s.PersonnelList, c.Controller, index, record)
+	if s.Occupancy == nil {
+		return event
+	}
+	if s.Occupancy.Observe(report.Entry{Controller: c.Controller, Index: index, Record: record}) {
+		event.Muster = s.Occupancy.Muster(time.Now())
+		event.Muster.Alarm = &event.Time
+		event.Muster.Controller = event.Controller
+		logrus.Warnf("Fire alarm on controller %s: %d on site.", event.Controller, len(event.Muster.Occupants))
+	}
+	return event
+}
+
+// LoadOccupancy replays the records since the given time into the occupancy so
+// that the people who arrived before the server started are tracked.
+//
+// A controller that cannot be read is skipped with a warning.
+func (s *Server) LoadOccupancy(since time.Time) {
+	s.init()
+	if s.Occupancy == nil {
+		return
+	}
+	for _, c := range s.controllers {
+		var entries []report.Entry
+		err := c.do(func(client *wire.Client) error {
+			// The end is well past now in case the controller's clock is ahead.
+			var err error
+			entries, err = report.Fetch(client, c.Controller, since, time.Now().Add(24*time.Hour))
+			return err
+		})
+		if err != nil {
+			logrus.Warnf("Could not read the records from controller %s: %v", c.key(), err)
+			continue
+		}
+		for _, entry := range entries {
+			s.Occupancy.Observe(entry)
+		}
+	}
+}
+
+// publish records the controller's next index and sends the events to every
+// subscriber.
+func (s *Server) publish(c *controller, next uint32, events []Event) {
+	s.monitor.mutex.Lock()
+	defer s.monitor.mutex.Unlock()
+
+	if s.monitor.next == nil {
+		s.monitor.next = map[string]uint32{}
+	}
+	s.monitor.next[c.key()] = next
+	for _, event := range events {
+		for subscription := range s.monitor.subscribers {
+			select {
+			case subscription.events <- event:
+			default:
+				logrus.Warnf("Dropping a subscriber that fell more than %d events behind.", subscriberBuffer)
+				close(subscription.events)
+				delete(s.monitor.subscribers, subscription)
+			}
+		}
+	}
+}
+
+// Subscribe returns a subscription to every new event.
+//
+// If the monitor is not running, then this returns nil.  Call `Unsubscribe`
+// when done.
+func (s *Server) Subscribe() *Subscription {
+	s.init()
+	s.monitor.mutex.Lock()
+	defer s.monitor.mutex.Unlock()
+
+	if !s.monitor.running {
+		return nil
+	}
+	events := make(chan Event, subscriberBuffer)
+	subscription := &Subscription{
+		Events: events,
+		events: events,
+		next:   map[string]uint32{},
+	}
+	for key, next := range s.monitor.next {
+		subscription.next[key] = next
+	}
+	if s.monitor.subscribers == nil {
+		s.monitor.subscribers = map[*Subscription]bool{}
+	}
+	s.monitor.subscribers[subscription] = true
+	return subscription
+}
+
+// Unsubscribe ends the subscription.
+func (s *Server) Unsubscribe(subscription *Subscription) {
+	s.monitor.mutex.Lock()
+	defer s.monitor.mutex.Unlock()
+
+	if s.monitor.subscribers[subscription] {
+		close(subscription.events)
+		delete(s.monitor.subscribers, subscription)
+	}
+}
+
+// Follow calls `handle` for every event after the cursor until the context is
+// done.
+//
+// If `handle` is too slow and the subscription is dropped, then this
+// subscribes again and replays the missed events from the controllers, the
+// same way that a reconnecting event stream does.  If the cursor is empty,
+// then only new events are handled.
+func (s *Server) Follow(ctx context.Context, cursor Cursor, handle func(Event)) {
+	position := cursor
+	for ctx.Err() == nil {
+		subscription := s.Subscribe()
+		if subscription == nil {
+			// The monitor has not started yet.
+			select {
+			case <-ctx.Done():
+			case <-time.After(100 * time.Millisecond):
+			}
+			continue
+		}
+		if position != nil {
+			logrus.Warnf("Subscribing again; replaying the events after %q.", position.String())
+		}
+		position = startPosition(subscription, position)
+		send := func(event Event) error {
+			handle(event)
+			return nil
+		}
+		s.catchUp(subscription, position, send)
+		func() {
+			defer s.Unsubscribe(subscription)
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case event, ok := <-subscription.Events:
+					if !ok {
+						return
+					}
+					s.deliver(position, event, send)
+				}
+			}
+		}()
+	}
+}
+
+// Cursor is the index of the last event seen from each controller.
+type Cursor map[string]uint32
+
+// ParseCursor parses a cursor from `Cursor.String`.
+func ParseCursor(value string) (Cursor, error) {
+	values, err := url.ParseQuery(value)
+	if err != nil {
+		return nil, err
+	}
+	cursor := Cursor{}
+	for key := range values {
+		v, err := strconv.ParseUint(values.Get(key), 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		cursor[key] = uint32(v)
+	}
+	return cursor, nil
+}
+
+// String returns the cursor in a form that can be passed to `ParseCursor`.
+func (c Cursor) String() string {
+	values := url.Values{}
+	for key, index := range c {
+		values.Set(key, strconv.FormatUint(uint64(index), 10))
+	}
+	return values.Encode()
+}
@@ -0,0 +1,318 @@
+// Package server provides an HTTP/JSON API for the controllers in a
+// controller file.
+//
+// The API is described by "openapi.yaml" (served at "/api/v1/openapi.yaml").
+package server
+
+import (
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tekkamanendless/cobra-controls/cobrafile"
+	"github.com/tekkamanendless/cobra-controls/report"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+// PathPrefix is the prefix for every API path.
+const PathPrefix = "/api/v1"
+
+// OpenAPI is the OpenAPI specification for the API.
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
+
+// Server serves the API.
+type Server struct {
+	ControllerList cobrafile.ControllerList
+	PersonnelList  cobrafile.PersonnelList
+	APIKeys        []string      // These are the keys that are accepted; if empty, then no key is required.
+	Protocol       wire.Protocol // This is the protocol used to talk to the controllers.
+	// Occupancy tracks who is on site from the monitored events; if this is
+	// nil, then occupancy is not tracked.
+	Occupancy *report.Occupancy
+
+	once        sync.Once
+	controllers []*controller
+	monitor     monitorState
+}
+
+// controller is a controller from the controller file along with its client.
+//
+// A client can only be used for one request at a time.
+type controller struct {
+	cobrafile.Controller
+	mutex  sync.Mutex
+	client *wire.Client
+}
+
+// Error is an error with an HTTP status code.
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// newError returns an error with the given status.
+func newError(status int, format string, args ...any) *Error {
+	return &Error{
+		Status:  status,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ErrorResponse is the body of every error response.
+type ErrorResponse struct {
+	Error string
+}
+
+func (s *Server) init() {
+	s.once.Do(func() {
+		for _, c := range s.ControllerList {
+			s.controllers = append(s.controllers, &controller{
+				Controller: c,
+				client: &wire.Client{
+					ControllerAddress: c.Address,
+					ControllerPort:    c.Port,
+					BoardAddress:      c.SN,
+					Protocol:          s.Protocol,
+					Location:          c.Location(),
+				},
+			})
+		}
+	})
+}
+
+// lookupController returns the controller with the given name (or address).
+func (s *Server) lookupController(name string) (*controller, error) {
+	for _, c := range s.controllers {
+		if c.Name == name {
+			return c, nil
+		}
+	}
+	for _, c := range s.controllers {
+		if c.Address == name {
+			return c, nil
+		}
+	}
+	return nil, newError(http.StatusNotFound, "no such controller: %s", name)
+}
+
+// do runs the function with the controller's client.
+//
+// Any error from the function is reported as a bad gateway.
+func (c *controller) do(f func(client *wire.Client) error) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	err := f(c.client)
+	if err != nil {
+		var httpError *Error
+		if errors.As(err, &httpError) {
+			return err
+		}
+		return newError(http.StatusBadGateway, "controller %s: %v", c.Name, err)
+	}
+	return nil
+}
+
+// authorized returns true if the request has a valid API key.
+//
+// The key may be given as "Authorization: Bearer <key>", as
+// "X-API-Key: <key>", or (since browsers cannot set headers on an event
+// stream) as the "api_key" query parameter.
+func (s *Server) authorized(r *http.Request) bool {
+	if len(s.APIKeys) == 0 {
+		return true
+	}
+	key := r.URL.Query().Get("api_key")
+	if value := r.Header.Get("X-API-Key"); value != "" {
+		key = value
+	}
+	if value := r.Header.Get("Authorization"); strings.HasPrefix(value, "Bearer ") {
+		key = strings.TrimPrefix(value, "Bearer ")
+	}
+	if key == "" {
+		return false
+	}
+	for _, apiKey := range s.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP serves the API.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.init()
+	logrus.Debugf("%s %s", r.Method, r.URL.Path) // The query may have the API key, so it is not logged.
+
+	if r.URL.Path == PathPrefix+"/openapi.yaml" {
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write(OpenAPI)
+		return
+	}
+
+	if !s.authorized(r) {
+		writeJSON(w, http.StatusUnauthorized, ErrorResponse{Error: "invalid or missing API key"})
+		return
+	}
+
+	result, err := s.route(w, r)
+	if err != nil {
+		status := http.StatusInternalServerError
+		var httpError *Error
+		if errors.As(err, &httpError) {
+			status = httpError.Status
+		}
+		logrus.Debugf("%s %s: %d: %v", r.Method, r.URL.Path, status, err)
+		writeJSON(w, status, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if result == nil {
+		// The handler wrote the response itself.
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// route calls the handler for the request.
+//
+// If the handler writes the response itself, then this returns nil.
+func (s *Server) route(w http.ResponseWriter, r *http.Request) (any, error) {
+	if !strings.HasPrefix(r.URL.Path, PathPrefix+"/") {
+		return nil, newError(http.StatusNotFound, "not found")
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, PathPrefix), "/"), "/")
+
+	// method returns an error if the request's method is not the expected one.
+	method := func(expected string) error {
+		if r.Method != expected {
+			return newError(http.StatusMethodNotAllowed, "method not allowed: %s", r.Method)
+		}
+		return nil
+	}
+
+	if len(parts) == 1 && parts[0] == "events" {
+		if err := method(http.MethodGet); err != nil {
+			return nil, err
+		}
+		return nil, s.stream(w, r)
+	}
+
+	if len(parts) == 1 && parts[0] == "occupancy" {
+		if err := method(http.MethodGet); err != nil {
+			return nil, err
+		}
+		if s.Occupancy == nil {
+			return nil, newError(http.StatusServiceUnavailable, "occupancy is not being tracked")
+		}
+		return s.Occupancy.Muster(time.Now()), nil
+	}
+
+	if parts[0] != "controllers" {
+		return nil, newError(http.StatusNotFound, "not found")
+	}
+	if len(parts) == 1 {
+		if err := method(http.MethodGet); err != nil {
+			return nil, err
+		}
+		return s.listControllers(), nil
+	}
+
+	c, err := s.lookupController(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(parts) == 2:
+		if err := method(http.MethodGet); err != nil {
+			return nil, err
+		}
+		return s.controllerInfo(c), nil
+	case len(parts) == 3 && parts[2] == "status":
+		if err := method(http.MethodGet); err != nil {
+			return nil, err
+		}
+		return s.controllerStatus(c)
+	case len(parts) == 4 && parts[2] == "doors":
+		if err := method(http.MethodGet); err != nil {
+			return nil, err
+		}
+		return s.doorStatus(c, parts[3])
+	case len(parts) == 5 && parts[2] == "doors" && parts[4] == "open":
+		if err := method(http.MethodPost); err != nil {
+			return nil, err
+		}
+		return s.openDoor(c, parts[3])
+	case len(parts) == 3 && parts[2] == "history":
+		if err := method(http.MethodGet); err != nil {
+			return nil, err
+		}
+		return s.history(c, r)
+	case len(parts) == 3 && parts[2] == "permissions":
+		switch r.Method {
+		case http.MethodGet:
+			return s.listPermissions(c, r.URL.Query().Get("card"))
+		case http.MethodPost:
+			var input PermissionInput
+			if err := readJSON(r, &input); err != nil {
+				return nil, err
+			}
+			return s.putPermission(c, input)
+		}
+		return nil, method(http.MethodGet)
+	case len(parts) == 5 && parts[2] == "permissions":
+		switch r.Method {
+		case http.MethodPut:
+			var input PermissionInput
+			if err := readJSON(r, &input); err != nil {
+				return nil, err
+			}
+			input.CardID = parts[3]
+			input.Door = DoorReference(parts[4])
+			return s.putPermission(c, input)
+		case http.MethodDelete:
+			return s.deletePermission(c, parts[3], parts[4])
+		}
+		return nil, method(http.MethodPut)
+	case len(parts) == 3 && parts[2] == "time":
+		if err := method(http.MethodPost); err != nil {
+			return nil, err
+		}
+		return s.syncTime(c)
+	}
+	return nil, newError(http.StatusNotFound, "not found")
+}
+
+// readJSON decodes the request body.
+func readJSON(r *http.Request, v any) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(v)
+	if err != nil {
+		return newError(http.StatusBadRequest, "could not decode request: %v", err)
+	}
+	return nil
+}
+
+// writeJSON writes the value as the response.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		logrus.Warnf("Could not write response: %v", err)
+	}
+}
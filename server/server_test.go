@@ -0,0 +1,267 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tekkamanendless/cobra-controls/cobrafile"
+	"github.com/tekkamanendless/cobra-controls/wire"
+	"github.com/tekkamanendless/cobra-controls/wire/wiretest"
+)
+
+// newTestServer returns a server for a single fake controller named "front".
+func newTestServer(t *testing.T) (*httptest.Server, *wiretest.Controller) {
+	fake, err := wiretest.NewController(0xF257)
+	require.Nil(t, err)
+	t.Cleanup(func() { fake.Close() })
+	fake.SetLocation(time.UTC)
+
+	s := &Server{
+		ControllerList: cobrafile.ControllerList{
+			{
+				Name:     "front",
+				Address:  fake.Address(),
+				Port:     fake.Port(),
+				SN:       fake.BoardAddress,
+				Doors:    []string{"Lobby", "Garage", "", ""},
+				TimeZone: time.UTC,
+			},
+		},
+		PersonnelList: cobrafile.PersonnelList{
+			{Name: "Alice", CardID: "17823439"},
+		},
+		APIKeys:  []string{"secret"},
+		Protocol: wire.ProtocolTCP,
+	}
+	httpServer := httptest.NewServer(s)
+	t.Cleanup(httpServer.Close)
+	return httpServer, fake
+}
+
+// call makes a request and decodes the response into `output`.
+func call(t *testing.T, method string, url string, body string, output any) int {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	request, err := http.NewRequest(method, url, reader)
+	require.Nil(t, err)
+	request.Header.Set("Authorization", "Bearer secret")
+	response, err := http.DefaultClient.Do(request)
+	require.Nil(t, err)
+	defer response.Body.Close()
+	if output != nil {
+		err = json.NewDecoder(response.Body).Decode(output)
+		require.Nil(t, err)
+	}
+	return response.StatusCode
+}
+
+func TestServer(t *testing.T) {
+	httpServer, fake := newTestServer(t)
+	base := httpServer.URL + PathPrefix
+
+	t.Run("Auth", func(t *testing.T) {
+		rows := []struct {
+			name   string
+			header string
+			value  string
+			status int
+		}{
+			{name: "Missing", status: http.StatusUnauthorized},
+			{name: "Wrong", header: "X-API-Key", value: "wrong", status: http.StatusUnauthorized},
+			{name: "Header", header: "X-API-Key", value: "secret", status: http.StatusOK},
+			{name: "Bearer", header: "Authorization", value: "Bearer secret", status: http.StatusOK},
+		}
+		for _, row := range rows {
+			t.Run(row.name, func(t *testing.T) {
+				request, err := http.NewRequest(http.MethodGet, base+"/controllers", nil)
+				require.Nil(t, err)
+				if row.header != "" {
+					request.Header.Set(row.header, row.value)
+				}
+				response, err := http.DefaultClient.Do(request)
+				require.Nil(t, err)
+				response.Body.Close()
+				assert.Equal(t, row.status, response.StatusCode)
+			})
+		}
+
+		response, err := http.Get(base + "/openapi.yaml")
+		require.Nil(t, err)
+		defer response.Body.Close()
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		contents, err := io.ReadAll(response.Body)
+		require.Nil(t, err)
+		assert.Equal(t, OpenAPI, contents)
+	})
+	t.Run("Controllers", func(t *testing.T) {
+		var output []ControllerInfo
+		status := call(t, http.MethodGet, base+"/controllers", "", &output)
+		assert.Equal(t, http.StatusOK, status)
+		require.Len(t, output, 1)
+		assert.Equal(t, "front", output[0].Name)
+		assert.Equal(t, DoorInfo{Door: 2, Name: "Garage"}, output[0].Doors[1])
+
+		var errorOutput ErrorResponse
+		status = call(t, http.MethodGet, base+"/controllers/back/status", "", &errorOutput)
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.NotEmpty(t, errorOutput.Error)
+	})
+	t.Run("Status", func(t *testing.T) {
+		fake.SetOffset(time.Minute)
+		fake.SetDoorState(wire.RelayStatus{Door2: true}, wire.MagnetState{Door1: true, Door3: true, Door4: true})
+		defer fake.SetOffset(0)
+
+		var output ControllerStatus
+		status := call(t, http.MethodGet, base+"/controllers/front/status", "", &output)
+		assert.Equal(t, http.StatusOK, status)
+		assert.InDelta(t, 60, output.DriftSeconds, 2)
+		assert.Equal(t, "no fault", output.FaultDescription)
+		require.Len(t, output.Doors, 4)
+		assert.Equal(t, DoorStatus{Door: 2, Name: "Garage", RelayEnergized: true, Open: true}, output.Doors[1])
+
+		var door DoorStatus
+		status = call(t, http.MethodGet, base+"/controllers/front/doors/lobby", "", &door)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, DoorStatus{Door: 1, Name: "Lobby"}, door)
+	})
+	t.Run("OpenDoor", func(t *testing.T) {
+		var output OpenDoorResponse
+		status := call(t, http.MethodPost, base+"/controllers/front/doors/Garage/open", "", &output)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, OpenDoorResponse{Controller: "front", Door: 2, DoorName: "Garage"}, output)
+
+		records := fake.Records()
+		require.NotEmpty(t, records)
+		assert.Equal(t, wire.RecordEventRemoteOpen, records[len(records)-1].Event())
+		assert.Equal(t, uint8(2), records[len(records)-1].Door())
+
+		status = call(t, http.MethodGet, base+"/controllers/front/doors/Garage/open", "", nil)
+		assert.Equal(t, http.StatusMethodNotAllowed, status)
+		status = call(t, http.MethodPost, base+"/controllers/front/doors/5/open", "", nil)
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+	t.Run("History", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			fake.AddRecord(wire.Record{
+				IDNumber:      23439,
+				AreaNumber:    178,
+				RecordState:   0b10010000, // Denied, no permission, door 1.
+				BrushDateTime: time.Date(2022, 12, 28, 11, 28, i, 0, time.UTC),
+			})
+		}
+		count := uint32(len(fake.Records()))
+
+		var output HistoryResponse
+		status := call(t, http.MethodGet, base+"/controllers/front/history?limit=3", "", &output)
+		assert.Equal(t, http.StatusOK, status)
+		require.Len(t, output.Events, 3)
+		assert.Equal(t, count, output.Events[0].Index)
+		assert.Equal(t, count-2, output.Events[2].Index)
+		assert.Equal(t, count-2, output.Next)
+		assert.Equal(t, Event{
+			Index:        count,
+			Time:         time.Date(2022, 12, 28, 11, 28, 4, 0, time.UTC),
+			Controller:   "front",
+			Door:         1,
+			DoorName:     "Lobby",
+			CardID:       "17823439",
+			Person:       "Alice",
+			Event:        wire.RecordEventDenied,
+			DeniedReason: "no permission",
+		}, output.Events[0])
+
+		var next HistoryResponse
+		status = call(t, http.MethodGet, base+"/controllers/front/history?limit=500&before=2", "", &next)
+		assert.Equal(t, http.StatusOK, status)
+		require.Len(t, next.Events, 1)
+		assert.Equal(t, uint32(1), next.Events[0].Index)
+		assert.Equal(t, uint32(0), next.Next)
+
+		status = call(t, http.MethodGet, base+"/controllers/front/history?limit=x", "", nil)
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+	t.Run("Permissions", func(t *testing.T) {
+		var permission Permission
+		status := call(t, http.MethodPost, base+"/controllers/front/permissions", `{"CardID":"17823439","Door":"Lobby"}`, &permission)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, Permission{CardID: "17823439", Person: "Alice", Door: 1, DoorName: "Lobby", StartDate: "2000-01-01", EndDate: "2050-12-31"}, permission)
+
+		status = call(t, http.MethodPut, base+"/controllers/front/permissions/17823439/2", `{"EndDate":"2030-06-30"}`, &permission)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "2030-06-30", permission.EndDate)
+
+		var permissions []Permission
+		status = call(t, http.MethodGet, base+"/controllers/front/permissions?card=17823439", "", &permissions)
+		assert.Equal(t, http.StatusOK, status)
+		require.Len(t, permissions, 2)
+		assert.Equal(t, uint8(1), permissions[0].Door)
+		assert.Equal(t, uint8(2), permissions[1].Door)
+		assert.Equal(t, "2030-06-30", permissions[1].EndDate)
+
+		status = call(t, http.MethodDelete, base+"/controllers/front/permissions/17823439/Lobby", "", nil)
+		assert.Equal(t, http.StatusOK, status)
+		status = call(t, http.MethodGet, base+"/controllers/front/permissions", "", &permissions)
+		assert.Equal(t, http.StatusOK, status)
+		require.Len(t, permissions, 1)
+		assert.Equal(t, uint8(2), permissions[0].Door)
+
+		// An update keeps what was not given.
+		area, cardID, err := wire.ParseCardID("17823439")
+		require.Nil(t, err)
+		fake.AddPermission(wire.GetUploadResponse{
+			IDNumber:   cardID,
+			AreaNumber: area,
+			DoorNumber: 3,
+			StartDate:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:    time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC),
+			Time:       2,
+			Password:   1234,
+			Standby1:   1,
+			Standby2:   3,
+		})
+		status = call(t, http.MethodPut, base+"/controllers/front/permissions/17823439/3", `{"EndDate":"2030-06-30"}`, &permission)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "2020-01-01", permission.StartDate)
+		assert.Equal(t, "2030-06-30", permission.EndDate)
+		stored := fake.Permissions()
+		require.Len(t, stored, 2)
+		assert.Equal(t, uint8(3), stored[1].DoorNumber)
+		assert.Equal(t, time.Date(2030, 6, 30, 0, 0, 0, 0, time.UTC), stored[1].EndDate)
+		assert.Equal(t, uint8(2), stored[1].Time)
+		assert.Equal(t, wire.PIN(1234), stored[1].Password)
+		assert.True(t, stored[1].FirstCard())
+		assert.Equal(t, uint8(3), stored[1].Group())
+
+		status = call(t, http.MethodPost, base+"/controllers/front/permissions", `{"CardID":"123","Door":1}`, nil)
+		assert.Equal(t, http.StatusBadRequest, status)
+		status = call(t, http.MethodPost, base+"/controllers/front/permissions", `{"CardID":"17823439","Door":1,"StartDate":"2030-01-01","EndDate":"2020-01-01"}`, nil)
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+	t.Run("Time", func(t *testing.T) {
+		fake.SetOffset(-time.Hour)
+
+		var output SyncTimeResponse
+		status := call(t, http.MethodPost, base+"/controllers/front/time", "", &output)
+		assert.Equal(t, http.StatusOK, status)
+		assert.InDelta(t, -3600, output.DriftSeconds, 2)
+		assert.WithinDuration(t, time.Now(), fake.Now(), 2*time.Second)
+	})
+	t.Run("Unreachable", func(t *testing.T) {
+		fake.SetFail(true)
+		defer fake.SetFail(false)
+
+		var output ErrorResponse
+		status := call(t, http.MethodGet, base+"/controllers/front/status", "", &output)
+		assert.Equal(t, http.StatusBadGateway, status)
+		assert.Contains(t, output.Error, "front")
+	})
+}
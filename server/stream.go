@@ -0,0 +1,199 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+// heartbeatInterval is how often a comment is sent on an idle event stream so
+// that proxies do not close it.
+const heartbeatInterval = 15 * time.Second
+
+// ReadyEvent is the first event on a stream, sent once any missed events have
+// been replayed.
+type ReadyEvent struct {
+	Cursor string
+}
+
+// stream sends every event as a server-sent event.
+//
+// The ID of each event is the cursor after that event; a client that
+// reconnects with "Last-Event-ID" (or the "cursor" query parameter) gets every
+// record that it missed, read directly from the controllers.
+func (s *Server) stream(w http.ResponseWriter, r *http.Request) error {
+	cursor := Cursor{}
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("cursor")
+	}
+	if value != "" {
+		var err error
+		cursor, err = ParseCursor(value)
+		if err != nil {
+			return newError(http.StatusBadRequest, "invalid cursor: %v", err)
+		}
+	}
+
+	subscription := s.Subscribe()
+	if subscription == nil {
+		return newError(http.StatusServiceUnavailable, "the monitor is not running")
+	}
+	defer s.Unsubscribe(subscription)
+
+	responseController := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Keep nginx from buffering the stream.
+	w.WriteHeader(http.StatusOK)
+
+	// This is the index of the last event sent from each controller.
+	position := startPosition(subscription, cursor)
+
+	// write sends the event with the position after it as its ID.
+	write := func(event Event) error {
+		return writeEvent(w, "", position.String(), event)
+	}
+
+	err := s.catchUp(subscription, position, write)
+	if err != nil {
+		return nil
+	}
+	err = writeEvent(w, "ready", position.String(), ReadyEvent{Cursor: position.String()})
+	if err != nil {
+		return nil
+	}
+	responseController.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-heartbeat.C:
+			_, err = io.WriteString(w, ": heartbeat\n\n")
+		case event, ok := <-subscription.Events:
+			if !ok {
+				// The client will reconnect with its cursor.
+				return nil
+			}
+			err = s.deliver(position, event, write)
+		}
+		if err != nil {
+			return nil
+		}
+		responseController.Flush()
+	}
+}
+
+// startPosition returns the index of the last event already seen from each
+// controller, starting a new subscription from the given cursor.
+//
+// A controller that is not in the cursor starts at the subscription.
+func startPosition(subscription *Subscription, cursor Cursor) Cursor {
+	position := Cursor{}
+	for key, next := range subscription.next {
+		position[key] = next - 1
+	}
+	for key, index := range cursor {
+		if next, ok := subscription.next[key]; ok && index >= next {
+			// The controller's records were cleared, so the cursor is no longer valid.
+			continue
+		}
+		position[key] = index
+	}
+	return position
+}
+
+// catchUp replays anything that was missed between the position and the
+// start of the subscription.
+func (s *Server) catchUp(subscription *Subscription, position Cursor, handle func(Event) error) error {
+	for key, next := range subscription.next {
+		if position[key]+1 < next {
+			err := s.replay(position, key, next, handle)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// deliver handles the event, first replaying anything that was missed from its
+// controller.
+func (s *Server) deliver(position Cursor, event Event, handle func(Event) error) error {
+	if last, ok := position[event.Controller]; ok && event.Index > last+1 {
+		err := s.replay(position, event.Controller, event.Index, handle)
+		if err != nil {
+			return err
+		}
+	}
+	position[event.Controller] = event.Index
+	return handle(event)
+}
+
+// replay handles the controller's events after the position, up to (but not
+// including) the given index.
+//
+// If the controller cannot be read, then the position is left where it was
+// so that the next event from that controller tries again.
+func (s *Server) replay(position Cursor, key string, before uint32, handle func(Event) error) error {
+	c, err := s.lookupController(key)
+	if err != nil {
+		return nil
+	}
+	start := position[key] + 1
+	if before-start > MaxReplay {
+		logrus.Warnf("Only replaying the last %d records from controller %s.", MaxReplay, key)
+		start = before - MaxReplay
+	}
+
+	var events []Event
+	err = c.do(func(client *wire.Client) error {
+		for index := start; index < before; index++ {
+			event, err := s.readEvent(c, client, index)
+			if err != nil {
+				return err
+			}
+			if event != nil {
+				events = append(events, *event)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logrus.Warnf("Could not replay records from controller %s: %v", key, err)
+	}
+	for _, event := range events {
+		position[key] = event.Index
+		err := handle(event)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeEvent writes a server-sent event.
+//
+// If the event type is empty, then the browser treats it as a "message".
+func writeEvent(w io.Writer, eventType string, id string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if eventType != "" {
+		_, err = fmt.Fprintf(w, "event: %s\n", eventType)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "id: %s\ndata: %s\n\n", id, data)
+	return err
+}
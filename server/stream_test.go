@@ -0,0 +1,386 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tekkamanendless/cobra-controls/cobrafile"
+	"github.com/tekkamanendless/cobra-controls/report"
+	"github.com/tekkamanendless/cobra-controls/wire"
+	"github.com/tekkamanendless/cobra-controls/wire/wiretest"
+)
+
+// sseEvent is a server-sent event.
+type sseEvent struct {
+	Type string
+	ID   string
+	Data string
+}
+
+// sseStream reads server-sent events.
+type sseStream struct {
+	response *http.Response
+	scanner  *bufio.Scanner
+}
+
+func openStream(t *testing.T, url string, lastEventID string) *sseStream {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.Nil(t, err)
+	if lastEventID != "" {
+		request.Header.Set("Last-Event-ID", lastEventID)
+	}
+	response, err := http.DefaultClient.Do(request)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+	return &sseStream{
+		response: response,
+		scanner:  bufio.NewScanner(response.Body),
+	}
+}
+
+func (s *sseStream) Close() {
+	s.response.Body.Close()
+}
+
+// Next returns the next event, skipping comments.
+func (s *sseStream) Next(t *testing.T) sseEvent {
+	var event sseEvent
+	for s.scanner.Scan() {
+		line := s.scanner.Text()
+		switch {
+		case line == "":
+			if event.Data != "" {
+				return event
+			}
+		case strings.HasPrefix(line, ":"):
+			// Comment.
+		case strings.HasPrefix(line, "event: "):
+			event.Type = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "id: "):
+			event.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			event.Data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	require.FailNow(t, "the stream ended", "%v", s.scanner.Err())
+	return event
+}
+
+// NextEvent returns the next access event.
+func (s *sseStream) NextEvent(t *testing.T) (Event, string) {
+	sse := s.Next(t)
+	require.Equal(t, "", sse.Type)
+	var event Event
+	err := json.Unmarshal([]byte(sse.Data), &event)
+	require.Nil(t, err)
+	return event, sse.ID
+}
+
+func TestStream(t *testing.T) {
+	fake, err := wiretest.NewController(0xF257)
+	require.Nil(t, err)
+	defer fake.Close()
+	fake.SetLocation(time.UTC)
+	fake.AddRecord(wire.Record{IDNumber: 1, AreaNumber: 178, RecordState: 0b00000000, BrushDateTime: time.Date(2022, 12, 28, 11, 0, 0, 0, time.UTC)})
+
+	s := &Server{
+		ControllerList: cobrafile.ControllerList{
+			{
+				Name:     "front",
+				Address:  fake.Address(),
+				Port:     fake.Port(),
+				SN:       fake.BoardAddress,
+				Doors:    []string{"Lobby", "Garage", "", ""},
+				TimeZone: time.UTC,
+			},
+		},
+		PersonnelList: cobrafile.PersonnelList{
+			{Name: "Alice", CardID: "17823439"},
+		},
+		Protocol: wire.ProtocolTCP,
+	}
+	httpServer := httptest.NewServer(s)
+	defer httpServer.Close()
+	url := httpServer.URL + PathPrefix + "/events"
+
+	status := call(t, http.MethodGet, url, "", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Monitor(ctx, 20*time.Millisecond)
+	require.Eventually(t, func() bool {
+		subscription := s.Subscribe()
+		if subscription == nil {
+			return false
+		}
+		s.Unsubscribe(subscription)
+		return true
+	}, time.Second, 10*time.Millisecond)
+
+	// polled waits until the monitor has seen every record on the controller.
+	polled := func() {
+		require.Eventually(t, func() bool {
+			s.monitor.mutex.Lock()
+			defer s.monitor.mutex.Unlock()
+			return s.monitor.next["front"] == uint32(len(fake.Records()))+1
+		}, time.Second, 10*time.Millisecond)
+	}
+	polled()
+
+	stream := openStream(t, url, "")
+	ready := stream.Next(t)
+	assert.Equal(t, "ready", ready.Type)
+	assert.Equal(t, "front=1", ready.ID)
+
+	fake.AddRecord(wire.Record{IDNumber: 23439, AreaNumber: 178, RecordState: 0b00000001, BrushDateTime: time.Date(2022, 12, 28, 11, 28, 42, 0, time.UTC)})
+	event, id := stream.NextEvent(t)
+	assert.Equal(t, Event{
+		Index:      2,
+		Time:       time.Date(2022, 12, 28, 11, 28, 42, 0, time.UTC),
+		Controller: "front",
+		Door:       2,
+		DoorName:   "Garage",
+		CardID:     "17823439",
+		Person:     "Alice",
+		Event:      wire.RecordEventGranted,
+		Granted:    true,
+	}, event)
+	assert.Equal(t, "front=2", id)
+	stream.Close()
+
+	// Miss a few events and then reconnect.
+	fake.AddRecord(
+		wire.Record{IDNumber: 23439, AreaNumber: 178, RecordState: 0b10010000, BrushDateTime: time.Date(2022, 12, 28, 11, 30, 0, 0, time.UTC)},
+		wire.Record{IDNumber: 0b0100, AreaNumber: 0, RecordState: 0b10100000, BrushDateTime: time.Date(2022, 12, 28, 11, 31, 0, 0, time.UTC)},
+	)
+	polled()
+
+	stream = openStream(t, url, id)
+	defer stream.Close()
+	event, id = stream.NextEvent(t)
+	assert.Equal(t, uint32(3), event.Index)
+	assert.Equal(t, "no permission", event.DeniedReason)
+	assert.Equal(t, "front=3", id)
+	event, id = stream.NextEvent(t)
+	assert.Equal(t, uint32(4), event.Index)
+	assert.Equal(t, wire.RecordEventFireAlarm, event.Event)
+	assert.True(t, event.Alarm)
+	assert.Equal(t, "front=4", id)
+	ready = stream.Next(t)
+	assert.Equal(t, "ready", ready.Type)
+	assert.Equal(t, "front=4", ready.ID)
+
+	// Live events continue after the replay.
+	fake.AddRecord(wire.Record{IDNumber: 2, AreaNumber: 178, BrushDateTime: time.Date(2022, 12, 28, 11, 32, 0, 0, time.UTC)})
+	event, _ = stream.NextEvent(t)
+	assert.Equal(t, uint32(5), event.Index)
+}
+
+func TestCursor(t *testing.T) {
+	cursor := Cursor{"front": 12, "back door": 3}
+	assert.Equal(t, "back+door=3&front=12", cursor.String())
+
+	output, err := ParseCursor(cursor.String())
+	require.Nil(t, err)
+	assert.Equal(t, cursor, output)
+
+	_, err = ParseCursor("front=x")
+	assert.NotNil(t, err)
+}
+
+func TestFollow(t *testing.T) {
+	fake, err := wiretest.NewController(0xF257)
+	require.Nil(t, err)
+	defer fake.Close()
+	fake.SetLocation(time.UTC)
+
+	s := &Server{
+		ControllerList: cobrafile.ControllerList{
+			{Name: "front", Address: fake.Address(), Port: fake.Port(), SN: fake.BoardAddress, TimeZone: time.UTC},
+		},
+		Protocol: wire.ProtocolTCP,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Monitor(ctx, 20*time.Millisecond)
+
+	// The first event holds up the handler until the subscriber has been dropped.
+	release := make(chan struct{})
+	var mutex sync.Mutex
+	var indexes []uint32
+	go s.Follow(ctx, nil, func(event Event) {
+		if event.Index == 1 {
+			<-release
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		indexes = append(indexes, event.Index)
+	})
+	subscribers := func() int {
+		s.monitor.mutex.Lock()
+		defer s.monitor.mutex.Unlock()
+		return len(s.monitor.subscribers)
+	}
+	require.Eventually(t, func() bool { return subscribers() == 1 }, time.Second, 10*time.Millisecond)
+
+	count := subscriberBuffer + 10
+	for i := 1; i <= count; i++ {
+		fake.AddRecord(wire.Record{IDNumber: uint16(i), AreaNumber: 178, BrushDateTime: time.Date(2022, 12, 28, 11, 0, 0, 0, time.UTC)})
+	}
+	require.Eventually(t, func() bool {
+		s.monitor.mutex.Lock()
+		defer s.monitor.mutex.Unlock()
+		return s.monitor.next["front"] == uint32(count)+1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, subscribers())
+	close(release)
+
+	// Every event is handled once, in order, even the ones that were dropped.
+	require.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(indexes) >= count
+	}, 5*time.Second, 10*time.Millisecond)
+	mutex.Lock()
+	defer mutex.Unlock()
+	for i, index := range indexes {
+		assert.Equal(t, uint32(i+1), index)
+	}
+	assert.Len(t, indexes, count)
+	assert.Equal(t, 1, subscribers())
+}
+
+func TestMonitorFirstPollFails(t *testing.T) {
+	fake, err := wiretest.NewController(0xF257)
+	require.Nil(t, err)
+	defer fake.Close()
+	fake.SetLocation(time.UTC)
+	for i := 1; i <= 5; i++ {
+		fake.AddRecord(wire.Record{IDNumber: uint16(i), AreaNumber: 178, BrushDateTime: time.Date(2022, 12, 28, 11, 0, 0, 0, time.UTC)})
+	}
+	fake.SetFail(true)
+
+	s := &Server{
+		ControllerList: cobrafile.ControllerList{
+			{Name: "front", Address: fake.Address(), Port: fake.Port(), SN: fake.BoardAddress, TimeZone: time.UTC},
+		},
+		Protocol: wire.ProtocolTCP,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Monitor(ctx, 20*time.Millisecond)
+
+	var subscription *Subscription
+	require.Eventually(t, func() bool {
+		subscription = s.Subscribe()
+		return subscription != nil
+	}, time.Second, 10*time.Millisecond)
+	defer s.Unsubscribe(subscription)
+
+	// Let a few polls fail; there is still no starting point.
+	time.Sleep(100 * time.Millisecond)
+	s.monitor.mutex.Lock()
+	_, ok := s.monitor.next["front"]
+	s.monitor.mutex.Unlock()
+	assert.False(t, ok)
+
+	// Once the controller is reachable, only the records after that are reported.
+	fake.SetFail(false)
+	require.Eventually(t, func() bool {
+		s.monitor.mutex.Lock()
+		defer s.monitor.mutex.Unlock()
+		return s.monitor.next["front"] == 6
+	}, time.Second, 10*time.Millisecond)
+	fake.AddRecord(wire.Record{IDNumber: 6, AreaNumber: 178, BrushDateTime: time.Date(2022, 12, 28, 11, 30, 0, 0, time.UTC)})
+	select {
+	case event := <-subscription.Events:
+		assert.Equal(t, uint32(6), event.Index)
+	case <-time.After(time.Second):
+		require.FailNow(t, "no event")
+	}
+	select {
+	case event := <-subscription.Events:
+		assert.Failf(t, "unexpected event", "%+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestOccupancy(t *testing.T) {
+	fake, err := wiretest.NewController(0xF257)
+	require.Nil(t, err)
+	defer fake.Close()
+	fake.SetLocation(time.UTC)
+	// Alice came in before the server started.
+	fake.AddRecord(wire.Record{IDNumber: 23439, AreaNumber: 178, RecordState: 0b00000000, BrushDateTime: time.Now().UTC().Add(-time.Hour).Truncate(time.Second)})
+
+	personnelList := cobrafile.PersonnelList{
+		{Name: "Alice", CardID: "17823439"},
+		{Name: "Bob", CardID: "17823440"},
+	}
+	s := &Server{
+		ControllerList: cobrafile.ControllerList{
+			{
+				Name:       "front",
+				Address:    fake.Address(),
+				Port:       fake.Port(),
+				SN:         fake.BoardAddress,
+				Doors:      []string{"Entrance", "Exit", "", ""},
+				Directions: []cobrafile.Direction{cobrafile.DirectionIn, cobrafile.DirectionOut, cobrafile.DirectionNone, cobrafile.DirectionNone},
+				TimeZone:   time.UTC,
+			},
+		},
+		PersonnelList: personnelList,
+		Protocol:      wire.ProtocolTCP,
+	}
+	httpServer := httptest.NewServer(s)
+	defer httpServer.Close()
+	url := httpServer.URL + PathPrefix + "/occupancy"
+
+	status := call(t, http.MethodGet, url, "", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+
+	s.Occupancy = report.NewOccupancy(personnelList, report.DefaultMaxAge)
+	s.LoadOccupancy(time.Now().Add(-24 * time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Monitor(ctx, 20*time.Millisecond)
+	var subscription *Subscription
+	require.Eventually(t, func() bool {
+		subscription = s.Subscribe()
+		return subscription != nil
+	}, time.Second, 10*time.Millisecond)
+	defer s.Unsubscribe(subscription)
+
+	now := time.Now().UTC().Truncate(2 * time.Second) // The records only store even seconds.
+	fake.AddRecord(
+		wire.Record{IDNumber: 23440, AreaNumber: 178, RecordState: 0b00000000, BrushDateTime: now},
+		wire.Record{IDNumber: 0b0100, AreaNumber: 0, RecordState: 0b10100000, BrushDateTime: now},
+	)
+	event := <-subscription.Events
+	assert.Nil(t, event.Muster)
+	event = <-subscription.Events
+	assert.Equal(t, wire.RecordEventFireAlarm, event.Event)
+	require.NotNil(t, event.Muster)
+	assert.Equal(t, "front", event.Muster.Controller)
+	assert.Equal(t, now, *event.Muster.Alarm)
+	require.Len(t, event.Muster.Occupants, 2)
+	assert.Equal(t, "Alice", event.Muster.Occupants[0].Name)
+	assert.Equal(t, "Bob", event.Muster.Occupants[1].Name)
+
+	var muster report.Muster
+	status = call(t, http.MethodGet, url, "", &muster)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, muster.Occupants, 2)
+}
@@ -0,0 +1,231 @@
+// Package timesync keeps the controllers' clocks in sync with the local clock.
+//
+// The controllers keep the wall time of their own time zone (the client's
+// location) to the second.  A check reads the controller's clock a few
+// times, uses the sample with the shortest round trip, and assumes that the
+// controller read its clock halfway through it.  If the drift is over the
+// threshold (or the time zone's offset has changed, such as for daylight
+// saving time), then the time is set so that it lands on a second boundary.
+package timesync
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+const (
+	// DefaultThreshold is the default drift allowed before the time is set.
+	DefaultThreshold = 2 * time.Second
+	// DefaultSamples is the default number of times that the clock is read per check.
+	DefaultSamples = 3
+)
+
+// Target is a controller to keep in sync.
+type Target struct {
+	Name   string
+	Client *wire.Client
+}
+
+// Measurement is the result of reading a controller's clock.
+type Measurement struct {
+	ControllerTime time.Time     // This is the time that the controller reported.
+	Drift          time.Duration // + is ahead, - is behind.
+	RoundTrip      time.Duration // This is the round trip of the sample that was used.
+}
+
+// Adjustment is a change to a controller's clock.
+type Adjustment struct {
+	Controller   string
+	Reason       string
+	Drift        time.Duration // This is the drift before the adjustment.
+	RoundTrip    time.Duration
+	PreviousTime time.Time // This is the controller's time before the adjustment.
+	Time         time.Time // This is the time that was set.
+}
+
+// String returns a description of the adjustment.
+func (a Adjustment) String() string {
+	return fmt.Sprintf("Controller: %s | Reason: %s | Drift: %v | Round trip: %v | Previous time: %s | New time: %s", a.Controller, a.Reason, a.Drift, a.RoundTrip.Round(time.Millisecond), a.PreviousTime.Format(time.DateTime), a.Time.Format(time.DateTime))
+}
+
+// Measure reads the controller's clock and returns its drift.
+func Measure(client *wire.Client, samples int) (*Measurement, error) {
+	if samples < 1 {
+		samples = 1
+	}
+	var best *Measurement
+	var lastErr error
+	for i := 0; i < samples; i++ {
+		start := time.Now()
+		status, err := client.OperationStatus(0)
+		roundTrip := time.Since(start)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if best != nil && roundTrip >= best.RoundTrip {
+			continue
+		}
+		// The controller's clock only has whole seconds, so on average it
+		// is half a second later than it reports.
+		midpoint := start.Add(roundTrip / 2)
+		reference := client.WallClock(midpoint).Add(time.Duration(midpoint.Nanosecond()))
+		best = &Measurement{
+			ControllerTime: status.CurrentTime,
+			Drift:          status.CurrentTime.Add(time.Second / 2).Sub(reference),
+			RoundTrip:      roundTrip,
+		}
+	}
+	if best == nil {
+		return nil, lastErr
+	}
+	return best, nil
+}
+
+// Set sets the controller's clock.
+//
+// The request is sent half a round trip before the next second boundary so
+// that it arrives as that second starts.
+func Set(client *wire.Client, roundTrip time.Duration) (time.Time, error) {
+	oneWay := roundTrip / 2
+	next := time.Now().Add(oneWay).Truncate(time.Second).Add(time.Second)
+	time.Sleep(time.Until(next.Add(-oneWay)))
+
+	request := wire.SetTimeRequest{
+		CurrentTime: client.WallClock(next),
+	}
+	var response wire.SetTimeResponse
+	err := client.Do(wire.FunctionSetTime, &request, &response)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return response.CurrentTime, nil
+}
+
+// Syncer checks the targets on a schedule.
+type Syncer struct {
+	Targets   []Target
+	Interval  time.Duration
+	Threshold time.Duration // Defaults to "DefaultThreshold".
+	Samples   int           // Defaults to "DefaultSamples".
+
+	mutex   sync.Mutex
+	offsets map[string]int // This is the UTC offset (in seconds) of each target's time zone at the last check.
+}
+
+// Run checks every target until the context is done.
+func (s *Syncer) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		s.CheckAll()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckAll checks every target once.
+func (s *Syncer) CheckAll() []Adjustment {
+	var mutex sync.Mutex
+	var result []Adjustment
+	var wg sync.WaitGroup
+	for _, target := range s.Targets {
+		wg.Add(1)
+		go func(target Target) {
+			defer wg.Done()
+			adjustment, err := s.Check(target)
+			if err != nil {
+				logrus.Errorf("Could not sync the time on controller %s: %v", target.Name, err)
+				return
+			}
+			if adjustment != nil {
+				mutex.Lock()
+				result = append(result, *adjustment)
+				mutex.Unlock()
+			}
+		}(target)
+	}
+	wg.Wait()
+	return result
+}
+
+// Check measures the target's drift and sets its time if needed.
+//
+// This returns nil if the time did not need to be set.
+func (s *Syncer) Check(target Target) (*Adjustment, error) {
+	threshold := s.Threshold
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+	samples := s.Samples
+	if samples <= 0 {
+		samples = DefaultSamples
+	}
+
+	measurement, err := Measure(target.Client, samples)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Debugf("Controller %s: drift: %v (round trip: %v)", target.Name, measurement.Drift, measurement.RoundTrip)
+
+	var reason string
+	if offset, changed := s.updateOffset(target); changed {
+		reason = fmt.Sprintf("time zone offset changed to %s", formatOffset(offset))
+	} else if measurement.Drift.Abs() > threshold {
+		reason = fmt.Sprintf("drift over %v", threshold)
+	} else {
+		return nil, nil
+	}
+
+	newTime, err := Set(target.Client, measurement.RoundTrip)
+	if err != nil {
+		return nil, err
+	}
+	adjustment := &Adjustment{
+		Controller:   target.Name,
+		Reason:       reason,
+		Drift:        measurement.Drift,
+		RoundTrip:    measurement.RoundTrip,
+		PreviousTime: measurement.ControllerTime,
+		Time:         newTime,
+	}
+	logrus.Infof("Adjusted the time. %s", adjustment)
+	return adjustment, nil
+}
+
+// updateOffset records the current UTC offset of the target's time zone and
+// returns it along with whether it changed since the last check.
+func (s *Syncer) updateOffset(target Target) (int, bool) {
+	location := target.Client.Location
+	if location == nil {
+		location = time.Local
+	}
+	_, offset := time.Now().In(location).Zone()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.offsets == nil {
+		s.offsets = map[string]int{}
+	}
+	previous, ok := s.offsets[target.Name]
+	s.offsets[target.Name] = offset
+	return offset, ok && previous != offset
+}
+
+// formatOffset returns the UTC offset as "+hh:mm".
+func formatOffset(offset int) string {
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	return fmt.Sprintf("%s%02d:%02d", sign, offset/3600, offset%3600/60)
+}
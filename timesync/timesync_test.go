@@ -0,0 +1,87 @@
+package timesync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tekkamanendless/cobra-controls/wire/wiretest"
+)
+
+func TestFormatOffset(t *testing.T) {
+	assert.Equal(t, "+00:00", formatOffset(0))
+	assert.Equal(t, "-05:00", formatOffset(-5*60*60))
+	assert.Equal(t, "+05:30", formatOffset(5*60*60+30*60))
+}
+
+func TestSyncer(t *testing.T) {
+	fake, err := wiretest.NewController(0xF257)
+	require.Nil(t, err)
+	defer fake.Close()
+
+	target := Target{
+		Name:   "front",
+		Client: fake.Client(),
+	}
+	s := &Syncer{
+		Targets:   []Target{target},
+		Threshold: 5 * time.Second,
+		Samples:   2,
+	}
+
+	t.Run("InSync", func(t *testing.T) {
+		fake.SetOffset(time.Second)
+		adjustment, err := s.Check(target)
+		require.Nil(t, err)
+		assert.Nil(t, adjustment)
+	})
+	t.Run("Drift", func(t *testing.T) {
+		fake.SetOffset(-time.Minute)
+		adjustments := s.CheckAll()
+		require.Len(t, adjustments, 1)
+		assert.Equal(t, "front", adjustments[0].Controller)
+		assert.Equal(t, "drift over 5s", adjustments[0].Reason)
+		assert.InDelta(t, -60, adjustments[0].Drift.Seconds(), 2)
+		assert.WithinDuration(t, target.Client.WallClock(time.Now()), fake.Now(), 2*time.Second)
+	})
+	t.Run("TimeZone", func(t *testing.T) {
+		// The controller is kept three hours ahead of the local time zone.
+		_, localOffset := time.Now().Zone()
+		target := Target{
+			Name:   "remote",
+			Client: fake.Client(),
+		}
+		target.Client.Location = time.FixedZone("Remote", localOffset+3*60*60)
+		adjustment, err := s.Check(target)
+		require.Nil(t, err)
+		require.NotNil(t, adjustment)
+		assert.InDelta(t, -3*60*60, adjustment.Drift.Seconds(), 2)
+		// The fake controller reports its wall time as UTC.
+		now := time.Now().In(target.Client.Location)
+		assert.WithinDuration(t, time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(), 0, time.UTC), fake.Now(), 2*time.Second)
+	})
+	t.Run("OffsetChange", func(t *testing.T) {
+		// Pretend that the offset was different at the last check.
+		s.mutex.Lock()
+		s.offsets["front"]++
+		s.mutex.Unlock()
+
+		fake.SetOffset(0)
+		adjustment, err := s.Check(target)
+		require.Nil(t, err)
+		require.NotNil(t, adjustment)
+		assert.Contains(t, adjustment.Reason, "time zone offset changed")
+
+		// The new offset is remembered.
+		adjustment, err = s.Check(target)
+		require.Nil(t, err)
+		assert.Nil(t, adjustment)
+	})
+	t.Run("Unreachable", func(t *testing.T) {
+		fake.SetFail(true)
+		defer fake.SetFail(false)
+		_, err := s.Check(target)
+		assert.NotNil(t, err)
+	})
+}
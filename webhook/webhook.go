@@ -0,0 +1,474 @@
+// Package webhook sends access events to HTTP endpoints based on a set of
+// rules.
+//
+// Each request is a JSON "Payload" signed with the rule's secret:
+//
+//	X-Cobra-Timestamp: <unix time>
+//	X-Cobra-Signature: sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">
+//
+// Failed deliveries are retried with an exponential backoff; once every
+// attempt has failed, the delivery is appended to the dead-letter file so that
+// it can be redelivered later.
+//
+// The configuration file is JSON; for example:
+//
+//	{
+//		"Rules": [
+//			{
+//				"Name": "security",
+//				"URL": "https://example.com/hooks/cobra",
+//				"Secret": "change me",
+//				"Conditions": ["denied", "duress", "door held open", "forced entry", "fire alarm", "card"],
+//				"Cards": ["17823439"]
+//			}
+//		],
+//		"DeadLetterFile": "/var/lib/cobra/dead-letters.json",
+//		"MaxAttempts": 5,
+//		"RetryDelay": "2s"
+//	}
+package webhook
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tekkamanendless/cobra-controls/server"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+const (
+	// DefaultMaxAttempts is the number of attempts made for each delivery if
+	// the config does not say otherwise.
+	DefaultMaxAttempts = 5
+	// DefaultRetryDelay is the delay before the first retry if the config does
+	// not say otherwise; the delay doubles after each attempt.
+	DefaultRetryDelay = 2 * time.Second
+)
+
+// Condition is something that an event can match.
+type Condition string
+
+const (
+	ConditionDenied       Condition = "denied"         // A card was denied access.
+	ConditionDuress       Condition = "duress"         // A duress alarm.
+	ConditionDoorHeldOpen Condition = "door held open" // A door was held open for too long.
+	ConditionForcedEntry  Condition = "forced entry"   // A door was opened without being unlocked.
+	ConditionFireAlarm    Condition = "fire alarm"     // A fire alarm.
+	ConditionCard         Condition = "card"           // One of the rule's cards was used (whether or not it was granted access).
+)
+
+// Config is the webhook configuration file.
+type Config struct {
+	Rules          []Rule
+	DeadLetterFile string `json:",omitempty"` // Failed deliveries are appended to this file (one JSON object per line); if empty, then they are only logged.
+	MaxAttempts    int    `json:",omitempty"` // Defaults to "DefaultMaxAttempts".
+	RetryDelay     string `json:",omitempty"` // This is a duration, such as "2s"; defaults to "DefaultRetryDelay".
+}
+
+// Rule is a webhook and the conditions that trigger it.
+type Rule struct {
+	Name        string
+	URL         string
+	Secret      string      `json:",omitempty"` // If empty, then requests are not signed.
+	Conditions  []Condition // The rule fires if any of these match.
+	Cards       []string    `json:",omitempty"` // These are the cards for "ConditionCard".
+	Controllers []string    `json:",omitempty"` // If not empty, then only events from these controllers are considered.
+}
+
+// Match returns the first condition that the event matches.
+func (r Rule) Match(event server.Event) (Condition, bool) {
+	if len(r.Controllers) > 0 && !slices.Contains(r.Controllers, event.Controller) {
+		return "", false
+	}
+	for _, condition := range r.Conditions {
+		var matched bool
+		switch condition {
+		case ConditionDenied:
+			matched = event.Event == wire.RecordEventDenied
+		case ConditionDuress:
+			matched = event.Event == wire.RecordEventDuress
+		case ConditionDoorHeldOpen:
+			matched = event.Event == wire.RecordEventDoorHeldOpen
+		case ConditionForcedEntry:
+			matched = event.Event == wire.RecordEventForcedEntry
+		case ConditionFireAlarm:
+			matched = event.Event == wire.RecordEventFireAlarm
+		case ConditionCard:
+			matched = event.CardID != "" && slices.Contains(r.Cards, event.CardID)
+		}
+		if matched {
+			return condition, true
+		}
+	}
+	return "", false
+}
+
+// Payload is the body of a webhook request.
+type Payload struct {
+	ID        string // This is unique to the delivery; it stays the same across retries.
+	Rule      string
+	Condition Condition
+	Event     server.Event
+}
+
+// DeadLetter is a delivery that failed.
+type DeadLetter struct {
+	Time     time.Time
+	Rule     string
+	URL      string
+	Attempts int
+	Error    string
+	Payload  Payload
+}
+
+// LoadConfig loads the webhook configuration file.
+func LoadConfig(filename string) (*Config, error) {
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var config Config
+	err = json.Unmarshal(contents, &config)
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Dispatcher sends webhooks for the events that match its rules.
+type Dispatcher struct {
+	Client *http.Client
+
+	config         Config
+	retryDelay     time.Duration
+	maxAttempts    int
+	wg             sync.WaitGroup
+	mutex          sync.Mutex // This protects the dead-letter file.
+	redeliverMutex sync.Mutex // This keeps two redeliveries from running at once.
+}
+
+// NewDispatcher returns a dispatcher for the configuration.
+func NewDispatcher(config Config) (*Dispatcher, error) {
+	d := &Dispatcher{
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		config:      config,
+		retryDelay:  DefaultRetryDelay,
+		maxAttempts: DefaultMaxAttempts,
+	}
+	if config.RetryDelay != "" {
+		v, err := time.ParseDuration(config.RetryDelay)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry delay: %w", err)
+		}
+		d.retryDelay = v
+	}
+	if config.MaxAttempts > 0 {
+		d.maxAttempts = config.MaxAttempts
+	}
+	for _, rule := range config.Rules {
+		if rule.URL == "" {
+			return nil, fmt.Errorf("rule %q: missing URL", rule.Name)
+		}
+		for _, condition := range rule.Conditions {
+			switch condition {
+			case ConditionDenied, ConditionDuress, ConditionDoorHeldOpen, ConditionForcedEntry, ConditionFireAlarm, ConditionCard:
+			default:
+				return nil, fmt.Errorf("rule %q: invalid condition: %q", rule.Name, condition)
+			}
+		}
+	}
+	return d, nil
+}
+
+// Handle sends a webhook for every rule that the event matches.
+//
+// The webhooks are sent in the background; call `Wait` to wait for them.
+func (d *Dispatcher) Handle(event server.Event) {
+	for _, rule := range d.config.Rules {
+		condition, ok := rule.Match(event)
+		if !ok {
+			continue
+		}
+		payload := Payload{
+			ID:        newID(),
+			Rule:      rule.Name,
+			Condition: condition,
+			Event:     event,
+		}
+		logrus.Debugf("Webhook %s: %s (%s)", rule.Name, condition, payload.ID)
+		d.wg.Add(1)
+		go func(rule Rule) {
+			defer d.wg.Done()
+			d.deliver(rule, payload)
+		}(rule)
+	}
+}
+
+// Wait waits for every delivery to finish (including the retries).
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// deliver sends the payload, retrying as needed; if every attempt fails, then
+// the payload is written to the dead-letter file.
+func (d *Dispatcher) deliver(rule Rule, payload Payload) bool {
+	deadLetter := d.try(rule, payload)
+	if deadLetter == nil {
+		return true
+	}
+	if d.config.DeadLetterFile != "" {
+		err := d.writeDeadLetters(*deadLetter)
+		if err != nil {
+			logrus.Errorf("Could not write to the dead-letter file: %v", err)
+		}
+	}
+	return false
+}
+
+// try sends the payload, retrying as needed.
+//
+// If every attempt fails, then this returns the dead letter.
+func (d *Dispatcher) try(rule Rule, payload Payload) *DeadLetter {
+	delay := d.retryDelay
+	var err error
+	attempt := 1
+	for ; ; attempt++ {
+		var retry bool
+		retry, err = d.send(rule, payload)
+		if err == nil {
+			return nil
+		}
+		logrus.Warnf("Webhook %s: attempt %d of %d failed: %v", rule.Name, attempt, d.maxAttempts, err)
+		if !retry || attempt >= d.maxAttempts {
+			break
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+
+	logrus.Errorf("Webhook %s: giving up on %s after %d attempts: %v", rule.Name, payload.ID, attempt, err)
+	return &DeadLetter{
+		Time:     time.Now(),
+		Rule:     rule.Name,
+		URL:      rule.URL,
+		Attempts: attempt,
+		Error:    err.Error(),
+		Payload:  payload,
+	}
+}
+
+// send makes a single attempt to deliver the payload.
+//
+// If this fails, then it also returns whether the delivery should be retried.
+func (d *Dispatcher) send(rule Rule, payload Payload) (bool, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return false, err
+	}
+	request, err := http.NewRequest(http.MethodPost, rule.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Cobra-Delivery", payload.ID)
+	if rule.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		request.Header.Set("X-Cobra-Timestamp", timestamp)
+		request.Header.Set("X-Cobra-Signature", Sign(rule.Secret, timestamp, body))
+	}
+
+	response, err := d.Client.Do(request)
+	if err != nil {
+		return true, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("unexpected status: %s", response.Status)
+	switch {
+	case response.StatusCode == http.StatusRequestTimeout, response.StatusCode == http.StatusTooManyRequests:
+		return true, err
+	case response.StatusCode >= 400 && response.StatusCode < 500:
+		// The endpoint rejected the request, so trying again will not help.
+		return false, err
+	}
+	return true, err
+}
+
+// Sign returns the value of the "X-Cobra-Signature" header.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns true if the signature matches the timestamp and body.
+//
+// This is what a receiver would use.
+func Verify(secret string, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Redeliver tries to deliver everything in the dead-letter file again (with
+// the usual retries), using the current rule for each one.
+//
+// Whatever still fails is left in the file.  The file is only replaced once
+// every delivery has been tried, so nothing is lost if this is interrupted
+// (the ones that already succeeded are sent again next time, with the same
+// IDs).  This returns the number of deliveries that succeeded and the number
+// that failed.
+func (d *Dispatcher) Redeliver() (int, int, error) {
+	if d.config.DeadLetterFile == "" {
+		return 0, 0, fmt.Errorf("no dead-letter file")
+	}
+	d.redeliverMutex.Lock()
+	defer d.redeliverMutex.Unlock()
+
+	d.mutex.Lock()
+	deadLetters, err := readDeadLetters(d.config.DeadLetterFile)
+	d.mutex.Unlock()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var succeeded int
+	var remaining []DeadLetter
+	for _, deadLetter := range deadLetters {
+		rule := Rule{
+			Name: deadLetter.Rule,
+			URL:  deadLetter.URL,
+		}
+		for _, r := range d.config.Rules {
+			if r.Name == deadLetter.Rule {
+				rule = r
+			}
+		}
+		failure := d.try(rule, deadLetter.Payload)
+		if failure == nil {
+			succeeded++
+		} else {
+			remaining = append(remaining, *failure)
+		}
+	}
+	failed := len(remaining)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	current, err := readDeadLetters(d.config.DeadLetterFile)
+	if err != nil {
+		return succeeded, failed, err
+	}
+	if len(current) > len(deadLetters) {
+		// These were added while the redelivery was running.
+		remaining = append(remaining, current[len(deadLetters):]...)
+	}
+	err = d.replaceDeadLettersLocked(remaining)
+	return succeeded, failed, err
+}
+
+// writeDeadLetters appends to the dead-letter file.
+func (d *Dispatcher) writeDeadLetters(deadLetters ...DeadLetter) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	file, err := os.OpenFile(d.config.DeadLetterFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+	for _, deadLetter := range deadLetters {
+		err := encoder.Encode(deadLetter)
+		if err != nil {
+			return err
+		}
+	}
+	return file.Close()
+}
+
+// replaceDeadLettersLocked replaces the dead-letter file.
+//
+// The new file is written next to it and then renamed over it, so the file is
+// never left partly written.
+func (d *Dispatcher) replaceDeadLettersLocked(deadLetters []DeadLetter) error {
+	filename := d.config.DeadLetterFile
+	file, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name()) // This fails harmlessly once the file has been renamed.
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+	for _, deadLetter := range deadLetters {
+		err := encoder.Encode(deadLetter)
+		if err != nil {
+			return err
+		}
+	}
+	err = file.Sync()
+	if err != nil {
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), filename)
+}
+
+// readDeadLetters reads the dead-letter file.
+//
+// A missing file has no dead letters.
+func readDeadLetters(filename string) ([]DeadLetter, error) {
+	file, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var result []DeadLetter
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var deadLetter DeadLetter
+		err := json.Unmarshal(scanner.Bytes(), &deadLetter)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode dead letter: %w", err)
+		}
+		result = append(result, deadLetter)
+	}
+	return result, scanner.Err()
+}
+
+// newID returns a random delivery ID.
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,218 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tekkamanendless/cobra-controls/server"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+func TestRuleMatch(t *testing.T) {
+	rule := Rule{
+		Conditions:  []Condition{ConditionDenied, ConditionFireAlarm, ConditionCard},
+		Cards:       []string{"17823439"},
+		Controllers: []string{"front"},
+	}
+	rows := []struct {
+		name      string
+		event     server.Event
+		condition Condition
+		matched   bool
+	}{
+		{name: "Denied", event: server.Event{Controller: "front", CardID: "17800001", Event: wire.RecordEventDenied}, condition: ConditionDenied, matched: true},
+		{name: "Granted", event: server.Event{Controller: "front", CardID: "17800001", Event: wire.RecordEventGranted}},
+		{name: "Card", event: server.Event{Controller: "front", CardID: "17823439", Event: wire.RecordEventGranted}, condition: ConditionCard, matched: true},
+		{name: "FireAlarm", event: server.Event{Controller: "front", Event: wire.RecordEventFireAlarm}, condition: ConditionFireAlarm, matched: true},
+		{name: "Duress", event: server.Event{Controller: "front", Event: wire.RecordEventDuress}},
+		{name: "OtherController", event: server.Event{Controller: "back", Event: wire.RecordEventDenied}},
+	}
+	for _, row := range rows {
+		t.Run(row.name, func(t *testing.T) {
+			condition, matched := rule.Match(row.event)
+			assert.Equal(t, row.matched, matched)
+			assert.Equal(t, row.condition, condition)
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	signature := Sign("secret", "1672226922", []byte(`{"ID":"x"}`))
+	assert.Equal(t, "sha256=", signature[:7])
+	assert.True(t, Verify("secret", "1672226922", []byte(`{"ID":"x"}`), signature))
+	assert.False(t, Verify("secret", "1672226923", []byte(`{"ID":"x"}`), signature))
+	assert.False(t, Verify("other", "1672226922", []byte(`{"ID":"x"}`), signature))
+}
+
+// receiver is a webhook endpoint that fails a given number of times.
+type receiver struct {
+	mutex    sync.Mutex
+	failures int
+	status   int
+	payloads []Payload
+	requests int
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, request *http.Request) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.requests++
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(r.status)
+		return
+	}
+	body, _ := io.ReadAll(request.Body)
+	if !Verify("secret", request.Header.Get("X-Cobra-Timestamp"), body, request.Header.Get("X-Cobra-Signature")) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var payload Payload
+	json.Unmarshal(body, &payload)
+	r.payloads = append(r.payloads, payload)
+}
+
+func TestDispatcher(t *testing.T) {
+	event := server.Event{
+		Index:      12,
+		Time:       time.Date(2022, 12, 28, 11, 28, 42, 0, time.UTC),
+		Controller: "front",
+		Door:       1,
+		CardID:     "17823439",
+		Event:      wire.RecordEventDenied,
+	}
+
+	newDispatcher := func(t *testing.T, url string) *Dispatcher {
+		d, err := NewDispatcher(Config{
+			Rules: []Rule{
+				{Name: "denied", URL: url, Secret: "secret", Conditions: []Condition{ConditionDenied}},
+				{Name: "fire", URL: url, Secret: "secret", Conditions: []Condition{ConditionFireAlarm}},
+			},
+			DeadLetterFile: filepath.Join(t.TempDir(), "dead-letters.json"),
+			MaxAttempts:    3,
+			RetryDelay:     "1ms",
+		})
+		require.Nil(t, err)
+		return d
+	}
+
+	t.Run("Retry", func(t *testing.T) {
+		r := &receiver{failures: 2, status: http.StatusServiceUnavailable}
+		httpServer := httptest.NewServer(r)
+		defer httpServer.Close()
+
+		d := newDispatcher(t, httpServer.URL)
+		d.Handle(event)
+		d.Wait()
+
+		assert.Equal(t, 3, r.requests)
+		require.Len(t, r.payloads, 1)
+		assert.Equal(t, "denied", r.payloads[0].Rule)
+		assert.Equal(t, ConditionDenied, r.payloads[0].Condition)
+		assert.Equal(t, event, r.payloads[0].Event)
+
+		deadLetters, err := readDeadLetters(d.config.DeadLetterFile)
+		require.Nil(t, err)
+		assert.Empty(t, deadLetters)
+	})
+	t.Run("DeadLetter", func(t *testing.T) {
+		r := &receiver{failures: 3, status: http.StatusInternalServerError}
+		httpServer := httptest.NewServer(r)
+		defer httpServer.Close()
+
+		d := newDispatcher(t, httpServer.URL)
+		d.Handle(event)
+		d.Wait()
+
+		assert.Equal(t, 3, r.requests)
+		assert.Empty(t, r.payloads)
+		deadLetters, err := readDeadLetters(d.config.DeadLetterFile)
+		require.Nil(t, err)
+		require.Len(t, deadLetters, 1)
+		assert.Equal(t, 3, deadLetters[0].Attempts)
+		assert.Equal(t, "denied", deadLetters[0].Rule)
+		assert.Equal(t, event, deadLetters[0].Payload.Event)
+
+		// The endpoint is back.
+		succeeded, failed, err := d.Redeliver()
+		require.Nil(t, err)
+		assert.Equal(t, 1, succeeded)
+		assert.Equal(t, 0, failed)
+		require.Len(t, r.payloads, 1)
+		assert.Equal(t, deadLetters[0].Payload.ID, r.payloads[0].ID)
+
+		deadLetters, err = readDeadLetters(d.config.DeadLetterFile)
+		require.Nil(t, err)
+		assert.Empty(t, deadLetters)
+	})
+	t.Run("Redeliver", func(t *testing.T) {
+		rejected := httptest.NewServer(&receiver{failures: 1, status: http.StatusBadRequest})
+		defer rejected.Close()
+
+		var d *Dispatcher
+		var during []DeadLetter
+		live := DeadLetter{Rule: "fire", URL: rejected.URL, Attempts: 3, Payload: Payload{ID: "live"}}
+		accepted := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The file is untouched while the redelivery is running, and a live failure can still be added.
+			var err error
+			during, err = readDeadLetters(d.config.DeadLetterFile)
+			require.Nil(t, err)
+			err = d.writeDeadLetters(live)
+			require.Nil(t, err)
+		}))
+		defer accepted.Close()
+
+		d = newDispatcher(t, accepted.URL)
+		original := []DeadLetter{
+			{Rule: "denied", URL: accepted.URL, Attempts: 3, Payload: Payload{ID: "a", Rule: "denied"}},
+			{Rule: "gone", URL: rejected.URL, Attempts: 3, Payload: Payload{ID: "b", Rule: "gone"}},
+		}
+		err := d.writeDeadLetters(original...)
+		require.Nil(t, err)
+
+		succeeded, failed, err := d.Redeliver()
+		require.Nil(t, err)
+		assert.Equal(t, 1, succeeded)
+		assert.Equal(t, 1, failed)
+		require.Len(t, during, 2)
+		assert.Equal(t, "a", during[0].Payload.ID)
+
+		deadLetters, err := readDeadLetters(d.config.DeadLetterFile)
+		require.Nil(t, err)
+		require.Len(t, deadLetters, 2)
+		assert.Equal(t, "b", deadLetters[0].Payload.ID)
+		assert.Equal(t, 1, deadLetters[0].Attempts)
+		assert.Equal(t, live, deadLetters[1])
+
+		files, err := filepath.Glob(filepath.Join(filepath.Dir(d.config.DeadLetterFile), "*"))
+		require.Nil(t, err)
+		assert.Equal(t, []string{d.config.DeadLetterFile}, files, "the temporary file is gone")
+	})
+	t.Run("NoRetry", func(t *testing.T) {
+		r := &receiver{failures: 1, status: http.StatusBadRequest}
+		httpServer := httptest.NewServer(r)
+		defer httpServer.Close()
+
+		d := newDispatcher(t, httpServer.URL)
+		d.Handle(event)
+		d.Wait()
+
+		assert.Equal(t, 1, r.requests)
+		deadLetters, err := readDeadLetters(d.config.DeadLetterFile)
+		require.Nil(t, err)
+		require.Len(t, deadLetters, 1)
+		assert.Equal(t, 1, deadLetters[0].Attempts)
+	})
+	t.Run("InvalidCondition", func(t *testing.T) {
+		_, err := NewDispatcher(Config{Rules: []Rule{{Name: "x", URL: "http://localhost", Conditions: []Condition{"bogus"}}}})
+		assert.NotNil(t, err)
+	})
+}
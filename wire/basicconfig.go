@@ -0,0 +1,192 @@
+package wire
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+const (
+	// BasicConfigLength is the length of the "basic" block of 0x10F9.
+	BasicConfigLength = 270
+	// SuperPasswordCount is the number of super password slots in the "basic" block.
+	SuperPasswordCount = 16
+	// NoSuperPassword is the value of an empty super password slot.
+	NoSuperPassword SuperPassword = 0xffff
+)
+
+const (
+	// basicConfigUnknown2Length is the length of the bytes between the control
+	// states and the super passwords.
+	basicConfigUnknown2Length = 62
+	// basicConfigUnknown3Length is the length of the bytes after the super
+	// passwords.
+	basicConfigUnknown3Length = BasicConfigLength - 2 - 2*4 - 4 - basicConfigUnknown2Length - 2*SuperPasswordCount
+)
+
+// basicConfigHeader is the start of a 0x10F9 request that pushes the "basic"
+// block: 3, 0, the index (1), and the kind (1 for basic; 4 is for access).
+var basicConfigHeader = []byte{3, 0, 1, 1}
+
+// SuperPassword is a super password; it opens a door from the keypad without a
+// card.
+//
+// A super password is never shown when it is formatted (with any verb) or
+// marshaled to JSON; use `uint16(password)` for the actual value.
+type SuperPassword uint16
+
+// ParseSuperPassword parses a super password of up to five digits.
+func ParseSuperPassword(value string) (SuperPassword, error) {
+	if len(value) == 0 || len(value) > 5 {
+		return 0, fmt.Errorf("invalid super password: expected 1 to 5 digits")
+	}
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("invalid super password: expected only digits")
+		}
+	}
+	v, err := strconv.ParseUint(value, 10, 16)
+	if err != nil || SuperPassword(v) == NoSuperPassword {
+		return 0, fmt.Errorf("invalid super password: it is too large")
+	}
+	return SuperPassword(v), nil
+}
+
+// IsSet returns true if the slot has a super password.
+func (p SuperPassword) IsSet() bool {
+	return p != NoSuperPassword
+}
+
+// String returns "set" or "none"; it never returns the super password itself.
+func (p SuperPassword) String() string {
+	if p.IsSet() {
+		return "set"
+	}
+	return "none"
+}
+
+// Format implements `fmt.Formatter` so that every verb shows the same thing as
+// `String`.
+func (p SuperPassword) Format(f fmt.State, verb rune) {
+	fmt.Fprint(f, p.String())
+}
+
+// MarshalJSON returns "set" or "none" (as a JSON string).
+func (p SuperPassword) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+// BasicConfig is the "basic" block that the vendor software pushes with 0x10F9.
+//
+// Only some of it is understood; the rest is kept as-is so that the block can
+// be sent back unchanged.  There is no known way to read the block from the
+// controller, so it has to come from a capture of the vendor software.
+type BasicConfig struct {
+	Unknown1       uint16
+	OpenDelays     [4]uint16 // These are in tenths of a second.
+	ControlStates  [4]uint8  // 1 is open, 2 is closed, and 3 is door controlled.
+	Unknown2       []byte    // The invalid card swiping setting is at offset 18.
+	SuperPasswords [SuperPasswordCount]SuperPassword
+	Unknown3       []byte // This is the rest of the block.
+}
+
+func (c *BasicConfig) Decode(reader *Reader) error {
+	var err error
+	c.Unknown1, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("field Unknown1: %w", err)
+	}
+	for i := range c.OpenDelays {
+		c.OpenDelays[i], err = reader.ReadUint16()
+		if err != nil {
+			return fmt.Errorf("field OpenDelays[%d]: %w", i, err)
+		}
+	}
+	for i := range c.ControlStates {
+		c.ControlStates[i], err = reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("field ControlStates[%d]: %w", i, err)
+		}
+	}
+	unknown2, err := reader.ReadBytes(basicConfigUnknown2Length)
+	if err != nil {
+		return fmt.Errorf("field Unknown2: %w", err)
+	}
+	c.Unknown2 = append([]byte{}, unknown2...)
+	for i := range c.SuperPasswords {
+		v, err := reader.ReadUint16()
+		if err != nil {
+			return fmt.Errorf("field SuperPasswords[%d]: %w", i, err)
+		}
+		c.SuperPasswords[i] = SuperPassword(v)
+	}
+	unknown3, err := reader.ReadBytes(basicConfigUnknown3Length)
+	if err != nil {
+		return fmt.Errorf("field Unknown3: %w", err)
+	}
+	c.Unknown3 = append([]byte{}, unknown3...)
+	return nil
+}
+
+func (c *BasicConfig) Encode(writer *Writer) error {
+	if len(c.Unknown2) != basicConfigUnknown2Length {
+		return fmt.Errorf("field Unknown2: invalid length: %d (expected: %d)", len(c.Unknown2), basicConfigUnknown2Length)
+	}
+	if len(c.Unknown3) != basicConfigUnknown3Length {
+		return fmt.Errorf("field Unknown3: invalid length: %d (expected: %d)", len(c.Unknown3), basicConfigUnknown3Length)
+	}
+	writer.WriteUint16(c.Unknown1)
+	for _, v := range c.OpenDelays {
+		writer.WriteUint16(v)
+	}
+	for _, v := range c.ControlStates {
+		writer.WriteUint8(v)
+	}
+	writer.WriteBytes(c.Unknown2)
+	for _, v := range c.SuperPasswords {
+		writer.WriteUint16(uint16(v))
+	}
+	writer.WriteBytes(c.Unknown3)
+	return nil
+}
+
+// BasicConfigRequest is a 0x10F9 request that pushes the "basic" block.
+type BasicConfigRequest struct {
+	Config  BasicConfig
+	Padding []byte // This is whatever followed the block in the captured request; it is sent as-is.
+}
+
+func (r *BasicConfigRequest) Decode(reader *Reader) error {
+	header, err := reader.ReadBytes(len(basicConfigHeader))
+	if err != nil {
+		return fmt.Errorf("could not read header: %w", err)
+	}
+	if !bytes.Equal(header, basicConfigHeader) {
+		return fmt.Errorf("not a basic block: header is %x (expected: %x)", header, basicConfigHeader)
+	}
+	block, err := reader.Read(BasicConfigLength)
+	if err != nil {
+		return fmt.Errorf("could not read block: %w", err)
+	}
+	err = r.Config.Decode(block)
+	if err != nil {
+		return err
+	}
+	padding, err := reader.ReadBytes(reader.Length())
+	if err != nil {
+		return fmt.Errorf("could not read padding: %w", err)
+	}
+	r.Padding = append([]byte{}, padding...)
+	return nil
+}
+
+func (r *BasicConfigRequest) Encode(writer *Writer) error {
+	writer.WriteBytes(basicConfigHeader)
+	err := r.Config.Encode(writer)
+	if err != nil {
+		return err
+	}
+	writer.WriteBytes(r.Padding)
+	return nil
+}
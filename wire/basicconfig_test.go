@@ -0,0 +1,113 @@
+package wire
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// basicConfigExample is a "basic" block request from the vendor software, with
+// an open delay of 5 seconds and door-controlled doors, followed by some
+// padding.
+const basicConfigExample = "03000101" +
+	"0000" + "3200320032003200" + "03030303" +
+	"000000000000000000000000000000010100100000fa006401015500000000700000000000000000000000000000000000000000000000000000000084941309ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000d00000000000000000000000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000ff000000000000000000000000000000000000000000000000000000000000000000fcffc3e3f929001000fcffffff0f" +
+	"00000000"
+
+func TestBasicConfigRequest(t *testing.T) {
+	input, err := hex.DecodeString(basicConfigExample)
+	require.Nil(t, err)
+
+	var request BasicConfigRequest
+	err = Decode(NewReader(input), &request)
+	require.Nil(t, err)
+	assert.Equal(t, [4]uint16{50, 50, 50, 50}, request.Config.OpenDelays)
+	assert.Equal(t, [4]uint8{3, 3, 3, 3}, request.Config.ControlStates)
+	assert.Len(t, request.Config.Unknown3, 162)
+	assert.Equal(t, SuperPassword(0x0913), request.Config.SuperPasswords[0])
+	for i := 1; i < SuperPasswordCount; i++ {
+		assert.False(t, request.Config.SuperPasswords[i].IsSet(), "slot %d", i)
+	}
+	assert.Equal(t, []byte{0, 0, 0, 0}, request.Padding)
+
+	// It encodes back to exactly the same bytes.
+	writer := NewWriter()
+	err = Encode(writer, &request)
+	require.Nil(t, err)
+	assert.Equal(t, input, writer.Bytes())
+
+	// Only the super password changes.
+	request.Config.SuperPasswords[1] = 1234
+	writer = NewWriter()
+	err = Encode(writer, &request)
+	require.Nil(t, err)
+	offset := 4 + 2 + 8 + 4 + 62 + 2
+	assert.Equal(t, input[:offset], writer.Bytes()[:offset])
+	assert.Equal(t, []byte{0xd2, 0x04}, writer.Bytes()[offset:offset+2])
+	assert.Equal(t, input[offset+2:], writer.Bytes()[offset+2:])
+
+	t.Run("Access block", func(t *testing.T) {
+		var request BasicConfigRequest
+		err := Decode(NewReader(append([]byte{3, 0, 0, 4}, input[4:]...)), &request)
+		assert.NotNil(t, err)
+	})
+	t.Run("Short", func(t *testing.T) {
+		var request BasicConfigRequest
+		err := Decode(NewReader(input[:100]), &request)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestParseSuperPassword(t *testing.T) {
+	rows := []struct {
+		input  string
+		output SuperPassword
+		valid  bool
+	}{
+		{input: "1234", output: 1234, valid: true},
+		{input: "0", output: 0, valid: true},
+		{input: "65534", output: 65534, valid: true},
+		{input: ""},
+		{input: "65535"},
+		{input: "65536"},
+		{input: "123456"},
+		{input: "12a4"},
+	}
+	for _, row := range rows {
+		t.Run(row.input, func(t *testing.T) {
+			password, err := ParseSuperPassword(row.input)
+			if !row.valid {
+				require.NotNil(t, err)
+				if row.input != "" {
+					assert.NotContains(t, err.Error(), row.input)
+				}
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, row.output, password)
+		})
+	}
+}
+
+func TestSuperPasswordFormat(t *testing.T) {
+	password := SuperPassword(2323)
+	for _, format := range []string{"%v", "%d", "%x", "%s", "%#v"} {
+		assert.Equal(t, "set", fmt.Sprintf(format, password), format)
+	}
+	assert.Equal(t, "none", fmt.Sprintf("%d", NoSuperPassword))
+
+	var config BasicConfig
+	config.SuperPasswords[0] = password
+	for _, format := range []string{"%v", "%+v", "%#v"} {
+		assert.NotContains(t, fmt.Sprintf(format, config), "2323", format)
+	}
+	contents, err := json.Marshal(config)
+	require.Nil(t, err)
+	assert.NotContains(t, string(contents), "2323")
+	assert.True(t, strings.HasPrefix(string(contents), "{"))
+}
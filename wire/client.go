@@ -5,6 +5,7 @@ import (
 	"net"
 	"os"
 	"reflect"
+	"strconv"
 	"sync"
 	"time"
 
@@ -28,9 +29,34 @@ type Client struct {
 
 	BufferSize int
 
+	// Location is the time zone that the controller's clock is kept in.
+	//
+	// The controllers keep wall time with no time zone; if this is set, then
+	// date-times are decoded in this time zone and encoded by converting them
+	// to it.  Otherwise, they are decoded as UTC and encoded as-is.
+	Location *time.Location
+
 	conn net.Conn
 }
 
+// WallClock returns the time as the controller's clock would show it (to the
+// second).
+//
+// If there is no location, then this is the local wall time as if it were UTC.
+func (c *Client) WallClock(t time.Time) time.Time {
+	if c.Location == nil {
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	}
+	return t.In(c.Location).Truncate(time.Second)
+}
+
+// newReader returns a reader that decodes date-times in the client's location.
+func (c *Client) newReader(contents []byte) *Reader {
+	reader := NewReader(contents)
+	reader.SetLocation(c.Location)
+	return reader
+}
+
 func (c *Client) init() error {
 	if len(c.Protocol) == 0 {
 		c.Protocol = ProtocolTCP
@@ -47,7 +73,7 @@ func (c *Client) init() error {
 
 		var err error
 		logrus.Debugf("Dialing (%s): %s:%d", c.Protocol, c.ControllerAddress, c.ControllerPort)
-		c.conn, err = net.Dial(string(c.Protocol), fmt.Sprintf("%s:%d", c.ControllerAddress, c.ControllerPort))
+		c.conn, err = net.Dial(string(c.Protocol), net.JoinHostPort(c.ControllerAddress, strconv.Itoa(int(c.ControllerPort))))
 		if err != nil {
 			return err
 		}
@@ -80,7 +106,7 @@ func (c *Client) RawUnicast(requestEnvelope Envelope) (*Envelope, error) {
 		if err != nil {
 			c.conn.Close()
 			c.conn = nil
-			return nil, fmt.Errorf("could not write message: %v", err)
+			return nil, fmt.Errorf("could not write message: %w", err)
 		}
 		logrus.Debugf("Bytes written: %d", bytesWritten)
 		if bytesWritten != messageWriter.Length() {
@@ -99,19 +125,19 @@ func (c *Client) RawUnicast(requestEnvelope Envelope) (*Envelope, error) {
 		if err != nil {
 			c.conn.Close()
 			c.conn = nil
-			return nil, fmt.Errorf("could not read contents: %v", err)
+			return nil, fmt.Errorf("could not read contents: %w", err)
 		}
 		contents = contents[0:bytesRead]
-		logrus.Debugf("Bytes read: (%d) %x", bytesRead, contents)
+		logrus.Debugf("Bytes read: (%d) %s", bytesRead, loggableContents(requestEnvelope.Function, contents))
 
 		reader := NewReader(contents)
 
 		var responseEnvelope Envelope
 		err = Decode(reader, &responseEnvelope)
 		if err != nil {
-			return nil, fmt.Errorf("could not decode envelope: %v", err)
+			return nil, fmt.Errorf("could not decode envelope: %w", err)
 		}
-		logrus.Debugf("Response: %x", responseEnvelope.Contents)
+		logrus.Debugf("Response: %s", loggableContents(requestEnvelope.Function, responseEnvelope.Contents))
 
 		return &responseEnvelope, nil
 	}
@@ -209,7 +235,7 @@ func (c *Client) RawMulticast(requestEnvelope Envelope) ([]*Envelope, error) {
 				}
 				_ = sourceAddress
 				contents = contents[0:bytesRead]
-				logrus.Debugf("Bytes read: (%d) %x", bytesRead, contents)
+				logrus.Debugf("Bytes read: (%d) %s", bytesRead, loggableContents(requestEnvelope.Function, contents))
 
 				mutex.Lock()
 				packets = append(packets, contents)
@@ -229,7 +255,7 @@ func (c *Client) RawMulticast(requestEnvelope Envelope) ([]*Envelope, error) {
 		if err != nil {
 			return nil, fmt.Errorf("could not decode envelope %d: %v", i, err)
 		}
-		logrus.Debugf("Response %d: %x", i, responseEnvelope.Contents)
+		logrus.Debugf("Response %d: %s", i, loggableContents(requestEnvelope.Function, responseEnvelope.Contents))
 
 		responseEnvelopes = append(responseEnvelopes, &responseEnvelope)
 	}
@@ -237,6 +263,17 @@ func (c *Client) RawMulticast(requestEnvelope Envelope) ([]*Envelope, error) {
 	return responseEnvelopes, nil
 }
 
+// loggableContents returns the contents of a message for a debug log.
+//
+// The contents of a secret function (see `FunctionInfo.Secret`) are replaced
+// by their length.
+func loggableContents(function uint16, contents []byte) string {
+	if info := LookupFunction(function); info != nil && info.Secret {
+		return fmt.Sprintf("(%d bytes redacted)", len(contents))
+	}
+	return fmt.Sprintf("%x", contents)
+}
+
 // Do performs a request and decodes the response.
 func (c *Client) Do(functionCode uint16, request any, response any) error {
 	_, err := c.DoWithEnvelopes(functionCode, request, response)
@@ -250,7 +287,13 @@ func (c *Client) DoWithEnvelopes(functionCode uint16, request any, response any)
 		return nil, err
 	}
 
+	logrus.Debugf("Function: %s (0x%04x)", FunctionName(functionCode), functionCode)
+	if err := checkFunctionTypes(functionCode, request, response); err != nil {
+		return nil, err
+	}
+
 	payloadWriter := NewWriter()
+	payloadWriter.SetLocation(c.Location)
 	if request != nil {
 		err := Encode(payloadWriter, request)
 		if err != nil {
@@ -271,7 +314,7 @@ func (c *Client) DoWithEnvelopes(functionCode uint16, request any, response any)
 		}
 
 		if response != nil {
-			err = Decode(NewReader(responseEnvelope.Contents), response)
+			err = Decode(c.newReader(responseEnvelope.Contents), response)
 			if err != nil {
 				return nil, fmt.Errorf("could not decode response: %w", err)
 			}
@@ -297,7 +340,9 @@ func (c *Client) DoWithEnvelopes(functionCode uint16, request any, response any)
 		logrus.Debugf("Response type: %v", myValue.Type())
 
 		readMany := false
-		if myValue.Type().Kind() == reflect.Array {
+		if _, ok := response.(Decoder); ok {
+			logrus.Debugf("This decodes itself.")
+		} else if myValue.Type().Kind() == reflect.Array {
 			logrus.Debugf("This is an array.")
 			readMany = true
 		} else if myValue.Type().Kind() == reflect.Slice {
@@ -313,9 +358,9 @@ func (c *Client) DoWithEnvelopes(functionCode uint16, request any, response any)
 			responseEnvelope := responseEnvelopes[0]
 
 			if response != nil {
-				err = Decode(NewReader(responseEnvelope.Contents), response)
+				err = Decode(c.newReader(responseEnvelope.Contents), response)
 				if err != nil {
-					return nil, fmt.Errorf("could not decode response: %v", err)
+					return nil, fmt.Errorf("could not decode response: %w", err)
 				}
 			}
 		} else {
@@ -329,9 +374,9 @@ func (c *Client) DoWithEnvelopes(functionCode uint16, request any, response any)
 						logrus.Debugf("Hit the capacity of the array or slice: %d", myValue.Cap())
 						break
 					}
-					err = Decode(NewReader(responseEnvelope.Contents), myValue.Index(i).Addr().Interface())
+					err = Decode(c.newReader(responseEnvelope.Contents), myValue.Index(i).Addr().Interface())
 					if err != nil {
-						return nil, fmt.Errorf("could not decode response %d: %v", i, err)
+						return nil, fmt.Errorf("could not decode response %d: %w", i, err)
 					}
 				}
 			}
@@ -342,3 +387,39 @@ func (c *Client) DoWithEnvelopes(functionCode uint16, request any, response any)
 
 	return nil, fmt.Errorf("invalid protocol: %s", c.Protocol)
 }
+
+// checkFunctionTypes returns an error if the request or response does not
+// match the types registered for the function.
+//
+// A `RawMessage` is always allowed, as is a slice or array of the response type.
+func checkFunctionTypes(functionCode uint16, request any, response any) error {
+	info := LookupFunction(functionCode)
+	if info == nil {
+		return nil
+	}
+
+	matches := func(expected reflect.Type, value any) bool {
+		if expected == nil || value == nil {
+			return true
+		}
+		myType := reflect.TypeOf(value)
+		for myType.Kind() == reflect.Pointer {
+			myType = myType.Elem()
+		}
+		if myType == reflect.TypeOf(RawMessage{}) {
+			return true
+		}
+		if myType.Kind() == reflect.Slice || myType.Kind() == reflect.Array {
+			myType = myType.Elem()
+		}
+		return myType == expected
+	}
+
+	if !matches(info.Request, request) {
+		return fmt.Errorf("invalid request type for %s: %T (expected: %v)", info.Name, request, info.Request)
+	}
+	if !matches(info.Response, response) {
+		return fmt.Errorf("invalid response type for %s: %T (expected: %v)", info.Name, response, info.Response)
+	}
+	return nil
+}
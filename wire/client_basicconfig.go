@@ -0,0 +1,22 @@
+package wire
+
+import (
+	"fmt"
+)
+
+// PushBasicConfig sends the "basic" block to the controller and returns the
+// result byte of the response.
+//
+// The meaning of the result is not known yet, so it is up to the caller to
+// decide what to make of it.
+func (c *Client) PushBasicConfig(request BasicConfigRequest) (uint8, error) {
+	var response RawMessage
+	err := c.Do(FunctionUnknown10F9, &request, &response)
+	if err != nil {
+		return 0, err
+	}
+	if len(response) == 0 {
+		return 0, fmt.Errorf("empty response")
+	}
+	return response[0], nil
+}
@@ -0,0 +1,52 @@
+package wire
+
+import (
+	"fmt"
+)
+
+// ControlPeriodMax is the highest control period index that will be queried.
+const ControlPeriodMax = 255
+
+// ControlPeriods returns all of the non-empty control periods on the controller.
+func (c *Client) ControlPeriods() ([]GetControlPeriodResponse, error) {
+	var result []GetControlPeriodResponse
+	for index := uint16(1); index <= ControlPeriodMax; index++ {
+		request := GetControlPeriodRequest{
+			TimeIndex: index,
+		}
+		var rawResponse RawMessage
+		err := c.Do(FunctionGetControlPeriod, &request, &rawResponse)
+		if err != nil {
+			return nil, fmt.Errorf("could not read control period %d: %w", index, err)
+		}
+		// An empty slot has no valid dates, so it cannot be decoded.
+		if len(rawResponse) < 2 || IsAll(rawResponse[2:], 0) {
+			continue
+		}
+		var response GetControlPeriodResponse
+		err = Decode(c.newReader(rawResponse), &response)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode control period %d: %w", index, err)
+		}
+		result = append(result, response)
+	}
+	return result, nil
+}
+
+// SetControlPeriod stores a control period on the controller.
+func (c *Client) SetControlPeriod(request UpdateControlPeriodRequest) error {
+	var response UpdateControlPeriodResponse
+	return c.Do(FunctionUpdateControlPeriod, &request, &response)
+}
+
+// ClearControlPeriod empties a control period.
+//
+// An empty slot reads back as its index followed by zeroes, so that is what is
+// written.
+func (c *Client) ClearControlPeriod(index uint16) error {
+	writer := NewWriter()
+	writer.WriteUint16(index)
+	request := RawMessage(writer.Bytes())
+	var response RawMessage
+	return c.Do(FunctionUpdateControlPeriod, &request, &response)
+}
@@ -0,0 +1,84 @@
+package wire
+
+import (
+	"fmt"
+)
+
+// OperationStatus returns the operation status along with the record at the
+// given index (0 means the latest record).
+func (c *Client) OperationStatus(recordIndex uint32) (*GetOperationStatusResponse, error) {
+	request := GetOperationStatusRequest{
+		RecordIndex: recordIndex,
+	}
+	var response GetOperationStatusResponse
+	err := c.Do(FunctionGetOperationStatus, &request, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Permissions returns all of the permissions stored on the controller.
+func (c *Client) Permissions() ([]GetUploadResponse, error) {
+	status, err := c.OperationStatus(0)
+	if err != nil {
+		return nil, fmt.Errorf("could not get operation status: %w", err)
+	}
+
+	var result []GetUploadResponse
+	for index := uint16(1); index <= status.PopedomAmount; index++ {
+		request := GetUploadRequest{
+			Index: index,
+		}
+		var rawResponse RawMessage
+		err := c.Do(FunctionGetUpload, &request, &rawResponse)
+		if err != nil {
+			return nil, fmt.Errorf("could not read permission %d: %w", index, err)
+		}
+		// An empty slot has no valid dates, so it cannot be decoded.
+		if IsAll(rawResponse, 0) || IsAll(rawResponse, 0xff) {
+			continue
+		}
+		var response GetUploadResponse
+		err = Decode(c.newReader(rawResponse), &response)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode permission %d: %w", index, err)
+		}
+		result = append(result, response)
+	}
+	return result, nil
+}
+
+// Permission returns the card's permission for the door.
+//
+// If there is no such permission, this returns nil.
+func (c *Client) Permission(area uint8, idNumber uint16, door uint8) (*GetUploadResponse, error) {
+	permissions, err := c.Permissions()
+	if err != nil {
+		return nil, err
+	}
+	for _, permission := range permissions {
+		if permission.AreaNumber == area && permission.IDNumber == idNumber && permission.DoorNumber == door {
+			return &permission, nil
+		}
+	}
+	return nil, nil
+}
+
+// AddPermission adds (or updates) a card's permission for a door.
+func (c *Client) AddPermission(request UpdatePermissionsRequest) error {
+	if request.Standby == nil {
+		request.Standby = []byte{0, 0, 0, 0}
+	}
+	var response UpdatePermissionsResponse
+	return c.Do(FunctionUpdatePermissions, &request, &response)
+}
+
+// DeletePermission removes a card's permission for a door.
+func (c *Client) DeletePermission(request DeletePermissionsRequest) error {
+	if request.Standby == nil {
+		request.Standby = []byte{0, 0, 0, 0}
+	}
+	var response DeletePermissionsResponse
+	return c.Do(FunctionDeletePermissions, &request, &response)
+}
@@ -0,0 +1,58 @@
+package wire
+
+import (
+	"fmt"
+)
+
+// Setting reads the value of the setting register at the given address.
+func (c *Client) Setting(address uint8) (uint8, error) {
+	request := GetSettingRequest{
+		Address: address,
+	}
+	var response GetSettingResponse
+	err := c.Do(FunctionGetSetting, &request, &response)
+	if err != nil {
+		return 0, err
+	}
+	return response.Value, nil
+}
+
+// Settings reads the entire setting register space.
+//
+// The result is indexed by address.
+func (c *Client) Settings() ([]uint8, error) {
+	result := make([]uint8, 256)
+	for address := 0; address < len(result); address++ {
+		value, err := c.Setting(uint8(address))
+		if err != nil {
+			return nil, fmt.Errorf("could not read setting 0x%02x: %w", address, err)
+		}
+		result[address] = value
+	}
+	return result, nil
+}
+
+// SetSetting writes the value of the setting register at the given address.
+//
+// If the setting is known, then the value is validated first.
+func (c *Client) SetSetting(address uint8, value uint8) error {
+	if setting := LookupSettingByAddress(address); setting != nil {
+		if err := setting.Validate(value); err != nil {
+			return err
+		}
+	}
+
+	request := UpdateSettingRequest{
+		Address: address,
+		Value:   value,
+	}
+	var response UpdateSettingResponse
+	err := c.Do(FunctionUpdateSetting, &request, &response)
+	if err != nil {
+		return err
+	}
+	if response.Result != 1 {
+		return fmt.Errorf("controller refused the setting: result %d", response.Result)
+	}
+	return nil
+}
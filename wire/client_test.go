@@ -0,0 +1,183 @@
+package wire
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPipeClient returns a TCP client connected to a fake controller that
+// answers every request with the given response contents.
+func newPipeClient(t *testing.T, respond func(request Envelope) []byte) *Client {
+	clientConn, controllerConn := net.Pipe()
+	t.Cleanup(func() {
+		clientConn.Close()
+		controllerConn.Close()
+	})
+	go func() {
+		buffer := make([]byte, 1024)
+		for {
+			bytesRead, err := controllerConn.Read(buffer)
+			if err != nil {
+				return
+			}
+			var request Envelope
+			err = Decode(NewReader(buffer[:bytesRead]), &request)
+			if err != nil {
+				return
+			}
+			response := Envelope{
+				BoardAddress: request.BoardAddress,
+				Function:     request.Function,
+				Contents:     respond(request),
+			}
+			writer := NewWriter()
+			err = Encode(writer, &response)
+			if err != nil {
+				return
+			}
+			_, err = controllerConn.Write(writer.Bytes())
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return &Client{
+		Protocol:     ProtocolTCP,
+		BoardAddress: 0xf010,
+		conn:         clientConn,
+	}
+}
+
+func TestCheckFunctionTypes(t *testing.T) {
+	assert.Nil(t, checkFunctionTypes(FunctionOpenDoor, &OpenDoorRequest{}, &OpenDoorResponse{}))
+	assert.Nil(t, checkFunctionTypes(FunctionOpenDoor, OpenDoorRequest{}, nil))
+	assert.Nil(t, checkFunctionTypes(FunctionGetBasicInfo, nil, &GetBasicInfoResponse{}))
+	assert.Nil(t, checkFunctionTypes(FunctionGetNetworkInfo, &GetNetworkInfoRequest{}, &[]GetNetworkInfoResponse{}))
+	assert.Nil(t, checkFunctionTypes(FunctionGetUpload, &GetUploadRequest{}, &RawMessage{}))
+	assert.Nil(t, checkFunctionTypes(FunctionUnknown10F9, &RawMessage{}, &RawMessage{}))
+	assert.Nil(t, checkFunctionTypes(0x1234, &OpenDoorRequest{}, &GetBasicInfoResponse{}))
+
+	assert.NotNil(t, checkFunctionTypes(FunctionGetBasicInfo, &OpenDoorRequest{}, &OpenDoorResponse{}))
+	assert.NotNil(t, checkFunctionTypes(FunctionOpenDoor, &OpenDoorRequest{}, &GetBasicInfoResponse{}))
+	assert.NotNil(t, checkFunctionTypes(FunctionGetNetworkInfo, &GetNetworkInfoRequest{}, &[]GetBasicInfoResponse{}))
+}
+
+func TestLoggableContents(t *testing.T) {
+	assert.Equal(t, "0102", loggableContents(FunctionGetOperationStatus, []byte{1, 2}))
+	assert.Equal(t, "(3 bytes redacted)", loggableContents(FunctionGetUpload, []byte{1, 2, 3}))
+	assert.Equal(t, "(3 bytes redacted)", loggableContents(FunctionUpdatePermissions, []byte{1, 2, 3}))
+	assert.Equal(t, "0102", loggableContents(0x1234, []byte{1, 2}))
+}
+
+func TestSetSetting(t *testing.T) {
+	result := uint8(1)
+	client := newPipeClient(t, func(request Envelope) []byte {
+		return []byte{result}
+	})
+
+	err := client.SetSetting(0x40, 1)
+	require.Nil(t, err)
+
+	result = 0
+	err = client.SetSetting(0x40, 1)
+	assert.NotNil(t, err)
+}
+
+func TestPushBasicConfig(t *testing.T) {
+	input, err := hex.DecodeString(basicConfigExample)
+	require.Nil(t, err)
+	var request BasicConfigRequest
+	err = Decode(NewReader(input), &request)
+	require.Nil(t, err)
+
+	var contents []byte
+	client := newPipeClient(t, func(envelope Envelope) []byte {
+		contents = envelope.Contents
+		return []byte{1}
+	})
+	result, err := client.PushBasicConfig(request)
+	require.Nil(t, err)
+	assert.Equal(t, uint8(1), result)
+	assert.Equal(t, input, contents)
+}
+
+func TestSetTimingTask(t *testing.T) {
+	task := RealizeTimingTaskRequest{
+		TaskIndex:   1,
+		WeekControl: 0x1f,
+		Door:        1,
+		Control:     DoorControlOpen,
+		StartTime:   time.Date(0, time.January, 1, 8, 0, 0, 0, time.UTC),
+		StartDate:   time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:     time.Date(2050, time.December, 31, 0, 0, 0, 0, time.UTC),
+	}
+
+	// The contents of the response are not checked, since they are not known.
+	var contents []byte
+	client := newPipeClient(t, func(envelope Envelope) []byte {
+		contents = envelope.Contents
+		return make([]byte, 26)
+	})
+	client.Location = time.UTC
+	err := client.SetTimingTask(task)
+	require.Nil(t, err)
+	assert.Equal(t, "01001F010100004021009F650000000000000000000000000000", fmt.Sprintf("%X", contents))
+
+	task.TaskIndex = TimingTaskMax + 1
+	err = client.SetTimingTask(task)
+	assert.NotNil(t, err)
+}
+
+func TestDoUDPRawMessage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("the UDP client waits for every response until its deadline")
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer conn.Close()
+	go func() {
+		buffer := make([]byte, 1024)
+		for {
+			bytesRead, address, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			var request Envelope
+			err = Decode(NewReader(buffer[:bytesRead]), &request)
+			if err != nil {
+				continue
+			}
+			response := Envelope{
+				BoardAddress: request.BoardAddress,
+				Function:     request.Function,
+				Contents:     []byte{1, 2, 3},
+			}
+			writer := NewWriter()
+			err = Encode(writer, &response)
+			if err != nil {
+				continue
+			}
+			conn.WriteTo(writer.Bytes(), address)
+		}
+	}()
+
+	client := &Client{
+		Protocol:          ProtocolUDP,
+		ControllerAddress: "127.0.0.1",
+		ControllerPort:    uint16(conn.LocalAddr().(*net.UDPAddr).Port),
+		BoardAddress:      0xf010,
+	}
+	var response RawMessage
+	err = client.Do(FunctionGetUpload, &GetUploadRequest{Index: 1}, &response)
+	require.Nil(t, err)
+	require.Len(t, response, 26)
+	assert.Equal(t, []byte{1, 2, 3}, []byte(response[:3]))
+	assert.True(t, IsAll(response[3:], 0))
+}
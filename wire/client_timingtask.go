@@ -0,0 +1,42 @@
+package wire
+
+import (
+	"fmt"
+)
+
+// TimingTaskMax is the highest timing task slot.
+const TimingTaskMax = 64
+
+// SetTimingTask stores a timing task on the controller.
+//
+// There is no known way to read a timing task back, so the caller must keep
+// track of which slots are in use.
+func (c *Client) SetTimingTask(task RealizeTimingTaskRequest) error {
+	if task.TaskIndex == 0 || task.TaskIndex > TimingTaskMax {
+		return fmt.Errorf("invalid task index: %d", task.TaskIndex)
+	}
+	if task.Door < 1 || task.Door > 4 {
+		return fmt.Errorf("invalid door: %d", task.Door)
+	}
+	switch task.Control {
+	case DoorControlOpen, DoorControlClosed, DoorControlControlled:
+		// This is valid.
+	default:
+		return fmt.Errorf("invalid control: %d", task.Control)
+	}
+
+	// The contents of the response have not been captured yet, so only the
+	// function is checked.
+	var response RawMessage
+	envelopes, err := c.DoWithEnvelopes(FunctionRealizeTimingTask, &task, &response)
+	if err != nil {
+		return err
+	}
+	if len(envelopes) == 0 {
+		return fmt.Errorf("no response")
+	}
+	if envelopes[0].Function != FunctionRealizeTimingTask {
+		return fmt.Errorf("unexpected response function: 0x%04x", envelopes[0].Function)
+	}
+	return nil
+}
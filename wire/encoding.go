@@ -19,6 +19,12 @@ const (
 	TypeHexDateTime = "hexdatetime"
 )
 
+//go:generate go run ../cmd/wire-gen -output encoding_generated.go
+
+// reflectionOnly ignores the Encoder and Decoder interfaces on structs so that
+// the tests can compare the reflection path against the generated code.
+var reflectionOnly = false
+
 // Encoder can encode an object.
 type Encoder interface {
 	Encode(*Writer) error
@@ -31,17 +37,49 @@ type Decoder interface {
 
 // Encode an object to the wire.
 func Encode(writer *Writer, v any) error {
-	return encodeViaReflection(writer, v, encodingOptions{})
+	return encodeViaReflection(writer, v, TagOptions{})
 }
 
 // Decode an object from the wire.
 func Decode(reader *Reader, v any) error {
 	logrus.Debugf("Decode: v: %+v", v)
-	return decodeViaReflection(reader, reflect.ValueOf(v), encodingOptions{})
+	return decodeViaReflection(reader, reflect.ValueOf(v), TagOptions{})
 }
 
-func encodeViaReflection(writer *Writer, input any, options encodingOptions) error {
-	if e, ok := input.(Encoder); ok {
+// Defaults returns the struct with each zero integer field that has a "default"
+// option set to its default.
+//
+// The defaults are not applied when encoding, so that zero can still be sent;
+// build a request with this (for example, `Defaults(OpenDoorRequest{Door: 1})`)
+// to get them.
+func Defaults[T any](value T) T {
+	v := reflect.ValueOf(&value).Elem()
+	if v.Kind() != reflect.Struct {
+		return value
+	}
+	for i := 0; i < v.NumField(); i++ {
+		myField := v.Type().Field(i)
+		if !myField.IsExported() {
+			continue
+		}
+		options, err := parseOptionsFromTag(myField.Tag.Get("wire"))
+		if err != nil || options.Default == nil {
+			continue
+		}
+		fieldValue := v.Field(i)
+		switch fieldValue.Kind() {
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if fieldValue.Uint() == 0 {
+				fieldValue.SetUint(*options.Default)
+			}
+		}
+	}
+	return value
+}
+
+func encodeViaReflection(writer *Writer, input any, options TagOptions) error {
+	// A nil pointer is handled below (it may need to be written as null bytes).
+	if e, ok := input.(Encoder); ok && !isNilPointer(reflect.ValueOf(input)) && !(reflectionOnly && isStruct(reflect.TypeOf(input))) {
 		logrus.Debugf("encodeViaReflection: Encoding via Encoder: %T", input)
 		return e.Encode(writer)
 	}
@@ -60,6 +98,9 @@ func encodeViaReflection(writer *Writer, input any, options encodingOptions) err
 			logrus.Debugf("encodeViaReflection: input is nil.")
 			switch myType.Elem().Kind() {
 			case reflect.Struct:
+				if options.Length == 0 {
+					options.Length = options.TypeLength()
+				}
 				if options.Length > 0 {
 					if options.Null != nil {
 						logrus.Debugf("encodeViaReflection: writing %d bytes of 0x%x.", options.Length, *options.Null)
@@ -79,38 +120,24 @@ func encodeViaReflection(writer *Writer, input any, options encodingOptions) err
 	case reflect.Struct:
 		if timeValue, ok := input.(time.Time); ok {
 			logrus.Debugf("encodeViaReflection: This is a time.Time.")
-			if options.Type == "" {
-				options.Type = TypeDateTime
-			}
-			switch options.Type {
-			case TypeDate:
-				writer.WriteDate(timeValue)
-			case TypeTime:
-				writer.WriteTime(timeValue)
-			case TypeDateTime:
-				writer.WriteDate(timeValue)
-				writer.WriteTime(timeValue)
-			case TypeHexDate:
-				writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Year() - 2000)))
-				writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Month())))
-				writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Day())))
-			case TypeHexTime:
-				writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Hour())))
-				writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Minute())))
-				writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Second())))
-			case TypeHexDateTime:
-				writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Year() - 2000)))
-				writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Month())))
-				writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Day())))
-				writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Weekday())))
-				writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Hour())))
-				writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Minute())))
-				writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Second())))
-			default:
-				return fmt.Errorf(fieldPrefix+"unhandled type: %s", options.Type)
+			err := encodeTime(writer, timeValue, options.Type)
+			if err != nil {
+				return fmt.Errorf(fieldPrefix+"%w", err)
 			}
 		} else {
 			myValue := reflect.ValueOf(input)
+
+			// Consecutive bitfields share a byte until one of them needs a bit that is already in use.
+			var bits uint8
+			var bitsUsed uint8
+			flushBits := func() {
+				if bitsUsed != 0 {
+					writer.WriteUint8(bits)
+				}
+				bits = 0
+				bitsUsed = 0
+			}
+
 			for f := 0; f < myType.NumField(); f++ {
 				logrus.Debugf("encodeViaReflection: f: %d", f)
 				myField := myType.Field(f)
@@ -124,28 +151,59 @@ func encodeViaReflection(writer *Writer, input any, options encodingOptions) err
 					return err
 				}
 				options.Name = myField.Name
+				err = options.ValidateKind(myField.Type.Kind())
+				if err != nil {
+					return fmt.Errorf("field %s: %w", myField.Name, err)
+				}
 				var myFieldValue reflect.Value
 				if myField.IsExported() {
 					myFieldValue = myValue.Field(f)
 				} else {
 					myFieldValue = reflect.New(myField.Type)
 				}
+				if options.Bitfield {
+					if bitsUsed&options.BitMask() != 0 {
+						flushBits()
+					}
+					value, err := encodeBitfield(bitfieldValue(reflect.Indirect(myFieldValue)), options)
+					if err != nil {
+						return fmt.Errorf("field %s: %w", myField.Name, err)
+					}
+					bits |= value
+					bitsUsed |= options.BitMask()
+					continue
+				}
+				flushBits()
 				err = encodeViaReflection(writer, myFieldValue.Interface(), options)
 				if err != nil {
 					return err
 				}
+				writer.WriteBytes(make([]byte, options.Pad))
 			}
+			flushBits()
 		}
 	case reflect.Uint8:
-		writer.WriteUint8(input.(uint8))
+		v, err := encodeUint8(uint8(reflect.ValueOf(input).Uint()), options)
+		if err != nil {
+			return fmt.Errorf(fieldPrefix+"%w", err)
+		}
+		writer.WriteUint8(v)
 	case reflect.Uint16:
-		writer.WriteUint16(input.(uint16))
+		v, err := encodeUint(reflect.ValueOf(input).Uint(), options)
+		if err != nil {
+			return fmt.Errorf(fieldPrefix+"%w", err)
+		}
+		writer.WriteUint16(uint16(v))
 	case reflect.Uint32:
+		v, err := encodeUint(reflect.ValueOf(input).Uint(), options)
+		if err != nil {
+			return fmt.Errorf(fieldPrefix+"%w", err)
+		}
 		switch options.Type {
 		case TypeUint24:
-			writer.WriteUint24(input.(uint32))
+			writer.WriteUint24(uint32(v))
 		default:
-			writer.WriteUint32(input.(uint32))
+			writer.WriteUint32(uint32(v))
 		}
 	case reflect.Array:
 		var bytesToWrite []byte
@@ -202,12 +260,12 @@ func encodeViaReflection(writer *Writer, input any, options encodingOptions) err
 	return nil
 }
 
-func decodeViaReflection(reader *Reader, myValue reflect.Value, options encodingOptions) error {
+func decodeViaReflection(reader *Reader, myValue reflect.Value, options TagOptions) error {
 	logrus.Debugf("decodeViaReflection: myValue: %+v", myValue)
 	logrus.Debugf("decodeViaReflection: options: %+v", options)
 
 	if myValue != reflect.ValueOf(nil) && myValue.CanInterface() {
-		if d, ok := myValue.Interface().(Decoder); ok {
+		if d, ok := myValue.Interface().(Decoder); ok && !isNilPointer(myValue) && !(reflectionOnly && isStruct(myValue.Type())) {
 			logrus.Debugf("decodeViaReflection: Decoding via Decoder: %+v", myValue.Interface())
 			return d.Decode(reader)
 		}
@@ -229,14 +287,7 @@ func decodeViaReflection(reader *Reader, myValue reflect.Value, options encoding
 			switch myType.Elem().Kind() {
 			case reflect.Struct:
 				if options.Length == 0 {
-					switch options.Type {
-					case TypeDate:
-						options.Length = 2
-					case TypeTime:
-						options.Length = 2
-					case TypeDateTime:
-						options.Length = 4
-					}
+					options.Length = options.TypeLength()
 				}
 				if options.Length > 0 {
 					logrus.Debugf("decodeViaReflection: reading %d bytes.", options.Length)
@@ -267,141 +318,21 @@ func decodeViaReflection(reader *Reader, myValue reflect.Value, options encoding
 	case reflect.Struct:
 		if _, ok := myValue.Interface().(time.Time); ok {
 			logrus.Debugf("decodeViaReflection: This is a time.Time.")
-			if options.Type == "" {
-				options.Type = TypeDateTime
+			v, err := decodeTime(reader, options.Type)
+			if err != nil {
+				return fmt.Errorf(fieldPrefix+"%w", err)
 			}
-			switch options.Type {
-			case TypeDate:
-				v, err := reader.ReadDate()
-				if err != nil {
-					return fmt.Errorf(fieldPrefix+"could not read date: %w", err)
-				}
-				if myValue.CanSet() {
-					myValue.Set(reflect.ValueOf(v))
-				} else {
-					return fmt.Errorf(fieldPrefix+"could not set date: %w", err)
-				}
-			case TypeTime:
-				v, err := reader.ReadTime()
-				if err != nil {
-					return fmt.Errorf(fieldPrefix+"could not read time: %w", err)
-				}
-				if myValue.CanSet() {
-					myValue.Set(reflect.ValueOf(v))
-				} else {
-					return fmt.Errorf(fieldPrefix+"could not set time: %w", err)
-				}
-			case TypeDateTime:
-				v1, err := reader.ReadDate()
-				if err != nil {
-					return fmt.Errorf(fieldPrefix+"could not read date: %w", err)
-				}
-				v2, err := reader.ReadTime()
-				if err != nil {
-					return fmt.Errorf(fieldPrefix+"could not read time: %w", err)
-				}
-				v := MergeDateTime(v1, v2)
-				if myValue.CanSet() {
-					myValue.Set(reflect.ValueOf(v))
-				} else {
-					return fmt.Errorf(fieldPrefix+"could not set datetime: %w", err)
-				}
-			case TypeHexDate:
-				year, err := reader.ReadUint8()
-				if err != nil {
-					return fmt.Errorf(fieldPrefix+"could not read year: %w", err)
-				}
-				year = InsaneBase16ToBase10(year)
-				month, err := reader.ReadUint8()
-				if err != nil {
-					return fmt.Errorf(fieldPrefix+"could not read month: %w", err)
-				}
-				month = InsaneBase16ToBase10(month)
-				day, err := reader.ReadUint8()
-				if err != nil {
-					return fmt.Errorf(fieldPrefix+"could not read day: %w", err)
-				}
-				day = InsaneBase16ToBase10(day)
-
-				v := time.Date(int(year)+2000, time.Month(month), int(day), 0, 0, 0, 0, time.UTC)
-				if myValue.CanSet() {
-					myValue.Set(reflect.ValueOf(v))
-				} else {
-					return fmt.Errorf(fieldPrefix+"could not set date: %w", err)
-				}
-			case TypeHexDateTime:
-				year, err := reader.ReadUint8()
-				if err != nil {
-					return fmt.Errorf(fieldPrefix+"could not read year: %w", err)
-				}
-				year = InsaneBase16ToBase10(year)
-				month, err := reader.ReadUint8()
-				if err != nil {
-					return fmt.Errorf(fieldPrefix+"could not read month: %w", err)
-				}
-				month = InsaneBase16ToBase10(month)
-				day, err := reader.ReadUint8()
-				if err != nil {
-					return fmt.Errorf(fieldPrefix+"could not read day: %w", err)
-				}
-				day = InsaneBase16ToBase10(day)
-
-				weekday, err := reader.ReadUint8()
-				if err != nil {
-					return fmt.Errorf(fieldPrefix+"could not read weekday: %w", err)
-				}
-				weekday = InsaneBase16ToBase10(weekday)
-				_ = weekday
-
-				hour, err := reader.ReadUint8()
-				if err != nil {
-					return fmt.Errorf(fieldPrefix+"could not read hour: %w", err)
-				}
-				hour = InsaneBase16ToBase10(hour)
-				minute, err := reader.ReadUint8()
-				if err != nil {
-					return fmt.Errorf(fieldPrefix+"could not read minute: %w", err)
-				}
-				minute = InsaneBase16ToBase10(minute)
-				second, err := reader.ReadUint8()
-				if err != nil {
-					return fmt.Errorf(fieldPrefix+"could not read second: %w", err)
-				}
-				second = InsaneBase16ToBase10(second)
-
-				v := time.Date(int(year)+2000, time.Month(month), int(day), int(hour), int(minute), int(second), 0, time.UTC)
-				if myValue.CanSet() {
-					myValue.Set(reflect.ValueOf(v))
-				} else {
-					return fmt.Errorf(fieldPrefix+"could not set date: %w", err)
-				}
-			case TypeHexTime:
-				hour, err := reader.ReadUint8()
-				if err != nil {
-					return fmt.Errorf(fieldPrefix+"could not read hour: %w", err)
-				}
-				hour = InsaneBase16ToBase10(hour)
-				minute, err := reader.ReadUint8()
-				if err != nil {
-					return fmt.Errorf(fieldPrefix+"could not read minute: %w", err)
-				}
-				minute = InsaneBase16ToBase10(minute)
-				second, err := reader.ReadUint8()
-				if err != nil {
-					return fmt.Errorf(fieldPrefix+"could not read second: %w", err)
-				}
-				second = InsaneBase16ToBase10(second)
-
-				v := time.Date(0, time.January, 1, int(hour), int(minute), int(second), 0, time.UTC)
-				if myValue.CanSet() {
-					myValue.Set(reflect.ValueOf(v))
-				} else {
-					return fmt.Errorf(fieldPrefix+"could not set date: %w", err)
-				}
-			default:
-				return fmt.Errorf(fieldPrefix+"unhandled type: %s", options.Type)
+			if myValue.CanSet() {
+				myValue.Set(reflect.ValueOf(v))
+			} else {
+				return fmt.Errorf(fieldPrefix + "could not set time")
 			}
 		} else {
+			// Consecutive bitfields share a byte until one of them needs a bit that is already in use.
+			var bits uint8
+			var bitsUsed uint8
+			haveBits := false
+
 			for f := 0; f < myType.NumField(); f++ {
 				logrus.Debugf("decodeViaReflection: f: %d", f)
 				myField := myType.Field(f)
@@ -415,11 +346,44 @@ func decodeViaReflection(reader *Reader, myValue reflect.Value, options encoding
 					return err
 				}
 				options.Name = myField.Name
+				err = options.ValidateKind(myField.Type.Kind())
+				if err != nil {
+					return fmt.Errorf("field %s: %w", myField.Name, err)
+				}
 				myFieldValue := myValue.Field(f)
+				if options.Bitfield {
+					if !haveBits || bitsUsed&options.BitMask() != 0 {
+						bits, err = reader.ReadUint8()
+						if err != nil {
+							return fmt.Errorf("field %s: %w", myField.Name, err)
+						}
+						bitsUsed = 0
+						haveBits = true
+					}
+					bitsUsed |= options.BitMask()
+					value, err := decodeBitfield(bits, options)
+					if err != nil {
+						return fmt.Errorf("field %s: %w", myField.Name, err)
+					}
+					if !myField.IsExported() {
+						continue
+					}
+					if myFieldValue.Kind() == reflect.Bool {
+						myFieldValue.SetBool(value != 0)
+					} else {
+						myFieldValue.SetUint(uint64(value))
+					}
+					continue
+				}
+				haveBits = false
 				err = decodeViaReflection(reader, myFieldValue, options)
 				if err != nil {
 					return err
 				}
+				_, err = reader.ReadBytes(options.Pad)
+				if err != nil {
+					return fmt.Errorf("field %s: could not read padding: %w", myField.Name, err)
+				}
 			}
 		}
 	case reflect.Array:
@@ -490,6 +454,10 @@ func decodeViaReflection(reader *Reader, myValue reflect.Value, options encoding
 			return err
 		}
 		logrus.Debugf("decodeViaReflection: read uint8: %v", v)
+		v, err = decodeUint8(v, options)
+		if err != nil {
+			return fmt.Errorf(fieldPrefix+"%w", err)
+		}
 		if myValue.CanUint() {
 			myValue.SetUint(uint64(v))
 		} else {
@@ -504,6 +472,10 @@ func decodeViaReflection(reader *Reader, myValue reflect.Value, options encoding
 			return err
 		}
 		logrus.Debugf("decodeViaReflection: read uint16: %v", v)
+		err = checkEnum(uint64(v), options.Enum)
+		if err != nil {
+			return fmt.Errorf(fieldPrefix+"%w", err)
+		}
 		if myValue.CanUint() {
 			myValue.SetUint(uint64(v))
 		} else {
@@ -517,6 +489,10 @@ func decodeViaReflection(reader *Reader, myValue reflect.Value, options encoding
 				return err
 			}
 			logrus.Debugf("decodeViaReflection: read uint24: %v", v)
+			err = checkEnum(uint64(v), options.Enum)
+			if err != nil {
+				return fmt.Errorf(fieldPrefix+"%w", err)
+			}
 			if myValue.CanUint() {
 				myValue.SetUint(uint64(v))
 			} else {
@@ -528,6 +504,10 @@ func decodeViaReflection(reader *Reader, myValue reflect.Value, options encoding
 				return err
 			}
 			logrus.Debugf("decodeViaReflection: read uint32: %v", v)
+			err = checkEnum(uint64(v), options.Enum)
+			if err != nil {
+				return fmt.Errorf(fieldPrefix+"%w", err)
+			}
 			if myValue.CanUint() {
 				myValue.SetUint(uint64(v))
 			} else {
@@ -541,3 +521,291 @@ func decodeViaReflection(reader *Reader, myValue reflect.Value, options encoding
 	}
 	return nil
 }
+
+// encodeTime writes the time using the given Type* constant.
+//
+// If the type is empty, then TypeDateTime is used.
+func encodeTime(writer *Writer, timeValue time.Time, timeType string) error {
+	if timeType == "" {
+		timeType = TypeDateTime
+	}
+	if writer.location != nil && (timeType == TypeDateTime || timeType == TypeHexDateTime) {
+		timeValue = timeValue.In(writer.location)
+	}
+	switch timeType {
+	case TypeDate:
+		writer.WriteDate(timeValue)
+	case TypeTime:
+		writer.WriteTime(timeValue)
+	case TypeDateTime:
+		writer.WriteDate(timeValue)
+		writer.WriteTime(timeValue)
+	case TypeHexDate:
+		writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Year() - 2000)))
+		writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Month())))
+		writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Day())))
+	case TypeHexTime:
+		writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Hour())))
+		writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Minute())))
+		writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Second())))
+	case TypeHexDateTime:
+		writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Year() - 2000)))
+		writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Month())))
+		writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Day())))
+		writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Weekday())))
+		writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Hour())))
+		writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Minute())))
+		writer.WriteUint8(InsaneBase10ToBase16(uint8(timeValue.Second())))
+	default:
+		return fmt.Errorf("unhandled type: %s", timeType)
+	}
+	return nil
+}
+
+// decodeTime reads a time using the given Type* constant.
+//
+// If the type is empty, then TypeDateTime is used.
+func decodeTime(reader *Reader, timeType string) (time.Time, error) {
+	if timeType == "" {
+		timeType = TypeDateTime
+	}
+	// readHex reads the "insane" hexadecimal values (see InsaneBase16ToBase10).
+	readHex := func(names ...string) ([]int, error) {
+		var result []int
+		for _, name := range names {
+			v, err := reader.ReadUint8()
+			if err != nil {
+				return nil, fmt.Errorf("could not read %s: %w", name, err)
+			}
+			result = append(result, int(InsaneBase16ToBase10(v)))
+		}
+		return result, nil
+	}
+	switch timeType {
+	case TypeDate:
+		v, err := reader.ReadDate()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("could not read date: %w", err)
+		}
+		return v, nil
+	case TypeTime:
+		v, err := reader.ReadTime()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("could not read time: %w", err)
+		}
+		return v, nil
+	case TypeDateTime:
+		v1, err := reader.ReadDate()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("could not read date: %w", err)
+		}
+		v2, err := reader.ReadTime()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("could not read time: %w", err)
+		}
+		return MergeDateTimeIn(v1, v2, reader.location), nil
+	case TypeHexDate:
+		values, err := readHex("year", "month", "day")
+		if err != nil {
+			return time.Time{}, err
+		}
+		if values[0] > 99 {
+			return time.Time{}, fmt.Errorf("invalid year: %d", values[0])
+		}
+		return newDateTime(values[0]+2000, values[1], values[2], 0, 0, 0)
+	case TypeHexTime:
+		values, err := readHex("hour", "minute", "second")
+		if err != nil {
+			return time.Time{}, err
+		}
+		return newDateTime(0, int(time.January), 1, values[0], values[1], values[2])
+	case TypeHexDateTime:
+		values, err := readHex("year", "month", "day", "weekday", "hour", "minute", "second")
+		if err != nil {
+			return time.Time{}, err
+		}
+		if values[0] > 99 {
+			return time.Time{}, fmt.Errorf("invalid year: %d", values[0])
+		}
+		// The weekday is ignored; it is implied by the date.
+		v, err := newDateTime(values[0]+2000, values[1], values[2], values[4], values[5], values[6])
+		if err != nil {
+			return time.Time{}, err
+		}
+		return MergeDateTimeIn(v, v, reader.location), nil
+	}
+	return time.Time{}, fmt.Errorf("unhandled type: %s", timeType)
+}
+
+// encodeFixedBytes writes the bytes, padding them with zeroes up to the length.
+//
+// If the length is not positive, then the bytes are written as-is.
+func encodeFixedBytes(writer *Writer, bytesToWrite []byte, length int) error {
+	if length > 0 {
+		if len(bytesToWrite) > length {
+			return fmt.Errorf("invalid length: wrote %d (expected %d)", len(bytesToWrite), length)
+		}
+		writer.WriteBytes(bytesToWrite)
+		for i := len(bytesToWrite); i < length; i++ {
+			writer.WriteUint8(0x00)
+		}
+		return nil
+	}
+	writer.WriteBytes(bytesToWrite)
+	return nil
+}
+
+// decodeFixedBytes reads a copy of the given number of bytes.
+//
+// If the length is negative, then everything that remains is read.
+func decodeFixedBytes(reader *Reader, length int) ([]byte, error) {
+	if length < 0 {
+		length = reader.Length()
+	}
+	contents, err := reader.ReadBytes(length)
+	if err != nil {
+		return nil, fmt.Errorf("could not read remainder: %w", err)
+	}
+	result := make([]byte, len(contents))
+	copy(result, contents)
+	return result, nil
+}
+
+// decodeEmptyRemainder reads everything that remains and fails if any of it is non-zero.
+//
+// This is the `_ [0]byte` field with "length:*".
+func decodeEmptyRemainder(reader *Reader) error {
+	contents, err := reader.ReadBytes(reader.Length())
+	if err != nil {
+		return fmt.Errorf("could not read remainder: %w", err)
+	}
+	if !IsAll(contents, 0) {
+		return fmt.Errorf("unexpected length: %d (expected: %d)", len(contents), 0)
+	}
+	return nil
+}
+
+// encodeUint checks the enum for an integer that is about to be written.
+func encodeUint(value uint64, options TagOptions) (uint64, error) {
+	err := checkEnum(value, options.Enum)
+	if err != nil {
+		return 0, err
+	}
+	return value, nil
+}
+
+// encodeUint8 is encodeUint for a uint8 that may also be binary-coded decimal.
+func encodeUint8(value uint8, options TagOptions) (uint8, error) {
+	v, err := encodeUint(uint64(value), options)
+	if err != nil {
+		return 0, err
+	}
+	value = uint8(v)
+	if options.BCD {
+		return encodeBCD(value)
+	}
+	return value, nil
+}
+
+// decodeUint8 converts a uint8 from binary-coded decimal (if needed) and checks
+// the enum.
+func decodeUint8(value uint8, options TagOptions) (uint8, error) {
+	var err error
+	if options.BCD {
+		value, err = decodeBCD(value)
+		if err != nil {
+			return 0, err
+		}
+	}
+	err = checkEnum(uint64(value), options.Enum)
+	if err != nil {
+		return 0, err
+	}
+	return value, nil
+}
+
+// checkEnum returns an error if the value is not one of the allowed values.
+//
+// If there are no allowed values (nil), then every value is allowed.
+func checkEnum(value uint64, allowed []uint64) error {
+	if allowed == nil {
+		return nil
+	}
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid value: %d (expected one of: %v)", value, allowed)
+}
+
+// encodeBCD returns the binary-coded decimal form of the value.
+//
+// For example, 22 is 0x22.
+func encodeBCD(value uint8) (uint8, error) {
+	if value > 99 {
+		return 0, fmt.Errorf("invalid bcd value: %d", value)
+	}
+	return InsaneBase10ToBase16(value), nil
+}
+
+// decodeBCD returns the value of a binary-coded decimal byte.
+//
+// For example, 0x22 is 22.
+func decodeBCD(value uint8) (uint8, error) {
+	if value&0x0F > 9 || value>>4 > 9 {
+		return 0, fmt.Errorf("invalid bcd byte: 0x%02x", value)
+	}
+	return InsaneBase16ToBase10(value), nil
+}
+
+// encodeBitfield returns the value shifted into the bits of the bitfield.
+func encodeBitfield(value uint64, options TagOptions) (uint8, error) {
+	err := checkEnum(value, options.Enum)
+	if err != nil {
+		return 0, err
+	}
+	if value > uint64(options.BitMask()>>options.BitStart) {
+		return 0, fmt.Errorf("value too large for bits %d-%d: %d", options.BitStart, options.BitEnd, value)
+	}
+	return uint8(value) << options.BitStart, nil
+}
+
+// decodeBitfield returns the value of the bitfield from the byte.
+func decodeBitfield(bits uint8, options TagOptions) (uint8, error) {
+	value := (bits & options.BitMask()) >> options.BitStart
+	err := checkEnum(uint64(value), options.Enum)
+	if err != nil {
+		return 0, err
+	}
+	return value, nil
+}
+
+// bitfieldValue returns the integer value of a bitfield (either a uint8 or a bool).
+func bitfieldValue(v reflect.Value) uint64 {
+	if v.Kind() == reflect.Bool {
+		return boolValue(v.Bool())
+	}
+	return v.Uint()
+}
+
+// boolValue returns 1 for true and 0 for false.
+func boolValue(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// isStruct returns true if the type is a struct (or a pointer to one).
+func isStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// isNilPointer returns true if the value is a nil pointer.
+func isNilPointer(v reflect.Value) bool {
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
@@ -0,0 +1,1947 @@
+// Code generated by wire-gen; DO NOT EDIT.
+
+package wire
+
+import (
+	"fmt"
+)
+
+// Encode encodes the ClearUploadRequest.
+func (v *ClearUploadRequest) Encode(writer *Writer) error {
+	return nil
+}
+
+// Decode decodes the ClearUploadRequest.
+func (v *ClearUploadRequest) Decode(reader *Reader) error {
+	var err error
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the ClearUploadResponse.
+func (v *ClearUploadResponse) Encode(writer *Writer) error {
+	writer.WriteUint8(v.Result)
+	return nil
+}
+
+// Decode decodes the ClearUploadResponse.
+func (v *ClearUploadResponse) Decode(reader *Reader) error {
+	var err error
+	v.Result, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Result: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the DeletePermissionsRequest.
+func (v *DeletePermissionsRequest) Encode(writer *Writer) error {
+	writer.WriteUint16(v.Empty1)
+	writer.WriteUint16(v.CardID)
+	writer.WriteUint8(v.Area)
+	writer.WriteUint8(v.Door)
+	if v.StartDate == nil {
+		for i := 0; i < 2; i++ {
+			writer.WriteUint8(0x00)
+		}
+	} else {
+		err := encodeTime(writer, *v.StartDate, TypeDate)
+		if err != nil {
+			return fmt.Errorf("field StartDate: %w", err)
+		}
+	}
+	if v.EndDate == nil {
+		for i := 0; i < 2; i++ {
+			writer.WriteUint8(0x00)
+		}
+	} else {
+		err := encodeTime(writer, *v.EndDate, TypeDate)
+		if err != nil {
+			return fmt.Errorf("field EndDate: %w", err)
+		}
+	}
+	writer.WriteUint8(v.Time)
+	writer.WriteUint24(uint32(v.Password))
+	{
+		err := encodeFixedBytes(writer, v.Standby, 4)
+		if err != nil {
+			return fmt.Errorf("field Standby: %w", err)
+		}
+	}
+	return nil
+}
+
+// Decode decodes the DeletePermissionsRequest.
+func (v *DeletePermissionsRequest) Decode(reader *Reader) error {
+	var err error
+	v.Empty1, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("field Empty1: %w", err)
+	}
+	v.CardID, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("field CardID: %w", err)
+	}
+	v.Area, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Area: %w", err)
+	}
+	v.Door, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Door: %w", err)
+	}
+	{
+		subReader, err := reader.Read(2)
+		if err != nil {
+			return fmt.Errorf("field StartDate: could not read sub structure: %w", err)
+		}
+		v.StartDate = nil
+		if !IsAll(subReader.Bytes(), 0x00) {
+			value, err := decodeTime(subReader, TypeDate)
+			if err != nil {
+				return fmt.Errorf("field StartDate: could not decode sub structure: %w", err)
+			}
+			v.StartDate = &value
+		}
+	}
+	{
+		subReader, err := reader.Read(2)
+		if err != nil {
+			return fmt.Errorf("field EndDate: could not read sub structure: %w", err)
+		}
+		v.EndDate = nil
+		if !IsAll(subReader.Bytes(), 0x00) {
+			value, err := decodeTime(subReader, TypeDate)
+			if err != nil {
+				return fmt.Errorf("field EndDate: could not decode sub structure: %w", err)
+			}
+			v.EndDate = &value
+		}
+	}
+	v.Time, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Time: %w", err)
+	}
+	{
+		value, err := reader.ReadUint24()
+		if err != nil {
+			return fmt.Errorf("field Password: %w", err)
+		}
+		v.Password = PIN(value)
+	}
+	v.Standby, err = decodeFixedBytes(reader, 4)
+	if err != nil {
+		return fmt.Errorf("field Standby: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the DeletePermissionsResponse.
+func (v *DeletePermissionsResponse) Encode(writer *Writer) error {
+	writer.WriteUint8(v.Result)
+	return nil
+}
+
+// Decode decodes the DeletePermissionsResponse.
+func (v *DeletePermissionsResponse) Decode(reader *Reader) error {
+	var err error
+	v.Result, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Result: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the DeleteRecordRequest.
+func (v *DeleteRecordRequest) Encode(writer *Writer) error {
+	writer.WriteUint32(v.RecordIndex)
+	{
+		err := encodeFixedBytes(writer, v.Unknown1, 4)
+		if err != nil {
+			return fmt.Errorf("field Unknown1: %w", err)
+		}
+	}
+	return nil
+}
+
+// Decode decodes the DeleteRecordRequest.
+func (v *DeleteRecordRequest) Decode(reader *Reader) error {
+	var err error
+	v.RecordIndex, err = reader.ReadUint32()
+	if err != nil {
+		return fmt.Errorf("field RecordIndex: %w", err)
+	}
+	v.Unknown1, err = decodeFixedBytes(reader, 4)
+	if err != nil {
+		return fmt.Errorf("field Unknown1: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the DeleteRecordResponse.
+func (v *DeleteRecordResponse) Encode(writer *Writer) error {
+	writer.WriteUint8(v.Result)
+	return nil
+}
+
+// Decode decodes the DeleteRecordResponse.
+func (v *DeleteRecordResponse) Decode(reader *Reader) error {
+	var err error
+	v.Result, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Result: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the GetBasicInfoRequest.
+func (v *GetBasicInfoRequest) Encode(writer *Writer) error {
+	return nil
+}
+
+// Decode decodes the GetBasicInfoRequest.
+func (v *GetBasicInfoRequest) Decode(reader *Reader) error {
+	var err error
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the GetBasicInfoResponse.
+func (v *GetBasicInfoResponse) Encode(writer *Writer) error {
+	{
+		err := encodeTime(writer, v.IssueDate, TypeHexDate)
+		if err != nil {
+			return fmt.Errorf("field IssueDate: %w", err)
+		}
+	}
+	writer.WriteUint8(v.Version)
+	writer.WriteUint8(v.Model)
+	{
+		err := encodeFixedBytes(writer, v.Unknown1, 21)
+		if err != nil {
+			return fmt.Errorf("field Unknown1: %w", err)
+		}
+	}
+	return nil
+}
+
+// Decode decodes the GetBasicInfoResponse.
+func (v *GetBasicInfoResponse) Decode(reader *Reader) error {
+	var err error
+	v.IssueDate, err = decodeTime(reader, TypeHexDate)
+	if err != nil {
+		return fmt.Errorf("field IssueDate: %w", err)
+	}
+	v.Version, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Version: %w", err)
+	}
+	v.Model, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Model: %w", err)
+	}
+	v.Unknown1, err = decodeFixedBytes(reader, 21)
+	if err != nil {
+		return fmt.Errorf("field Unknown1: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the GetControlPeriodRequest.
+func (v *GetControlPeriodRequest) Encode(writer *Writer) error {
+	writer.WriteUint16(v.TimeIndex)
+	return nil
+}
+
+// Decode decodes the GetControlPeriodRequest.
+func (v *GetControlPeriodRequest) Decode(reader *Reader) error {
+	var err error
+	v.TimeIndex, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("field TimeIndex: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the GetControlPeriodResponse.
+func (v *GetControlPeriodResponse) Encode(writer *Writer) error {
+	writer.WriteUint16(v.TimeIndex)
+	writer.WriteUint8(v.WeekControl)
+	writer.WriteUint8(v.NextLinkTimeIndex)
+	writer.WriteUint8(v.Standby1)
+	writer.WriteUint8(v.Standby2)
+	{
+		err := encodeTime(writer, v.StartTime1, TypeTime)
+		if err != nil {
+			return fmt.Errorf("field StartTime1: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.EndTime1, TypeTime)
+		if err != nil {
+			return fmt.Errorf("field EndTime1: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.StartTime2, TypeTime)
+		if err != nil {
+			return fmt.Errorf("field StartTime2: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.EndTime2, TypeTime)
+		if err != nil {
+			return fmt.Errorf("field EndTime2: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.StartTime3, TypeTime)
+		if err != nil {
+			return fmt.Errorf("field StartTime3: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.EndTime3, TypeTime)
+		if err != nil {
+			return fmt.Errorf("field EndTime3: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.StartDate, TypeDate)
+		if err != nil {
+			return fmt.Errorf("field StartDate: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.EndDate, TypeDate)
+		if err != nil {
+			return fmt.Errorf("field EndDate: %w", err)
+		}
+	}
+	writer.WriteUint8(v.Standby3)
+	writer.WriteUint8(v.Standby4)
+	writer.WriteUint8(v.Standby5)
+	writer.WriteUint8(v.Standby6)
+	return nil
+}
+
+// Decode decodes the GetControlPeriodResponse.
+func (v *GetControlPeriodResponse) Decode(reader *Reader) error {
+	var err error
+	v.TimeIndex, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("field TimeIndex: %w", err)
+	}
+	v.WeekControl, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field WeekControl: %w", err)
+	}
+	v.NextLinkTimeIndex, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field NextLinkTimeIndex: %w", err)
+	}
+	v.Standby1, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby1: %w", err)
+	}
+	v.Standby2, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby2: %w", err)
+	}
+	v.StartTime1, err = decodeTime(reader, TypeTime)
+	if err != nil {
+		return fmt.Errorf("field StartTime1: %w", err)
+	}
+	v.EndTime1, err = decodeTime(reader, TypeTime)
+	if err != nil {
+		return fmt.Errorf("field EndTime1: %w", err)
+	}
+	v.StartTime2, err = decodeTime(reader, TypeTime)
+	if err != nil {
+		return fmt.Errorf("field StartTime2: %w", err)
+	}
+	v.EndTime2, err = decodeTime(reader, TypeTime)
+	if err != nil {
+		return fmt.Errorf("field EndTime2: %w", err)
+	}
+	v.StartTime3, err = decodeTime(reader, TypeTime)
+	if err != nil {
+		return fmt.Errorf("field StartTime3: %w", err)
+	}
+	v.EndTime3, err = decodeTime(reader, TypeTime)
+	if err != nil {
+		return fmt.Errorf("field EndTime3: %w", err)
+	}
+	v.StartDate, err = decodeTime(reader, TypeDate)
+	if err != nil {
+		return fmt.Errorf("field StartDate: %w", err)
+	}
+	v.EndDate, err = decodeTime(reader, TypeDate)
+	if err != nil {
+		return fmt.Errorf("field EndDate: %w", err)
+	}
+	v.Standby3, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby3: %w", err)
+	}
+	v.Standby4, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby4: %w", err)
+	}
+	v.Standby5, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby5: %w", err)
+	}
+	v.Standby6, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby6: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the GetNetworkInfoRequest.
+func (v *GetNetworkInfoRequest) Encode(writer *Writer) error {
+	writer.WriteUint8(v.Unknown1)
+	return nil
+}
+
+// Decode decodes the GetNetworkInfoRequest.
+func (v *GetNetworkInfoRequest) Decode(reader *Reader) error {
+	var err error
+	v.Unknown1, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Unknown1: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the GetNetworkInfoResponse.
+func (v *GetNetworkInfoResponse) Encode(writer *Writer) error {
+	{
+		err := encodeFixedBytes(writer, v.MACAddress, 6)
+		if err != nil {
+			return fmt.Errorf("field MACAddress: %w", err)
+		}
+	}
+	if v.IPAddress.To4() == nil {
+		return fmt.Errorf("field IPAddress: could not convert address to IPv4")
+	}
+	{
+		err := encodeFixedBytes(writer, v.IPAddress.To4(), 4)
+		if err != nil {
+			return fmt.Errorf("field IPAddress: %w", err)
+		}
+	}
+	if v.Netmask.To4() == nil {
+		return fmt.Errorf("field Netmask: could not convert address to IPv4")
+	}
+	{
+		err := encodeFixedBytes(writer, v.Netmask.To4(), 4)
+		if err != nil {
+			return fmt.Errorf("field Netmask: %w", err)
+		}
+	}
+	if v.Gateway.To4() == nil {
+		return fmt.Errorf("field Gateway: could not convert address to IPv4")
+	}
+	{
+		err := encodeFixedBytes(writer, v.Gateway.To4(), 4)
+		if err != nil {
+			return fmt.Errorf("field Gateway: %w", err)
+		}
+	}
+	writer.WriteUint16(v.Port)
+	return nil
+}
+
+// Decode decodes the GetNetworkInfoResponse.
+func (v *GetNetworkInfoResponse) Decode(reader *Reader) error {
+	var err error
+	v.MACAddress, err = decodeFixedBytes(reader, 6)
+	if err != nil {
+		return fmt.Errorf("field MACAddress: %w", err)
+	}
+	v.IPAddress, err = decodeFixedBytes(reader, 4)
+	if err != nil {
+		return fmt.Errorf("field IPAddress: %w", err)
+	}
+	v.Netmask, err = decodeFixedBytes(reader, 4)
+	if err != nil {
+		return fmt.Errorf("field Netmask: %w", err)
+	}
+	v.Gateway, err = decodeFixedBytes(reader, 4)
+	if err != nil {
+		return fmt.Errorf("field Gateway: %w", err)
+	}
+	v.Port, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("field Port: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the GetOperationStatusRequest.
+func (v *GetOperationStatusRequest) Encode(writer *Writer) error {
+	writer.WriteUint32(v.RecordIndex)
+	return nil
+}
+
+// Decode decodes the GetOperationStatusRequest.
+func (v *GetOperationStatusRequest) Decode(reader *Reader) error {
+	var err error
+	v.RecordIndex, err = reader.ReadUint32()
+	if err != nil {
+		return fmt.Errorf("field RecordIndex: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the GetOperationStatusResponse.
+func (v *GetOperationStatusResponse) Encode(writer *Writer) error {
+	{
+		err := encodeTime(writer, v.CurrentTime, TypeHexDateTime)
+		if err != nil {
+			return fmt.Errorf("field CurrentTime: %w", err)
+		}
+	}
+	writer.WriteUint24(v.RecordCount)
+	writer.WriteUint16(v.PopedomAmount)
+	if v.Record == nil {
+		for i := 0; i < 8; i++ {
+			writer.WriteUint8(0xff)
+		}
+	} else {
+		err := v.Record.Encode(writer)
+		if err != nil {
+			return fmt.Errorf("field Record: %w", err)
+		}
+	}
+	{
+		err := v.RelayStatus.Encode(writer)
+		if err != nil {
+			return fmt.Errorf("field RelayStatus: %w", err)
+		}
+	}
+	{
+		err := v.MagnetState.Encode(writer)
+		if err != nil {
+			return fmt.Errorf("field MagnetState: %w", err)
+		}
+	}
+	writer.WriteUint8(v.Reserved1)
+	writer.WriteUint8(uint8(v.FaultNumber))
+	writer.WriteUint8(v.Reserved2)
+	writer.WriteUint8(v.Reserved3)
+	return nil
+}
+
+// Decode decodes the GetOperationStatusResponse.
+func (v *GetOperationStatusResponse) Decode(reader *Reader) error {
+	var err error
+	v.CurrentTime, err = decodeTime(reader, TypeHexDateTime)
+	if err != nil {
+		return fmt.Errorf("field CurrentTime: %w", err)
+	}
+	v.RecordCount, err = reader.ReadUint24()
+	if err != nil {
+		return fmt.Errorf("field RecordCount: %w", err)
+	}
+	v.PopedomAmount, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("field PopedomAmount: %w", err)
+	}
+	{
+		subReader, err := reader.Read(8)
+		if err != nil {
+			return fmt.Errorf("field Record: could not read sub structure: %w", err)
+		}
+		v.Record = nil
+		if !IsAll(subReader.Bytes(), 0xff) {
+			var value Record
+			err := value.Decode(subReader)
+			if err != nil {
+				return fmt.Errorf("field Record: could not decode sub structure: %w", err)
+			}
+			v.Record = &value
+		}
+	}
+	err = v.RelayStatus.Decode(reader)
+	if err != nil {
+		return fmt.Errorf("field RelayStatus: %w", err)
+	}
+	err = v.MagnetState.Decode(reader)
+	if err != nil {
+		return fmt.Errorf("field MagnetState: %w", err)
+	}
+	v.Reserved1, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Reserved1: %w", err)
+	}
+	{
+		value, err := reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("field FaultNumber: %w", err)
+		}
+		v.FaultNumber = FaultNumber(value)
+	}
+	v.Reserved2, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Reserved2: %w", err)
+	}
+	v.Reserved3, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Reserved3: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the GetRecordRequest.
+func (v *GetRecordRequest) Encode(writer *Writer) error {
+	writer.WriteUint32(v.RecordIndex)
+	return nil
+}
+
+// Decode decodes the GetRecordRequest.
+func (v *GetRecordRequest) Decode(reader *Reader) error {
+	var err error
+	v.RecordIndex, err = reader.ReadUint32()
+	if err != nil {
+		return fmt.Errorf("field RecordIndex: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the GetRecordResponse.
+func (v *GetRecordResponse) Encode(writer *Writer) error {
+	writer.WriteUint16(v.CardNumber)
+	writer.WriteUint8(v.AreaNumber)
+	writer.WriteUint8(v.BrushCardState)
+	{
+		err := encodeTime(writer, v.BrushCardDateTime, TypeDateTime)
+		if err != nil {
+			return fmt.Errorf("field BrushCardDateTime: %w", err)
+		}
+	}
+	{
+		err := encodeFixedBytes(writer, v.Unknown1, -1)
+		if err != nil {
+			return fmt.Errorf("field Unknown1: %w", err)
+		}
+	}
+	return nil
+}
+
+// Decode decodes the GetRecordResponse.
+func (v *GetRecordResponse) Decode(reader *Reader) error {
+	var err error
+	v.CardNumber, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("field CardNumber: %w", err)
+	}
+	v.AreaNumber, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field AreaNumber: %w", err)
+	}
+	v.BrushCardState, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field BrushCardState: %w", err)
+	}
+	v.BrushCardDateTime, err = decodeTime(reader, TypeDateTime)
+	if err != nil {
+		return fmt.Errorf("field BrushCardDateTime: %w", err)
+	}
+	v.Unknown1, err = decodeFixedBytes(reader, -1)
+	if err != nil {
+		return fmt.Errorf("field Unknown1: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the GetSettingRequest.
+func (v *GetSettingRequest) Encode(writer *Writer) error {
+	writer.WriteUint8(v.Address)
+	writer.WriteUint8(v.Unknown1)
+	return nil
+}
+
+// Decode decodes the GetSettingRequest.
+func (v *GetSettingRequest) Decode(reader *Reader) error {
+	var err error
+	v.Address, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Address: %w", err)
+	}
+	v.Unknown1, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Unknown1: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the GetSettingResponse.
+func (v *GetSettingResponse) Encode(writer *Writer) error {
+	writer.WriteUint8(v.Value)
+	{
+		err := encodeFixedBytes(writer, v.Unknown1, -1)
+		if err != nil {
+			return fmt.Errorf("field Unknown1: %w", err)
+		}
+	}
+	return nil
+}
+
+// Decode decodes the GetSettingResponse.
+func (v *GetSettingResponse) Decode(reader *Reader) error {
+	var err error
+	v.Value, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Value: %w", err)
+	}
+	v.Unknown1, err = decodeFixedBytes(reader, -1)
+	if err != nil {
+		return fmt.Errorf("field Unknown1: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the GetUploadRequest.
+func (v *GetUploadRequest) Encode(writer *Writer) error {
+	writer.WriteUint16(v.Index)
+	return nil
+}
+
+// Decode decodes the GetUploadRequest.
+func (v *GetUploadRequest) Decode(reader *Reader) error {
+	var err error
+	v.Index, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("field Index: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the GetUploadResponse.
+func (v *GetUploadResponse) Encode(writer *Writer) error {
+	writer.WriteUint16(v.IDNumber)
+	writer.WriteUint8(v.AreaNumber)
+	writer.WriteUint8(v.DoorNumber)
+	{
+		err := encodeTime(writer, v.StartDate, TypeDate)
+		if err != nil {
+			return fmt.Errorf("field StartDate: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.EndDate, TypeDate)
+		if err != nil {
+			return fmt.Errorf("field EndDate: %w", err)
+		}
+	}
+	writer.WriteUint8(v.Time)
+	writer.WriteUint24(uint32(v.Password))
+	writer.WriteUint8(v.Standby1)
+	writer.WriteUint8(v.Standby2)
+	writer.WriteUint8(v.Standby3)
+	writer.WriteUint8(v.Standby4)
+	return nil
+}
+
+// Decode decodes the GetUploadResponse.
+func (v *GetUploadResponse) Decode(reader *Reader) error {
+	var err error
+	v.IDNumber, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("field IDNumber: %w", err)
+	}
+	v.AreaNumber, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field AreaNumber: %w", err)
+	}
+	v.DoorNumber, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field DoorNumber: %w", err)
+	}
+	v.StartDate, err = decodeTime(reader, TypeDate)
+	if err != nil {
+		return fmt.Errorf("field StartDate: %w", err)
+	}
+	v.EndDate, err = decodeTime(reader, TypeDate)
+	if err != nil {
+		return fmt.Errorf("field EndDate: %w", err)
+	}
+	v.Time, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Time: %w", err)
+	}
+	{
+		value, err := reader.ReadUint24()
+		if err != nil {
+			return fmt.Errorf("field Password: %w", err)
+		}
+		v.Password = PIN(value)
+	}
+	v.Standby1, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby1: %w", err)
+	}
+	v.Standby2, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby2: %w", err)
+	}
+	v.Standby3, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby3: %w", err)
+	}
+	v.Standby4, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby4: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the MagnetState.
+func (v *MagnetState) Encode(writer *Writer) error {
+	{
+		var bits uint8
+		{
+			value, err := encodeBitfield(boolValue(v.Door1), TagOptions{BitStart: 0, BitEnd: 0})
+			if err != nil {
+				return fmt.Errorf("field Door1: %w", err)
+			}
+			bits |= value
+		}
+		{
+			value, err := encodeBitfield(boolValue(v.Door2), TagOptions{BitStart: 1, BitEnd: 1})
+			if err != nil {
+				return fmt.Errorf("field Door2: %w", err)
+			}
+			bits |= value
+		}
+		{
+			value, err := encodeBitfield(boolValue(v.Door3), TagOptions{BitStart: 2, BitEnd: 2})
+			if err != nil {
+				return fmt.Errorf("field Door3: %w", err)
+			}
+			bits |= value
+		}
+		{
+			value, err := encodeBitfield(boolValue(v.Door4), TagOptions{BitStart: 3, BitEnd: 3})
+			if err != nil {
+				return fmt.Errorf("field Door4: %w", err)
+			}
+			bits |= value
+		}
+		{
+			value, err := encodeBitfield(uint64(v.Unused), TagOptions{BitStart: 4, BitEnd: 7})
+			if err != nil {
+				return fmt.Errorf("field Unused: %w", err)
+			}
+			bits |= value
+		}
+		writer.WriteUint8(bits)
+	}
+	return nil
+}
+
+// Decode decodes the MagnetState.
+func (v *MagnetState) Decode(reader *Reader) error {
+	var err error
+	{
+		var bits uint8
+		bits, err = reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("field Door1: %w", err)
+		}
+		{
+			value, err := decodeBitfield(bits, TagOptions{BitStart: 0, BitEnd: 0})
+			if err != nil {
+				return fmt.Errorf("field Door1: %w", err)
+			}
+			v.Door1 = value != 0
+		}
+		{
+			value, err := decodeBitfield(bits, TagOptions{BitStart: 1, BitEnd: 1})
+			if err != nil {
+				return fmt.Errorf("field Door2: %w", err)
+			}
+			v.Door2 = value != 0
+		}
+		{
+			value, err := decodeBitfield(bits, TagOptions{BitStart: 2, BitEnd: 2})
+			if err != nil {
+				return fmt.Errorf("field Door3: %w", err)
+			}
+			v.Door3 = value != 0
+		}
+		{
+			value, err := decodeBitfield(bits, TagOptions{BitStart: 3, BitEnd: 3})
+			if err != nil {
+				return fmt.Errorf("field Door4: %w", err)
+			}
+			v.Door4 = value != 0
+		}
+		{
+			value, err := decodeBitfield(bits, TagOptions{BitStart: 4, BitEnd: 7})
+			if err != nil {
+				return fmt.Errorf("field Unused: %w", err)
+			}
+			v.Unused = value
+		}
+	}
+	return nil
+}
+
+// Encode encodes the OpenDoorRequest.
+func (v *OpenDoorRequest) Encode(writer *Writer) error {
+	{
+		value := v.Door
+		var err error
+		err = checkEnum(uint64(value), []uint64{1, 2, 3, 4})
+		if err != nil {
+			return fmt.Errorf("field Door: %w", err)
+		}
+		writer.WriteUint8(value)
+	}
+	writer.WriteUint8(v.Unkonwn1)
+	return nil
+}
+
+// Decode decodes the OpenDoorRequest.
+func (v *OpenDoorRequest) Decode(reader *Reader) error {
+	var err error
+	{
+		value, err := reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("field Door: %w", err)
+		}
+		err = checkEnum(uint64(value), []uint64{1, 2, 3, 4})
+		if err != nil {
+			return fmt.Errorf("field Door: %w", err)
+		}
+		v.Door = value
+	}
+	v.Unkonwn1, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Unkonwn1: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the OpenDoorResponse.
+func (v *OpenDoorResponse) Encode(writer *Writer) error {
+	return nil
+}
+
+// Decode decodes the OpenDoorResponse.
+func (v *OpenDoorResponse) Decode(reader *Reader) error {
+	var err error
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the RealizeTimingTaskRequest.
+func (v *RealizeTimingTaskRequest) Encode(writer *Writer) error {
+	writer.WriteUint16(v.TaskIndex)
+	writer.WriteUint8(v.WeekControl)
+	writer.WriteUint8(v.Door)
+	writer.WriteUint8(v.Control)
+	writer.WriteUint8(v.Standby1)
+	{
+		err := encodeTime(writer, v.StartTime, TypeTime)
+		if err != nil {
+			return fmt.Errorf("field StartTime: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.StartDate, TypeDate)
+		if err != nil {
+			return fmt.Errorf("field StartDate: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.EndDate, TypeDate)
+		if err != nil {
+			return fmt.Errorf("field EndDate: %w", err)
+		}
+	}
+	writer.WriteUint8(v.Standby2)
+	writer.WriteUint8(v.Standby3)
+	return nil
+}
+
+// Decode decodes the RealizeTimingTaskRequest.
+func (v *RealizeTimingTaskRequest) Decode(reader *Reader) error {
+	var err error
+	v.TaskIndex, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("field TaskIndex: %w", err)
+	}
+	v.WeekControl, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field WeekControl: %w", err)
+	}
+	v.Door, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Door: %w", err)
+	}
+	v.Control, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Control: %w", err)
+	}
+	v.Standby1, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby1: %w", err)
+	}
+	v.StartTime, err = decodeTime(reader, TypeTime)
+	if err != nil {
+		return fmt.Errorf("field StartTime: %w", err)
+	}
+	v.StartDate, err = decodeTime(reader, TypeDate)
+	if err != nil {
+		return fmt.Errorf("field StartDate: %w", err)
+	}
+	v.EndDate, err = decodeTime(reader, TypeDate)
+	if err != nil {
+		return fmt.Errorf("field EndDate: %w", err)
+	}
+	v.Standby2, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby2: %w", err)
+	}
+	v.Standby3, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby3: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the Record.
+func (v *Record) Encode(writer *Writer) error {
+	writer.WriteUint16(v.IDNumber)
+	writer.WriteUint8(v.AreaNumber)
+	writer.WriteUint8(v.RecordState)
+	{
+		err := encodeTime(writer, v.BrushDateTime, TypeDateTime)
+		if err != nil {
+			return fmt.Errorf("field BrushDateTime: %w", err)
+		}
+	}
+	return nil
+}
+
+// Decode decodes the Record.
+func (v *Record) Decode(reader *Reader) error {
+	var err error
+	v.IDNumber, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("field IDNumber: %w", err)
+	}
+	v.AreaNumber, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field AreaNumber: %w", err)
+	}
+	v.RecordState, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field RecordState: %w", err)
+	}
+	v.BrushDateTime, err = decodeTime(reader, TypeDateTime)
+	if err != nil {
+		return fmt.Errorf("field BrushDateTime: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the RelayStatus.
+func (v *RelayStatus) Encode(writer *Writer) error {
+	{
+		var bits uint8
+		{
+			value, err := encodeBitfield(boolValue(v.Door1), TagOptions{BitStart: 0, BitEnd: 0})
+			if err != nil {
+				return fmt.Errorf("field Door1: %w", err)
+			}
+			bits |= value
+		}
+		{
+			value, err := encodeBitfield(boolValue(v.Door2), TagOptions{BitStart: 1, BitEnd: 1})
+			if err != nil {
+				return fmt.Errorf("field Door2: %w", err)
+			}
+			bits |= value
+		}
+		{
+			value, err := encodeBitfield(boolValue(v.Door3), TagOptions{BitStart: 2, BitEnd: 2})
+			if err != nil {
+				return fmt.Errorf("field Door3: %w", err)
+			}
+			bits |= value
+		}
+		{
+			value, err := encodeBitfield(boolValue(v.Door4), TagOptions{BitStart: 3, BitEnd: 3})
+			if err != nil {
+				return fmt.Errorf("field Door4: %w", err)
+			}
+			bits |= value
+		}
+		{
+			value, err := encodeBitfield(uint64(v.Unused), TagOptions{BitStart: 4, BitEnd: 7})
+			if err != nil {
+				return fmt.Errorf("field Unused: %w", err)
+			}
+			bits |= value
+		}
+		writer.WriteUint8(bits)
+	}
+	return nil
+}
+
+// Decode decodes the RelayStatus.
+func (v *RelayStatus) Decode(reader *Reader) error {
+	var err error
+	{
+		var bits uint8
+		bits, err = reader.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("field Door1: %w", err)
+		}
+		{
+			value, err := decodeBitfield(bits, TagOptions{BitStart: 0, BitEnd: 0})
+			if err != nil {
+				return fmt.Errorf("field Door1: %w", err)
+			}
+			v.Door1 = value != 0
+		}
+		{
+			value, err := decodeBitfield(bits, TagOptions{BitStart: 1, BitEnd: 1})
+			if err != nil {
+				return fmt.Errorf("field Door2: %w", err)
+			}
+			v.Door2 = value != 0
+		}
+		{
+			value, err := decodeBitfield(bits, TagOptions{BitStart: 2, BitEnd: 2})
+			if err != nil {
+				return fmt.Errorf("field Door3: %w", err)
+			}
+			v.Door3 = value != 0
+		}
+		{
+			value, err := decodeBitfield(bits, TagOptions{BitStart: 3, BitEnd: 3})
+			if err != nil {
+				return fmt.Errorf("field Door4: %w", err)
+			}
+			v.Door4 = value != 0
+		}
+		{
+			value, err := decodeBitfield(bits, TagOptions{BitStart: 4, BitEnd: 7})
+			if err != nil {
+				return fmt.Errorf("field Unused: %w", err)
+			}
+			v.Unused = value
+		}
+	}
+	return nil
+}
+
+// Encode encodes the SetNetworkInfoRequest.
+func (v *SetNetworkInfoRequest) Encode(writer *Writer) error {
+	{
+		err := encodeFixedBytes(writer, v.MACAddress, 6)
+		if err != nil {
+			return fmt.Errorf("field MACAddress: %w", err)
+		}
+	}
+	if v.IPAddress.To4() == nil {
+		return fmt.Errorf("field IPAddress: could not convert address to IPv4")
+	}
+	{
+		err := encodeFixedBytes(writer, v.IPAddress.To4(), 4)
+		if err != nil {
+			return fmt.Errorf("field IPAddress: %w", err)
+		}
+	}
+	if v.Netmask.To4() == nil {
+		return fmt.Errorf("field Netmask: could not convert address to IPv4")
+	}
+	{
+		err := encodeFixedBytes(writer, v.Netmask.To4(), 4)
+		if err != nil {
+			return fmt.Errorf("field Netmask: %w", err)
+		}
+	}
+	if v.Gateway.To4() == nil {
+		return fmt.Errorf("field Gateway: could not convert address to IPv4")
+	}
+	{
+		err := encodeFixedBytes(writer, v.Gateway.To4(), 4)
+		if err != nil {
+			return fmt.Errorf("field Gateway: %w", err)
+		}
+	}
+	writer.WriteUint16(v.Port)
+	return nil
+}
+
+// Decode decodes the SetNetworkInfoRequest.
+func (v *SetNetworkInfoRequest) Decode(reader *Reader) error {
+	var err error
+	v.MACAddress, err = decodeFixedBytes(reader, 6)
+	if err != nil {
+		return fmt.Errorf("field MACAddress: %w", err)
+	}
+	v.IPAddress, err = decodeFixedBytes(reader, 4)
+	if err != nil {
+		return fmt.Errorf("field IPAddress: %w", err)
+	}
+	v.Netmask, err = decodeFixedBytes(reader, 4)
+	if err != nil {
+		return fmt.Errorf("field Netmask: %w", err)
+	}
+	v.Gateway, err = decodeFixedBytes(reader, 4)
+	if err != nil {
+		return fmt.Errorf("field Gateway: %w", err)
+	}
+	v.Port, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("field Port: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the SetNetworkInfoResponse.
+func (v *SetNetworkInfoResponse) Encode(writer *Writer) error {
+	writer.WriteUint8(v.Unknown1)
+	return nil
+}
+
+// Decode decodes the SetNetworkInfoResponse.
+func (v *SetNetworkInfoResponse) Decode(reader *Reader) error {
+	var err error
+	v.Unknown1, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Unknown1: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the SetTimeRequest.
+func (v *SetTimeRequest) Encode(writer *Writer) error {
+	{
+		err := encodeTime(writer, v.CurrentTime, TypeHexDateTime)
+		if err != nil {
+			return fmt.Errorf("field CurrentTime: %w", err)
+		}
+	}
+	return nil
+}
+
+// Decode decodes the SetTimeRequest.
+func (v *SetTimeRequest) Decode(reader *Reader) error {
+	var err error
+	v.CurrentTime, err = decodeTime(reader, TypeHexDateTime)
+	if err != nil {
+		return fmt.Errorf("field CurrentTime: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the SetTimeResponse.
+func (v *SetTimeResponse) Encode(writer *Writer) error {
+	{
+		err := encodeTime(writer, v.CurrentTime, TypeHexDateTime)
+		if err != nil {
+			return fmt.Errorf("field CurrentTime: %w", err)
+		}
+	}
+	return nil
+}
+
+// Decode decodes the SetTimeResponse.
+func (v *SetTimeResponse) Decode(reader *Reader) error {
+	var err error
+	v.CurrentTime, err = decodeTime(reader, TypeHexDateTime)
+	if err != nil {
+		return fmt.Errorf("field CurrentTime: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the TailPlusPermissionsRequest.
+func (v *TailPlusPermissionsRequest) Encode(writer *Writer) error {
+	writer.WriteUint16(v.UploadIndex)
+	writer.WriteUint16(v.CardNumber)
+	writer.WriteUint8(v.AreaNumber)
+	writer.WriteUint8(v.Door)
+	{
+		err := encodeTime(writer, v.StartDate, TypeDate)
+		if err != nil {
+			return fmt.Errorf("field StartDate: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.EndDate, TypeDate)
+		if err != nil {
+			return fmt.Errorf("field EndDate: %w", err)
+		}
+	}
+	writer.WriteUint8(v.Time)
+	writer.WriteUint24(uint32(v.Password))
+	writer.WriteUint8(v.Standby1)
+	writer.WriteUint8(v.Standby2)
+	writer.WriteUint8(v.Standby3)
+	writer.WriteUint8(v.Standby4)
+	return nil
+}
+
+// Decode decodes the TailPlusPermissionsRequest.
+func (v *TailPlusPermissionsRequest) Decode(reader *Reader) error {
+	var err error
+	v.UploadIndex, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("field UploadIndex: %w", err)
+	}
+	v.CardNumber, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("field CardNumber: %w", err)
+	}
+	v.AreaNumber, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field AreaNumber: %w", err)
+	}
+	v.Door, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Door: %w", err)
+	}
+	v.StartDate, err = decodeTime(reader, TypeDate)
+	if err != nil {
+		return fmt.Errorf("field StartDate: %w", err)
+	}
+	v.EndDate, err = decodeTime(reader, TypeDate)
+	if err != nil {
+		return fmt.Errorf("field EndDate: %w", err)
+	}
+	v.Time, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Time: %w", err)
+	}
+	{
+		value, err := reader.ReadUint24()
+		if err != nil {
+			return fmt.Errorf("field Password: %w", err)
+		}
+		v.Password = PIN(value)
+	}
+	v.Standby1, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby1: %w", err)
+	}
+	v.Standby2, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby2: %w", err)
+	}
+	v.Standby3, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby3: %w", err)
+	}
+	v.Standby4, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby4: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the TailPlusPermissionsResponse.
+func (v *TailPlusPermissionsResponse) Encode(writer *Writer) error {
+	writer.WriteUint8(v.Result)
+	return nil
+}
+
+// Decode decodes the TailPlusPermissionsResponse.
+func (v *TailPlusPermissionsResponse) Decode(reader *Reader) error {
+	var err error
+	v.Result, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Result: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the Unknown1098Request.
+func (v *Unknown1098Request) Encode(writer *Writer) error {
+	return nil
+}
+
+// Decode decodes the Unknown1098Request.
+func (v *Unknown1098Request) Decode(reader *Reader) error {
+	var err error
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the Unknown1098Response.
+func (v *Unknown1098Response) Encode(writer *Writer) error {
+	writer.WriteUint8(v.Result)
+	return nil
+}
+
+// Decode decodes the Unknown1098Response.
+func (v *Unknown1098Response) Decode(reader *Reader) error {
+	var err error
+	v.Result, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Result: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the UpdateControlPeriodRequest.
+func (v *UpdateControlPeriodRequest) Encode(writer *Writer) error {
+	writer.WriteUint16(v.TimeIndex)
+	writer.WriteUint8(v.WeekControl)
+	writer.WriteUint8(v.NextLinkTimeIndex)
+	writer.WriteUint8(v.Standby1)
+	writer.WriteUint8(v.Standby2)
+	{
+		err := encodeTime(writer, v.StartTime1, TypeTime)
+		if err != nil {
+			return fmt.Errorf("field StartTime1: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.EndTime1, TypeTime)
+		if err != nil {
+			return fmt.Errorf("field EndTime1: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.StartTime2, TypeTime)
+		if err != nil {
+			return fmt.Errorf("field StartTime2: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.EndTime2, TypeTime)
+		if err != nil {
+			return fmt.Errorf("field EndTime2: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.StartTime3, TypeTime)
+		if err != nil {
+			return fmt.Errorf("field StartTime3: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.EndTime3, TypeTime)
+		if err != nil {
+			return fmt.Errorf("field EndTime3: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.StartDate, TypeDate)
+		if err != nil {
+			return fmt.Errorf("field StartDate: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.EndDate, TypeDate)
+		if err != nil {
+			return fmt.Errorf("field EndDate: %w", err)
+		}
+	}
+	writer.WriteUint8(v.Standby3)
+	writer.WriteUint8(v.Standby4)
+	writer.WriteUint8(v.Standby5)
+	writer.WriteUint8(v.Standby6)
+	return nil
+}
+
+// Decode decodes the UpdateControlPeriodRequest.
+func (v *UpdateControlPeriodRequest) Decode(reader *Reader) error {
+	var err error
+	v.TimeIndex, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("field TimeIndex: %w", err)
+	}
+	v.WeekControl, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field WeekControl: %w", err)
+	}
+	v.NextLinkTimeIndex, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field NextLinkTimeIndex: %w", err)
+	}
+	v.Standby1, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby1: %w", err)
+	}
+	v.Standby2, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby2: %w", err)
+	}
+	v.StartTime1, err = decodeTime(reader, TypeTime)
+	if err != nil {
+		return fmt.Errorf("field StartTime1: %w", err)
+	}
+	v.EndTime1, err = decodeTime(reader, TypeTime)
+	if err != nil {
+		return fmt.Errorf("field EndTime1: %w", err)
+	}
+	v.StartTime2, err = decodeTime(reader, TypeTime)
+	if err != nil {
+		return fmt.Errorf("field StartTime2: %w", err)
+	}
+	v.EndTime2, err = decodeTime(reader, TypeTime)
+	if err != nil {
+		return fmt.Errorf("field EndTime2: %w", err)
+	}
+	v.StartTime3, err = decodeTime(reader, TypeTime)
+	if err != nil {
+		return fmt.Errorf("field StartTime3: %w", err)
+	}
+	v.EndTime3, err = decodeTime(reader, TypeTime)
+	if err != nil {
+		return fmt.Errorf("field EndTime3: %w", err)
+	}
+	v.StartDate, err = decodeTime(reader, TypeDate)
+	if err != nil {
+		return fmt.Errorf("field StartDate: %w", err)
+	}
+	v.EndDate, err = decodeTime(reader, TypeDate)
+	if err != nil {
+		return fmt.Errorf("field EndDate: %w", err)
+	}
+	v.Standby3, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby3: %w", err)
+	}
+	v.Standby4, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby4: %w", err)
+	}
+	v.Standby5, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby5: %w", err)
+	}
+	v.Standby6, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby6: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the UpdateControlPeriodResponse.
+func (v *UpdateControlPeriodResponse) Encode(writer *Writer) error {
+	writer.WriteUint16(v.TimeIndex)
+	writer.WriteUint8(v.WeekControl)
+	writer.WriteUint8(v.NextLinkTimeIndex)
+	writer.WriteUint8(v.Standby1)
+	writer.WriteUint8(v.Standby2)
+	{
+		err := encodeTime(writer, v.StartTime1, TypeTime)
+		if err != nil {
+			return fmt.Errorf("field StartTime1: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.EndTime1, TypeTime)
+		if err != nil {
+			return fmt.Errorf("field EndTime1: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.StartTime2, TypeTime)
+		if err != nil {
+			return fmt.Errorf("field StartTime2: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.EndTime2, TypeTime)
+		if err != nil {
+			return fmt.Errorf("field EndTime2: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.StartTime3, TypeTime)
+		if err != nil {
+			return fmt.Errorf("field StartTime3: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.EndTime3, TypeTime)
+		if err != nil {
+			return fmt.Errorf("field EndTime3: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.StartDate, TypeDate)
+		if err != nil {
+			return fmt.Errorf("field StartDate: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.EndDate, TypeDate)
+		if err != nil {
+			return fmt.Errorf("field EndDate: %w", err)
+		}
+	}
+	writer.WriteUint8(v.Standby3)
+	writer.WriteUint8(v.Standby4)
+	writer.WriteUint8(v.Standby5)
+	writer.WriteUint8(v.Standby6)
+	return nil
+}
+
+// Decode decodes the UpdateControlPeriodResponse.
+func (v *UpdateControlPeriodResponse) Decode(reader *Reader) error {
+	var err error
+	v.TimeIndex, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("field TimeIndex: %w", err)
+	}
+	v.WeekControl, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field WeekControl: %w", err)
+	}
+	v.NextLinkTimeIndex, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field NextLinkTimeIndex: %w", err)
+	}
+	v.Standby1, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby1: %w", err)
+	}
+	v.Standby2, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby2: %w", err)
+	}
+	v.StartTime1, err = decodeTime(reader, TypeTime)
+	if err != nil {
+		return fmt.Errorf("field StartTime1: %w", err)
+	}
+	v.EndTime1, err = decodeTime(reader, TypeTime)
+	if err != nil {
+		return fmt.Errorf("field EndTime1: %w", err)
+	}
+	v.StartTime2, err = decodeTime(reader, TypeTime)
+	if err != nil {
+		return fmt.Errorf("field StartTime2: %w", err)
+	}
+	v.EndTime2, err = decodeTime(reader, TypeTime)
+	if err != nil {
+		return fmt.Errorf("field EndTime2: %w", err)
+	}
+	v.StartTime3, err = decodeTime(reader, TypeTime)
+	if err != nil {
+		return fmt.Errorf("field StartTime3: %w", err)
+	}
+	v.EndTime3, err = decodeTime(reader, TypeTime)
+	if err != nil {
+		return fmt.Errorf("field EndTime3: %w", err)
+	}
+	v.StartDate, err = decodeTime(reader, TypeDate)
+	if err != nil {
+		return fmt.Errorf("field StartDate: %w", err)
+	}
+	v.EndDate, err = decodeTime(reader, TypeDate)
+	if err != nil {
+		return fmt.Errorf("field EndDate: %w", err)
+	}
+	v.Standby3, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby3: %w", err)
+	}
+	v.Standby4, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby4: %w", err)
+	}
+	v.Standby5, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby5: %w", err)
+	}
+	v.Standby6, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Standby6: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the UpdatePermissionsRequest.
+func (v *UpdatePermissionsRequest) Encode(writer *Writer) error {
+	writer.WriteUint16(v.Unknown1)
+	writer.WriteUint16(v.CardID)
+	writer.WriteUint8(v.Area)
+	writer.WriteUint8(v.Door)
+	{
+		err := encodeTime(writer, v.StartDate, TypeDate)
+		if err != nil {
+			return fmt.Errorf("field StartDate: %w", err)
+		}
+	}
+	{
+		err := encodeTime(writer, v.EndDate, TypeDate)
+		if err != nil {
+			return fmt.Errorf("field EndDate: %w", err)
+		}
+	}
+	writer.WriteUint8(v.Time)
+	writer.WriteUint24(uint32(v.Password))
+	{
+		err := encodeFixedBytes(writer, v.Standby, 4)
+		if err != nil {
+			return fmt.Errorf("field Standby: %w", err)
+		}
+	}
+	return nil
+}
+
+// Decode decodes the UpdatePermissionsRequest.
+func (v *UpdatePermissionsRequest) Decode(reader *Reader) error {
+	var err error
+	v.Unknown1, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("field Unknown1: %w", err)
+	}
+	v.CardID, err = reader.ReadUint16()
+	if err != nil {
+		return fmt.Errorf("field CardID: %w", err)
+	}
+	v.Area, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Area: %w", err)
+	}
+	v.Door, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Door: %w", err)
+	}
+	v.StartDate, err = decodeTime(reader, TypeDate)
+	if err != nil {
+		return fmt.Errorf("field StartDate: %w", err)
+	}
+	v.EndDate, err = decodeTime(reader, TypeDate)
+	if err != nil {
+		return fmt.Errorf("field EndDate: %w", err)
+	}
+	v.Time, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Time: %w", err)
+	}
+	{
+		value, err := reader.ReadUint24()
+		if err != nil {
+			return fmt.Errorf("field Password: %w", err)
+		}
+		v.Password = PIN(value)
+	}
+	v.Standby, err = decodeFixedBytes(reader, 4)
+	if err != nil {
+		return fmt.Errorf("field Standby: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the UpdatePermissionsResponse.
+func (v *UpdatePermissionsResponse) Encode(writer *Writer) error {
+	writer.WriteUint8(v.Result)
+	return nil
+}
+
+// Decode decodes the UpdatePermissionsResponse.
+func (v *UpdatePermissionsResponse) Decode(reader *Reader) error {
+	var err error
+	v.Result, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Result: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the UpdateSettingRequest.
+func (v *UpdateSettingRequest) Encode(writer *Writer) error {
+	writer.WriteUint8(v.Address)
+	writer.WriteUint8(v.Unknown1)
+	writer.WriteUint8(v.Value)
+	return nil
+}
+
+// Decode decodes the UpdateSettingRequest.
+func (v *UpdateSettingRequest) Decode(reader *Reader) error {
+	var err error
+	v.Address, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Address: %w", err)
+	}
+	v.Unknown1, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Unknown1: %w", err)
+	}
+	v.Value, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Value: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
+
+// Encode encodes the UpdateSettingResponse.
+func (v *UpdateSettingResponse) Encode(writer *Writer) error {
+	writer.WriteUint8(v.Result)
+	return nil
+}
+
+// Decode decodes the UpdateSettingResponse.
+func (v *UpdateSettingResponse) Decode(reader *Reader) error {
+	var err error
+	v.Result, err = reader.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("field Result: %w", err)
+	}
+	err = decodeEmptyRemainder(reader)
+	if err != nil {
+		return fmt.Errorf("field _: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package wire
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// compareCodecs decodes and re-encodes the contents with both the generated
+// code and the reflection path, and fails if they differ in any way.
+func compareCodecs(t *testing.T, myType reflect.Type, contents []byte) {
+	generatedValue := reflect.New(myType)
+	generatedErr := Decode(NewReader(contents), generatedValue.Interface())
+
+	reflectionOnly = true
+	defer func() {
+		reflectionOnly = false
+	}()
+
+	reflectionValue := reflect.New(myType)
+	reflectionErr := Decode(NewReader(contents), reflectionValue.Interface())
+
+	if reflectionErr != nil {
+		assert.NotNil(t, generatedErr, "%v: the reflection path failed but the generated code did not: %v", myType, reflectionErr)
+		return
+	}
+	require.Nil(t, generatedErr, "%v: the generated code failed but the reflection path did not", myType)
+	assert.Equal(t, reflectionValue.Interface(), generatedValue.Interface(), "%v", myType)
+
+	reflectionWriter := NewWriter()
+	reflectionErr = Encode(reflectionWriter, reflectionValue.Interface())
+
+	reflectionOnly = false
+	generatedWriter := NewWriter()
+	generatedErr = Encode(generatedWriter, generatedValue.Interface())
+
+	if reflectionErr != nil {
+		assert.NotNil(t, generatedErr, "%v: the reflection path failed but the generated code did not: %v", myType, reflectionErr)
+		return
+	}
+	require.Nil(t, generatedErr, "%v: the generated code failed but the reflection path did not", myType)
+	assert.Equal(t, reflectionWriter.Bytes(), generatedWriter.Bytes(), "%v", myType)
+}
+
+func TestGeneratedCodecs(t *testing.T) {
+	t.Run("Registry", func(t *testing.T) {
+		// Every request and response should have generated code.
+		for _, info := range Functions() {
+			for _, myType := range []reflect.Type{info.Request, info.Response} {
+				if myType == nil {
+					continue
+				}
+				assert.Implements(t, (*Encoder)(nil), reflect.New(myType).Interface(), "%v", myType)
+				assert.Implements(t, (*Decoder)(nil), reflect.New(myType).Interface(), "%v", myType)
+			}
+		}
+	})
+	t.Run("Corpus", func(t *testing.T) {
+		for _, packet := range loadCorpus(t) {
+			var envelope Envelope
+			err := Decode(NewReader(packet.Data), &envelope)
+			require.Nil(t, err)
+
+			info := LookupFunction(envelope.Function)
+			require.NotNil(t, info)
+			myType := info.Response
+			if packet.ToController {
+				myType = info.Request
+			}
+			compareCodecs(t, myType, envelope.Contents)
+		}
+	})
+	t.Run("Null", func(t *testing.T) {
+		// These exercise the null pointers.
+		compareCodecs(t, reflect.TypeOf(GetOperationStatusResponse{}), []byte{0x22, 0x12, 0x28, 0x03, 0x11, 0x41, 0x41, 0x9E, 0x29, 0x00, 0x52, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x00, 0xFF, 0x00, 0x00, 0x00, 0x00})
+		compareCodecs(t, reflect.TypeOf(DeletePermissionsRequest{}), []byte{0x00, 0x00, 0x70, 0x28, 0x53, 0x01, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
+	})
+}
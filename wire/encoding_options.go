@@ -2,41 +2,109 @@ package wire
 
 import (
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 )
 
-// encodingOptions represents the options encoded in the "wire" field tag.
+// TagOptions represents the options encoded in the "wire" field tag.
 // type:{date,datetime,date,uint24}
 // length:{*,[0-9]+}
-type encodingOptions struct {
-	Name   string // This is primarily passed in to help with rendering a meaningful error message.
-	Type   string // This is a Type* constant for the type of the field.
-	Length int    // -1 for "read everything until the end", otherwise this is the actual length.  "0" is acceptable.
-	Null   *uint8 // If this value is null, fill in this byte for the length (typically 0xff).
+// null:[0-9]+
+// enum:[0-9]+(|[0-9]+)*
+// bcd
+// bitfield:{[0-7],[0-7]-[0-7]}
+// default:[0-9]+
+// pad:[0-9]+
+type TagOptions struct {
+	Name     string   // This is primarily passed in to help with rendering a meaningful error message.
+	Type     string   // This is a Type* constant for the type of the field.
+	Length   int      // -1 for "read everything until the end", otherwise this is the actual length.  "0" is acceptable.
+	Null     *uint8   // If this value is null, fill in this byte for the length (typically 0xff).
+	Enum     []uint64 // If set, these are the only values that the field may have.
+	BCD      bool     // If true, the value is written as binary-coded decimal (for example, 22 is 0x22).
+	Bitfield bool     // If true, the value is packed into the bits BitStart through BitEnd of a shared byte.
+	BitStart int      // This is the first (lowest) bit of the bitfield.
+	BitEnd   int      // This is the last (highest) bit of the bitfield.
+	Default  *uint64  // If set, this is the value that `Defaults` fills in for zero; it is not applied when encoding.
+	Pad      int      // This is the number of zero bytes that follow the field.
 }
 
 const (
-	OptionLength = "length"
-	OptionNull   = "null"
-	OptionType   = "type"
+	OptionBCD      = "bcd"
+	OptionBitfield = "bitfield"
+	OptionDefault  = "default"
+	OptionEnum     = "enum"
+	OptionLength   = "length"
+	OptionNull     = "null"
+	OptionPad      = "pad"
+	OptionType     = "type"
 )
 
-// parseOptionsFromTag parses the "wire" tag and returns the "encodingOptions" for it.
-func parseOptionsFromTag(tag string) (encodingOptions, error) {
-	options := encodingOptions{}
+// ParseTag parses the "wire" tag.
+//
+// This is used by the code generator (see "cmd/wire-gen").
+func ParseTag(tag string) (TagOptions, error) {
+	return parseOptionsFromTag(tag)
+}
+
+// parseOptionsFromTag parses the "wire" tag and returns the "TagOptions" for it.
+func parseOptionsFromTag(tag string) (TagOptions, error) {
+	options := TagOptions{}
 	if tag == "" {
 		return options, nil
 	}
 	items := strings.Split(tag, ",")
 	for _, item := range items {
 		parts := strings.SplitN(item, ":", 2)
+		key := parts[0]
+		if key == OptionBCD {
+			// This is a flag; it has no value.
+			if len(parts) != 1 {
+				return options, fmt.Errorf("invalid option: %q", item)
+			}
+			options.BCD = true
+			continue
+		}
 		if len(parts) != 2 {
 			return options, fmt.Errorf("invalid option: %q", item)
 		}
-		key := parts[0]
 		value := parts[1]
 		switch key {
+		case OptionBitfield:
+			start, end, found := strings.Cut(value, "-")
+			if !found {
+				end = start
+			}
+			v, err := strconv.ParseUint(start, 10, 3)
+			if err != nil {
+				return options, fmt.Errorf("invalid bitfield: %q: %w", value, err)
+			}
+			options.BitStart = int(v)
+			v, err = strconv.ParseUint(end, 10, 3)
+			if err != nil {
+				return options, fmt.Errorf("invalid bitfield: %q: %w", value, err)
+			}
+			options.BitEnd = int(v)
+			if options.BitEnd < options.BitStart {
+				return options, fmt.Errorf("invalid bitfield: %q", value)
+			}
+			options.Bitfield = true
+		case OptionDefault:
+			v, err := strconv.ParseUint(value, 0 /*auto-detect*/, 32)
+			if err != nil {
+				return options, fmt.Errorf("invalid default: %q: %w", value, err)
+			}
+			options.Default = &v
+		case OptionEnum:
+			options.Enum = []uint64{}
+			for _, part := range strings.Split(value, "|") {
+				v, err := strconv.ParseUint(part, 0 /*auto-detect*/, 32)
+				if err != nil {
+					return options, fmt.Errorf("invalid enum: %q: %w", value, err)
+				}
+				options.Enum = append(options.Enum, v)
+			}
 		case OptionLength:
 			if value == "*" {
 				options.Length = -1
@@ -55,6 +123,12 @@ func parseOptionsFromTag(tag string) (encodingOptions, error) {
 			nullValue := new(uint8)
 			*nullValue = uint8(v)
 			options.Null = nullValue
+		case OptionPad:
+			v, err := strconv.ParseUint(value, 10, 16)
+			if err != nil {
+				return options, fmt.Errorf("invalid pad: %q: %w", value, err)
+			}
+			options.Pad = int(v)
 		case OptionType:
 			switch value {
 			case TypeDate, TypeDateTime, TypeHexDate, TypeHexDateTime, TypeHexTime, TypeTime, TypeUint24:
@@ -67,5 +141,58 @@ func parseOptionsFromTag(tag string) (encodingOptions, error) {
 			return options, fmt.Errorf("invalid key: %q", key)
 		}
 	}
+	if options.Bitfield && (options.Type != "" || options.Length != 0 || options.Null != nil || options.BCD || options.Default != nil || options.Pad != 0) {
+		return options, fmt.Errorf("a bitfield may only have an enum")
+	}
 	return options, nil
 }
+
+// ValidateKind returns an error if the options cannot be used with a field of
+// the given kind.
+//
+// This is used by the code generator (see "cmd/wire-gen").
+func (o TagOptions) ValidateKind(kind reflect.Kind) error {
+	isUint := kind == reflect.Uint8 || kind == reflect.Uint16 || kind == reflect.Uint32
+	if o.Enum != nil && !isUint {
+		return fmt.Errorf("an enum must be an integer")
+	}
+	if o.Default != nil && !isUint {
+		return fmt.Errorf("a default must be an integer")
+	}
+	if o.BCD && kind != reflect.Uint8 {
+		return fmt.Errorf("bcd must be a uint8")
+	}
+	if o.Bitfield {
+		switch kind {
+		case reflect.Uint8:
+		case reflect.Bool:
+			if o.BitStart != o.BitEnd {
+				return fmt.Errorf("a bool bitfield must be a single bit")
+			}
+		default:
+			return fmt.Errorf("a bitfield must be a uint8 or a bool")
+		}
+	}
+	return nil
+}
+
+// BitMask returns the bits of the byte that the bitfield uses.
+func (o TagOptions) BitMask() uint8 {
+	return uint8((1<<(o.BitEnd+1))-1) &^ uint8((1<<o.BitStart)-1)
+}
+
+// TypeLength returns the number of bytes used by the type, or 0 if the type
+// does not have a fixed length.
+//
+// This is used to fill in the length of a nil pointer to a time.
+func (o TagOptions) TypeLength() int {
+	switch o.Type {
+	case TypeDate:
+		return 2
+	case TypeTime:
+		return 2
+	case TypeDateTime:
+		return 4
+	}
+	return 0
+}
@@ -201,5 +201,140 @@ func TestEncoding(t *testing.T) {
 				require.NotNil(t, err)
 			})
 		})
+		t.Run("Enum", func(t *testing.T) {
+			type MyStruct struct {
+				Key1 uint8 `wire:"enum:1|2|0x10"`
+			}
+			t.Run("Decode", func(t *testing.T) {
+				var output MyStruct
+				err := Decode(NewReader([]byte{0x10}), &output)
+				require.Nil(t, err)
+				require.Equal(t, MyStruct{Key1: 16}, output)
+
+				err = Decode(NewReader([]byte{0x03}), &output)
+				require.NotNil(t, err)
+			})
+			t.Run("Encode", func(t *testing.T) {
+				writer := NewWriter()
+				err := Encode(writer, MyStruct{Key1: 2})
+				require.Nil(t, err)
+				require.Equal(t, []byte{0x02}, writer.Bytes())
+
+				err = Encode(NewWriter(), MyStruct{Key1: 3})
+				require.NotNil(t, err)
+			})
+			t.Run("Invalid", func(t *testing.T) {
+				type MyStruct struct {
+					Key1 uint8 `wire:"enum:1|BOGUS"`
+				}
+				err := Encode(NewWriter(), MyStruct{})
+				require.NotNil(t, err)
+			})
+		})
+		t.Run("BCD", func(t *testing.T) {
+			type MyStruct struct {
+				Key1 uint8 `wire:"bcd"`
+			}
+			t.Run("Decode", func(t *testing.T) {
+				var output MyStruct
+				err := Decode(NewReader([]byte{0x59}), &output)
+				require.Nil(t, err)
+				require.Equal(t, MyStruct{Key1: 59}, output)
+
+				err = Decode(NewReader([]byte{0x5A}), &output)
+				require.NotNil(t, err)
+			})
+			t.Run("Encode", func(t *testing.T) {
+				writer := NewWriter()
+				err := Encode(writer, MyStruct{Key1: 59})
+				require.Nil(t, err)
+				require.Equal(t, []byte{0x59}, writer.Bytes())
+
+				err = Encode(NewWriter(), MyStruct{Key1: 100})
+				require.NotNil(t, err)
+			})
+			t.Run("Not a uint8", func(t *testing.T) {
+				type MyStruct struct {
+					Key1 uint16 `wire:"bcd"`
+				}
+				err := Encode(NewWriter(), MyStruct{})
+				require.NotNil(t, err)
+			})
+		})
+		t.Run("Bitfield", func(t *testing.T) {
+			type MyStruct struct {
+				Key1 bool  `wire:"bitfield:0"`
+				Key2 uint8 `wire:"bitfield:1-3"`
+				Key3 bool  `wire:"bitfield:7"`
+				Key4 uint8 `wire:"bitfield:0-7"` // This overlaps, so it starts a new byte.
+				Key5 uint8
+			}
+			t.Run("Decode", func(t *testing.T) {
+				var output MyStruct
+				err := Decode(NewReader([]byte{0x8B, 0xAA, 0x01}), &output)
+				require.Nil(t, err)
+				require.Equal(t, MyStruct{Key1: true, Key2: 5, Key3: true, Key4: 0xAA, Key5: 1}, output)
+			})
+			t.Run("Encode", func(t *testing.T) {
+				writer := NewWriter()
+				err := Encode(writer, MyStruct{Key1: true, Key2: 5, Key3: true, Key4: 0xAA, Key5: 1})
+				require.Nil(t, err)
+				require.Equal(t, []byte{0x8B, 0xAA, 0x01}, writer.Bytes())
+
+				err = Encode(NewWriter(), MyStruct{Key2: 8})
+				require.NotNil(t, err)
+			})
+			t.Run("Invalid", func(t *testing.T) {
+				for _, tag := range []string{"bitfield:8", "bitfield:3-1", "bitfield:0,pad:1"} {
+					_, err := parseOptionsFromTag(tag)
+					require.NotNil(t, err, "tag: %q", tag)
+				}
+			})
+		})
+		t.Run("Default", func(t *testing.T) {
+			type MyStruct struct {
+				Key1 uint16 `wire:"default:0x1234"`
+			}
+			t.Run("Decode", func(t *testing.T) {
+				var output MyStruct
+				err := Decode(NewReader([]byte{0x00, 0x00}), &output)
+				require.Nil(t, err)
+				require.Equal(t, MyStruct{Key1: 0}, output)
+			})
+			t.Run("Encode", func(t *testing.T) {
+				// The default is not applied when encoding, so zero can be sent.
+				writer := NewWriter()
+				err := Encode(writer, MyStruct{})
+				require.Nil(t, err)
+				require.Equal(t, []byte{0x00, 0x00}, writer.Bytes())
+
+				writer = NewWriter()
+				err = Encode(writer, Defaults(MyStruct{}))
+				require.Nil(t, err)
+				require.Equal(t, []byte{0x34, 0x12}, writer.Bytes())
+			})
+			t.Run("Defaults", func(t *testing.T) {
+				require.Equal(t, MyStruct{Key1: 0x1234}, Defaults(MyStruct{}))
+				require.Equal(t, MyStruct{Key1: 5}, Defaults(MyStruct{Key1: 5}))
+			})
+		})
+		t.Run("Pad", func(t *testing.T) {
+			type MyStruct struct {
+				Key1 uint8 `wire:"pad:2"`
+				Key2 uint8
+			}
+			t.Run("Decode", func(t *testing.T) {
+				var output MyStruct
+				err := Decode(NewReader([]byte{0x01, 0xFF, 0xFF, 0x02}), &output)
+				require.Nil(t, err)
+				require.Equal(t, MyStruct{Key1: 1, Key2: 2}, output)
+			})
+			t.Run("Encode", func(t *testing.T) {
+				writer := NewWriter()
+				err := Encode(writer, MyStruct{Key1: 1, Key2: 2})
+				require.Nil(t, err)
+				require.Equal(t, []byte{0x01, 0x00, 0x00, 0x02}, writer.Bytes())
+			})
+		})
 	})
 }
@@ -1,5 +1,10 @@
 package wire
 
+import (
+	"fmt"
+	"reflect"
+)
+
 const (
 	FunctionClearUpload         = 0x1093 // TODO: I'm not exactly sure what this is.
 	FunctionUnknown1098         = 0x1098 // TODO: Maybe ping/pong?
@@ -8,14 +13,135 @@ const (
 	FunctionSetTime             = 0x108b
 	FunctionGetRecord           = 0x108d
 	FunctionDeleteRecord        = 0x108e
+	FunctionSetDoorControl      = 0x108f // TODO: Not implemented yet; sets the door controls (online/delay).
+	FunctionUploadMission       = 0x1091 // TODO: Not implemented yet.
 	FunctionGetUpload           = 0x1095
+	FunctionGetControlPeriod    = 0x1096
 	FunctionUpdateControlPeriod = 0x1097
 	FunctionTailPlusPermissions = 0x109b
 	FunctionOpenDoor            = 0x109d
 	FunctionGetSetting          = 0x10f1
 	FunctionUpdateSetting       = 0x10f4
+	FunctionRealizeTimingTask   = 0x10f5
+	FunctionUnknown10F9         = 0x10f9 // TODO: This appears to be the thing that pushes the config up.
+	FunctionFormat              = 0x10ff // TODO: Not implemented yet; this will factory-reset the unit.
 	FunctionGetNetworkInfo      = 0x1101
 	FunctionUpdatePermissions   = 0x1107
 	FunctionDeletePermissions   = 0x1108
 	FunctionSetNetworkInfo      = 0x11f2
 )
+
+// FunctionInfo describes a function.
+type FunctionInfo struct {
+	Code     uint16       // This is the function code in the envelope.
+	Name     string       // This is the human-readable name of the function.
+	Request  reflect.Type // This is the request type; nil if the request has not been decoded yet.
+	Response reflect.Type // This is the response type; nil if the response has not been decoded yet.
+	ReadOnly bool         // If set, then the function does not change anything on the controller.
+	Secret   bool         // If set, then the contents may include PINs or passwords, so they are never logged.
+}
+
+// NewRequest returns a pointer to a new request for the function.
+//
+// If the request type is not known, then this returns nil.
+func (f FunctionInfo) NewRequest() any {
+	if f.Request == nil {
+		return nil
+	}
+	return reflect.New(f.Request).Interface()
+}
+
+// NewResponse returns a pointer to a new response for the function.
+//
+// If the response type is not known, then this returns nil.
+func (f FunctionInfo) NewResponse() any {
+	if f.Response == nil {
+		return nil
+	}
+	return reflect.New(f.Response).Interface()
+}
+
+// DecodeRequest decodes the contents of a request envelope for the function.
+//
+// The result is the request value (not a pointer).  If the request type is not
+// known, then this returns nil.
+func (f FunctionInfo) DecodeRequest(contents []byte) (any, error) {
+	return decodeFunctionValue(f.NewRequest(), contents)
+}
+
+// DecodeResponse decodes the contents of a response envelope for the function.
+//
+// The result is the response value (not a pointer).  If the response type is
+// not known, then this returns nil.
+func (f FunctionInfo) DecodeResponse(contents []byte) (any, error) {
+	return decodeFunctionValue(f.NewResponse(), contents)
+}
+
+func decodeFunctionValue(value any, contents []byte) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	err := Decode(NewReader(contents), value)
+	if err != nil {
+		return nil, err
+	}
+	return reflect.ValueOf(value).Elem().Interface(), nil
+}
+
+// functionRegistry is the list of every known function.
+//
+// Adding a function here makes it available to the client and the packet tools.
+var functionRegistry = []FunctionInfo{
+	{Code: FunctionClearUpload, Name: "ClearUpload", Request: reflect.TypeOf(ClearUploadRequest{}), Response: reflect.TypeOf(ClearUploadResponse{})},
+	{Code: FunctionUnknown1098, Name: "Unknown1098", Request: reflect.TypeOf(Unknown1098Request{}), Response: reflect.TypeOf(Unknown1098Response{}), ReadOnly: true},
+	{Code: FunctionGetOperationStatus, Name: "GetOperationStatus", Request: reflect.TypeOf(GetOperationStatusRequest{}), Response: reflect.TypeOf(GetOperationStatusResponse{}), ReadOnly: true},
+	{Code: FunctionGetBasicInfo, Name: "GetBasicInfo", Request: reflect.TypeOf(GetBasicInfoRequest{}), Response: reflect.TypeOf(GetBasicInfoResponse{}), ReadOnly: true},
+	{Code: FunctionSetTime, Name: "SetTime", Request: reflect.TypeOf(SetTimeRequest{}), Response: reflect.TypeOf(SetTimeResponse{})},
+	{Code: FunctionGetRecord, Name: "GetRecord", Request: reflect.TypeOf(GetRecordRequest{}), Response: reflect.TypeOf(GetRecordResponse{}), ReadOnly: true},
+	{Code: FunctionDeleteRecord, Name: "DeleteRecord", Request: reflect.TypeOf(DeleteRecordRequest{}), Response: reflect.TypeOf(DeleteRecordResponse{})},
+	{Code: FunctionSetDoorControl, Name: "SetDoorControl"},
+	{Code: FunctionUploadMission, Name: "UploadMission"},
+	{Code: FunctionGetUpload, Name: "GetUpload", Request: reflect.TypeOf(GetUploadRequest{}), Response: reflect.TypeOf(GetUploadResponse{}), ReadOnly: true, Secret: true},
+	{Code: FunctionGetControlPeriod, Name: "GetControlPeriod", Request: reflect.TypeOf(GetControlPeriodRequest{}), Response: reflect.TypeOf(GetControlPeriodResponse{}), ReadOnly: true},
+	{Code: FunctionUpdateControlPeriod, Name: "UpdateControlPeriod", Request: reflect.TypeOf(UpdateControlPeriodRequest{}), Response: reflect.TypeOf(UpdateControlPeriodResponse{})},
+	{Code: FunctionTailPlusPermissions, Name: "TailPlusPermissions", Request: reflect.TypeOf(TailPlusPermissionsRequest{}), Response: reflect.TypeOf(TailPlusPermissionsResponse{}), Secret: true},
+	{Code: FunctionOpenDoor, Name: "OpenDoor", Request: reflect.TypeOf(OpenDoorRequest{}), Response: reflect.TypeOf(OpenDoorResponse{})},
+	{Code: FunctionGetSetting, Name: "GetSetting", Request: reflect.TypeOf(GetSettingRequest{}), Response: reflect.TypeOf(GetSettingResponse{}), ReadOnly: true},
+	{Code: FunctionUpdateSetting, Name: "UpdateSetting", Request: reflect.TypeOf(UpdateSettingRequest{}), Response: reflect.TypeOf(UpdateSettingResponse{})},
+	{Code: FunctionRealizeTimingTask, Name: "RealizeTimingTask", Request: reflect.TypeOf(RealizeTimingTaskRequest{})},
+	{Code: FunctionUnknown10F9, Name: "Unknown10F9", Secret: true},
+	{Code: FunctionFormat, Name: "Format"},
+	{Code: FunctionGetNetworkInfo, Name: "GetNetworkInfo", Request: reflect.TypeOf(GetNetworkInfoRequest{}), Response: reflect.TypeOf(GetNetworkInfoResponse{}), ReadOnly: true},
+	{Code: FunctionUpdatePermissions, Name: "UpdatePermissions", Request: reflect.TypeOf(UpdatePermissionsRequest{}), Response: reflect.TypeOf(UpdatePermissionsResponse{}), Secret: true},
+	{Code: FunctionDeletePermissions, Name: "DeletePermissions", Request: reflect.TypeOf(DeletePermissionsRequest{}), Response: reflect.TypeOf(DeletePermissionsResponse{}), Secret: true},
+	{Code: FunctionSetNetworkInfo, Name: "SetNetworkInfo", Request: reflect.TypeOf(SetNetworkInfoRequest{}), Response: reflect.TypeOf(SetNetworkInfoResponse{})},
+}
+
+// Functions returns every known function.
+func Functions() []FunctionInfo {
+	result := make([]FunctionInfo, len(functionRegistry))
+	copy(result, functionRegistry)
+	return result
+}
+
+// LookupFunction returns the function with the given code.
+//
+// If no function is found, this returns nil.
+func LookupFunction(code uint16) *FunctionInfo {
+	for _, info := range functionRegistry {
+		if info.Code == code {
+			return &info
+		}
+	}
+	return nil
+}
+
+// FunctionName returns the name of the function with the given code.
+//
+// If the function is not known, then a name based on the code is returned.
+func FunctionName(code uint16) string {
+	if info := LookupFunction(code); info != nil {
+		return info.Name
+	}
+	return fmt.Sprintf("Unknown%04X", code)
+}
@@ -12,7 +12,7 @@ type DeletePermissionsRequest struct {
 	StartDate *time.Time `wire:"type:date,null:0x00"`
 	EndDate   *time.Time `wire:"type:date,null:0x00"`
 	Time      uint8      // TODO: WHAT IS THIS
-	Password  uint32     `wire:"type:uint24"` // 24-bit password
+	Password  PIN        `wire:"type:uint24"` // 24-bit password
 	Standby   []byte     `wire:"length:4"`
 	_         [0]byte    `wire:"length:*"` // Fail if there are any leftover bytes.
 }
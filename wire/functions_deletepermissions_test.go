@@ -25,6 +25,20 @@ func TestDeletePermissions(t *testing.T) {
 				Standby:   []byte{0, 0, 0, 0},
 			},
 		},
+		{
+			input: "0000702853010000000001000000000000000000000000000000",
+			output: DeletePermissionsRequest{
+				Empty1:    0,
+				CardID:    10352,
+				Area:      83,
+				Door:      1,
+				StartDate: nil,
+				EndDate:   nil,
+				Time:      1,
+				Password:  0,
+				Standby:   []byte{0, 0, 0, 0},
+			},
+		},
 		{
 			input:  "00007028530121009F650140E201000000000000000000000001",
 			output: DeletePermissionsRequest{},
@@ -0,0 +1,8 @@
+package wire
+
+type GetControlPeriodRequest struct {
+	TimeIndex uint16
+	_         [0]byte `wire:"length:*"` // Fail if there are any leftover bytes.
+}
+
+type GetControlPeriodResponse UpdateControlPeriodRequest
@@ -1,6 +1,7 @@
 package wire
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -89,15 +90,194 @@ func (r Record) AccessGranted() bool {
 	return (r.RecordState&0b10000000 == 0)
 }
 
+// RecordEvent is what a record represents.
+type RecordEvent string
+
+const (
+	RecordEventGranted       RecordEvent = "granted"             // A card was granted access.
+	RecordEventDenied        RecordEvent = "denied"              // A card was denied access (see "DeniedReason").
+	RecordEventButton        RecordEvent = "button"              // The exit button was pressed.
+	RecordEventRemoteOpen    RecordEvent = "remote open"         // The door was opened remotely (for example, "OpenDoor").
+	RecordEventSuperPassword RecordEvent = "super password open" // The door was opened with the super password.
+	RecordEventDoorOpened    RecordEvent = "door opened"         // The door sensor reported that the door opened.
+	RecordEventDoorClosed    RecordEvent = "door closed"         // The door sensor reported that the door closed.
+	RecordEventDuress        RecordEvent = "duress alarm"
+	RecordEventDoorHeldOpen  RecordEvent = "door held open alarm"
+	RecordEventForcedEntry   RecordEvent = "forced entry alarm"
+	RecordEventFireAlarm     RecordEvent = "fire alarm"  // This applies to the whole controller.
+	RecordEventForcedLock    RecordEvent = "forced lock" // This applies to the whole controller.
+	RecordEventUnknown       RecordEvent = "unknown"
+)
+
+// deniedReasons maps the upper six bits of the record state to the reason that
+// a card was denied.
+//
+// TODO: The anti-passback, multi-card, and interlock modes cannot be read or
+// configured yet; only these denied reasons are documented, and none of the
+// captures show which setting registers hold the modes.
+var deniedReasons = map[uint8]string{
+	0b100000: "non-specific",
+	0b100100: "no permission",
+	0b101000: "incorrect password",
+	0b101100: "system fault",
+	0b110000: "anti-passback, multi-card, or interlock",
+	0b110001: "anti-passback",
+	0b110010: "multi-card",
+	0b110011: "first card",
+	0b110100: "door normally closed",
+	0b110101: "interlock",
+	0b111000: "card expired or outside of its time period",
+}
+
+// IsSpecial returns true if this is a special record (for example, a button or
+// an alarm) instead of a card.
+func (r Record) IsSpecial() bool {
+	return r.AreaNumber == 0 && r.IDNumber < 100
+}
+
+// Event returns what the record represents.
+func (r Record) Event() RecordEvent {
+	if !r.IsSpecial() {
+		if r.AccessGranted() {
+			return RecordEventGranted
+		}
+		return RecordEventDenied
+	}
+	switch {
+	case r.IDNumber&0b1100 == 0b0000:
+		switch r.RecordState {
+		case 0b00000000:
+			return RecordEventButton
+		case 0b00000011:
+			return RecordEventRemoteOpen
+		case 0b10000001:
+			return RecordEventDuress
+		case 0b10000010:
+			return RecordEventDoorHeldOpen
+		case 0b10000100:
+			return RecordEventForcedEntry
+		}
+	case r.IDNumber == 0b0101:
+		return RecordEventSuperPassword
+	case r.IDNumber&0b1100 == 0b1000:
+		return RecordEventDoorOpened
+	case r.IDNumber&0b1100 == 0b1100:
+		return RecordEventDoorClosed
+	case r.IDNumber == 0b0100 && r.RecordState == 0b10100000:
+		return RecordEventFireAlarm
+	case r.IDNumber == 0b0110 && r.RecordState == 0b10100000:
+		return RecordEventForcedLock
+	}
+	return RecordEventUnknown
+}
+
+// IsAlarm returns true if the record is an alarm.
+func (r Record) IsAlarm() bool {
+	switch r.Event() {
+	case RecordEventDuress, RecordEventDoorHeldOpen, RecordEventForcedEntry, RecordEventFireAlarm:
+		return true
+	}
+	return false
+}
+
+// DeniedReason returns the reason that a card was denied, or an empty string
+// if the record is not a denied card.
+func (r Record) DeniedReason() string {
+	if r.Event() != RecordEventDenied {
+		return ""
+	}
+	if reason, ok := deniedReasons[r.RecordState>>2]; ok {
+		return reason
+	}
+	return fmt.Sprintf("unknown (0x%02x)", r.RecordState>>2)
+}
+
+// DoorBits has one bit per door.
+type DoorBits struct {
+	Door1  bool  `wire:"bitfield:0"`
+	Door2  bool  `wire:"bitfield:1"`
+	Door3  bool  `wire:"bitfield:2"`
+	Door4  bool  `wire:"bitfield:3"`
+	Unused uint8 `wire:"bitfield:4-7"` // These bits do not belong to a door, but they are kept so that the value round-trips.
+}
+
+// Door returns the bit for the door with a one index (1-4).
+// An invalid door is always false.
+func (b DoorBits) Door(door uint8) bool {
+	switch door {
+	case 1:
+		return b.Door1
+	case 2:
+		return b.Door2
+	case 3:
+		return b.Door3
+	case 4:
+		return b.Door4
+	}
+	return false
+}
+
+// RelayStatus is the state of the door relays.
+type RelayStatus DoorBits
+
+// Energized returns true if the relay for the door (1-4) is energized (that is,
+// the lock has been released).
+func (s RelayStatus) Energized(door uint8) bool {
+	return DoorBits(s).Door(door)
+}
+
+// MagnetState is the state of the door sensors (the "magnetism signal").
+//
+// A set bit means that the magnet is engaged; every bit (including the unused
+// ones) is set when all of the doors are closed.
+type MagnetState DoorBits
+
+// DoorOpen returns true if the sensor for the door (1-4) reports that the door
+// is open.
+func (s MagnetState) DoorOpen(door uint8) bool {
+	if door < 1 || door > 4 {
+		return false
+	}
+	return !DoorBits(s).Door(door)
+}
+
+// FaultNumber is the fault reported by the controller.
+type FaultNumber uint8
+
+const (
+	FaultNone FaultNumber = 0
+)
+
+// faultDescriptions has the descriptions of the known faults.
+//
+// The protocol documents the fault byte but not its values, and only 0 (no
+// fault) has been seen in a capture, so every other value is shown by number.
+//
+// TODO: The other fault codes are deferred until there is a capture of a
+// controller that reports a fault.
+var faultDescriptions = map[FaultNumber]string{
+	FaultNone: "no fault",
+}
+
+// Description returns a human-readable description of the fault.
+//
+// A fault whose meaning is not known is described as such, along with its number.
+func (f FaultNumber) Description() string {
+	if description, ok := faultDescriptions[f]; ok {
+		return description
+	}
+	return fmt.Sprintf("fault %d (meaning not known)", uint8(f))
+}
+
 type GetOperationStatusResponse struct {
 	CurrentTime   time.Time `wire:"type:hexdatetime"`
 	RecordCount   uint32    `wire:"type:uint24"` // This is the number of access records available.
 	PopedomAmount uint16    // TODO: Is the number of fobs registered on the door?
 	Record        *Record   `wire:"length:8,null:0xff"` // This is the access record for the index requested.
-	RelayStatus   uint8
-	MagnetState   uint8
+	RelayStatus   RelayStatus
+	MagnetState   MagnetState
 	Reserved1     uint8
-	FaultNumber   uint8
+	FaultNumber   FaultNumber
 	Reserved2     uint8
 	Reserved3     uint8
 	_             [0]byte `wire:"length:*"` // Fail if there are any leftover bytes.
@@ -1,8 +1,12 @@
 package wire
 
 import (
+	"encoding/hex"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetOperationStatus(t *testing.T) {
@@ -28,10 +32,10 @@ func TestGetOperationStatus(t *testing.T) {
 					RecordState:   0,
 					BrushDateTime: time.Date(2022, 12, 28, 11, 28, 42, 0, time.UTC),
 				},
-				RelayStatus: 0,
-				MagnetState: 255,
+				RelayStatus: RelayStatus{},
+				MagnetState: MagnetState{Door1: true, Door2: true, Door3: true, Door4: true, Unused: 0xF},
 				Reserved1:   0,
-				FaultNumber: 0,
+				FaultNumber: FaultNone,
 				Reserved2:   0,
 				Reserved3:   0,
 			},
@@ -43,10 +47,25 @@ func TestGetOperationStatus(t *testing.T) {
 				RecordCount:   10654,
 				PopedomAmount: 338,
 				Record:        nil,
-				RelayStatus:   0,
-				MagnetState:   255,
+				RelayStatus:   RelayStatus{},
+				MagnetState:   MagnetState{Door1: true, Door2: true, Door3: true, Door4: true, Unused: 0xF},
+				Reserved1:     0,
+				FaultNumber:   FaultNone,
+				Reserved2:     0,
+				Reserved3:     0,
+			},
+		},
+		{
+			input: "221228031141419E29005201FFFFFFFFFFFFFFFF05F600070000",
+			output: GetOperationStatusResponse{
+				CurrentTime:   time.Date(2022, 12, 28, 11, 41, 41, 0, time.UTC),
+				RecordCount:   10654,
+				PopedomAmount: 338,
+				Record:        nil,
+				RelayStatus:   RelayStatus{Door1: true, Door3: true},
+				MagnetState:   MagnetState{Door2: true, Door3: true, Unused: 0xF},
 				Reserved1:     0,
-				FaultNumber:   0,
+				FaultNumber:   7,
 				Reserved2:     0,
 				Reserved3:     0,
 			},
@@ -54,3 +73,69 @@ func TestGetOperationStatus(t *testing.T) {
 	}
 	runEncodeDecodeTests(t, rows)
 }
+
+func TestDoorBits(t *testing.T) {
+	relayStatus := RelayStatus{Door1: true, Door3: true}
+	assert.True(t, relayStatus.Energized(1))
+	assert.False(t, relayStatus.Energized(2))
+	assert.True(t, relayStatus.Energized(3))
+	assert.False(t, relayStatus.Energized(4))
+	assert.False(t, relayStatus.Energized(5))
+
+	magnetState := MagnetState{Door2: true, Door3: true, Unused: 0xF}
+	assert.True(t, magnetState.DoorOpen(1))
+	assert.False(t, magnetState.DoorOpen(2))
+	assert.False(t, magnetState.DoorOpen(3))
+	assert.True(t, magnetState.DoorOpen(4))
+	assert.False(t, magnetState.DoorOpen(0))
+
+	assert.Equal(t, "no fault", FaultNone.Description())
+	assert.Equal(t, "fault 7 (meaning not known)", FaultNumber(7).Description())
+}
+
+func TestRecordEvent(t *testing.T) {
+	rows := []struct {
+		record       Record
+		event        RecordEvent
+		door         uint8
+		alarm        bool
+		deniedReason string
+	}{
+		{record: Record{IDNumber: 23439, AreaNumber: 178, RecordState: 0b00000010}, event: RecordEventGranted, door: 3},
+		{record: Record{IDNumber: 23439, AreaNumber: 178, RecordState: 0b10010001}, event: RecordEventDenied, door: 2, deniedReason: "no permission"},
+		{record: Record{IDNumber: 23439, AreaNumber: 178, RecordState: 0b11100000}, event: RecordEventDenied, door: 1, deniedReason: "card expired or outside of its time period"},
+		{record: Record{IDNumber: 23439, AreaNumber: 178, RecordState: 0b11111100}, event: RecordEventDenied, door: 1, deniedReason: "unknown (0x3f)"},
+		{record: Record{IDNumber: 0b0001, RecordState: 0b00000000}, event: RecordEventButton, door: 2},
+		{record: Record{IDNumber: 0b0010, RecordState: 0b00000011}, event: RecordEventRemoteOpen, door: 3},
+		{record: Record{IDNumber: 0b0101, RecordState: 0b00000001}, event: RecordEventSuperPassword, door: 2},
+		{record: Record{IDNumber: 0b1000, RecordState: 0b00000000}, event: RecordEventDoorOpened, door: 1},
+		{record: Record{IDNumber: 0b1111, RecordState: 0b00000000}, event: RecordEventDoorClosed, door: 4},
+		{record: Record{IDNumber: 0b0000, RecordState: 0b10000001}, event: RecordEventDuress, door: 1, alarm: true},
+		{record: Record{IDNumber: 0b0001, RecordState: 0b10000010}, event: RecordEventDoorHeldOpen, door: 2, alarm: true},
+		{record: Record{IDNumber: 0b0011, RecordState: 0b10000100}, event: RecordEventForcedEntry, door: 4, alarm: true},
+		{record: Record{IDNumber: 0b0100, RecordState: 0b10100000}, event: RecordEventFireAlarm, door: 0, alarm: true},
+		{record: Record{IDNumber: 0b0110, RecordState: 0b10100000}, event: RecordEventForcedLock, door: 0},
+		{record: Record{IDNumber: 0b0100, RecordState: 0b00000001}, event: RecordEventUnknown, door: 0},
+	}
+	for _, row := range rows {
+		assert.Equal(t, row.event, row.record.Event(), "record: %+v", row.record)
+		assert.Equal(t, row.door, row.record.Door(), "record: %+v", row.record)
+		assert.Equal(t, row.alarm, row.record.IsAlarm(), "record: %+v", row.record)
+		assert.Equal(t, row.deniedReason, row.record.DeniedReason(), "record: %+v", row.record)
+	}
+}
+
+func TestGetOperationStatusLocation(t *testing.T) {
+	location := time.FixedZone("CET", 60*60)
+	input, err := hex.DecodeString("221228031141419E290052018F5BB2009C2D955B00FF00000000")
+	require.Nil(t, err)
+
+	reader := NewReader(input)
+	reader.SetLocation(location)
+	var response GetOperationStatusResponse
+	err = Decode(reader, &response)
+	require.Nil(t, err)
+	assert.Equal(t, time.Date(2022, 12, 28, 11, 41, 41, 0, location), response.CurrentTime)
+	require.NotNil(t, response.Record)
+	assert.Equal(t, time.Date(2022, 12, 28, 11, 28, 42, 0, location), response.Record.BrushDateTime)
+}
@@ -14,10 +14,40 @@ type GetUploadResponse struct {
 	StartDate  time.Time `wire:"type:date"`
 	EndDate    time.Time `wire:"type:date"`
 	Time       uint8
-	Password   uint32 `wire:"type:uint24"`
+	Password   PIN `wire:"type:uint24"`
 	Standby1   uint8
 	Standby2   uint8
 	Standby3   uint8
 	Standby4   uint8
 	_          [0]byte `wire:"length:*"` // Fail if there are any leftover bytes.
 }
+
+// FirstCard returns true if the card is a "first card" user; a door that
+// requires a first card denies everyone else until one of these cards has been
+// used.
+func (r GetUploadResponse) FirstCard() bool {
+	return r.Standby1 != 0
+}
+
+// Group returns the card's user group for multi-card opening; 0 is the general
+// user group.
+func (r GetUploadResponse) Group() uint8 {
+	return r.Standby2
+}
+
+// UpdateRequest returns the request that stores this permission as-is.
+//
+// Use this to change part of an existing permission without losing the rest
+// (such as its PIN).
+func (r GetUploadResponse) UpdateRequest() UpdatePermissionsRequest {
+	return Defaults(UpdatePermissionsRequest{
+		CardID:    r.IDNumber,
+		Area:      r.AreaNumber,
+		Door:      r.DoorNumber,
+		StartDate: r.StartDate,
+		EndDate:   r.EndDate,
+		Time:      r.Time,
+		Password:  r.Password,
+		Standby:   []byte{r.Standby1, r.Standby2, r.Standby3, r.Standby4},
+	})
+}
@@ -3,6 +3,8 @@ package wire
 import (
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestGetUpload(t *testing.T) {
@@ -37,3 +39,39 @@ func TestGetUpload(t *testing.T) {
 	}
 	runEncodeDecodeTests(t, rows)
 }
+
+func TestPermissionStandby(t *testing.T) {
+	standby := PermissionStandby(true, 3)
+	assert.Equal(t, []byte{1, 3, 0, 0}, standby)
+	assert.Equal(t, []byte{0, 0, 0, 0}, PermissionStandby(false, 0))
+
+	response := GetUploadResponse{Standby1: standby[0], Standby2: standby[1]}
+	assert.True(t, response.FirstCard())
+	assert.Equal(t, uint8(3), response.Group())
+	assert.False(t, GetUploadResponse{}.FirstCard())
+}
+
+func TestGetUploadUpdateRequest(t *testing.T) {
+	response := GetUploadResponse{
+		IDNumber:   40384,
+		AreaNumber: 11,
+		DoorNumber: 2,
+		StartDate:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:    time.Date(2050, 12, 31, 0, 0, 0, 0, time.UTC),
+		Time:       1,
+		Password:   1234,
+		Standby1:   1,
+		Standby2:   3,
+	}
+	assert.Equal(t, UpdatePermissionsRequest{
+		Unknown1:  1,
+		CardID:    40384,
+		Area:      11,
+		Door:      2,
+		StartDate: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2050, 12, 31, 0, 0, 0, 0, time.UTC),
+		Time:      1,
+		Password:  1234,
+		Standby:   []byte{1, 3, 0, 0},
+	}, response.UpdateRequest())
+}
@@ -1,8 +1,8 @@
 package wire
 
 type OpenDoorRequest struct {
-	Door     uint8
-	Unkonwn1 uint8
+	Door     uint8   `wire:"enum:1|2|3|4"`
+	Unkonwn1 uint8   `wire:"default:1"`
 	_        [0]byte `wire:"length:*"` // Fail if there are any leftover bytes.
 }
 
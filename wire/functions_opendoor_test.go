@@ -13,11 +13,24 @@ func TestOpenDoor(t *testing.T) {
 				Unkonwn1: 1,
 			},
 		},
+		{
+			// An explicit zero is sent as-is.
+			input: "0400000000000000000000000000000000000000000000000000",
+			output: OpenDoorRequest{
+				Door:     4,
+				Unkonwn1: 0,
+			},
+		},
 		{
 			input:  "0401000000000000000000000000000000000000000000000001",
 			output: OpenDoorRequest{},
 			fail:   true,
 		},
+		{
+			input:  "0501000000000000000000000000000000000000000000000000",
+			output: OpenDoorRequest{},
+			fail:   true,
+		},
 		{
 			input:  "0000000000000000000000000000000000000000000000000000",
 			output: OpenDoorResponse{},
@@ -0,0 +1,52 @@
+package wire
+
+import "time"
+
+// Door control values, as used by timing tasks and the door configuration.
+const (
+	DoorControlOpen       = 1 // The door is held open.
+	DoorControlClosed     = 2 // The door is held closed.
+	DoorControlControlled = 3 // The door is controlled by the card readers.
+)
+
+// RealizeTimingTaskRequest stores a timing task in the given slot.
+//
+// When the task time is reached on one of the days in the week control, the
+// door is put into the given control state.
+//
+// This only ever writes the slot; there is no known function to read one back.
+type RealizeTimingTaskRequest struct {
+	TaskIndex   uint16    // This is the 1-index slot for the task.
+	WeekControl uint8     // This is a bitmask of the days of the week; see `WeekControl`.
+	Door        uint8     // This is the door (1-4); 0 means that the slot is empty.
+	Control     uint8     // This is one of the DoorControl* constants.
+	Standby1    uint8     // This should always be zero.
+	StartTime   time.Time `wire:"type:time"` // This is the time of day that the task runs.
+	StartDate   time.Time `wire:"type:date"` // This is the first day that the task is active.
+	EndDate     time.Time `wire:"type:date"` // This is the last day that the task is active.
+	Standby2    uint8
+	Standby3    uint8
+	_           [0]byte `wire:"length:*"` // Fail if there are any leftover bytes.
+}
+
+// WeekControl returns the week control bitmask for the given days.
+//
+// Bit 0 is Monday and bit 6 is Sunday.
+func WeekControl(days ...time.Weekday) uint8 {
+	var result uint8
+	for _, day := range days {
+		result |= 1 << ((uint8(day) + 6) % 7)
+	}
+	return result
+}
+
+// WeekControlDays returns the days of the week present in the bitmask.
+func WeekControlDays(weekControl uint8) []time.Weekday {
+	var result []time.Weekday
+	for i := uint8(0); i < 7; i++ {
+		if weekControl&(1<<i) != 0 {
+			result = append(result, time.Weekday((i+1)%7))
+		}
+	}
+	return result
+}
@@ -0,0 +1,45 @@
+package wire
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRealizeTimingTask(t *testing.T) {
+	rows := []EncodeDecodeTest{
+		{
+			input: "01001F010100004021009F650000000000000000000000000000",
+			output: RealizeTimingTaskRequest{
+				TaskIndex:   1,
+				WeekControl: 0x1f,
+				Door:        1,
+				Control:     DoorControlOpen,
+				StartTime:   time.Date(0, time.January, 1, 8, 0, 0, 0, time.UTC),
+				StartDate:   time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC),
+				EndDate:     time.Date(2050, time.December, 31, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			input:  "01001F010100004021009F650000000000000000000000000001",
+			output: RealizeTimingTaskRequest{},
+			fail:   true,
+		},
+	}
+	runEncodeDecodeTests(t, rows)
+}
+
+func TestWeekControl(t *testing.T) {
+	weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+	assert.Equal(t, uint8(0x00), WeekControl())
+	assert.Equal(t, uint8(0x01), WeekControl(time.Monday))
+	assert.Equal(t, uint8(0x40), WeekControl(time.Sunday))
+	assert.Equal(t, uint8(0x1f), WeekControl(weekdays...))
+	assert.Equal(t, uint8(0x7f), WeekControl(time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday))
+
+	assert.Equal(t, weekdays, WeekControlDays(0x1f))
+	assert.Equal(t, []time.Weekday{time.Saturday, time.Sunday}, WeekControlDays(0x60))
+	assert.Nil(t, WeekControlDays(0x00))
+}
@@ -0,0 +1,68 @@
+package wire
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFunctionRegistry(t *testing.T) {
+	t.Run("Unique", func(t *testing.T) {
+		codes := map[uint16]bool{}
+		names := map[string]bool{}
+		for _, info := range Functions() {
+			assert.False(t, codes[info.Code], "duplicate code: 0x%04x", info.Code)
+			assert.False(t, names[info.Name], "duplicate name: %s", info.Name)
+			codes[info.Code] = true
+			names[info.Name] = true
+		}
+	})
+	t.Run("Types", func(t *testing.T) {
+		for _, info := range Functions() {
+			if info.Request != nil {
+				assert.Equal(t, reflect.Struct, info.Request.Kind(), "%s request", info.Name)
+				assert.Equal(t, reflect.PointerTo(info.Request), reflect.TypeOf(info.NewRequest()))
+			} else {
+				assert.Nil(t, info.NewRequest())
+			}
+			if info.Response != nil {
+				assert.Equal(t, reflect.Struct, info.Response.Kind(), "%s response", info.Name)
+				assert.Equal(t, reflect.PointerTo(info.Response), reflect.TypeOf(info.NewResponse()))
+			} else {
+				assert.Nil(t, info.NewResponse())
+			}
+		}
+	})
+	t.Run("Lookup", func(t *testing.T) {
+		info := LookupFunction(FunctionOpenDoor)
+		require.NotNil(t, info)
+		assert.Equal(t, "OpenDoor", info.Name)
+		assert.IsType(t, &OpenDoorRequest{}, info.NewRequest())
+		assert.IsType(t, &OpenDoorResponse{}, info.NewResponse())
+
+		assert.Nil(t, LookupFunction(0x0000))
+		assert.Equal(t, "OpenDoor", FunctionName(FunctionOpenDoor))
+		assert.Equal(t, "Unknown1234", FunctionName(0x1234))
+	})
+	t.Run("Decode", func(t *testing.T) {
+		info := LookupFunction(FunctionOpenDoor)
+		require.NotNil(t, info)
+
+		value, err := info.DecodeRequest([]byte{0x02, 0x00})
+		require.Nil(t, err)
+		assert.Equal(t, OpenDoorRequest{Door: 2}, value)
+
+		value, err = LookupFunction(FunctionFormat).DecodeRequest([]byte{0x00})
+		require.Nil(t, err)
+		assert.Nil(t, value)
+	})
+	t.Run("ReadOnly", func(t *testing.T) {
+		assert.True(t, LookupFunction(FunctionGetOperationStatus).ReadOnly)
+		assert.True(t, LookupFunction(FunctionGetSetting).ReadOnly)
+		assert.False(t, LookupFunction(FunctionOpenDoor).ReadOnly)
+		assert.False(t, LookupFunction(FunctionSetTime).ReadOnly)
+		assert.False(t, LookupFunction(FunctionFormat).ReadOnly)
+	})
+}
@@ -1,8 +1,12 @@
 package wire
 
 import (
+	"encoding/hex"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSetTime(t *testing.T) {
@@ -27,3 +31,30 @@ func TestSetTime(t *testing.T) {
 	}
 	runEncodeDecodeTests(t, rows)
 }
+
+func TestSetTimeLocation(t *testing.T) {
+	location := time.FixedZone("EST", -5*60*60)
+	input, err := hex.DecodeString("2212230521385000000000000000000000000000000000000000")
+	require.Nil(t, err)
+
+	writer := NewWriter()
+	writer.SetLocation(location)
+	err = Encode(writer, &SetTimeRequest{
+		CurrentTime: time.Date(2022, 12, 24, 2, 38, 50, 0, time.UTC),
+	})
+	require.Nil(t, err)
+	assert.Equal(t, input[:7], writer.Bytes()[:7])
+
+	reader := NewReader(input)
+	reader.SetLocation(location)
+	var response SetTimeResponse
+	err = Decode(reader, &response)
+	require.Nil(t, err)
+	assert.Equal(t, time.Date(2022, 12, 23, 21, 38, 50, 0, location), response.CurrentTime)
+	assert.True(t, response.CurrentTime.Equal(time.Date(2022, 12, 24, 2, 38, 50, 0, time.UTC)))
+
+	// Without a location, the wall time is decoded as UTC.
+	err = Decode(NewReader(input), &response)
+	require.Nil(t, err)
+	assert.Equal(t, time.Date(2022, 12, 23, 21, 38, 50, 0, time.UTC), response.CurrentTime)
+}
@@ -10,7 +10,7 @@ type TailPlusPermissionsRequest struct {
 	StartDate   time.Time `wire:"type:date"`
 	EndDate     time.Time `wire:"type:date"`
 	Time        uint8
-	Password    uint32 `wire:"type:uint24"`
+	Password    PIN `wire:"type:uint24"`
 	Standby1    uint8
 	Standby2    uint8
 	Standby3    uint8
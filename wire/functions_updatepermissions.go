@@ -5,14 +5,14 @@ import (
 )
 
 type UpdatePermissionsRequest struct {
-	Unknown1  uint16
+	Unknown1  uint16 `wire:"default:1"`
 	CardID    uint16
 	Area      uint8
 	Door      uint8
 	StartDate time.Time `wire:"type:date"`
 	EndDate   time.Time `wire:"type:date"`
 	Time      uint8     // TODO: WHAT IS THIS
-	Password  uint32    `wire:"type:uint24"` // 24-bit password
+	Password  PIN       `wire:"type:uint24"` // 24-bit password
 	Standby   []byte    `wire:"length:4"`
 	_         [0]byte   `wire:"length:*"` // Fail if there are any leftover bytes.
 }
@@ -21,3 +21,15 @@ type UpdatePermissionsResponse struct {
 	Result uint8
 	_      [0]byte `wire:"length:*"` // Fail if there are any leftover bytes.
 }
+
+// PermissionStandby returns the "standby" bytes of a permission.
+//
+// The first byte marks a "first card" user, and the second is the user group
+// for multi-card opening (0 is the general user group); the rest are unused.
+func PermissionStandby(firstCard bool, group uint8) []byte {
+	standby := []byte{0, group, 0, 0}
+	if firstCard {
+		standby[0] = 1
+	}
+	return standby
+}
@@ -8,6 +8,6 @@ type UpdateSettingRequest struct {
 }
 
 type UpdateSettingResponse struct {
-	Result uint8   // 0 means successs.
+	Result uint8   // 1 is success (as in the captured response to a write); 0 is failure.
 	_      [0]byte `wire:"length:*"` // Fail if there are any leftover bytes.
 }
@@ -0,0 +1,152 @@
+package wire
+
+import (
+	"bufio"
+	"encoding/hex"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// corpusPacket is a single packet from "testdata/packets.txt".
+type corpusPacket struct {
+	ToController bool
+	Data         []byte
+}
+
+// loadCorpus loads the synthetic packets used to seed the fuzz tests.
+func loadCorpus(t testing.TB) []corpusPacket {
+	file, err := os.Open("testdata/packets.txt")
+	require.Nil(t, err)
+	defer file.Close()
+
+	var result []corpusPacket
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Fields(line)
+		require.Len(t, parts, 2, "line: %q", line)
+		require.Contains(t, []string{"to-controller", "from-controller"}, parts[0], "line: %q", line)
+		data, err := hex.DecodeString(parts[1])
+		require.Nil(t, err, "line: %q", line)
+		result = append(result, corpusPacket{
+			ToController: parts[0] == "to-controller",
+			Data:         data,
+		})
+	}
+	require.Nil(t, scanner.Err())
+	require.NotEmpty(t, result)
+	return result
+}
+
+func TestCorpus(t *testing.T) {
+	for _, packet := range loadCorpus(t) {
+		var envelope Envelope
+		err := Decode(NewReader(packet.Data), &envelope)
+		require.Nil(t, err, "packet: %X", packet.Data)
+
+		info := LookupFunction(envelope.Function)
+		require.NotNil(t, info, "function: 0x%04x", envelope.Function)
+		if packet.ToController {
+			_, err = info.DecodeRequest(envelope.Contents)
+		} else {
+			_, err = info.DecodeResponse(envelope.Contents)
+		}
+		assert.Nil(t, err, "function: %s; packet: %X", info.Name, packet.Data)
+	}
+}
+
+func FuzzEnvelope(f *testing.F) {
+	for _, packet := range loadCorpus(f) {
+		f.Add(packet.Data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var envelope Envelope
+		err := Decode(NewReader(data), &envelope)
+		if err != nil {
+			return
+		}
+
+		// The contents are padded when encoding, so the first round trip may add bytes.
+		writer := NewWriter()
+		err = Encode(writer, &envelope)
+		require.Nil(t, err)
+		encoded := writer.Bytes()
+
+		var roundTrip Envelope
+		err = Decode(NewReader(encoded), &roundTrip)
+		require.Nil(t, err, "encoded: %X", encoded)
+		assert.Equal(t, envelope.BoardAddress, roundTrip.BoardAddress)
+		assert.Equal(t, envelope.Function, roundTrip.Function)
+
+		writer = NewWriter()
+		err = Encode(writer, &roundTrip)
+		require.Nil(t, err)
+		assert.Equal(t, encoded, writer.Bytes())
+	})
+}
+
+func FuzzFunctions(f *testing.F) {
+	for _, packet := range loadCorpus(f) {
+		var envelope Envelope
+		err := Decode(NewReader(packet.Data), &envelope)
+		require.Nil(f, err)
+		f.Add(envelope.Function, packet.ToController, envelope.Contents)
+	}
+	f.Fuzz(func(t *testing.T, function uint16, toController bool, contents []byte) {
+		info := LookupFunction(function)
+		if info == nil {
+			return
+		}
+		if myType := info.Response; !toController && myType != nil {
+			compareCodecs(t, myType, contents)
+		} else if myType := info.Request; toController && myType != nil {
+			compareCodecs(t, myType, contents)
+		}
+
+		var value any
+		var err error
+		if toController {
+			value, err = info.DecodeRequest(contents)
+		} else {
+			value, err = info.DecodeResponse(contents)
+		}
+		if err != nil || value == nil {
+			return
+		}
+
+		// Encoding is allowed to normalize the value (for example, dropping unused bytes), but
+		// once normalized, it must be stable.
+		pointer := reflect.New(reflect.TypeOf(value))
+		pointer.Elem().Set(reflect.ValueOf(value))
+		writer := NewWriter()
+		err = Encode(writer, pointer.Interface())
+		if err != nil {
+			// Some decoded values cannot be represented (for example, a date before 2000).
+			return
+		}
+		encoded := writer.Bytes()
+
+		var roundTrip any
+		if toController {
+			roundTrip, err = info.DecodeRequest(encoded)
+		} else {
+			roundTrip, err = info.DecodeResponse(encoded)
+		}
+		require.Nil(t, err, "function: %s; encoded: %X", info.Name, encoded)
+
+		pointer = reflect.New(reflect.TypeOf(roundTrip))
+		pointer.Elem().Set(reflect.ValueOf(roundTrip))
+		writer = NewWriter()
+		err = Encode(writer, pointer.Interface())
+		require.Nil(t, err, "function: %s; value: %+v", info.Name, roundTrip)
+		assert.Equal(t, encoded, writer.Bytes(), "function: %s", info.Name)
+	})
+}
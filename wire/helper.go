@@ -2,6 +2,7 @@ package wire
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 )
 
@@ -11,6 +12,23 @@ func CardID(prefix uint8, suffix uint16) string {
 	return fmt.Sprintf("%d%05d", prefix, suffix)
 }
 
+// ParseCardID returns the 8-bit prefix and the 16-bit suffix of the human-
+// readable card ID; this is the opposite of `CardID`.
+func ParseCardID(cardID string) (uint8, uint16, error) {
+	if len(cardID) < 6 {
+		return 0, 0, fmt.Errorf("invalid card ID: %q", cardID)
+	}
+	prefix, err := strconv.ParseUint(cardID[:len(cardID)-5], 10, 8)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid card ID: %q: %w", cardID, err)
+	}
+	suffix, err := strconv.ParseUint(cardID[len(cardID)-5:], 10, 16)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid card ID: %q: %w", cardID, err)
+	}
+	return uint8(prefix), uint16(suffix), nil
+}
+
 // IsAll returns true if all bytes in the slice are the specified value.
 //
 // If the slice is empty, then this returns true.
@@ -24,9 +42,35 @@ func IsAll(data []byte, expectedValue byte) bool {
 }
 
 // MergeDateTime takes the date portion from `dateOnly` and the time portion
-// from `timeOnly` and returns a single timestamp.
+// from `timeOnly` and returns a single timestamp in UTC.
 func MergeDateTime(dateOnly, timeOnly time.Time) time.Time {
-	return time.Date(dateOnly.Year(), dateOnly.Month(), dateOnly.Day(), timeOnly.Hour(), timeOnly.Minute(), timeOnly.Second(), 0, time.UTC)
+	return MergeDateTimeIn(dateOnly, timeOnly, time.UTC)
+}
+
+// MergeDateTimeIn is `MergeDateTime` for a timestamp in the given time zone.
+//
+// A wall time that does not exist in the time zone (because of a daylight
+// saving time change) is normalized by `time.Date`.
+func MergeDateTimeIn(dateOnly, timeOnly time.Time, location *time.Location) time.Time {
+	if location == nil {
+		location = time.UTC
+	}
+	return time.Date(dateOnly.Year(), dateOnly.Month(), dateOnly.Day(), timeOnly.Hour(), timeOnly.Minute(), timeOnly.Second(), 0, location)
+}
+
+// newDateTime returns the timestamp for the given components.
+//
+// Unlike `time.Date`, this returns an error if any of the components are out
+// of range instead of normalizing them (for example, February 30th).
+func newDateTime(year, month, day, hour, minute, second int) (time.Time, error) {
+	output := time.Date(year, time.Month(month), day, hour, minute, second, 0, time.UTC)
+	if output.Year() != year || int(output.Month()) != month || output.Day() != day {
+		return time.Time{}, fmt.Errorf("invalid date: %04d-%02d-%02d", year, month, day)
+	}
+	if output.Hour() != hour || output.Minute() != minute || output.Second() != second {
+		return time.Time{}, fmt.Errorf("invalid time: %02d:%02d:%02d", hour, minute, second)
+	}
+	return output, nil
 }
 
 // InsaneBase16ToBase10 is an insane decoder function.
@@ -47,6 +47,38 @@ func TestCardID(t *testing.T) {
 	}
 }
 
+func TestParseCardID(t *testing.T) {
+	rows := []struct {
+		input  string
+		prefix uint8
+		suffix uint16
+		fail   bool
+	}{
+		{input: "000000", prefix: 0, suffix: 0},
+		{input: "100001", prefix: 1, suffix: 1},
+		{input: "25065000", prefix: 250, suffix: 65000},
+		{input: "17823439", prefix: 178, suffix: 23439},
+		{input: "12345", fail: true},
+		{input: "170000", fail: true},
+		{input: "25600001", fail: true},
+		{input: "1A00001", fail: true},
+		{input: "1-0001", fail: true},
+	}
+	for rowIndex, row := range rows {
+		t.Run(fmt.Sprintf("%d/%s", rowIndex, row.input), func(t *testing.T) {
+			prefix, suffix, err := ParseCardID(row.input)
+			if row.fail {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, row.prefix, prefix)
+			assert.Equal(t, row.suffix, suffix)
+			assert.Equal(t, row.input, CardID(prefix, suffix))
+		})
+	}
+}
+
 func TestInsaneBase16ToBase10(t *testing.T) {
 	rows := []struct {
 		input  uint8
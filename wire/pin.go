@@ -0,0 +1,60 @@
+package wire
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// PIN is the 24-bit password of a permission; the card's holder must enter it
+// on the keypad along with the card.  Zero means that there is no PIN.
+//
+// A PIN is never shown when it is formatted (with any verb) or marshaled to
+// JSON, so that it cannot end up in a log; use `uint32(pin)` for the actual
+// value.
+type PIN uint32
+
+// ParsePIN parses a PIN of up to six digits.
+func ParsePIN(value string) (PIN, error) {
+	if len(value) == 0 || len(value) > 6 {
+		return 0, fmt.Errorf("invalid PIN: expected 1 to 6 digits")
+	}
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("invalid PIN: expected only digits")
+		}
+	}
+	v, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid PIN")
+	}
+	if v == 0 {
+		return 0, fmt.Errorf("invalid PIN: it must not be zero")
+	}
+	return PIN(v), nil
+}
+
+// IsSet returns true if there is a PIN.
+func (p PIN) IsSet() bool {
+	return p != 0
+}
+
+// String returns "set" or "none"; it never returns the PIN itself.
+func (p PIN) String() string {
+	if p.IsSet() {
+		return "set"
+	}
+	return "none"
+}
+
+// Format implements `fmt.Formatter` so that every verb (including "%d" and
+// "%#v") shows the same thing as `String`.
+func (p PIN) Format(f fmt.State, verb rune) {
+	fmt.Fprint(f, p.String())
+}
+
+// MarshalJSON returns "set" or "none" (as a JSON string); it never returns the
+// PIN itself.
+func (p PIN) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
@@ -0,0 +1,63 @@
+package wire
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePIN(t *testing.T) {
+	rows := []struct {
+		input  string
+		output PIN
+		valid  bool
+	}{
+		{input: "1234", output: 1234, valid: true},
+		{input: "000042", output: 42, valid: true},
+		{input: "999999", output: 999999, valid: true},
+		{input: ""},
+		{input: "0"},
+		{input: "1234567"},
+		{input: "12a4"},
+		{input: "-123"},
+	}
+	for _, row := range rows {
+		t.Run(row.input, func(t *testing.T) {
+			pin, err := ParsePIN(row.input)
+			if !row.valid {
+				require.NotNil(t, err)
+				if row.input != "" {
+					assert.NotContains(t, err.Error(), row.input)
+				}
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, row.output, pin)
+		})
+	}
+}
+
+func TestPINFormat(t *testing.T) {
+	pin := PIN(123456)
+	for _, format := range []string{"%v", "%d", "%x", "%X", "%s", "%#v", "%08d"} {
+		assert.Equal(t, "set", fmt.Sprintf(format, pin), format)
+	}
+	assert.Equal(t, "none", fmt.Sprintf("%d", PIN(0)))
+
+	request := UpdatePermissionsRequest{CardID: 23439, Password: pin}
+	for _, format := range []string{"%v", "%+v", "%#v"} {
+		assert.NotContains(t, fmt.Sprintf(format, request), "123456", format)
+	}
+	assert.Equal(t, uint32(123456), uint32(request.Password))
+
+	contents, err := json.Marshal(request)
+	require.Nil(t, err)
+	assert.NotContains(t, string(contents), "123456")
+	assert.Contains(t, string(contents), `"Password":"set"`)
+	contents, err = json.Marshal(GetUploadResponse{})
+	require.Nil(t, err)
+	assert.Contains(t, string(contents), `"Password":"none"`)
+}
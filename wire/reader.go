@@ -6,7 +6,8 @@ import (
 )
 
 type Reader struct {
-	data []byte
+	data     []byte
+	location *time.Location
 }
 
 func NewReader(data []byte) *Reader {
@@ -20,6 +21,18 @@ func NewReader(data []byte) *Reader {
 	return r
 }
 
+// SetLocation sets the time zone that date-times are decoded in.
+//
+// By default, they are decoded as UTC.
+func (r *Reader) SetLocation(location *time.Location) {
+	r.location = location
+}
+
+// Location returns the time zone that date-times are decoded in (nil means UTC).
+func (r *Reader) Location() *time.Location {
+	return r.location
+}
+
 func (r *Reader) Bytes() []byte {
 	return r.data
 }
@@ -29,6 +42,9 @@ func (r *Reader) Length() int {
 }
 
 func (r *Reader) ReadBytes(count int) ([]byte, error) {
+	if count < 0 {
+		return nil, fmt.Errorf("invalid count: %d", count)
+	}
 	if count == 0 {
 		return []byte{}, nil
 	}
@@ -45,7 +61,9 @@ func (r *Reader) Read(count int) (*Reader, error) {
 	if err != nil {
 		return nil, err
 	}
-	return NewReader(contents), nil
+	reader := NewReader(contents)
+	reader.location = r.location
+	return reader, nil
 }
 
 func (r *Reader) ReadUint8() (uint8, error) {
@@ -91,12 +109,11 @@ func (r *Reader) ReadDate() (time.Time, error) {
 		return time.Time{}, fmt.Errorf("invalid month: %d", month)
 	}
 	day := (value & 0b0000000000011111) >> 0
-	if month < 1 || month > 31 {
-		return time.Time{}, fmt.Errorf("invalid day: %d", month)
+	if day < 1 || day > 31 {
+		return time.Time{}, fmt.Errorf("invalid day: %d", day)
 	}
 
-	output := time.Date(2000+int(year), time.Month(month), int(day), 0, 0, 0, 0, time.UTC)
-	return output, nil
+	return newDateTime(2000+int(year), int(month), int(day), 0, 0, 0)
 }
 
 func (r *Reader) ReadTime() (time.Time, error) {
@@ -114,8 +131,8 @@ func (r *Reader) ReadTime() (time.Time, error) {
 	if minutes >= 60 {
 		return time.Time{}, fmt.Errorf("invalid minute: %d", minutes)
 	}
-	if seconds >= 60 {
-		return time.Time{}, fmt.Errorf("invalid second: %d", seconds)
+	if seconds*2 >= 60 {
+		return time.Time{}, fmt.Errorf("invalid second: %d", seconds*2)
 	}
 
 	output := time.Date(0, time.January, 1, int(hours), int(minutes), int(seconds)*2, 0, time.UTC)
@@ -14,6 +14,14 @@ func TestReadDate(t *testing.T) {
 	output, err := r.ReadDate()
 	require.Nil(t, err)
 	assert.Equal(t, time.Date(2001, time.May, 19, 0, 0, 0, 0, time.UTC), output)
+
+	// Day 0 is not a day.
+	_, err = NewReader([]byte{0xA0, 0x02}).ReadDate()
+	assert.NotNil(t, err)
+
+	// February 30th is not a day either.
+	_, err = NewReader([]byte{0x5E, 0x02}).ReadDate()
+	assert.NotNil(t, err)
 }
 
 func TestReadTime(t *testing.T) {
@@ -22,6 +30,21 @@ func TestReadTime(t *testing.T) {
 	output, err := r.ReadTime()
 	require.Nil(t, err)
 	assert.Equal(t, time.Date(0, time.January, 1, 11, 40, 26, 0, time.UTC), output)
+
+	// The seconds are stored halved, so 31 would be 62 seconds.
+	_, err = NewReader([]byte{0x1F, 0x00}).ReadTime()
+	assert.NotNil(t, err)
+}
+
+func TestReadBytes(t *testing.T) {
+	r := NewReader([]byte{0x01, 0x02})
+	_, err := r.ReadBytes(-1)
+	assert.NotNil(t, err)
+	_, err = r.ReadBytes(3)
+	assert.NotNil(t, err)
+	output, err := r.ReadBytes(2)
+	require.Nil(t, err)
+	assert.Equal(t, []byte{0x01, 0x02}, output)
 }
 
 func TestReadUint8(t *testing.T) {
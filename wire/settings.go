@@ -0,0 +1,81 @@
+package wire
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SettingValue is a named value for a setting.
+type SettingValue struct {
+	Value uint8
+	Name  string
+}
+
+// Setting describes a setting register that is read with `GetSettingRequest`
+// and written with `UpdateSettingRequest`.
+type Setting struct {
+	Address     uint8
+	Name        string         // This is a short name for the setting.
+	Description string         // This is a human-readable description.
+	Values      []SettingValue // If present, these are the only allowed values.
+	Min         uint8          // If there are no named values, this is the minimum value.
+	Max         uint8          // If there are no named values, this is the maximum value; 0 means 0xff.
+}
+
+// KnownSettings is the list of setting registers whose meaning is known.
+//
+// Each entry must cite the capture that it was confirmed from; registers that
+// are not listed here are only known by their address.  Note that the door
+// controls and open delays are not setting registers; they are set with 0x108F
+// and pushed in the "basic" block of 0x10F9.
+//
+// TODO: No register has been identified yet, so this is empty; until one is,
+// the registers can only be dumped, compared, and restored by address.
+var KnownSettings = []Setting{}
+
+// LookupSettingByAddress returns the known setting at the given address.
+//
+// If no setting is found, this returns nil.
+func LookupSettingByAddress(address uint8) *Setting {
+	for _, setting := range KnownSettings {
+		if setting.Address == address {
+			return &setting
+		}
+	}
+	return nil
+}
+
+// Validate returns an error if the value is not allowed for the setting.
+func (s Setting) Validate(value uint8) error {
+	if len(s.Values) > 0 {
+		for _, settingValue := range s.Values {
+			if settingValue.Value == value {
+				return nil
+			}
+		}
+		var names []string
+		for _, settingValue := range s.Values {
+			names = append(names, settingValue.Name)
+		}
+		return fmt.Errorf("invalid value for %s: %d (expected one of: %s)", s.Name, value, strings.Join(names, ", "))
+	}
+
+	max := s.Max
+	if max == 0 {
+		max = 0xff
+	}
+	if value < s.Min || value > max {
+		return fmt.Errorf("invalid value for %s: %d (expected %d to %d)", s.Name, value, s.Min, max)
+	}
+	return nil
+}
+
+// FormatValue returns a human-readable version of the value.
+func (s Setting) FormatValue(value uint8) string {
+	for _, settingValue := range s.Values {
+		if settingValue.Value == value {
+			return settingValue.Name
+		}
+	}
+	return fmt.Sprintf("%d", value)
+}
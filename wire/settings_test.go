@@ -0,0 +1,45 @@
+package wire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettings(t *testing.T) {
+	t.Run("Unique", func(t *testing.T) {
+		names := map[string]bool{}
+		addresses := map[uint8]bool{}
+		for _, setting := range KnownSettings {
+			assert.False(t, names[setting.Name], "duplicate name: %s", setting.Name)
+			assert.False(t, addresses[setting.Address], "duplicate address: 0x%02x", setting.Address)
+			names[setting.Name] = true
+			addresses[setting.Address] = true
+		}
+	})
+	t.Run("Lookup", func(t *testing.T) {
+		assert.Nil(t, LookupSettingByAddress(0xff))
+	})
+	t.Run("Named values", func(t *testing.T) {
+		setting := Setting{
+			Name: "mode",
+			Values: []SettingValue{
+				{Value: 1, Name: "on"},
+				{Value: 2, Name: "off"},
+			},
+		}
+
+		assert.Nil(t, setting.Validate(2))
+		assert.Equal(t, "off", setting.FormatValue(2))
+		assert.NotNil(t, setting.Validate(3))
+		assert.Equal(t, "3", setting.FormatValue(3))
+	})
+	t.Run("Range", func(t *testing.T) {
+		setting := Setting{Name: "delay", Min: 1}
+
+		assert.Nil(t, setting.Validate(10))
+		assert.Nil(t, setting.Validate(0xff))
+		assert.Equal(t, "10", setting.FormatValue(10))
+		assert.NotNil(t, setting.Validate(0))
+	})
+}
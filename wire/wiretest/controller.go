@@ -0,0 +1,334 @@
+// Package wiretest provides a fake controller for testing code that talks to
+// controllers through a wire.Client.
+//
+// The fake understands enough of the protocol to read the status and the
+// access records, open doors, set the time, and manage permissions.
+package wiretest
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+// Controller is a fake controller listening on a local TCP port.
+type Controller struct {
+	BoardAddress uint16
+
+	mutex       sync.Mutex
+	listener    net.Listener
+	location    *time.Location // This is the time zone that the controller's clock is kept in.
+	offset      time.Duration  // This is how far the controller's clock is ahead of the wall clock.
+	relayStatus wire.RelayStatus
+	magnetState wire.MagnetState
+	faultNumber wire.FaultNumber
+	records     []wire.Record
+	permissions []wire.GetUploadResponse
+	requests    []wire.Envelope
+	fail        bool
+}
+
+// NewController starts a fake controller on a random local port.
+//
+// Call `Close` when done.
+func NewController(boardAddress uint16) (*Controller, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	c := &Controller{
+		BoardAddress: boardAddress,
+		listener:     listener,
+		location:     time.Local,
+		magnetState:  wire.MagnetState{Door1: true, Door2: true, Door3: true, Door4: true, Unused: 0xF},
+	}
+	go c.serve()
+	return c, nil
+}
+
+// Close stops the controller.
+func (c *Controller) Close() error {
+	return c.listener.Close()
+}
+
+// Address returns the address that the controller is listening on.
+func (c *Controller) Address() string {
+	host, _, _ := net.SplitHostPort(c.listener.Addr().String())
+	return host
+}
+
+// Port returns the port that the controller is listening on.
+func (c *Controller) Port() uint16 {
+	_, port, _ := net.SplitHostPort(c.listener.Addr().String())
+	v, _ := strconv.ParseUint(port, 10, 16)
+	return uint16(v)
+}
+
+// Client returns a new client for the controller.
+func (c *Controller) Client() *wire.Client {
+	return &wire.Client{
+		Protocol:          wire.ProtocolTCP,
+		ControllerAddress: c.Address(),
+		ControllerPort:    c.Port(),
+		BoardAddress:      c.BoardAddress,
+	}
+}
+
+// Now returns the controller's current time.
+func (c *Controller) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now()
+}
+
+func (c *Controller) now() time.Time {
+	return c.wallClock().Add(c.offset)
+}
+
+// wallClock returns the current wall time in the controller's time zone as if
+// it were UTC.
+func (c *Controller) wallClock() time.Time {
+	t := time.Now().In(c.location)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
+
+// SetLocation sets the time zone that the controller's clock is kept in (by
+// default, the local time zone).
+func (c *Controller) SetLocation(location *time.Location) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.location = location
+}
+
+// SetOffset sets how far the controller's clock is ahead of the wall clock.
+func (c *Controller) SetOffset(offset time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.offset = offset
+}
+
+// SetDoorState sets the relay and sensor state that is reported.
+func (c *Controller) SetDoorState(relayStatus wire.RelayStatus, magnetState wire.MagnetState) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.relayStatus = relayStatus
+	c.magnetState = magnetState
+}
+
+// SetFault sets the fault that is reported.
+func (c *Controller) SetFault(faultNumber wire.FaultNumber) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.faultNumber = faultNumber
+}
+
+// SetFail makes the controller drop every connection (as if it were unreachable).
+func (c *Controller) SetFail(fail bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.fail = fail
+}
+
+// AddRecord adds access records; the first record has index 1.
+func (c *Controller) AddRecord(records ...wire.Record) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.records = append(c.records, records...)
+}
+
+// Records returns the access records.
+func (c *Controller) Records() []wire.Record {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]wire.Record{}, c.records...)
+}
+
+// AddPermission adds a permission.
+func (c *Controller) AddPermission(permissions ...wire.GetUploadResponse) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.permissions = append(c.permissions, permissions...)
+}
+
+// Permissions returns the permissions.
+func (c *Controller) Permissions() []wire.GetUploadResponse {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]wire.GetUploadResponse{}, c.permissions...)
+}
+
+// Requests returns every request that the controller has received.
+func (c *Controller) Requests() []wire.Envelope {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]wire.Envelope{}, c.requests...)
+}
+
+func (c *Controller) serve() {
+	for {
+		conn, err := c.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logrus.Warnf("wiretest: could not accept connection: %v", err)
+			}
+			return
+		}
+		go c.handle(conn)
+	}
+}
+
+// handle answers the requests on the connection.
+//
+// Each read is assumed to be a single envelope, just like the client does.
+func (c *Controller) handle(conn net.Conn) {
+	defer conn.Close()
+	buffer := make([]byte, 1024)
+	for {
+		bytesRead, err := conn.Read(buffer)
+		if err != nil {
+			return
+		}
+		var envelope wire.Envelope
+		err = wire.Decode(wire.NewReader(buffer[:bytesRead]), &envelope)
+		if err != nil {
+			logrus.Warnf("wiretest: could not decode envelope: %v", err)
+			return
+		}
+		response, ok := c.respond(envelope)
+		if !ok {
+			return
+		}
+		writer := wire.NewWriter()
+		if response != nil {
+			err = wire.Encode(writer, response)
+			if err != nil {
+				logrus.Warnf("wiretest: could not encode response: %v", err)
+				return
+			}
+		}
+		responseEnvelope := wire.Envelope{
+			BoardAddress: c.BoardAddress,
+			Function:     envelope.Function,
+			Contents:     writer.Bytes(),
+		}
+		writer = wire.NewWriter()
+		err = wire.Encode(writer, &responseEnvelope)
+		if err != nil {
+			logrus.Warnf("wiretest: could not encode envelope: %v", err)
+			return
+		}
+		_, err = conn.Write(writer.Bytes())
+		if err != nil {
+			return
+		}
+	}
+}
+
+// respond returns the response to the request.
+//
+// If this returns false, then the connection is closed.
+func (c *Controller) respond(envelope wire.Envelope) (any, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.fail {
+		return nil, false
+	}
+	c.requests = append(c.requests, envelope)
+	if envelope.BoardAddress != c.BoardAddress {
+		return nil, false
+	}
+
+	info := wire.LookupFunction(envelope.Function)
+	if info == nil {
+		return nil, false
+	}
+	request, err := info.DecodeRequest(envelope.Contents)
+	if err != nil {
+		logrus.Warnf("wiretest: could not decode %s request: %v", info.Name, err)
+		return nil, false
+	}
+
+	switch request := request.(type) {
+	case wire.GetOperationStatusRequest:
+		response := &wire.GetOperationStatusResponse{
+			CurrentTime:   c.now(),
+			RecordCount:   uint32(len(c.records)),
+			PopedomAmount: uint16(len(c.permissions)),
+			RelayStatus:   c.relayStatus,
+			MagnetState:   c.magnetState,
+			FaultNumber:   c.faultNumber,
+		}
+		index := request.RecordIndex
+		if index == 0 || index == 0xFFFFFFFF {
+			index = uint32(len(c.records))
+		}
+		if index >= 1 && index <= uint32(len(c.records)) {
+			record := c.records[index-1]
+			response.Record = &record
+		}
+		return response, true
+	case wire.OpenDoorRequest:
+		// This is what a real controller records for a remote open.
+		c.records = append(c.records, wire.Record{
+			IDNumber:      uint16(request.Door - 1),
+			RecordState:   0b00000011,
+			BrushDateTime: c.now(),
+		})
+		return &wire.OpenDoorResponse{}, true
+	case wire.SetTimeRequest:
+		c.offset = request.CurrentTime.Sub(c.wallClock())
+		return &wire.SetTimeResponse{CurrentTime: request.CurrentTime}, true
+	case wire.GetUploadRequest:
+		if request.Index < 1 || int(request.Index) > len(c.permissions) {
+			// An empty slot is all zeroes.
+			return nil, true
+		}
+		response := c.permissions[request.Index-1]
+		return &response, true
+	case wire.UpdatePermissionsRequest:
+		permission := wire.GetUploadResponse{
+			IDNumber:   request.CardID,
+			AreaNumber: request.Area,
+			DoorNumber: request.Door,
+			StartDate:  request.StartDate,
+			EndDate:    request.EndDate,
+			Time:       request.Time,
+			Password:   request.Password,
+		}
+		if len(request.Standby) == 4 {
+			permission.Standby1 = request.Standby[0]
+			permission.Standby2 = request.Standby[1]
+			permission.Standby3 = request.Standby[2]
+			permission.Standby4 = request.Standby[3]
+		}
+		replaced := false
+		for i, existing := range c.permissions {
+			if existing.IDNumber == permission.IDNumber && existing.AreaNumber == permission.AreaNumber && existing.DoorNumber == permission.DoorNumber {
+				c.permissions[i] = permission
+				replaced = true
+			}
+		}
+		if !replaced {
+			c.permissions = append(c.permissions, permission)
+		}
+		return &wire.UpdatePermissionsResponse{Result: 1}, true
+	case wire.DeletePermissionsRequest:
+		var permissions []wire.GetUploadResponse
+		for _, existing := range c.permissions {
+			if existing.IDNumber == request.CardID && existing.AreaNumber == request.Area && existing.DoorNumber == request.Door {
+				continue
+			}
+			permissions = append(permissions, existing)
+		}
+		c.permissions = permissions
+		return &wire.DeletePermissionsResponse{Result: 1}, true
+	}
+	logrus.Warnf("wiretest: unsupported function: %s", info.Name)
+	return nil, false
+}
@@ -0,0 +1,86 @@
+package wiretest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tekkamanendless/cobra-controls/wire"
+)
+
+func TestController(t *testing.T) {
+	controller, err := NewController(0xF257)
+	require.Nil(t, err)
+	defer controller.Close()
+
+	record := wire.Record{IDNumber: 23439, AreaNumber: 178, BrushDateTime: time.Date(2022, 12, 28, 11, 28, 42, 0, time.UTC)}
+	controller.AddRecord(record)
+	controller.SetOffset(time.Hour)
+
+	client := controller.Client()
+
+	t.Run("Status", func(t *testing.T) {
+		status, err := client.OperationStatus(0)
+		require.Nil(t, err)
+		assert.Equal(t, uint32(1), status.RecordCount)
+		assert.Equal(t, &record, status.Record)
+		assert.WithinDuration(t, controller.Now(), status.CurrentTime, 2*time.Second)
+
+		status, err = client.OperationStatus(2)
+		require.Nil(t, err)
+		assert.Nil(t, status.Record)
+	})
+	t.Run("OpenDoor", func(t *testing.T) {
+		var response wire.OpenDoorResponse
+		err := client.Do(wire.FunctionOpenDoor, &wire.OpenDoorRequest{Door: 3}, &response)
+		require.Nil(t, err)
+
+		records := controller.Records()
+		require.Len(t, records, 2)
+		assert.Equal(t, wire.RecordEventRemoteOpen, records[1].Event())
+		assert.Equal(t, uint8(3), records[1].Door())
+	})
+	t.Run("SetTime", func(t *testing.T) {
+		newTime := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+		var response wire.SetTimeResponse
+		err := client.Do(wire.FunctionSetTime, &wire.SetTimeRequest{CurrentTime: newTime}, &response)
+		require.Nil(t, err)
+		assert.Equal(t, newTime, response.CurrentTime)
+		assert.WithinDuration(t, newTime, controller.Now(), 2*time.Second)
+	})
+	t.Run("Permissions", func(t *testing.T) {
+		request := wire.UpdatePermissionsRequest{
+			CardID:    23439,
+			Area:      178,
+			Door:      1,
+			StartDate: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:   time.Date(2050, 12, 31, 0, 0, 0, 0, time.UTC),
+		}
+		err := client.AddPermission(request)
+		require.Nil(t, err)
+		request.Door = 2
+		err = client.AddPermission(request)
+		require.Nil(t, err)
+
+		permissions, err := client.Permissions()
+		require.Nil(t, err)
+		require.Len(t, permissions, 2)
+		assert.Equal(t, uint8(1), permissions[0].DoorNumber)
+		assert.Equal(t, uint8(2), permissions[1].DoorNumber)
+
+		err = client.DeletePermission(wire.DeletePermissionsRequest{CardID: 23439, Area: 178, Door: 1})
+		require.Nil(t, err)
+		permissions, err = client.Permissions()
+		require.Nil(t, err)
+		require.Len(t, permissions, 1)
+		assert.Equal(t, uint8(2), permissions[0].DoorNumber)
+	})
+	t.Run("Fail", func(t *testing.T) {
+		controller.SetFail(true)
+		defer controller.SetFail(false)
+
+		_, err := client.OperationStatus(0)
+		assert.NotNil(t, err)
+	})
+}
@@ -8,7 +8,8 @@ import (
 )
 
 type Writer struct {
-	buffer bytes.Buffer
+	buffer   bytes.Buffer
+	location *time.Location
 }
 
 func NewWriter() *Writer {
@@ -18,6 +19,18 @@ func NewWriter() *Writer {
 	return w
 }
 
+// SetLocation sets the time zone that date-times are encoded in.
+//
+// By default, they are encoded as-is (in whatever time zone they have).
+func (w *Writer) SetLocation(location *time.Location) {
+	w.location = location
+}
+
+// Location returns the time zone that date-times are encoded in (nil means as-is).
+func (w *Writer) Location() *time.Location {
+	return w.location
+}
+
 func (w *Writer) Bytes() []byte {
 	return w.buffer.Bytes()
 }